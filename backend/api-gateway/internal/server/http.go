@@ -4,11 +4,14 @@ import (
 	v1 "api-gateway/api/helloworld/v1"
 	"api-gateway/internal/conf"
 	"api-gateway/internal/service"
+	"auth-service/pkg/authclient"
 
+	"context"
 	"io"
 	nethttp "net/http"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -98,6 +101,25 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 		}
 	}
 	limiter := newLimiter(rps, burst)
+	// Tenants are reached at <tenant>.TENANT_BASE_DOMAIN unless the client
+	// names one in the X-Tenant-ID header
+	tenantBaseDomain := strings.ToLower(strings.Trim(os.Getenv("TENANT_BASE_DOMAIN"), "."))
+	// Check bearer tokens with auth-service over gRPC if AUTH_GRPC_ADDR is set
+	// (valid tokens are cached for AUTH_GRPC_CACHE_TTL, default 30s)
+	var tokens *authclient.Client
+	if addr := os.Getenv("AUTH_GRPC_ADDR"); addr != "" {
+		cacheTTL := 30 * time.Second
+		if v := os.Getenv("AUTH_GRPC_CACHE_TTL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+				cacheTTL = d
+			}
+		}
+		client, err := authclient.New(addr, cacheTTL)
+		if err != nil {
+			log.NewHelper(logger).Fatalf("failed to create auth-service client: %v", err)
+		}
+		tokens = client
+	}
 
 	srv := kratoshttp.NewServer(opts...)
 	v1.RegisterGreeterHTTPServer(srv, greeter)
@@ -179,6 +201,9 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 			return
 		}
 		req.Header = r.Header
+		if tenantID := resolveTenant(r, tenantBaseDomain); tenantID != "" {
+			req.Header.Set(tenantHeader, tenantID)
+		}
 		resp, err := nethttp.DefaultClient.Do(req)
 		if err != nil {
 			nethttp.Error(w, "Bad Gateway", nethttp.StatusBadGateway)
@@ -199,6 +224,9 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 			w.Write([]byte("rate limit exceeded"))
 			return
 		}
+		if !checkToken(w, r, tokens) {
+			return
+		}
 		target := "http://user-service:8082" + r.URL.Path
 		req, err := nethttp.NewRequest(r.Method, target, r.Body)
 		if err != nil {
@@ -206,6 +234,9 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 			return
 		}
 		req.Header = r.Header
+		if tenantID := resolveTenant(r, tenantBaseDomain); tenantID != "" {
+			req.Header.Set(tenantHeader, tenantID)
+		}
 		resp, err := nethttp.DefaultClient.Do(req)
 		if err != nil {
 			nethttp.Error(w, "Bad Gateway", nethttp.StatusBadGateway)
@@ -243,3 +274,57 @@ func clientIP(r *nethttp.Request) string {
 	}
 	return host
 }
+
+// checkToken rejects a request whose bearer token auth-service does not
+// accept, including revoked tokens and tokens of ended sessions, with 401. It
+// answers 503 if auth-service cannot be asked. Requests without a bearer token
+// and all requests when tokens is nil are let through; the services decide
+// which endpoints need a token.
+func checkToken(w nethttp.ResponseWriter, r *nethttp.Request, tokens *authclient.Client) bool {
+	if tokens == nil {
+		return true
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	resp, err := tokens.ValidateToken(ctx, strings.TrimSpace(token))
+	if err != nil {
+		nethttp.Error(w, "Service Unavailable", nethttp.StatusServiceUnavailable)
+		return false
+	}
+	if !resp.GetValid() {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		nethttp.Error(w, "invalid or expired token", nethttp.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// tenantHeader carries the tenant of a request to the backend services.
+const tenantHeader = "X-Tenant-ID"
+
+// resolveTenant returns the tenant a request is for: the X-Tenant-ID header
+// if the client set one, otherwise the subdomain of baseDomain the request was
+// sent to. It returns "" if neither names a tenant; the services then use the
+// default tenant.
+func resolveTenant(r *nethttp.Request, baseDomain string) string {
+	if tenantID := strings.TrimSpace(r.Header.Get(tenantHeader)); tenantID != "" {
+		return strings.ToLower(tenantID)
+	}
+	if baseDomain == "" {
+		return ""
+	}
+	host := strings.ToLower(r.Host)
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	subdomain := strings.TrimSuffix(host, "."+baseDomain)
+	if subdomain == host || subdomain == "" || strings.Contains(subdomain, ".") {
+		return ""
+	}
+	return subdomain
+}
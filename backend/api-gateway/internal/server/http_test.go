@@ -0,0 +1,132 @@
+package server
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"auth-service/pkg/authclient"
+	"context"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuthService accepts only the token "valid".
+type fakeAuthService struct {
+	authv1.UnimplementedAuthServiceServer
+}
+
+func (fakeAuthService) ValidateToken(ctx context.Context, req *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	return &authv1.ValidateTokenResponse{Valid: req.GetToken() == "valid", UserId: "u1"}, nil
+}
+
+// newAuthClient returns an authclient connected in memory to a
+// fakeAuthService, and the server to stop it.
+func newAuthClient(t *testing.T) (*authclient.Client, *grpc.Server) {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	authv1.RegisterAuthServiceServer(server, fakeAuthService{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := authclient.New("passthrough:///bufnet", 0,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("authclient.New: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestCheckToken(t *testing.T) {
+	client, _ := newAuthClient(t)
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{name: "valid token", authorization: "Bearer valid", want: nethttp.StatusOK},
+		{name: "scheme in lower case", authorization: "bearer valid", want: nethttp.StatusOK},
+		{name: "no token", want: nethttp.StatusOK},
+		{name: "other scheme", authorization: "Basic dXNlcjpwYXNz", want: nethttp.StatusOK},
+		{name: "revoked token", authorization: "Bearer revoked", want: nethttp.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(nethttp.MethodGet, "/api/users/u1", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			ok := checkToken(w, r, client)
+			if ok != (tt.want == nethttp.StatusOK) || w.Code != tt.want {
+				t.Errorf("checkToken = %t with status %d, want status %d", ok, w.Code, tt.want)
+			}
+			if tt.want == nethttp.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+				t.Errorf("WWW-Authenticate = %q, want invalid_token", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestCheckTokenAuthServiceDown(t *testing.T) {
+	client, server := newAuthClient(t)
+	server.Stop()
+
+	r := httptest.NewRequest(nethttp.MethodGet, "/api/users/u1", nil)
+	r.Header.Set("Authorization", "Bearer valid")
+	w := httptest.NewRecorder()
+	if checkToken(w, r, client) || w.Code != nethttp.StatusServiceUnavailable {
+		t.Errorf("checkToken with auth-service down status = %d, want %d", w.Code, nethttp.StatusServiceUnavailable)
+	}
+
+	// Without a client the services check tokens themselves.
+	w = httptest.NewRecorder()
+	if !checkToken(w, r, nil) {
+		t.Errorf("checkToken without a client status = %d, want the request let through", w.Code)
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		header     string
+		baseDomain string
+		want       string
+	}{
+		{name: "header", host: "api.example.com", header: " Acme ", baseDomain: "example.com", want: "acme"},
+		{name: "header wins over the subdomain", host: "globex.example.com", header: "acme", baseDomain: "example.com", want: "acme"},
+		{name: "subdomain", host: "acme.example.com", baseDomain: "example.com", want: "acme"},
+		{name: "subdomain with a port", host: "ACME.example.com:8080", baseDomain: "example.com", want: "acme"},
+		{name: "nested subdomain", host: "a.acme.example.com", baseDomain: "example.com"},
+		{name: "base domain itself", host: "example.com", baseDomain: "example.com"},
+		{name: "other domain", host: "acme.example.org", baseDomain: "example.com"},
+		{name: "lookalike domain", host: "acme.evilexample.com", baseDomain: "example.com"},
+		{name: "no base domain", host: "acme.example.com"},
+		{name: "IPv6 host", host: "[::1]:8080", baseDomain: "example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(nethttp.MethodGet, "/api/users/u1", nil)
+			r.Host = tt.host
+			if tt.header != "" {
+				r.Header.Set(tenantHeader, tt.header)
+			}
+			if got := resolveTenant(r, tt.baseDomain); got != tt.want {
+				t.Errorf("resolveTenant = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
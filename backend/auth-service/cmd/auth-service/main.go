@@ -1,19 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	authv1 "auth-service/api/auth/v1"
 	"auth-service/internal/config"
 	"auth-service/internal/handlers"
 	"auth-service/internal/logger"
 	"auth-service/internal/middleware"
+	"auth-service/internal/migrations"
 	"auth-service/internal/services"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -41,19 +48,119 @@ func main() {
 	}()
 	log.Info("MongoDB connection established")
 
-	// Initialize JWT service
-	jwtConfig := config.NewJWTConfig(cfg.JWTSecret)
-	jwtService := services.NewJWTService(jwtConfig)
-	log.Info("JWT service initialized")
+	// User lifecycle events are stored in the transaction of the change
+	// they describe, which standalone servers do not support
+	transactionsCtx, cancelTransactions := context.WithTimeout(context.Background(), 10*time.Second)
+	supportsTransactions, err := mongoConfig.SupportsTransactions(transactionsCtx)
+	cancelTransactions()
+	if err != nil {
+		log.Error("Failed to inspect MongoDB deployment", zap.Error(err))
+		os.Exit(1)
+	}
+	if !supportsTransactions {
+		log.Error("MongoDB must run as a replica set or sharded cluster to support transactions")
+		os.Exit(1)
+	}
 
-	// Initialize Kafka publisher for user lifecycle events.
-	kafkaPublisher, err := services.NewKafkaPublisher(
-		cfg.KafkaBrokers,
-		cfg.KafkaClientID,
-		cfg.KafkaTopicUserCreated,
-		cfg.KafkaTopicUserUpdated,
-		cfg.KafkaTopicUserDeleted,
+	// Apply pending migrations before anything reads the collections
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+	err = mongoConfig.Migrate(migrateCtx, migrations.All)
+	cancelMigrate()
+	if err != nil {
+		log.Error("Failed to apply database migrations", zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Database migrations applied")
+
+	// Initialize JWT signing keys and service
+	jwtConfig := config.NewJWTConfig(
+		cfg.JWTSigningAlgorithm,
+		cfg.JWTIssuer,
+		cfg.JWTAudience,
+		cfg.AccessTokenTTL,
+		cfg.ImpersonationTokenTTL,
+		cfg.JWTKeyRotationInterval,
+		cfg.JWTKeyCheckInterval,
 	)
+	// Retired keys must outlive every token they signed, including tokens
+	// signed just before a rotation that another replica has not yet seen.
+	keyRetention := max(jwtConfig.AccessTokenTTL, jwtConfig.ImpersonationTTL) + jwtConfig.KeyCheckInterval
+	signingKeyRepository := services.NewMongoSigningKeyRepository(mongoConfig)
+	keyStore, err := services.NewKeyStore(signingKeyRepository, jwtConfig.SigningAlgorithm, jwtConfig.KeyRotationInterval, keyRetention, log)
+	if err != nil {
+		log.Error("Failed to initialize JWT key store", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := ensureIndexes(signingKeyRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create signing key indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := ensureIndexes(keyStore.Refresh); err != nil {
+		log.Error("Failed to load JWT signing keys", zap.Error(err))
+		os.Exit(1)
+	}
+	keyStoreCtx, cancelKeyStore := context.WithCancel(context.Background())
+	defer cancelKeyStore()
+	go keyStore.Start(keyStoreCtx, jwtConfig.KeyCheckInterval)
+
+	jwtService := services.NewJWTService(jwtConfig, keyStore)
+	log.Info("JWT service initialized", zap.String("alg", jwtConfig.SigningAlgorithm))
+
+	// Initialize password hashing
+	passwordHashConfig := config.NewPasswordHashConfig(
+		cfg.PasswordHashAlgorithm,
+		uint32(cfg.Argon2Memory),
+		uint32(cfg.Argon2Iterations),
+		uint8(cfg.Argon2Parallelism),
+		cfg.BcryptCost,
+	)
+	passwordService, err := services.NewPasswordService(passwordHashConfig, log)
+	if err != nil {
+		log.Error("Failed to initialize password hashing", zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Password hashing initialized", zap.String("algorithm", cfg.PasswordHashAlgorithm))
+
+	// Initialize the password policy
+	passwordPolicyConfig := config.NewPasswordPolicyConfig(
+		cfg.PasswordMinLength,
+		cfg.PasswordMaxLength,
+		cfg.PasswordRequireUppercase,
+		cfg.PasswordRequireLowercase,
+		cfg.PasswordRequireDigit,
+		cfg.PasswordRequireSymbol,
+		cfg.PasswordMaxRepeats,
+		cfg.PasswordRejectPersonalInfo,
+		cfg.BreachedPasswordFile,
+	)
+	passwordPolicy, err := services.NewPasswordPolicy(passwordPolicyConfig)
+	if err != nil {
+		log.Error("Failed to initialize password policy", zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Password policy initialized", zap.Int("breached_passwords", passwordPolicy.BreachedPasswordCount()))
+
+	// Initialize refresh token storage
+	refreshTokenRepository := services.NewMongoRefreshTokenRepository(mongoConfig)
+	if err := ensureIndexes(refreshTokenRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create refresh token indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	refreshTokenService := services.NewRefreshTokenService(refreshTokenRepository, cfg.RefreshTokenTTL)
+
+	// Initialize Kafka publisher for user lifecycle events.
+	kafkaTopics := services.KafkaTopics{
+		UserCreated:     cfg.KafkaTopicUserCreated,
+		UserUpdated:     cfg.KafkaTopicUserUpdated,
+		UserDeleted:     cfg.KafkaTopicUserDeleted,
+		TokenRevoked:    cfg.KafkaTopicTokenRevoked,
+		PasswordChanged: cfg.KafkaTopicPasswordChanged,
+		LoginFailed:     cfg.KafkaTopicLoginFailed,
+		AccountLocked:   cfg.KafkaTopicAccountLocked,
+		Audit:           cfg.KafkaTopicAudit,
+		Invitation:      cfg.KafkaTopicInvitation,
+	}
+	kafkaPublisher, err := services.NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaClientID, kafkaTopics)
 	if err != nil {
 		log.Error("Failed to initialize Kafka publisher", zap.Error(err))
 	}
@@ -65,13 +172,215 @@ func main() {
 		}
 	}()
 
+	// Initialize the transactional outbox for user lifecycle events
+	outboxConfig := config.NewOutboxConfig(cfg.OutboxPollInterval, cfg.OutboxRetryBase, cfg.OutboxRetryMax, cfg.OutboxRetention)
+	outboxRepository := services.NewMongoOutboxRepository(mongoConfig)
+	outboxService := services.NewOutboxService(outboxRepository, kafkaPublisher, kafkaTopics, outboxConfig, log)
+	if err := ensureIndexes(outboxRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create outbox indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	outboxCtx, cancelOutbox := context.WithCancel(context.Background())
+	defer cancelOutbox()
+	go outboxService.Start(outboxCtx)
+
+	// Initialize account storage
+	userRepository := services.NewMongoUserRepository(mongoConfig)
+
+	// Initialize the access token denylist; user-wide revocations must outlive
+	// every token they cover, impersonation tokens included
+	revocationService := services.NewRevocationService(mongoConfig, kafkaPublisher, max(jwtConfig.AccessTokenTTL, jwtConfig.ImpersonationTTL), log)
+	if err := ensureIndexes(revocationService.EnsureIndexes); err != nil {
+		log.Error("Failed to create revoked token indexes", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Initialize TOTP second factors
+	mfaService := services.NewMFAService(mongoConfig, cfg.MFATOTPIssuer)
+	if err := ensureIndexes(mfaService.EnsureIndexes); err != nil {
+		log.Error("Failed to create mfa challenge indexes", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Initialize outgoing mail and email verification
+	mailConfig := config.NewMailConfig(cfg.MailDriver, cfg.MailFrom, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailOutboxDir)
+	mailer, err := services.NewMailer(mailConfig)
+	if err != nil {
+		log.Error("Failed to initialize mailer", zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Mailer initialized", zap.String("driver", mailConfig.Driver))
+
+	oneTimeTokenSecret := []byte(cfg.OneTimeTokenSecret)
+	if len(oneTimeTokenSecret) == 0 {
+		// Without a shared secret, links only work on this replica until it restarts.
+		oneTimeTokenSecret = make([]byte, 32)
+		if _, err := rand.Read(oneTimeTokenSecret); err != nil {
+			log.Error("Failed to generate one-time token secret", zap.Error(err))
+			os.Exit(1)
+		}
+		log.Warn("ONE_TIME_TOKEN_SECRET is not set, using a random secret")
+	}
+	oneTimeTokenRepository := services.NewMongoOneTimeTokenRepository(mongoConfig)
+	if err := ensureIndexes(oneTimeTokenRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create one-time token indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	oneTimeTokenService := services.NewOneTimeTokenService(oneTimeTokenRepository, oneTimeTokenSecret)
+	emailVerificationService := services.NewEmailVerificationService(
+		userRepository,
+		mongoConfig,
+		oneTimeTokenService,
+		mailer,
+		outboxService,
+		cfg.AppBaseURL,
+		cfg.EmailVerificationTTL,
+		cfg.EmailResendInterval,
+	)
+
+	// Initialize invitations; expired ones are marked in the background
+	invitationRepository := services.NewMongoInvitationRepository(mongoConfig)
+	invitationService := services.NewInvitationService(
+		invitationRepository,
+		mongoConfig,
+		userRepository,
+		mailer,
+		outboxService,
+		cfg.AppBaseURL,
+		cfg.InvitationTTL,
+		cfg.InvitationOnly,
+		log,
+	)
+	if err := ensureIndexes(invitationRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create invitation indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	invitationCtx, cancelInvitations := context.WithCancel(context.Background())
+	defer cancelInvitations()
+	go invitationService.Start(invitationCtx)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, log)
+	if cfg.InvitationOnly {
+		log.Info("Registration is invitation-only")
+	}
+
+	// Initialize login brute-force protection
+	loginThrottleConfig := config.NewLoginThrottleConfig(
+		cfg.LoginBackoffThreshold,
+		cfg.LoginBackoffBase,
+		cfg.LoginBackoffMax,
+		cfg.LoginLockoutThreshold,
+		cfg.LoginLockoutDuration,
+		cfg.LoginIPBackoffThreshold,
+		cfg.LoginFailureWindow,
+	)
+	loginAttemptRepository := services.NewMongoLoginAttemptRepository(mongoConfig)
+	if err := ensureIndexes(loginAttemptRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create login attempt indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	loginThrottleService := services.NewLoginThrottleService(loginAttemptRepository, loginThrottleConfig, kafkaPublisher)
+
+	// Initialize session tracking; sessions live as long as their refresh tokens
+	sessionService := services.NewSessionService(mongoConfig, refreshTokenService, cfg.RefreshTokenTTL)
+	if err := ensureIndexes(sessionService.EnsureIndexes); err != nil {
+		log.Error("Failed to create session indexes", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Initialize the authentication audit trail
+	auditRepository := services.NewMongoAuditRepository(mongoConfig)
+	if err := ensureIndexes(auditRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create audit indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	auditService := services.NewAuditService(auditRepository, kafkaPublisher)
+	auditHandler := handlers.NewAuditHandler(auditService, log)
+
+	// Initialize tenants
+	tenantService := services.NewTenantService(services.NewMongoTenantRepository(mongoConfig))
+	tenantHandler := handlers.NewTenantHandler(tenantService, log)
+
 	// Initialize services
-	authService := services.NewAuthService(mongoConfig, jwtService, kafkaPublisher)
+	authService := services.NewAuthService(userRepository, userRepository, mongoConfig, jwtService, passwordService, passwordPolicy, refreshTokenService, revocationService, mfaService, emailVerificationService, invitationService, loginThrottleService, sessionService, auditService, tenantService, outboxService, kafkaPublisher, log)
 	authHandler := handlers.NewAuthHandler(authService, log)
+	sessionHandler := handlers.NewSessionHandler(authService, log)
+	mfaHandler := handlers.NewMFAHandler(mfaService, authService, log)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService, log)
+	passwordResetService := services.NewPasswordResetService(
+		userRepository,
+		authService,
+		oneTimeTokenService,
+		mailer,
+		cfg.AppBaseURL,
+		cfg.PasswordResetTTL,
+		cfg.PasswordResetResendInterval,
+	)
+	passwordHandler := handlers.NewPasswordHandler(authService, passwordResetService, log)
+	magicLinkService := services.NewMagicLinkService(
+		userRepository,
+		authService,
+		oneTimeTokenService,
+		mailer,
+		cfg.AppBaseURL,
+		cfg.MagicLinkTTL,
+		cfg.MagicLinkResendInterval,
+	)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, log)
+
+	// Initialize passkeys
+	webAuthnRepository := services.NewMongoWebAuthnRepository(mongoConfig)
+	if err := ensureIndexes(webAuthnRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create webauthn indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	webAuthnService := services.NewWebAuthnService(webAuthnRepository, cfg.WebAuthnRPID, cfg.WebAuthnRPName, cfg.WebAuthnRPOrigins, auditService)
+	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService, authService, log)
+	jwksHandler := handlers.NewJWKSHandler(keyStore, log)
+
+	// Initialize the OpenID Connect provider
+	oauthClientService := services.NewOAuthClientService(services.NewMongoOAuthClientRepository(mongoConfig), passwordService, cfg.ClientSecretRotationOverlap)
+	authorizationCodeRepository := services.NewMongoAuthorizationCodeRepository(mongoConfig)
+	if err := ensureIndexes(authorizationCodeRepository.EnsureIndexes); err != nil {
+		log.Error("Failed to create authorization code indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	oidcService := services.NewOIDCService(authorizationCodeRepository, authService, jwtService, oauthClientService, jwtConfig.Issuer)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, authService, log)
+	oauthClientHandler := handlers.NewOAuthClientHandler(oauthClientService, log)
+	grpcHandler := handlers.NewAuthGRPCHandler(authService, oidcService, keyStore, log)
 	log.Info("Auth service and handlers initialized")
 
+	// Initialize Kafka consumer for profile changes made through user-service.
+	consumer, err := services.NewUserEventConsumer(
+		cfg.KafkaBrokers,
+		cfg.KafkaGroupID,
+		cfg.KafkaClientID,
+		cfg.KafkaTopicUserUpdated,
+		cfg.KafkaTopicUserDeleted,
+		authService,
+		log,
+	)
+	if err != nil {
+		log.Error("Failed to initialize Kafka consumer", zap.Error(err))
+	}
+	defer func() {
+		if consumer != nil {
+			if closeErr := consumer.Close(); closeErr != nil {
+				log.Error("Failed to close Kafka consumer", zap.Error(closeErr))
+			}
+		}
+	}()
+
+	consumerCtx, cancelConsumer := context.WithCancel(context.Background())
+	defer cancelConsumer()
+	go func() {
+		if consumer != nil {
+			consumer.Start(consumerCtx)
+		}
+	}()
+
 	// Setup routes using the router
-	r := SetupRoutes(authHandler, log)
+	r := SetupRoutes(authService, tenantService, outboxService, authHandler, sessionHandler, emailVerificationHandler, passwordHandler, magicLinkHandler, mfaHandler, webAuthnHandler, jwksHandler, oidcHandler, oauthClientHandler, tenantHandler, auditHandler, invitationHandler, log)
 
 	// Start the server
 	serverAddr := fmt.Sprintf(":%s", cfg.Port)
@@ -87,15 +396,41 @@ func main() {
 		}
 	}()
 
+	// Serve the gRPC API for other services alongside the HTTP API
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
+	if err != nil {
+		log.Error("Failed to listen for gRPC", zap.Error(err))
+		os.Exit(1)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(middleware.GRPCZapInterceptor(log)))
+	authv1.RegisterAuthServiceServer(grpcServer, grpcHandler)
+	go func() {
+		log.Info("Auth gRPC server starting", zap.String("port", cfg.GRPCPort))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Error("Failed to start auth gRPC server", zap.Error(err))
+		}
+	}()
+
 	<-quit
+	grpcServer.GracefulStop()
+	cancelKeyStore()
+	cancelOutbox()
+	cancelInvitations()
 	log.Info("Shutting down auth service...")
 }
 
+// ensureIndexes runs an index setup function with a bounded timeout
+func ensureIndexes(setup func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return setup(ctx)
+}
+
 // EnableCORS is a middleware function that enables CORS for all routes
 func EnableCORS(c *gin.Context) {
 	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 	c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID")
 
 	if c.Request.Method == "OPTIONS" {
 		c.AbortWithStatus(http.StatusOK)
@@ -106,7 +441,25 @@ func EnableCORS(c *gin.Context) {
 }
 
 // SetupRoutes configures all routes for the auth service
-func SetupRoutes(authHandler *handlers.AuthHandler, log logger.Logger) *gin.Engine {
+func SetupRoutes(
+	authService *services.AuthService,
+	tenantService *services.TenantService,
+	outboxService *services.OutboxService,
+	authHandler *handlers.AuthHandler,
+	sessionHandler *handlers.SessionHandler,
+	emailVerificationHandler *handlers.EmailVerificationHandler,
+	passwordHandler *handlers.PasswordHandler,
+	magicLinkHandler *handlers.MagicLinkHandler,
+	mfaHandler *handlers.MFAHandler,
+	webAuthnHandler *handlers.WebAuthnHandler,
+	jwksHandler *handlers.JWKSHandler,
+	oidcHandler *handlers.OIDCHandler,
+	oauthClientHandler *handlers.OAuthClientHandler,
+	tenantHandler *handlers.TenantHandler,
+	auditHandler *handlers.AuditHandler,
+	invitationHandler *handlers.InvitationHandler,
+	log logger.Logger,
+) *gin.Engine {
 	r := gin.Default()
 
 	// Enable CORS
@@ -115,18 +468,108 @@ func SetupRoutes(authHandler *handlers.AuthHandler, log logger.Logger) *gin.Engi
 	// User middleware
 	r.Use(middleware.ZapMiddleware(log))
 
+	// Every request acts in a tenant
+	r.Use(middleware.ResolveTenant(tenantService))
+
 	// API routes
 	api := r.Group("/api/auth")
 	{
 		api.POST("/login", authHandler.Login)
 		api.POST("/register", authHandler.Register)
+		api.POST("/verify-email", emailVerificationHandler.Verify)
+		api.POST("/verify-email/resend", emailVerificationHandler.Resend)
+		api.POST("/password/forgot", passwordHandler.Forgot)
+		api.POST("/password/reset", passwordHandler.Reset)
+		api.PUT("/password", middleware.RequireAuth(authService), middleware.ForbidImpersonation(), passwordHandler.Change)
+		api.POST("/magic-link", magicLinkHandler.Request)
+		api.POST("/magic-link/consume", magicLinkHandler.Consume)
 		api.POST("/validate", authHandler.ValidateToken)
 		api.POST("/refresh", authHandler.RefreshToken)
+		api.POST("/logout", middleware.RequireAuth(authService), authHandler.Logout)
+		api.POST("/mfa/verify", mfaHandler.Verify)
+	}
+
+	// Session management routes
+	sessions := r.Group("/api/auth/sessions", middleware.RequireAuth(authService))
+	{
+		sessions.GET("", sessionHandler.List)
+		sessions.DELETE("", middleware.ForbidImpersonation(), sessionHandler.TerminateOthers)
+		sessions.DELETE("/:id", middleware.ForbidImpersonation(), sessionHandler.Terminate)
+	}
+
+	// Audit trail, admins only
+	r.GET("/api/auth/audit", middleware.RequireAuth(authService), middleware.RequireRole(authService, "admin"), auditHandler.List)
+
+	// MFA management routes
+	mfa := r.Group("/api/auth/mfa", middleware.RequireAuth(authService), middleware.ForbidImpersonation())
+	{
+		mfa.POST("/totp/enroll", mfaHandler.EnrollTOTP)
+		mfa.POST("/totp/confirm", mfaHandler.ConfirmTOTP)
+		mfa.POST("/totp/disable", mfaHandler.DisableTOTP)
+		mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
+
+	// Passkey routes
+	webauthn := r.Group("/api/auth/webauthn")
+	{
+		webauthn.POST("/login/begin", webAuthnHandler.BeginLogin)
+		webauthn.POST("/login/finish", webAuthnHandler.FinishLogin)
+		webauthn.POST("/register/begin", middleware.RequireAuth(authService), middleware.ForbidImpersonation(), webAuthnHandler.BeginRegistration)
+		webauthn.POST("/register/finish", middleware.RequireAuth(authService), middleware.ForbidImpersonation(), webAuthnHandler.FinishRegistration)
+		webauthn.GET("/credentials", middleware.RequireAuth(authService), webAuthnHandler.ListCredentials)
+		webauthn.DELETE("/credentials/:id", middleware.RequireAuth(authService), middleware.ForbidImpersonation(), webAuthnHandler.DeleteCredential)
+	}
+
+	// Admin routes
+	admin := r.Group("/api/auth/admin", middleware.RequireAuth(authService), middleware.RequireRole(authService, "admin"))
+	{
+		admin.POST("/users/:id/revoke-tokens", authHandler.RevokeUserTokens)
+		admin.POST("/users/:id/unlock", authHandler.UnlockUser)
+		admin.PUT("/users/:id/role", authHandler.AssignRole)
+		admin.POST("/users/:id/impersonate", authHandler.Impersonate)
+		admin.GET("/roles", authHandler.ListRoles)
+		admin.GET("/invitations", invitationHandler.List)
+		admin.POST("/invitations", invitationHandler.Create)
+		admin.GET("/invitations/:id", invitationHandler.Get)
+		admin.POST("/invitations/:id/revoke", invitationHandler.Revoke)
 	}
 
-	// Health check endpoint
+	// Deployment-wide admin routes, admins of the default tenant only
+	platform := r.Group("/api/auth/admin", middleware.RequireAuth(authService), middleware.RequireRole(authService, "admin"), middleware.RequireTenant(services.DefaultTenantID))
+	{
+		platform.GET("/tenants", tenantHandler.ListTenants)
+		platform.POST("/tenants", tenantHandler.CreateTenant)
+		platform.PUT("/tenants/:id", tenantHandler.UpdateTenant)
+		platform.GET("/oauth-clients", oauthClientHandler.ListClients)
+		platform.POST("/oauth-clients", oauthClientHandler.CreateClient)
+		platform.GET("/oauth-clients/:id", oauthClientHandler.GetClient)
+		platform.PUT("/oauth-clients/:id", oauthClientHandler.UpdateClient)
+		platform.DELETE("/oauth-clients/:id", oauthClientHandler.DeleteClient)
+		platform.POST("/oauth-clients/:id/rotate-secret", oauthClientHandler.RotateSecret)
+	}
+
+	// Public verification keys and OpenID Connect discovery
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+	r.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+
+	// OpenID Connect provider endpoints
+	oauth := r.Group("/oauth2")
+	{
+		oauth.GET("/authorize", oidcHandler.AuthorizeForm)
+		oauth.POST("/authorize", oidcHandler.Authorize)
+		oauth.POST("/token", oidcHandler.Token)
+		oauth.POST("/introspect", oidcHandler.Introspect)
+		oauth.GET("/userinfo", middleware.RequireDelegatedAuth(authService), oidcHandler.UserInfo)
+		oauth.POST("/userinfo", middleware.RequireDelegatedAuth(authService), oidcHandler.UserInfo)
+	}
+
+	// Health check endpoint, reporting the events not yet published
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "auth-service"})
+		health := gin.H{"status": "healthy", "service": "auth-service"}
+		if backlog, err := outboxService.Backlog(c.Request.Context()); err == nil {
+			health["outbox_backlog"] = backlog
+		}
+		c.JSON(http.StatusOK, health)
 	})
 
 	return r
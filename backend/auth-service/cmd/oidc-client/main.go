@@ -0,0 +1,304 @@
+// Command oidc-client exercises the auth-service OpenID Connect provider end
+// to end: discovery, the authorization code flow with PKCE, the token
+// endpoint, ID token verification against the JWKS, and UserInfo. It signs in
+// through the provider's login form, so no browser or external IdP is needed.
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	IDToken     string `json:"id_token"`
+}
+
+type idTokenClaims struct {
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Nonce           string `json:"nonce"`
+	AccessTokenHash string `json:"at_hash"`
+	jwt.RegisteredClaims
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+}
+
+func main() {
+	issuer := flag.String("issuer", "http://localhost:8081", "OpenID Provider issuer URL")
+	clientID := flag.String("client-id", "", "registered OAuth client ID")
+	clientSecret := flag.String("client-secret", "", "client secret (empty for public clients)")
+	redirectURI := flag.String("redirect-uri", "http://localhost:9999/callback", "registered redirect URI")
+	scope := flag.String("scope", "openid profile email", "requested scopes")
+	email := flag.String("email", "", "account email")
+	password := flag.String("password", "", "account password")
+	flag.Parse()
+
+	if *clientID == "" || *email == "" || *password == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*issuer, *clientID, *clientSecret, *redirectURI, *scope, *email, *password); err != nil {
+		fmt.Fprintln(os.Stderr, "FAIL:", err)
+		os.Exit(1)
+	}
+	fmt.Println("OK: OpenID Connect flow completed")
+}
+
+func run(issuer, clientID, clientSecret, redirectURI, scope, email, password string) error {
+	// The client must see the provider's redirect instead of following it.
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var config discovery
+	if err := getJSON(httpClient, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", "", &config); err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+	if config.Issuer != strings.TrimRight(issuer, "/") {
+		return fmt.Errorf("discovery: issuer %q does not match %q", config.Issuer, issuer)
+	}
+	fmt.Println("discovery:", config.Issuer)
+
+	verifier := randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomString()
+	nonce := randomString()
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {scope},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	resp, err := httpClient.Get(config.AuthorizationEndpoint + "?" + params.Encode())
+	if err != nil {
+		return fmt.Errorf("authorize: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("authorize: unexpected status %d", resp.StatusCode)
+	}
+
+	// Submit the login form the way a browser would.
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("email", email)
+	form.Set("password", password)
+	resp, err = httpClient.PostForm(config.AuthorizationEndpoint, form)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		return fmt.Errorf("login: unexpected status %d", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("login: invalid redirect: %w", err)
+	}
+	callback := location.Query()
+	if callback.Get("error") != "" {
+		return fmt.Errorf("login: %s: %s", callback.Get("error"), callback.Get("error_description"))
+	}
+	if callback.Get("state") != state {
+		return errors.New("login: state mismatch")
+	}
+	code := callback.Get("code")
+	if code == "" {
+		return errors.New("login: no authorization code in redirect")
+	}
+	fmt.Println("authorization code received")
+
+	tokenForm := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {clientID},
+	}
+	req, err := http.NewRequest(http.MethodPost, config.TokenEndpoint, strings.NewReader(tokenForm.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	var tokens tokenResponse
+	if err := doJSON(httpClient, req, &tokens); err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	if tokens.IDToken == "" || tokens.AccessToken == "" {
+		return errors.New("token: response is missing tokens")
+	}
+	fmt.Println("tokens received, scope:", tokens.Scope)
+
+	keys, err := fetchJWKS(httpClient, config.JWKSURI)
+	if err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	claims := &idTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokens.IDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			key, ok := keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown kid %q", kid)
+			}
+			return key, nil
+		},
+		jwt.WithValidMethods([]string{"RS256", "EdDSA"}),
+		jwt.WithIssuer(config.Issuer),
+		jwt.WithAudience(clientID),
+	)
+	if err != nil {
+		return fmt.Errorf("id_token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return errors.New("id_token: nonce mismatch")
+	}
+	if claims.AccessTokenHash != accessTokenHash(token.Method.Alg(), tokens.AccessToken) {
+		return errors.New("id_token: at_hash mismatch")
+	}
+	fmt.Printf("id_token verified: sub=%s email=%s name=%s\n", claims.Subject, claims.Email, claims.Name)
+
+	var userInfo map[string]interface{}
+	if err := getJSON(httpClient, config.UserInfoEndpoint, tokens.AccessToken, &userInfo); err != nil {
+		return fmt.Errorf("userinfo: %w", err)
+	}
+	if userInfo["sub"] != claims.Subject {
+		return errors.New("userinfo: sub does not match id_token")
+	}
+	fmt.Println("userinfo:", userInfo)
+
+	return nil
+}
+
+func getJSON(client *http.Client, target, bearer string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return doJSON(client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("unexpected status %d: %v", resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func fetchJWKS(client *http.Client, target string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(client, target, "", &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		switch key.KeyType {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[key.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "OKP":
+			x, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil {
+				return nil, err
+			}
+			keys[key.KeyID] = ed25519.PublicKey(x)
+		}
+	}
+	return keys, nil
+}
+
+func accessTokenHash(algorithm, accessToken string) string {
+	var digest []byte
+	if algorithm == "EdDSA" {
+		sum := sha512.Sum512([]byte(accessToken))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(accessToken))
+		digest = sum[:]
+	}
+	return base64.RawURLEncoding.EncodeToString(digest[:len(digest)/2])
+}
+
+func randomString() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
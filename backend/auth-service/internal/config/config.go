@@ -2,33 +2,160 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the auth service
 type Config struct {
-	Port                  string
-	MongoURI              string
-	MongoDB               string
-	JWTSecret             string
-	KafkaBrokers          string
-	KafkaClientID         string
-	KafkaTopicUserCreated string
-	KafkaTopicUserUpdated string
-	KafkaTopicUserDeleted string
+	Port                        string
+	GRPCPort                    string
+	MongoURI                    string
+	MongoDB                     string
+	JWTSigningAlgorithm         string
+	JWTIssuer                   string
+	JWTAudience                 string
+	JWTKeyRotationInterval      time.Duration
+	JWTKeyCheckInterval         time.Duration
+	AccessTokenTTL              time.Duration
+	ImpersonationTokenTTL       time.Duration
+	RefreshTokenTTL             time.Duration
+	KafkaBrokers                string
+	KafkaClientID               string
+	KafkaGroupID                string
+	KafkaTopicUserCreated       string
+	KafkaTopicUserUpdated       string
+	KafkaTopicUserDeleted       string
+	KafkaTopicTokenRevoked      string
+	KafkaTopicPasswordChanged   string
+	KafkaTopicLoginFailed       string
+	KafkaTopicAccountLocked     string
+	KafkaTopicAudit             string
+	KafkaTopicInvitation        string
+	OutboxPollInterval          time.Duration
+	OutboxRetryBase             time.Duration
+	OutboxRetryMax              time.Duration
+	OutboxRetention             time.Duration
+	PasswordHashAlgorithm       string
+	Argon2Memory                int
+	Argon2Iterations            int
+	Argon2Parallelism           int
+	BcryptCost                  int
+	PasswordMinLength           int
+	PasswordMaxLength           int
+	PasswordRequireUppercase    bool
+	PasswordRequireLowercase    bool
+	PasswordRequireDigit        bool
+	PasswordRequireSymbol       bool
+	PasswordMaxRepeats          int
+	PasswordRejectPersonalInfo  bool
+	BreachedPasswordFile        string
+	MFATOTPIssuer               string
+	WebAuthnRPID                string
+	WebAuthnRPName              string
+	WebAuthnRPOrigins           []string
+	AppBaseURL                  string
+	OneTimeTokenSecret          string
+	EmailVerificationTTL        time.Duration
+	EmailResendInterval         time.Duration
+	MailDriver                  string
+	MailFrom                    string
+	SMTPHost                    string
+	SMTPPort                    int
+	SMTPUsername                string
+	SMTPPassword                string
+	MailOutboxDir               string
+	PasswordResetTTL            time.Duration
+	PasswordResetResendInterval time.Duration
+	MagicLinkTTL                time.Duration
+	MagicLinkResendInterval     time.Duration
+	InvitationTTL               time.Duration
+	InvitationOnly              bool
+	LoginBackoffThreshold       int
+	LoginBackoffBase            time.Duration
+	LoginBackoffMax             time.Duration
+	LoginLockoutThreshold       int
+	LoginLockoutDuration        time.Duration
+	LoginIPBackoffThreshold     int
+	LoginFailureWindow          time.Duration
+	ClientSecretRotationOverlap time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	return &Config{
-		Port:                  getEnv("PORT", "8081"),
-		MongoURI:              getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:               getEnv("MONGO_DB", "auth_db"),
-		JWTSecret:             getEnv("JWT_SECRET", "your-secret-key"),
-		KafkaBrokers:          getEnv("KAFKA_BROKERS", ""),
-		KafkaClientID:         getEnv("KAFKA_CLIENT_ID", "auth-service"),
-		KafkaTopicUserCreated: getEnv("KAFKA_TOPIC_USER_CREATED", "user.created.v1"),
-		KafkaTopicUserUpdated: getEnv("KAFKA_TOPIC_USER_UPDATED", "user.updated.v1"),
-		KafkaTopicUserDeleted: getEnv("KAFKA_TOPIC_USER_DELETED", "user.deleted.v1"),
+		Port:                        getEnv("PORT", "8081"),
+		GRPCPort:                    getEnv("GRPC_PORT", "9091"),
+		MongoURI:                    getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDB:                     getEnv("MONGO_DB", "auth_db"),
+		JWTSigningAlgorithm:         getEnv("JWT_SIGNING_ALGORITHM", "RS256"),
+		JWTIssuer:                   getEnv("JWT_ISSUER", "http://auth-service:8081"),
+		JWTAudience:                 getEnv("JWT_AUDIENCE", "project-api"),
+		JWTKeyRotationInterval:      getEnvDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+		JWTKeyCheckInterval:         getEnvDuration("JWT_KEY_CHECK_INTERVAL", time.Hour),
+		AccessTokenTTL:              getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		ImpersonationTokenTTL:       getEnvDuration("IMPERSONATION_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:             getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		KafkaBrokers:                getEnv("KAFKA_BROKERS", ""),
+		KafkaClientID:               getEnv("KAFKA_CLIENT_ID", "auth-service"),
+		KafkaGroupID:                getEnv("KAFKA_GROUP_ID", "auth-service-group"),
+		KafkaTopicUserCreated:       getEnv("KAFKA_TOPIC_USER_CREATED", "user.created.v1"),
+		KafkaTopicUserUpdated:       getEnv("KAFKA_TOPIC_USER_UPDATED", "user.updated.v1"),
+		KafkaTopicUserDeleted:       getEnv("KAFKA_TOPIC_USER_DELETED", "user.deleted.v1"),
+		KafkaTopicTokenRevoked:      getEnv("KAFKA_TOPIC_TOKEN_REVOKED", "token.revoked.v1"),
+		KafkaTopicPasswordChanged:   getEnv("KAFKA_TOPIC_PASSWORD_CHANGED", "security.password_changed.v1"),
+		KafkaTopicLoginFailed:       getEnv("KAFKA_TOPIC_LOGIN_FAILED", "security.login_failed.v1"),
+		KafkaTopicAccountLocked:     getEnv("KAFKA_TOPIC_ACCOUNT_LOCKED", "security.account_locked.v1"),
+		KafkaTopicAudit:             getEnv("KAFKA_TOPIC_AUDIT", "audit.auth.v1"),
+		KafkaTopicInvitation:        getEnv("KAFKA_TOPIC_INVITATION", "invitation.lifecycle.v1"),
+		OutboxPollInterval:          getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetryBase:             getEnvDuration("OUTBOX_RETRY_BASE", time.Second),
+		OutboxRetryMax:              getEnvDuration("OUTBOX_RETRY_MAX", 5*time.Minute),
+		OutboxRetention:             getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		PasswordHashAlgorithm:       getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2Memory:                getEnvInt("ARGON2_MEMORY_KB", 64*1024),
+		Argon2Iterations:            getEnvInt("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:           getEnvInt("ARGON2_PARALLELISM", 2),
+		BcryptCost:                  getEnvInt("BCRYPT_COST", 12),
+		PasswordMinLength:           getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:           getEnvInt("PASSWORD_MAX_LENGTH", 128),
+		PasswordRequireUppercase:    getEnvBool("PASSWORD_REQUIRE_UPPERCASE", false),
+		PasswordRequireLowercase:    getEnvBool("PASSWORD_REQUIRE_LOWERCASE", false),
+		PasswordRequireDigit:        getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol:       getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordMaxRepeats:          getEnvInt("PASSWORD_MAX_REPEATS", 3),
+		PasswordRejectPersonalInfo:  getEnvBool("PASSWORD_REJECT_PERSONAL_INFO", true),
+		BreachedPasswordFile:        getEnv("BREACHED_PASSWORD_FILE", ""),
+		MFATOTPIssuer:               getEnv("MFA_TOTP_ISSUER", "Project"),
+		WebAuthnRPID:                getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName:              getEnv("WEBAUTHN_RP_NAME", "Project"),
+		WebAuthnRPOrigins:           getEnvList("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:4200"}),
+		AppBaseURL:                  getEnv("APP_BASE_URL", "http://localhost:4200"),
+		OneTimeTokenSecret:          getEnv("ONE_TIME_TOKEN_SECRET", ""),
+		EmailVerificationTTL:        getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		EmailResendInterval:         getEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		MailDriver:                  getEnv("MAIL_DRIVER", "file"),
+		MailFrom:                    getEnv("MAIL_FROM", "no-reply@localhost"),
+		SMTPHost:                    getEnv("SMTP_HOST", ""),
+		SMTPPort:                    getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:                getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:                getEnv("SMTP_PASSWORD", ""),
+		MailOutboxDir:               getEnv("MAIL_OUTBOX_DIR", "mail-outbox"),
+		PasswordResetTTL:            getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetResendInterval: getEnvDuration("PASSWORD_RESET_RESEND_INTERVAL", time.Minute),
+		MagicLinkTTL:                getEnvDuration("MAGIC_LINK_TTL", 15*time.Minute),
+		MagicLinkResendInterval:     getEnvDuration("MAGIC_LINK_RESEND_INTERVAL", time.Minute),
+		InvitationTTL:               getEnvDuration("INVITATION_TTL", 7*24*time.Hour),
+		InvitationOnly:              getEnvBool("REGISTRATION_INVITATION_ONLY", false),
+		LoginBackoffThreshold:       getEnvInt("LOGIN_BACKOFF_THRESHOLD", 3),
+		LoginBackoffBase:            getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:             getEnvDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+		LoginLockoutThreshold:       getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginLockoutDuration:        getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginIPBackoffThreshold:     getEnvInt("LOGIN_IP_BACKOFF_THRESHOLD", 20),
+		LoginFailureWindow:          getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		ClientSecretRotationOverlap: getEnvDuration("CLIENT_SECRET_ROTATION_OVERLAP", 24*time.Hour),
 	}
 }
 
@@ -39,3 +166,49 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "15m") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool gets a boolean environment variable (e.g. "true") or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList gets a comma-separated environment variable or returns a default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
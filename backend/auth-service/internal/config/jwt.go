@@ -1,13 +1,27 @@
 package config
 
+import "time"
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	SecretKey string
+	SigningAlgorithm    string
+	Issuer              string
+	Audience            string
+	AccessTokenTTL      time.Duration
+	ImpersonationTTL    time.Duration
+	KeyRotationInterval time.Duration
+	KeyCheckInterval    time.Duration
 }
 
 // NewJWTConfig creates a new JWT configuration
-func NewJWTConfig(secretKey string) *JWTConfig {
+func NewJWTConfig(signingAlgorithm, issuer, audience string, accessTokenTTL, impersonationTTL, keyRotationInterval, keyCheckInterval time.Duration) *JWTConfig {
 	return &JWTConfig{
-		SecretKey: secretKey,
+		SigningAlgorithm:    signingAlgorithm,
+		Issuer:              issuer,
+		Audience:            audience,
+		AccessTokenTTL:      accessTokenTTL,
+		ImpersonationTTL:    impersonationTTL,
+		KeyRotationInterval: keyRotationInterval,
+		KeyCheckInterval:    keyCheckInterval,
 	}
 }
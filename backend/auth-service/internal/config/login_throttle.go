@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// LoginThrottleConfig holds brute-force protection settings for logins
+type LoginThrottleConfig struct {
+	BackoffThreshold   int
+	BackoffBase        time.Duration
+	BackoffMax         time.Duration
+	LockoutThreshold   int
+	LockoutDuration    time.Duration
+	IPBackoffThreshold int
+	FailureWindow      time.Duration
+}
+
+// NewLoginThrottleConfig creates a new login throttle configuration
+func NewLoginThrottleConfig(backoffThreshold int, backoffBase, backoffMax time.Duration, lockoutThreshold int, lockoutDuration time.Duration, ipBackoffThreshold int, failureWindow time.Duration) *LoginThrottleConfig {
+	return &LoginThrottleConfig{
+		BackoffThreshold:   backoffThreshold,
+		BackoffBase:        backoffBase,
+		BackoffMax:         backoffMax,
+		LockoutThreshold:   lockoutThreshold,
+		LockoutDuration:    lockoutDuration,
+		IPBackoffThreshold: ipBackoffThreshold,
+		FailureWindow:      failureWindow,
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string
+}
+
+// NewMailConfig creates a new mail configuration
+func NewMailConfig(driver, from, smtpHost string, smtpPort int, smtpUsername, smtpPassword, outboxDir string) *MailConfig {
+	return &MailConfig{
+		Driver:       driver,
+		From:         from,
+		SMTPHost:     smtpHost,
+		SMTPPort:     smtpPort,
+		SMTPUsername: smtpUsername,
+		SMTPPassword: smtpPassword,
+		OutboxDir:    outboxDir,
+	}
+}
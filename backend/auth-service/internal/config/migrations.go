@@ -0,0 +1,102 @@
+package config
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration is a versioned, one-off change to the database schema or data.
+// Replicas starting at the same time may both run a migration before either
+// records it, so Up must be safe to run more than once.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// migrationRecord is the schema_migrations entry of an applied migration
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// MigrationLog records which migrations have been applied. Migrate keeps it
+// in the schema_migrations collection.
+type MigrationLog interface {
+	// Applied reports whether the migration with the version was recorded.
+	Applied(ctx context.Context, version int) (bool, error)
+	// Record records a migration as applied. Recording it twice is not an
+	// error.
+	Record(ctx context.Context, migration Migration, appliedAt time.Time) error
+}
+
+// mongoMigrationLog records applied migrations in the schema_migrations
+// collection.
+type mongoMigrationLog struct {
+	collection *mongo.Collection
+}
+
+func (l mongoMigrationLog) Applied(ctx context.Context, version int) (bool, error) {
+	err := l.collection.FindOne(ctx, bson.M{"_id": version}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l mongoMigrationLog) Record(ctx context.Context, migration Migration, appliedAt time.Time) error {
+	_, err := l.collection.InsertOne(ctx, migrationRecord{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   appliedAt,
+	})
+	// Another replica may have recorded the same migration meanwhile.
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Migrate applies the migrations not yet recorded in the schema_migrations
+// collection. See RunMigrations.
+func (m *MongoDBConfig) Migrate(ctx context.Context, migrations []Migration) error {
+	return RunMigrations(ctx, m.database, mongoMigrationLog{collection: m.database.Collection("schema_migrations")}, migrations)
+}
+
+// RunMigrations applies the migrations not yet recorded in log to db in
+// version order, recording each one after it succeeds. It stops at the first
+// failure so later migrations never run on a partial schema.
+func RunMigrations(ctx context.Context, db *mongo.Database, log MigrationLog, migrations []Migration) error {
+	pending := slices.Clone(migrations)
+	slices.SortFunc(pending, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	for _, migration := range pending {
+		applied, err := log.Applied(ctx, migration.Version)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		if err := migration.Up(ctx, db); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		if err := log.Record(ctx, migration, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -43,6 +44,36 @@ func (m *MongoDBConfig) GetCollection(name string) *mongo.Collection {
 	return m.database.Collection(name)
 }
 
+// SupportsTransactions reports whether the deployment is a replica set or a
+// sharded cluster. Standalone servers reject transactions.
+func (m *MongoDBConfig) SupportsTransactions(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := m.database.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
+
+// WithTransaction runs fn in a transaction: the writes fn makes through the
+// context it is given either all commit or none do. fn is run again when the
+// transaction fails with a transient error, so it must not have other side
+// effects.
+func (m *MongoDBConfig) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := m.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
 // Close closes the MongoDB connection
 func (m *MongoDBConfig) Close() error {
 	if m == nil || m.client == nil {
@@ -0,0 +1,51 @@
+package config
+
+// PasswordHashConfig holds password hashing configuration
+type PasswordHashConfig struct {
+	Algorithm         string
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// NewPasswordHashConfig creates a new password hashing configuration
+func NewPasswordHashConfig(algorithm string, argon2Memory, argon2Iterations uint32, argon2Parallelism uint8, bcryptCost int) *PasswordHashConfig {
+	return &PasswordHashConfig{
+		Algorithm:         algorithm,
+		Argon2Memory:      argon2Memory,
+		Argon2Iterations:  argon2Iterations,
+		Argon2Parallelism: argon2Parallelism,
+		BcryptCost:        bcryptCost,
+	}
+}
+
+// PasswordPolicyConfig holds the rules new passwords have to satisfy. A zero
+// MaxLength or MaxRepeats disables that rule; an empty BreachedPasswordFile
+// disables the breached-password check.
+type PasswordPolicyConfig struct {
+	MinLength            int
+	MaxLength            int
+	RequireUppercase     bool
+	RequireLowercase     bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	MaxRepeats           int
+	RejectPersonalInfo   bool
+	BreachedPasswordFile string
+}
+
+// NewPasswordPolicyConfig creates a new password policy configuration
+func NewPasswordPolicyConfig(minLength, maxLength int, requireUppercase, requireLowercase, requireDigit, requireSymbol bool, maxRepeats int, rejectPersonalInfo bool, breachedPasswordFile string) *PasswordPolicyConfig {
+	return &PasswordPolicyConfig{
+		MinLength:            minLength,
+		MaxLength:            maxLength,
+		RequireUppercase:     requireUppercase,
+		RequireLowercase:     requireLowercase,
+		RequireDigit:         requireDigit,
+		RequireSymbol:        requireSymbol,
+		MaxRepeats:           maxRepeats,
+		RejectPersonalInfo:   rejectPersonalInfo,
+		BreachedPasswordFile: breachedPasswordFile,
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// auditExportTimeout bounds how long an NDJSON export may stream.
+const auditExportTimeout = 5 * time.Minute
+
+// AuditHandler handles admin HTTP requests for the authentication audit trail
+type AuditHandler struct {
+	auditService *services.AuditService
+	logger       logger.Logger
+}
+
+// NewAuditHandler creates a new AuditHandler with the provided audit service
+func NewAuditHandler(auditService *services.AuditService, logger logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// List handles requests for audit events of the admin's tenant. With format=ndjson every matching
+// event is streamed in chronological order, one JSON object per line;
+// otherwise the most recent events are returned as a JSON array.
+func (h *AuditHandler) List(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Failed to bind audit query",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Admins only see the trail of their own tenant.
+	query.TenantID = middleware.TenantID(c)
+
+	if query.Format == "ndjson" {
+		h.export(c, query)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := h.auditService.Find(ctx, query)
+	if err != nil {
+		h.logger.Error("Failed to query audit events",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query audit events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func (h *AuditHandler) export(c *gin.Context, query models.AuditQuery) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), auditExportTimeout)
+	defer cancel()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="auth-audit.ndjson"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	err := h.auditService.Export(ctx, query, func(event *models.AuditEvent) error {
+		return encoder.Encode(event)
+	})
+	if err != nil {
+		// The status line is already sent; the truncated body is all the
+		// client gets.
+		h.logger.Error("Failed to export audit events",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
 	"auth-service/internal/models"
 	"auth-service/internal/services"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -42,7 +46,27 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	response, err := h.authService.Login(req.Email, req.Password)
+	response, err := h.authService.Login(req.Email, req.Password, clientInfo(c))
+	var blocked *services.LoginBlockedError
+	if errors.As(err, &blocked) {
+		h.logger.Warn("Login blocked",
+			zap.String("email", req.Email),
+			zap.Error(err),
+			zap.Duration("retry_after", blocked.RetryAfter),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		setRetryAfter(c, blocked.RetryAfter)
+		c.JSON(loginBlockedStatus(blocked), gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		h.logger.Warn("Login rejected, email not verified",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Warn("Login failed", 
 			zap.String("email", req.Email),
@@ -78,7 +102,49 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	response, err := h.authService.Register(req)
+	response, err := h.authService.Register(req, clientInfo(c))
+	var weak *services.PasswordPolicyError
+	if errors.As(err, &weak) {
+		h.logger.Info("Registration rejected by password policy",
+			zap.String("email", req.Email),
+			zap.Int("violations", len(weak.Violations)),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "violations": weak.Violations})
+		return
+	}
+	if errors.Is(err, services.ErrUserExists) {
+		h.logger.Info("Registration rejected, email already registered",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrSignupDisabled) {
+		h.logger.Info("Registration rejected, signup disabled for tenant",
+			zap.String("tenant_id", middleware.TenantID(c)),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvitationRequired) {
+		h.logger.Info("Registration rejected, invitation required",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidInvitation) {
+		h.logger.Info("Registration rejected, invalid invitation",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Warn("Registration failed", 
 			zap.String("email", req.Email),
@@ -146,7 +212,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	response, err := h.authService.RefreshToken(req.Token)
+	response, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(c))
+	if errors.Is(err, services.ErrRefreshTokenReused) {
+		h.logger.Warn("Refresh token reuse detected, token family revoked",
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Warn("Token refresh failed", 
 			zap.Error(err),
@@ -161,3 +234,228 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	)
 	c.JSON(http.StatusOK, response)
 }
+
+// Logout handles logout requests by revoking the caller's access token and
+// optionally its refresh token family
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Failed to bind logout request",
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.authService.Logout(claims, req.RefreshToken, clientInfo(c)); err != nil {
+		h.logger.Error("Logout failed",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	h.logger.Info("Logout successful",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Logged out"})
+}
+
+// RevokeUserTokens handles admin requests to revoke every token of a user
+func (h *AuthHandler) RevokeUserTokens(c *gin.Context) {
+	userID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.RevokeTokensRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Failed to bind revoke tokens request",
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "admin_revocation"
+	}
+
+	err := h.authService.RevokeAllTokens(userID, req.Reason, admin.UserID, clientInfo(c))
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to revoke user tokens",
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke tokens"})
+		return
+	}
+
+	h.logger.Info("User tokens revoked",
+		zap.String("user_id", userID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("reason", req.Reason),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "All tokens revoked"})
+}
+
+// UnlockUser handles admin requests to clear a user's failed login lockout
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	err := h.authService.UnlockAccount(middleware.TenantID(c), userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to unlock user",
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock user"})
+		return
+	}
+
+	h.logger.Info("User unlocked",
+		zap.String("user_id", userID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Account unlocked"})
+}
+
+// AssignRole handles admin requests to change a user's role
+func (h *AuthHandler) AssignRole(c *gin.Context) {
+	userID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind assign role request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.AssignRole(userID, req.Role, admin.UserID, clientInfo(c))
+	if errors.Is(err, services.ErrUnknownRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "roles": services.RoleNames()})
+		return
+	}
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to assign role",
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		return
+	}
+
+	h.logger.Info("Role assigned",
+		zap.String("user_id", userID),
+		zap.String("role", user.Role),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "user_id": user.ID, "role": user.Role})
+}
+
+// ListRoles handles admin requests for the known roles and their permissions
+func (h *AuthHandler) ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"roles": services.Roles()})
+}
+
+// Impersonate handles admin requests for a short-lived token that acts as another user
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	userID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind impersonation request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.Impersonate(userID, admin, req.Reason, clientInfo(c))
+	if errors.Is(err, services.ErrImpersonationNotAllowed) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to start impersonation",
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start impersonation"})
+		return
+	}
+
+	h.logger.Warn("Impersonation started",
+		zap.String("user_id", userID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("reason", req.Reason),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
+
+// loginBlockedStatus maps a blocked login to 423 Locked for account lockouts
+// and 429 Too Many Requests for back-off delays.
+func loginBlockedStatus(blocked *services.LoginBlockedError) int {
+	if errors.Is(blocked, services.ErrAccountLocked) {
+		return http.StatusLocked
+	}
+	return http.StatusTooManyRequests
+}
+
+// clientInfo describes the device the request came from and the tenant it
+// acts in.
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		TenantID:  middleware.TenantID(c),
+	}
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounded up.
+func setRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+}
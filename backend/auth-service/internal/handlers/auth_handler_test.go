@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testPassword = "Correct-Horse-7"
+
+// testServer serves the authentication and OpenID Connect routes of
+// auth-service over in-memory dependencies.
+type testServer struct {
+	*httptest.Server
+	users   *testutil.MemoryUserRepository
+	clients *testutil.MemoryOAuthClientDirectory
+	audit   *testutil.MemoryAuditRepository
+	grpc    *AuthGRPCHandler
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	keyStore, err := testutil.NewMemoryKeyStore("EdDSA")
+	if err != nil {
+		t.Fatalf("NewMemoryKeyStore: %v", err)
+	}
+	jwtService := services.NewJWTService(config.NewJWTConfig("EdDSA", "auth-service", "api", 15*time.Minute, 5*time.Minute, 0, 0), keyStore)
+	passwords, err := services.NewPasswordService(config.NewPasswordHashConfig("argon2id", 1024, 1, 1, 4), logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewPasswordService: %v", err)
+	}
+	policy, err := services.NewPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 10})
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+
+	users := testutil.NewMemoryUserRepository()
+	events := testutil.NewMemoryEventPublisher()
+	tenants := testutil.NewMemoryTenantDirectory(models.Tenant{
+		ID:       services.DefaultTenantID,
+		Status:   services.TenantStatusActive,
+		Settings: models.TenantSettings{AllowSignup: true},
+	})
+	refreshTokens := services.NewRefreshTokenService(testutil.NewMemoryRefreshTokenRepository(), time.Hour)
+	throttle := services.NewLoginThrottleService(testutil.NewMemoryLoginAttemptRepository(),
+		config.NewLoginThrottleConfig(5, time.Second, time.Minute, 3, 15*time.Minute, 50, time.Hour), nil)
+	audit := testutil.NewMemoryAuditRepository()
+	auditService := services.NewAuditService(audit, nil)
+	authService := services.NewAuthService(users, users, testutil.MemoryTransactor{}, jwtService, passwords, policy,
+		refreshTokens, testutil.NewMemoryRevocationList(), testutil.NewMemoryMFAVerifier(), testutil.NewMemoryVerificationSender(),
+		testutil.NewMemoryInvitationRedeemer(false), throttle, testutil.NewMemorySessionStore(refreshTokens, time.Hour),
+		auditService, tenants, events, nil, logger.NewNopLogger())
+
+	clients := testutil.NewMemoryOAuthClientDirectory()
+	oidcService := services.NewOIDCService(testutil.NewMemoryAuthorizationCodeRepository(), authService, jwtService, clients, "http://auth.example.com")
+
+	authHandler := NewAuthHandler(authService, logger.NewNopLogger())
+	auditHandler := NewAuditHandler(auditService, logger.NewNopLogger())
+	passwordHandler := NewPasswordHandler(authService, nil, logger.NewNopLogger())
+	oidcHandler := NewOIDCHandler(oidcService, authService, logger.NewNopLogger())
+	r := gin.New()
+	r.Use(middleware.ResolveTenant(tenants))
+	api := r.Group("/api/auth")
+	{
+		api.POST("/login", authHandler.Login)
+		api.POST("/register", authHandler.Register)
+		api.POST("/validate", authHandler.ValidateToken)
+		api.POST("/refresh", authHandler.RefreshToken)
+		api.POST("/logout", middleware.RequireAuth(authService), authHandler.Logout)
+		api.PUT("/password", middleware.RequireAuth(authService), middleware.ForbidImpersonation(), passwordHandler.Change)
+	}
+	r.GET("/api/auth/audit", middleware.RequireAuth(authService), middleware.RequireRole(authService, "admin"), auditHandler.List)
+	admin := r.Group("/api/auth/admin", middleware.RequireAuth(authService), middleware.RequireRole(authService, "admin"))
+	{
+		admin.PUT("/users/:id/role", authHandler.AssignRole)
+		admin.GET("/roles", authHandler.ListRoles)
+		admin.POST("/users/:id/impersonate", authHandler.Impersonate)
+	}
+	oauth := r.Group("/oauth2")
+	{
+		oauth.GET("/authorize", oidcHandler.AuthorizeForm)
+		oauth.POST("/authorize", oidcHandler.Authorize)
+		oauth.POST("/token", oidcHandler.Token)
+		oauth.POST("/introspect", oidcHandler.Introspect)
+		oauth.GET("/userinfo", middleware.RequireDelegatedAuth(authService), oidcHandler.UserInfo)
+	}
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	grpcHandler := NewAuthGRPCHandler(authService, oidcService, keyStore, logger.NewNopLogger())
+	return &testServer{Server: server, users: users, clients: clients, audit: audit, grpc: grpcHandler}
+}
+
+// post sends body as JSON and decodes the JSON response into out, if given.
+func (s *testServer) post(t *testing.T, path, bearer string, body, out interface{}) *http.Response {
+	t.Helper()
+
+	return s.send(t, http.MethodPost, path, bearer, body, out)
+}
+
+// send makes a request with body as JSON, if given, and decodes the JSON
+// response into out, if given.
+func (s *testServer) send(t *testing.T, method, path, bearer string, body, out interface{}) *http.Response {
+	t.Helper()
+
+	var payload []byte
+	if body != nil || method == http.MethodPost {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, s.URL+path, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s response: %v", path, err)
+		}
+	}
+	return resp
+}
+
+// registerActive registers an account through the API and activates it as
+// if the verification link had been followed.
+func (s *testServer) registerActive(t *testing.T, email string) {
+	t.Helper()
+
+	var registered models.RegisterResponse
+	resp := s.post(t, "/api/auth/register", "", models.RegisterRequest{
+		Name: "Jane Roe", Email: email, Password: testPassword, ConfirmPassword: testPassword,
+	}, &registered)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if _, err := s.users.Activate(context.Background(), registered.User.ID, email); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+}
+
+func (s *testServer) login(t *testing.T, email string) models.LoginResponse {
+	t.Helper()
+
+	var login models.LoginResponse
+	resp := s.post(t, "/api/auth/login", "", models.LoginRequest{Email: email, Password: testPassword}, &login)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	return login
+}
+
+// registerAdmin registers an active account and makes it an admin of the
+// default tenant.
+func (s *testServer) registerAdmin(t *testing.T, email string) {
+	t.Helper()
+
+	s.registerActive(t, email)
+	user, err := s.users.FindByEmail(context.Background(), services.DefaultTenantID, email)
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if _, err := s.users.SetRole(context.Background(), services.DefaultTenantID, user.ID, "admin"); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+}
+
+func TestRegisterHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "taken@example.com")
+
+	tests := []struct {
+		name string
+		req  models.RegisterRequest
+		want int
+	}{
+		{name: "created", req: models.RegisterRequest{Name: "New", Email: "new@example.com", Password: testPassword, ConfirmPassword: testPassword}, want: http.StatusCreated},
+		{name: "passwords differ", req: models.RegisterRequest{Name: "New", Email: "other@example.com", Password: testPassword, ConfirmPassword: "something-else"}, want: http.StatusBadRequest},
+		{name: "invalid email", req: models.RegisterRequest{Name: "New", Email: "not-an-email", Password: testPassword, ConfirmPassword: testPassword}, want: http.StatusBadRequest},
+		{name: "weak password", req: models.RegisterRequest{Name: "New", Email: "weak@example.com", Password: "short", ConfirmPassword: "short"}, want: http.StatusBadRequest},
+		{name: "email taken", req: models.RegisterRequest{Name: "New", Email: "Taken@example.com", Password: testPassword, ConfirmPassword: testPassword}, want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			resp := s.post(t, "/api/auth/register", "", tt.req, &body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+
+	var weak struct {
+		Violations []models.PasswordViolation `json:"violations"`
+	}
+	s.post(t, "/api/auth/register", "", models.RegisterRequest{Name: "New", Email: "weak@example.com", Password: "short", ConfirmPassword: "short"}, &weak)
+	if len(weak.Violations) != 1 || weak.Violations[0].Rule != services.PasswordRuleMinLength {
+		t.Errorf("violations = %+v, want min_length", weak.Violations)
+	}
+}
+
+func TestRegisterHandlerUnknownTenant(t *testing.T) {
+	s := newTestServer(t)
+
+	payload, _ := json.Marshal(models.RegisterRequest{Name: "New", Email: "new@example.com", Password: testPassword, ConfirmPassword: testPassword})
+	req, _ := http.NewRequest(http.MethodPost, s.URL+"/api/auth/register", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.TenantHeader, "nowhere")
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+
+	login := s.login(t, "jane@example.com")
+	if login.Status != "success" || login.Token == "" || login.RefreshToken == "" {
+		t.Errorf("login response = %+v, want tokens", login)
+	}
+
+	var validation models.TokenValidationResponse
+	s.post(t, "/api/auth/validate", "", models.TokenValidationRequest{Token: login.Token}, &validation)
+	if !validation.Valid || validation.TenantID != services.DefaultTenantID {
+		t.Errorf("validation = %+v, want a valid token of the default tenant", validation)
+	}
+}
+
+func TestLoginHandlerPendingAccount(t *testing.T) {
+	s := newTestServer(t)
+	s.post(t, "/api/auth/register", "", models.RegisterRequest{
+		Name: "Jane Roe", Email: "jane@example.com", Password: testPassword, ConfirmPassword: testPassword,
+	}, nil)
+
+	resp := s.post(t, "/api/auth/login", "", models.LoginRequest{Email: "jane@example.com", Password: testPassword}, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+func TestLoginHandlerLocksAfterFailures(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+
+	for i := 0; i < 3; i++ {
+		resp := s.post(t, "/api/auth/login", "", models.LoginRequest{Email: "jane@example.com", Password: "wrong-password"}, nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("failure %d status = %d, want %d", i+1, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	resp := s.post(t, "/api/auth/login", "", models.LoginRequest{Email: "jane@example.com", Password: testPassword}, nil)
+	if resp.StatusCode != http.StatusLocked {
+		t.Fatalf("status while locked = %d, want %d", resp.StatusCode, http.StatusLocked)
+	}
+	if resp.Header.Get("Retry-After") != "900" {
+		t.Errorf("Retry-After = %q, want 900", resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestRefreshHandlerDetectsReuse(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+
+	var refreshed models.RefreshTokenResponse
+	resp := s.post(t, "/api/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: login.RefreshToken}, &refreshed)
+	if resp.StatusCode != http.StatusOK || refreshed.Token == "" {
+		t.Fatalf("refresh status = %d, response %+v", resp.StatusCode, refreshed)
+	}
+
+	resp = s.post(t, "/api/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: login.RefreshToken}, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("replayed refresh status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	resp = s.post(t, "/api/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken}, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh after reuse status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+
+	if resp := s.post(t, "/api/auth/logout", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("logout without a token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp := s.post(t, "/api/auth/logout", login.Token, models.LogoutRequest{RefreshToken: login.RefreshToken}, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if resp := s.post(t, "/api/auth/logout", login.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("second logout status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	var validation models.TokenValidationResponse
+	s.post(t, "/api/auth/validate", "", models.TokenValidationRequest{Token: login.Token}, &validation)
+	if validation.Valid {
+		t.Error("validate reports a logged out token as valid")
+	}
+	if resp := s.post(t, "/api/auth/refresh", "", models.RefreshTokenRequest{RefreshToken: login.RefreshToken}, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh after logout status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminRoutesRequireAdminRole(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+	s.registerAdmin(t, "admin@example.com")
+	jane := s.login(t, "jane@example.com")
+	admin := s.login(t, "admin@example.com")
+
+	if resp := s.send(t, http.MethodGet, "/api/auth/admin/roles", "", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("roles without a token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := s.send(t, http.MethodGet, "/api/auth/admin/roles", jane.Token, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("roles as a customer status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	var roles struct {
+		Roles map[string][]string `json:"roles"`
+	}
+	if resp := s.send(t, http.MethodGet, "/api/auth/admin/roles", admin.Token, nil, &roles); resp.StatusCode != http.StatusOK || len(roles.Roles["admin"]) == 0 {
+		t.Errorf("roles as an admin status = %d, body %+v", resp.StatusCode, roles)
+	}
+}
+
+func TestAssignRoleHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerActive(t, "jane@example.com")
+	s.registerAdmin(t, "admin@example.com")
+	jane := s.login(t, "jane@example.com")
+	admin := s.login(t, "admin@example.com")
+	user, err := s.users.FindByEmail(context.Background(), services.DefaultTenantID, "jane@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	other := &models.User{ID: "other", TenantID: "acme", Email: "other@example.com", EmailNormalized: "other@example.com", Status: "active", Role: "customer"}
+	if err := s.users.Create(context.Background(), other); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A customer cannot promote itself.
+	path := "/api/auth/admin/users/" + user.ID + "/role"
+	if resp := s.send(t, http.MethodPut, path, jane.Token, models.AssignRoleRequest{Role: "admin"}, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("self-promotion status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		role   string
+		want   int
+	}{
+		{name: "unknown role", userID: user.ID, role: "owner", want: http.StatusBadRequest},
+		{name: "user of another tenant", userID: other.ID, role: "admin", want: http.StatusNotFound},
+		{name: "assigned", userID: user.ID, role: "admin", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			resp := s.send(t, http.MethodPut, "/api/auth/admin/users/"+tt.userID+"/role", admin.Token, models.AssignRoleRequest{Role: tt.role}, &body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d (body %v)", resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+
+	if resp := s.send(t, http.MethodGet, "/api/auth/admin/roles", jane.Token, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("roles with a token issued before the promotion status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	// Tokens issued in the millisecond of the revocation are revoked as well.
+	time.Sleep(2 * time.Millisecond)
+	promoted := s.login(t, "jane@example.com")
+	if resp := s.send(t, http.MethodGet, "/api/auth/admin/roles", promoted.Token, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("roles after the promotion status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	stored, err := s.users.FindByID(context.Background(), "acme", other.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.Role != "customer" {
+		t.Errorf("role of the other tenant's user = %q, want customer", stored.Role)
+	}
+}
+
+func TestAuditHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerAdmin(t, "admin@example.com")
+	s.registerActive(t, "jane@example.com")
+	jane := s.login(t, "jane@example.com")
+	s.post(t, "/api/auth/login", "", models.LoginRequest{Email: "jane@example.com", Password: "wrong-password"}, nil)
+	admin := s.login(t, "admin@example.com")
+	other := &models.AuditEvent{ID: "other", TenantID: "acme", EventType: services.AuditLogin, Outcome: services.AuditOutcomeSuccess, Timestamp: time.Now().UTC()}
+	if err := s.audit.Insert(context.Background(), other); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	if resp := s.send(t, http.MethodGet, "/api/auth/audit", jane.Token, nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("audit as a customer status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	var events []models.AuditEvent
+	resp := s.send(t, http.MethodGet, "/api/auth/audit?event_type=login", admin.Token, nil, &events)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("audit status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(events) != 3 || events[0].Email != "admin@example.com" || events[1].Outcome != services.AuditOutcomeFailure || events[1].Reason != "invalid_credentials" {
+		t.Errorf("events = %+v, want the three logins of the tenant, newest first", events)
+	}
+	for _, event := range events {
+		if event.TenantID != services.DefaultTenantID || event.EventType != services.AuditLogin {
+			t.Errorf("event %+v is not a login of the admin's tenant", event)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/api/auth/audit?format=ndjson&user_id="+events[2].UserID, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+admin.Token)
+	export, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/auth/audit: %v", err)
+	}
+	defer export.Body.Close()
+	if export.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("export Content-Type = %q, want application/x-ndjson", export.Header.Get("Content-Type"))
+	}
+	var exported []models.AuditEvent
+	decoder := json.NewDecoder(export.Body)
+	for decoder.More() {
+		var event models.AuditEvent
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("decode export line: %v", err)
+		}
+		exported = append(exported, event)
+	}
+	if len(exported) != 3 || exported[0].EventType != services.AuditRegister || exported[1].Outcome != services.AuditOutcomeSuccess || exported[2].Outcome != services.AuditOutcomeFailure {
+		t.Errorf("exported = %+v, want jane's registration and logins in chronological order", exported)
+	}
+
+	if resp := s.send(t, http.MethodGet, "/api/auth/audit?limit=5000", admin.Token, nil, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("audit with an oversized limit status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestImpersonateHandler(t *testing.T) {
+	s := newTestServer(t)
+	s.registerAdmin(t, "admin@example.com")
+	s.registerActive(t, "jane@example.com")
+	admin := s.login(t, "admin@example.com")
+	jane, err := s.users.FindByEmail(context.Background(), services.DefaultTenantID, "jane@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	path := "/api/auth/admin/users/" + jane.ID + "/impersonate"
+
+	if resp := s.post(t, path, admin.Token, models.ImpersonationRequest{}, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("impersonate without a reason status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if resp := s.post(t, "/api/auth/admin/users/unknown/impersonate", admin.Token, models.ImpersonationRequest{Reason: "ticket 42"}, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("impersonate of an unknown user status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	var impersonation models.ImpersonationResponse
+	if resp := s.post(t, path, admin.Token, models.ImpersonationRequest{Reason: "ticket 42"}, &impersonation); resp.StatusCode != http.StatusOK {
+		t.Fatalf("impersonate status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The impersonation token reads as the user but cannot act as its owner.
+	var validation models.TokenValidationResponse
+	s.post(t, "/api/auth/validate", "", models.TokenValidationRequest{Token: impersonation.Token}, &validation)
+	if !validation.Valid || validation.UserID != jane.ID || validation.ActorID == "" {
+		t.Errorf("validation = %+v, want %s with an actor", validation, jane.ID)
+	}
+	change := models.ChangePasswordRequest{CurrentPassword: testPassword, Password: "Another-Horse-8", ConfirmPassword: "Another-Horse-8"}
+	if resp := s.send(t, http.MethodPut, "/api/auth/password", impersonation.Token, change, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("password change while impersonating status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if resp := s.post(t, "/api/auth/admin/users/"+jane.ID+"/impersonate", impersonation.Token, models.ImpersonationRequest{Reason: "ticket 42"}, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("impersonate with an impersonation token status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	// The password is unchanged.
+	s.login(t, "jane@example.com")
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EmailVerificationHandler handles HTTP requests for confirming email addresses
+type EmailVerificationHandler struct {
+	verificationService *services.EmailVerificationService
+	logger              logger.Logger
+}
+
+// NewEmailVerificationHandler creates a new EmailVerificationHandler with the provided service
+func NewEmailVerificationHandler(verificationService *services.EmailVerificationService, logger logger.Logger) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+		logger:              logger,
+	}
+}
+
+// Verify handles requests carrying the token from a verification link
+func (h *EmailVerificationHandler) Verify(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind verify email request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.verificationService.Verify(ctx, req.Token)
+	if errors.Is(err, services.ErrInvalidOneTimeToken) {
+		h.logger.Warn("Email verification failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrUserExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Email verification failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	h.logger.Info("Email verified",
+		zap.String("user_id", user.ID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Email verified"})
+}
+
+// Resend handles requests for a new verification email. The response is the
+// same whether or not a pending account exists for the address.
+func (h *EmailVerificationHandler) Resend(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind resend verification request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.verificationService.Resend(ctx, middleware.TenantID(c), req.Email)
+	if errors.Is(err, services.ErrVerificationThrottled) {
+		h.logger.Info("Verification email throttled",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	} else if err != nil {
+		h.logger.Error("Failed to resend verification email",
+			zap.String("email", req.Email),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "If the address belongs to an unverified account, a new verification email has been sent",
+	})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthGRPCHandler serves the auth.v1 gRPC API used by other services to check
+// access tokens
+type AuthGRPCHandler struct {
+	authv1.UnimplementedAuthServiceServer
+	authService *services.AuthService
+	oidcService *services.OIDCService
+	keyStore    *services.KeyStore
+	logger      logger.Logger
+}
+
+// NewAuthGRPCHandler creates a new AuthGRPCHandler with the provided services
+func NewAuthGRPCHandler(authService *services.AuthService, oidcService *services.OIDCService, keyStore *services.KeyStore, logger logger.Logger) *AuthGRPCHandler {
+	return &AuthGRPCHandler{
+		authService: authService,
+		oidcService: oidcService,
+		keyStore:    keyStore,
+		logger:      logger,
+	}
+}
+
+// ValidateToken checks an access token the same way as POST /api/auth/validate.
+// Invalid tokens are not an error; they are reported with valid=false.
+func (h *AuthGRPCHandler) ValidateToken(ctx context.Context, req *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	claims, err := h.authService.Authenticate(req.GetToken())
+	if err != nil {
+		return &authv1.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	response := &authv1.ValidateTokenResponse{
+		Valid:         true,
+		PrincipalType: claims.PrincipalType(),
+		UserId:        claims.UserID,
+		ClientId:      claims.ClientID,
+		Scope:         claims.Scope,
+		Role:          claims.Role,
+		Permissions:   claims.Permissions,
+		SessionId:     claims.SessionID,
+		ActorId:       claims.ActorID(),
+	}
+	if claims.PrincipalType() == services.PrincipalUser {
+		response.TenantId = claims.Tenant()
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	return response, nil
+}
+
+// Introspect describes an access token the same way as POST /oauth2/introspect
+func (h *AuthGRPCHandler) Introspect(ctx context.Context, req *authv1.IntrospectRequest) (*authv1.IntrospectResponse, error) {
+	response, err := h.oidcService.Introspect(models.IntrospectionRequest{Token: req.GetToken()}, req.GetClientId(), req.GetClientSecret())
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if errors.As(err, &oauthErr) {
+			h.logger.Warn("gRPC introspection request rejected",
+				zap.String("client_id", req.GetClientId()),
+				zap.String("error", oauthErr.Code),
+			)
+			return nil, status.Error(grpcCode(oauthErr.Status), oauthErr.Description)
+		}
+
+		h.logger.Error("gRPC introspection request failed",
+			zap.String("client_id", req.GetClientId()),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, "failed to introspect token")
+	}
+
+	introspection := &authv1.IntrospectResponse{
+		Active:        response.Active,
+		Sub:           response.Subject,
+		Exp:           response.ExpiresAt,
+		Iat:           response.IssuedAt,
+		Nbf:           response.NotBefore,
+		Iss:           response.Issuer,
+		Aud:           response.Audience,
+		Jti:           response.JTI,
+		Scope:         response.Scope,
+		ClientId:      response.ClientID,
+		TokenType:     response.TokenType,
+		PrincipalType: response.PrincipalType,
+		UserId:        response.UserID,
+		Role:          response.Role,
+		Permissions:   response.Permissions,
+		Amr:           response.AMR,
+		Sid:           response.SessionID,
+		TenantId:      response.TenantID,
+	}
+	if response.Actor != nil {
+		introspection.Act = &authv1.Actor{Sub: response.Actor.Subject}
+	}
+	return introspection, nil
+}
+
+// GetPublicKeys returns the keys published at /.well-known/jwks.json
+func (h *AuthGRPCHandler) GetPublicKeys(ctx context.Context, req *authv1.GetPublicKeysRequest) (*authv1.GetPublicKeysResponse, error) {
+	jwks := h.keyStore.JWKS()
+
+	response := &authv1.GetPublicKeysResponse{Keys: make([]*authv1.PublicKey, 0, len(jwks.Keys))}
+	for _, key := range jwks.Keys {
+		response.Keys = append(response.Keys, &authv1.PublicKey{
+			Kty: key.KeyType,
+			Kid: key.KeyID,
+			Use: key.Use,
+			Alg: key.Algorithm,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Curve,
+			X:   key.X,
+		})
+	}
+	return response, nil
+}
+
+// grpcCode maps the HTTP status of an OAuth error to a gRPC status code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/pkg/authclient"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// userID returns the ID of the account of email in the default tenant.
+func (s *testServer) userID(t *testing.T, email string) string {
+	t.Helper()
+
+	user, err := s.users.FindByEmail(context.Background(), services.DefaultTenantID, email)
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	return user.ID
+}
+
+// dialGRPC serves the auth.v1 gRPC API of the server in memory and returns
+// an authclient connected to it.
+func (s *testServer) dialGRPC(t *testing.T, cacheTTL time.Duration) *authclient.Client {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnaryInterceptor(middleware.GRPCZapInterceptor(logger.NewNopLogger())))
+	authv1.RegisterAuthServiceServer(server, s.grpc)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := authclient.New("passthrough:///bufnet", cacheTTL,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("authclient.New: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGRPCValidateToken(t *testing.T) {
+	s := newTestServer(t)
+	client := s.dialGRPC(t, 0)
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+	janeID := s.userID(t, "jane@example.com")
+	ctx := context.Background()
+
+	response, err := client.ValidateToken(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !response.GetValid() || response.GetUserId() != janeID || response.GetTenantId() != services.DefaultTenantID ||
+		response.GetPrincipalType() != services.PrincipalUser || response.GetRole() != "customer" || response.GetSessionId() == "" ||
+		response.GetActorId() != "" || response.GetExpiresAt() <= time.Now().Unix() {
+		t.Errorf("validation = %v, want the token of %s", response, janeID)
+	}
+
+	if resp := s.post(t, "/api/auth/logout", login.Token, models.LogoutRequest{RefreshToken: login.RefreshToken}, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	for name, token := range map[string]string{"revoked": login.Token, "malformed": "not-a-token", "empty": ""} {
+		response, err := client.ValidateToken(ctx, token)
+		if err != nil {
+			t.Fatalf("ValidateToken of the %s token: %v", name, err)
+		}
+		if response.GetValid() || response.GetUserId() != "" {
+			t.Errorf("validation of the %s token = %v, want only valid=false", name, response)
+		}
+	}
+}
+
+func TestGRPCValidateImpersonationToken(t *testing.T) {
+	s := newTestServer(t)
+	client := s.dialGRPC(t, 0)
+	s.registerAdmin(t, "admin@example.com")
+	s.registerActive(t, "jane@example.com")
+	admin := s.login(t, "admin@example.com")
+	adminID, janeID := s.userID(t, "admin@example.com"), s.userID(t, "jane@example.com")
+	var impersonation models.ImpersonationResponse
+	if resp := s.post(t, "/api/auth/admin/users/"+janeID+"/impersonate", admin.Token, models.ImpersonationRequest{Reason: "ticket 42"}, &impersonation); resp.StatusCode != http.StatusOK {
+		t.Fatalf("impersonate status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	response, err := client.ValidateToken(context.Background(), impersonation.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !response.GetValid() || response.GetUserId() != janeID || response.GetActorId() != adminID {
+		t.Errorf("validation = %v, want %s held by %s", response, janeID, adminID)
+	}
+}
+
+func TestGRPCIntrospect(t *testing.T) {
+	s := newTestServer(t)
+	client := s.dialGRPC(t, 0)
+	s.clients.Put(models.OAuthClient{
+		ID:            "gateway",
+		Name:          "API Gateway",
+		GrantTypes:    []string{models.GrantTypeClientCredentials},
+		AllowedScopes: []string{services.PermissionTokensIntrospect},
+	}, "gateway-secret")
+	s.clients.Put(models.OAuthClient{
+		ID:            "billing",
+		Name:          "Billing",
+		GrantTypes:    []string{models.GrantTypeClientCredentials},
+		AllowedScopes: []string{services.PermissionUsersReadAny},
+	}, "billing-secret")
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+	janeID := s.userID(t, "jane@example.com")
+	ctx := context.Background()
+
+	response, err := client.Introspect(ctx, login.Token, "gateway", "gateway-secret")
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if !response.GetActive() || response.GetSub() != janeID || response.GetTenantId() != services.DefaultTenantID ||
+		response.GetTokenType() != "Bearer" || response.GetExp() <= response.GetIat() || response.GetAct() != nil {
+		t.Errorf("introspection = %v, want the active token of %s", response, janeID)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		clientID string
+		secret   string
+		want     codes.Code
+	}{
+		{name: "wrong secret", token: login.Token, clientID: "gateway", secret: "guess", want: codes.Unauthenticated},
+		{name: "unknown client", token: login.Token, clientID: "unknown", secret: "guess", want: codes.Unauthenticated},
+		{name: "client without the scope", token: login.Token, clientID: "billing", secret: "billing-secret", want: codes.PermissionDenied},
+		{name: "no token", clientID: "gateway", secret: "gateway-secret", want: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := client.Introspect(ctx, tt.token, tt.clientID, tt.secret)
+			if got := status.Code(err); got != tt.want || response != nil {
+				t.Errorf("Introspect = %v, code %s, want no response and %s", response, got, tt.want)
+			}
+		})
+	}
+
+	if resp := s.post(t, "/api/auth/logout", login.Token, models.LogoutRequest{RefreshToken: login.RefreshToken}, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	response, err = client.Introspect(ctx, login.Token, "gateway", "gateway-secret")
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if response.GetActive() || response.GetSub() != "" {
+		t.Errorf("introspection after logout = %v, want only active=false", response)
+	}
+}
+
+func TestGRPCGetPublicKeysVerifyTokens(t *testing.T) {
+	s := newTestServer(t)
+	client := s.dialGRPC(t, 0)
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+
+	keys, err := client.GetPublicKeys(context.Background())
+	if err != nil {
+		t.Fatalf("GetPublicKeys: %v", err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("GetPublicKeys returned no keys")
+	}
+
+	// The token verifies with the published key named in its header.
+	_, err = jwt.Parse(login.Token, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range keys {
+			if key.GetKid() != token.Header["kid"] {
+				continue
+			}
+			if key.GetKty() != "OKP" || key.GetCrv() != "Ed25519" || key.GetAlg() != "EdDSA" || key.GetUse() != "sig" {
+				t.Errorf("key = %v, want an Ed25519 signing key", key)
+			}
+			x, err := base64.RawURLEncoding.DecodeString(key.GetX())
+			if err != nil {
+				return nil, err
+			}
+			return ed25519.PublicKey(x), nil
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil {
+		t.Errorf("verify token with the published keys: %v", err)
+	}
+}
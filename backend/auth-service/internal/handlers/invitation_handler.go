@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InvitationHandler handles admin requests for the invitations of their tenant
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+	logger            logger.Logger
+}
+
+// NewInvitationHandler creates a new InvitationHandler with the provided invitation service
+func NewInvitationHandler(invitationService *services.InvitationService, logger logger.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		logger:            logger,
+	}
+}
+
+// Create handles requests to invite an email address to the admin's tenant
+func (h *InvitationHandler) Create(c *gin.Context) {
+	admin := middleware.GetClaims(c)
+
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind invitation request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	invitation, err := h.invitationService.Create(ctx, middleware.TenantID(c), req, admin.UserID)
+	if errors.Is(err, services.ErrUnknownRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "roles": services.RoleNames()})
+		return
+	}
+	if errors.Is(err, services.ErrUserExists) || errors.Is(err, services.ErrInvitationPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to create invitation",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invitation"})
+		return
+	}
+
+	h.logger.Info("Invitation sent",
+		zap.String("invitation_id", invitation.ID),
+		zap.String("role", invitation.Role),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// List handles requests for the invitations of the admin's tenant, newest first
+func (h *InvitationHandler) List(c *gin.Context) {
+	var query models.InvitationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Failed to bind invitation query",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.TenantID = middleware.TenantID(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitations, err := h.invitationService.List(ctx, query)
+	if err != nil {
+		h.logger.Error("Failed to list invitations",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Get handles requests for a single invitation of the admin's tenant
+func (h *InvitationHandler) Get(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, err := h.invitationService.Get(ctx, middleware.TenantID(c), c.Param("id"))
+	if errors.Is(err, services.ErrInvitationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get invitation",
+			zap.String("invitation_id", c.Param("id")),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get invitation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// Revoke handles requests to withdraw a pending invitation
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	invitationID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invitation, err := h.invitationService.Revoke(ctx, middleware.TenantID(c), invitationID, admin.UserID)
+	if errors.Is(err, services.ErrInvitationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvitationNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to revoke invitation",
+			zap.String("invitation_id", invitationID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke invitation"})
+		return
+	}
+
+	h.logger.Info("Invitation revoked",
+		zap.String("invitation_id", invitationID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, invitation)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler serves the public keys used to verify tokens
+type JWKSHandler struct {
+	keyStore *services.KeyStore
+	logger   logger.Logger
+}
+
+// NewJWKSHandler creates a new JWKSHandler with the provided key store
+func NewJWKSHandler(keyStore *services.KeyStore, logger logger.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		keyStore: keyStore,
+		logger:   logger,
+	}
+}
+
+// GetJWKS handles requests for the JSON Web Key Set
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Verifiers refetch on unknown kids, so a short cache is enough.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keyStore.JWKS())
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MagicLinkHandler handles HTTP requests for passwordless sign-in links
+type MagicLinkHandler struct {
+	magicLinkService *services.MagicLinkService
+	logger           logger.Logger
+}
+
+// NewMagicLinkHandler creates a new MagicLinkHandler with the provided service
+func NewMagicLinkHandler(magicLinkService *services.MagicLinkService, logger logger.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		logger:           logger,
+	}
+}
+
+// Request handles requests to email a sign-in link. The response is the same
+// whether or not an account exists for the address.
+func (h *MagicLinkHandler) Request(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind magic link request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.magicLinkService.Send(ctx, middleware.TenantID(c), req.Email, req.Nonce)
+	if errors.Is(err, services.ErrMagicLinkThrottled) {
+		h.logger.Info("Magic link email throttled",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	} else if err != nil {
+		h.logger.Error("Failed to send magic link email",
+			zap.String("email", req.Email),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "If an account exists for the address, a sign-in link has been sent",
+	})
+}
+
+// Consume handles requests to sign in with the token from a magic link
+func (h *MagicLinkHandler) Consume(c *gin.Context) {
+	var req models.ConsumeMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind consume magic link request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.magicLinkService.Consume(ctx, req.Token, req.Nonce, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidOneTimeToken) || errors.Is(err, services.ErrMagicLinkNonceMismatch) {
+		h.logger.Warn("Magic link login failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Magic link login failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign in"})
+		return
+	}
+
+	h.logger.Info("Magic link login successful",
+		zap.String("status", response.Status),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
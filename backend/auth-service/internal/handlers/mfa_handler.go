@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MFAHandler handles HTTP requests for TOTP two-factor authentication
+type MFAHandler struct {
+	mfaService  *services.MFAService
+	authService *services.AuthService
+	logger      logger.Logger
+}
+
+// NewMFAHandler creates a new MFAHandler with the provided services
+func NewMFAHandler(mfaService *services.MFAService, authService *services.AuthService, logger logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaService:  mfaService,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// EnrollTOTP handles requests to start TOTP enrollment for the caller
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	user, err := h.authService.GetUser(claims.Tenant(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.mfaService.Enroll(ctx, user.ID, user.Email)
+	if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to enroll TOTP",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enroll mfa"})
+		return
+	}
+
+	h.logger.Info("TOTP enrollment started",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmTOTP handles requests to activate a pending TOTP enrollment
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.mfaService.Confirm(ctx, claims.UserID, req.Code)
+	if err != nil {
+		h.writeMFAError(c, "Failed to confirm TOTP", claims.UserID, err)
+		return
+	}
+
+	h.logger.Info("MFA enabled",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{Status: "success", RecoveryCodes: codes})
+}
+
+// DisableTOTP handles requests to turn MFA off, which require a current code
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.mfaService.Disable(ctx, claims.UserID, req.Code); err != nil {
+		h.writeMFAError(c, "Failed to disable MFA", claims.UserID, err)
+		return
+	}
+
+	h.logger.Info("MFA disabled",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "MFA disabled"})
+}
+
+// RegenerateRecoveryCodes handles requests to replace the caller's recovery codes
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(ctx, claims.UserID, req.Code)
+	if err != nil {
+		h.writeMFAError(c, "Failed to regenerate recovery codes", claims.UserID, err)
+		return
+	}
+
+	h.logger.Info("Recovery codes regenerated",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{Status: "success", RecoveryCodes: codes})
+}
+
+// Verify handles the second step of an MFA login
+func (h *MFAHandler) Verify(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind mfa verify request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.authService.VerifyMFA(req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		h.logger.Warn("MFA verification failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		switch {
+		case errors.Is(err, services.ErrInvalidMFAChallenge), errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrMFANotEnrolled):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify mfa"})
+		}
+		return
+	}
+
+	h.logger.Info("MFA login successful",
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *MFAHandler) writeMFAError(c *gin.Context, message, userID string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFANotEnrolled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message,
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "mfa request failed"})
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OAuthClientHandler handles admin requests for the OAuth client registry
+type OAuthClientHandler struct {
+	clientService *services.OAuthClientService
+	logger        logger.Logger
+}
+
+// NewOAuthClientHandler creates a new OAuthClientHandler with the provided client service
+func NewOAuthClientHandler(clientService *services.OAuthClientService, logger logger.Logger) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		clientService: clientService,
+		logger:        logger,
+	}
+}
+
+// CreateClient handles requests to register a new OAuth client
+func (h *OAuthClientHandler) CreateClient(c *gin.Context) {
+	admin := middleware.GetClaims(c)
+
+	var req models.OAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind OAuth client request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.clientService.Create(req)
+	if errors.Is(err, services.ErrInvalidOAuthClient) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to create OAuth client",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
+		return
+	}
+
+	h.logger.Info("OAuth client created",
+		zap.String("client_id", response.ID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListClients handles requests to list all OAuth clients
+func (h *OAuthClientHandler) ListClients(c *gin.Context) {
+	clients, err := h.clientService.List()
+	if err != nil {
+		h.logger.Error("Failed to list OAuth clients",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// GetClient handles requests for a single OAuth client
+func (h *OAuthClientHandler) GetClient(c *gin.Context) {
+	client, err := h.clientService.Get(c.Param("id"))
+	if errors.Is(err, services.ErrOAuthClientNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get OAuth client",
+			zap.String("client_id", c.Param("id")),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get client"})
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// UpdateClient handles requests to change an OAuth client's grant types,
+// redirect URIs, scopes or name
+func (h *OAuthClientHandler) UpdateClient(c *gin.Context) {
+	clientID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.OAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind OAuth client request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := h.clientService.Update(clientID, req)
+	if errors.Is(err, services.ErrOAuthClientNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidOAuthClient) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update OAuth client",
+			zap.String("client_id", clientID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update client"})
+		return
+	}
+
+	h.logger.Info("OAuth client updated",
+		zap.String("client_id", clientID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, client)
+}
+
+// DeleteClient handles requests to remove an OAuth client
+func (h *OAuthClientHandler) DeleteClient(c *gin.Context) {
+	clientID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	err := h.clientService.Delete(clientID)
+	if errors.Is(err, services.ErrOAuthClientNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete OAuth client",
+			zap.String("client_id", clientID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete client"})
+		return
+	}
+
+	h.logger.Info("OAuth client deleted",
+		zap.String("client_id", clientID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Client deleted"})
+}
+
+// RotateSecret handles requests to replace a confidential client's secret.
+// The new secret is returned once; the previous one keeps working for the
+// overlap window.
+func (h *OAuthClientHandler) RotateSecret(c *gin.Context) {
+	clientID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.RotateClientSecretRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Error("Failed to bind rotate secret request",
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var overlap *time.Duration
+	if req.OverlapSeconds != nil {
+		d := time.Duration(*req.OverlapSeconds) * time.Second
+		overlap = &d
+	}
+
+	response, err := h.clientService.RotateSecret(clientID, overlap)
+	if errors.Is(err, services.ErrOAuthClientNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrPublicOAuthClient) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to rotate OAuth client secret",
+			zap.String("client_id", clientID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate secret"})
+		return
+	}
+
+	h.logger.Info("OAuth client secret rotated",
+		zap.String("client_id", clientID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// loginPage is the login form shown during the authorization code flow. The
+// authorization parameters are carried through as hidden fields.
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+{{if .ClientName}}<h1>Sign in to continue to {{.ClientName}}</h1>{{end}}
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+{{if .Request}}
+<form method="post" action="/oauth2/authorize">
+  <input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+  <input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+  <input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+  <input type="hidden" name="scope" value="{{.Request.Scope}}">
+  <input type="hidden" name="state" value="{{.Request.State}}">
+  <input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+  <input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+  <input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+  <label>Email <input type="email" name="email" required autofocus></label>
+  <label>Password <input type="password" name="password" required></label>
+  {{if .MFARequired}}<label>Authentication code <input type="text" name="code" inputmode="numeric" autocomplete="one-time-code" required></label>{{end}}
+  <button type="submit">Sign in</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type loginPageData struct {
+	ClientName  string
+	Error       string
+	MFARequired bool
+	Request     *models.AuthorizeRequest
+}
+
+// OIDCHandler handles the OpenID Connect provider endpoints
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+	authService *services.AuthService
+	logger      logger.Logger
+}
+
+// NewOIDCHandler creates a new OIDCHandler with the provided services
+func NewOIDCHandler(oidcService *services.OIDCService, authService *services.AuthService, logger logger.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Discovery handles requests for the OpenID Provider configuration
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.oidcService.Discovery())
+}
+
+// AuthorizeForm validates an authorization request and shows the login form
+func (h *OIDCHandler) AuthorizeForm(c *gin.Context) {
+	req, client, ok := h.bindAuthorizeRequest(c)
+	if !ok {
+		return
+	}
+
+	h.renderLogin(c, http.StatusOK, loginPageData{ClientName: client.Name, Request: req})
+}
+
+// Authorize checks the submitted credentials and redirects back to the client
+// with an authorization code
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	req, client, ok := h.bindAuthorizeRequest(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.authService.CheckCredentials(c.PostForm("email"), c.PostForm("password"), clientInfo(c))
+	if err != nil {
+		h.logger.Warn("Authorization login failed",
+			zap.String("client_id", client.ID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		status := http.StatusUnauthorized
+		message := "Invalid email or password."
+		var blocked *services.LoginBlockedError
+		switch {
+		case errors.As(err, &blocked):
+			setRetryAfter(c, blocked.RetryAfter)
+			status = loginBlockedStatus(blocked)
+			message = "Too many failed sign in attempts, please try again later."
+		case errors.Is(err, services.ErrEmailNotVerified):
+			status = http.StatusForbidden
+			message = "Please verify your email address before signing in."
+		case !errors.Is(err, services.ErrInvalidCredentials):
+			status = http.StatusInternalServerError
+			message = "Sign in failed, please try again."
+		}
+		h.renderLogin(c, status, loginPageData{ClientName: client.Name, Error: message, Request: req})
+		return
+	}
+
+	amr, err := h.authService.CheckSecondFactor(user, c.PostForm("code"), clientInfo(c))
+	if err != nil {
+		data := loginPageData{ClientName: client.Name, MFARequired: true, Request: req}
+		status := http.StatusUnauthorized
+		switch {
+		case errors.Is(err, services.ErrMFARequired):
+			data.Error = "Enter the code from your authenticator app or a recovery code."
+			status = http.StatusOK
+		case errors.Is(err, services.ErrInvalidMFACode):
+			data.Error = "Invalid authentication code."
+		default:
+			h.logger.Error("Failed to verify second factor",
+				zap.String("client_id", client.ID),
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			data.Error = "Sign in failed, please try again."
+			status = http.StatusInternalServerError
+		}
+		h.renderLogin(c, status, data)
+		return
+	}
+
+	code, err := h.oidcService.IssueCode(*req, user.ID, amr, clientInfo(c))
+	if err != nil {
+		h.logger.Error("Failed to issue authorization code",
+			zap.String("client_id", client.ID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		h.redirectWithError(c, req, &services.OAuthError{Code: "server_error", Description: "failed to issue authorization code"})
+		return
+	}
+
+	h.logger.Info("Authorization code issued",
+		zap.String("client_id", client.ID),
+		zap.String("user_id", user.ID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	h.redirect(c, req, url.Values{"code": {code}})
+}
+
+// bindAuthorizeRequest binds and validates the authorization parameters. When
+// it returns false a response has already been written.
+func (h *OIDCHandler) bindAuthorizeRequest(c *gin.Context) (*models.AuthorizeRequest, *models.OAuthClient, bool) {
+	var req models.AuthorizeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.renderLogin(c, http.StatusBadRequest, loginPageData{Error: "Malformed authorization request."})
+		return nil, nil, false
+	}
+
+	client, err := h.oidcService.ValidateClient(req)
+	if err != nil {
+		// The redirect URI is not trusted, so the error is shown to the user.
+		var oauthErr *services.OAuthError
+		if !errors.As(err, &oauthErr) {
+			h.logger.Error("Failed to load OAuth client",
+				zap.String("client_id", req.ClientID),
+				zap.Error(err),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			oauthErr = &services.OAuthError{Description: "the authorization server is unavailable", Status: http.StatusInternalServerError}
+		}
+		h.renderLogin(c, oauthErr.Status, loginPageData{Error: "Invalid authorization request: " + oauthErr.Description + "."})
+		return nil, nil, false
+	}
+
+	if err := h.oidcService.ValidateAuthorizeRequest(client, req); err != nil {
+		var oauthErr *services.OAuthError
+		errors.As(err, &oauthErr)
+		h.redirectWithError(c, &req, oauthErr)
+		return nil, nil, false
+	}
+
+	return &req, client, true
+}
+
+func (h *OIDCHandler) renderLogin(c *gin.Context, status int, data loginPageData) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := loginPage.Execute(c.Writer, data); err != nil {
+		h.logger.Error("Failed to render login page", zap.Error(err))
+	}
+}
+
+func (h *OIDCHandler) redirectWithError(c *gin.Context, req *models.AuthorizeRequest, oauthErr *services.OAuthError) {
+	h.redirect(c, req, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+	})
+}
+
+// redirect sends the user back to the validated redirect URI with the given
+// parameters and the client's state.
+func (h *OIDCHandler) redirect(c *gin.Context, req *models.AuthorizeRequest, params url.Values) {
+	target, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		h.renderLogin(c, http.StatusBadRequest, loginPageData{Error: "Invalid redirect URI."})
+		return
+	}
+
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	target.RawQuery = query.Encode()
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target.String())
+}
+
+// Token handles token requests from OAuth clients
+func (h *OIDCHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req models.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.writeOAuthError(c, &services.OAuthError{Code: "invalid_request", Description: "malformed token request", Status: http.StatusBadRequest})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+
+	response, err := h.oidcService.Exchange(req, clientID, clientSecret)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if errors.As(err, &oauthErr) {
+			h.logger.Warn("Token request rejected",
+				zap.String("client_id", clientID),
+				zap.String("error", oauthErr.Code),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			h.writeOAuthError(c, oauthErr)
+			return
+		}
+
+		h.logger.Error("Token request failed",
+			zap.String("client_id", clientID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		h.writeOAuthError(c, &services.OAuthError{Code: "server_error", Description: "failed to issue tokens", Status: http.StatusInternalServerError})
+		return
+	}
+
+	h.logger.Info("Tokens issued to OAuth client",
+		zap.String("client_id", clientID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
+
+// Introspect handles RFC 7662 token introspection requests from service accounts
+func (h *OIDCHandler) Introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req models.IntrospectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.writeOAuthError(c, &services.OAuthError{Code: "invalid_request", Description: "malformed introspection request", Status: http.StatusBadRequest})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+
+	response, err := h.oidcService.Introspect(req, clientID, clientSecret)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if errors.As(err, &oauthErr) {
+			h.logger.Warn("Introspection request rejected",
+				zap.String("client_id", clientID),
+				zap.String("error", oauthErr.Code),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			h.writeOAuthError(c, oauthErr)
+			return
+		}
+
+		h.logger.Error("Introspection request failed",
+			zap.String("client_id", clientID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		h.writeOAuthError(c, &services.OAuthError{Code: "server_error", Description: "failed to introspect token", Status: http.StatusInternalServerError})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UserInfo handles OpenID Connect UserInfo requests. It must run after
+// RequireDelegatedAuth.
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	response, err := h.oidcService.UserInfo(claims)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if errors.As(err, &oauthErr) {
+			h.writeOAuthError(c, oauthErr)
+			return
+		}
+
+		h.logger.Error("UserInfo request failed",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		h.writeOAuthError(c, &services.OAuthError{Code: "server_error", Description: "failed to load user info", Status: http.StatusInternalServerError})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// clientCredentials returns the client ID and secret of a request.
+// client_secret_basic takes precedence over client_secret_post.
+func clientCredentials(c *gin.Context, formID, formSecret string) (string, string) {
+	clientID, clientSecret := formID, formSecret
+	if username, password, ok := c.Request.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(username)
+		clientSecret, _ = url.QueryUnescape(password)
+	}
+	return clientID, clientSecret
+}
+
+func (h *OIDCHandler) writeOAuthError(c *gin.Context, oauthErr *services.OAuthError) {
+	if oauthErr.Code == "invalid_client" {
+		c.Header("WWW-Authenticate", `Basic realm="auth-service"`)
+	}
+	c.JSON(oauthErr.Status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const (
+	testClientID    = "web-app"
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// addTestClient registers a public client using the authorization code flow.
+func (s *testServer) addTestClient() {
+	s.clients.Put(models.OAuthClient{
+		ID:            testClientID,
+		Name:          "Web App",
+		GrantTypes:    []string{models.GrantTypeAuthorizationCode},
+		RedirectURIs:  []string{testRedirectURI},
+		AllowedScopes: []string{"openid", "profile", "email"},
+	}, "")
+}
+
+// authorizeParams are the parameters of a valid authorization request with
+// the PKCE challenge of verifier.
+func authorizeParams(verifier string) url.Values {
+	sum := sha256.Sum256([]byte(verifier))
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {testClientID},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {"openid profile email"},
+		"state":                 {"state-1"},
+		"nonce":                 {"nonce-1"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// postForm sends form without following redirects.
+func (s *testServer) postForm(t *testing.T, path string, form url.Values) *http.Response {
+	t.Helper()
+
+	client := *s.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.PostForm(s.URL+path, form)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// authorize signs in through the authorization endpoint and returns the code
+// it redirects back with.
+func (s *testServer) authorize(t *testing.T, email, verifier string) string {
+	t.Helper()
+
+	form := authorizeParams(verifier)
+	form.Set("email", email)
+	form.Set("password", testPassword)
+	resp := s.postForm(t, "/oauth2/authorize", form)
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse Location: %v", err)
+	}
+	query := location.Query()
+	if !strings.HasPrefix(location.String(), testRedirectURI+"?") || query.Get("state") != "state-1" || query.Get("code") == "" {
+		t.Fatalf("authorize redirected to %s, want %s with a code and the state", location, testRedirectURI)
+	}
+	return query.Get("code")
+}
+
+// exchange redeems code at the token endpoint and decodes the JSON response
+// into out.
+func (s *testServer) exchange(t *testing.T, code, redirectURI, verifier string, out interface{}) *http.Response {
+	t.Helper()
+
+	resp := s.postForm(t, "/oauth2/token", url.Values{
+		"grant_type":    {models.GrantTypeAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {testClientID},
+	})
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("decode token response: %v", err)
+	}
+	return resp
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	s := newTestServer(t)
+	s.addTestClient()
+	s.registerActive(t, "jane@example.com")
+
+	form, err := s.Client().Get(s.URL + "/oauth2/authorize?" + authorizeParams(testVerifier).Encode())
+	if err != nil {
+		t.Fatalf("GET /oauth2/authorize: %v", err)
+	}
+	form.Body.Close()
+	if form.StatusCode != http.StatusOK {
+		t.Fatalf("login form status = %d, want %d", form.StatusCode, http.StatusOK)
+	}
+
+	code := s.authorize(t, "jane@example.com", testVerifier)
+
+	var tokens models.TokenResponse
+	resp := s.exchange(t, code, testRedirectURI, testVerifier, &tokens)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("token status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if tokens.AccessToken == "" || tokens.IDToken == "" || tokens.TokenType != "Bearer" || tokens.Scope != "openid profile email" {
+		t.Errorf("token response = %+v, want an access token and an ID token for openid profile email", tokens)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Errorf("token Cache-Control = %q, want no-store", resp.Header.Get("Cache-Control"))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/oauth2/userinfo", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	userInfoResp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /oauth2/userinfo: %v", err)
+	}
+	defer userInfoResp.Body.Close()
+	if userInfoResp.StatusCode != http.StatusOK {
+		t.Fatalf("userinfo status = %d, want %d", userInfoResp.StatusCode, http.StatusOK)
+	}
+	var info models.UserInfoResponse
+	if err := json.NewDecoder(userInfoResp.Body).Decode(&info); err != nil {
+		t.Fatalf("decode userinfo response: %v", err)
+	}
+	if info.Subject == "" || info.Email != "jane@example.com" || info.Name != "Jane Roe" || info.EmailVerified == nil || !*info.EmailVerified {
+		t.Errorf("userinfo = %+v, want the verified profile and email of jane@example.com", info)
+	}
+}
+
+func TestOIDCAccessTokenIsLimitedToOIDCEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	s.addTestClient()
+	s.registerActive(t, "jane@example.com")
+
+	var tokens models.TokenResponse
+	code := s.authorize(t, "jane@example.com", testVerifier)
+	if resp := s.exchange(t, code, testRedirectURI, testVerifier, &tokens); resp.StatusCode != http.StatusOK {
+		t.Fatalf("token status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	login := s.login(t, "jane@example.com")
+
+	// A client's access token only grants its scope, not the account
+	if resp := s.post(t, "/api/auth/logout", tokens.AccessToken, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("logout with an OIDC access token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/oauth2/userinfo", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+login.Token)
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /oauth2/userinfo: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("userinfo with a first-party token status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestOIDCTokenRejectsCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		redeemFirst bool
+		redirectURI string
+		verifier    string
+	}{
+		{name: "wrong code_verifier", redirectURI: testRedirectURI, verifier: "not-the-verifier-of-the-code-challenge-at-all"},
+		{name: "mismatched redirect_uri", redirectURI: "https://app.example.com/other", verifier: testVerifier},
+		{name: "reused code", redeemFirst: true, redirectURI: testRedirectURI, verifier: testVerifier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.addTestClient()
+			s.registerActive(t, "jane@example.com")
+			code := s.authorize(t, "jane@example.com", testVerifier)
+
+			if tt.redeemFirst {
+				var tokens models.TokenResponse
+				if resp := s.exchange(t, code, testRedirectURI, testVerifier, &tokens); resp.StatusCode != http.StatusOK {
+					t.Fatalf("first token status = %d, want %d", resp.StatusCode, http.StatusOK)
+				}
+			}
+
+			var body map[string]string
+			resp := s.exchange(t, code, tt.redirectURI, tt.verifier, &body)
+			if resp.StatusCode != http.StatusBadRequest || body["error"] != "invalid_grant" {
+				t.Errorf("token status = %d, body %v, want %d invalid_grant", resp.StatusCode, body, http.StatusBadRequest)
+			}
+
+			// A rejected code is spent as well.
+			resp = s.exchange(t, code, testRedirectURI, testVerifier, &body)
+			if resp.StatusCode != http.StatusBadRequest || body["error"] != "invalid_grant" {
+				t.Errorf("retry status = %d, body %v, want %d invalid_grant", resp.StatusCode, body, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestOIDCAuthorizeRejectsUnregisteredRedirectURI(t *testing.T) {
+	s := newTestServer(t)
+	s.addTestClient()
+	s.registerActive(t, "jane@example.com")
+
+	form := authorizeParams(testVerifier)
+	form.Set("redirect_uri", "https://evil.example.com/callback")
+	form.Set("email", "jane@example.com")
+	form.Set("password", testPassword)
+	resp := s.postForm(t, "/oauth2/authorize", form)
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Location") != "" {
+		t.Errorf("authorize status = %d, Location %q, want %d without a redirect", resp.StatusCode, resp.Header.Get("Location"), http.StatusBadRequest)
+	}
+}
+
+func TestOIDCIntrospect(t *testing.T) {
+	s := newTestServer(t)
+	s.clients.Put(models.OAuthClient{
+		ID:            "gateway",
+		Name:          "API Gateway",
+		GrantTypes:    []string{models.GrantTypeClientCredentials},
+		AllowedScopes: []string{services.PermissionTokensIntrospect},
+	}, "gateway-secret")
+	s.registerActive(t, "jane@example.com")
+	login := s.login(t, "jane@example.com")
+
+	introspect := func(t *testing.T, token, secret string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/oauth2/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gateway", secret)
+		resp, err := s.Client().Do(req)
+		if err != nil {
+			t.Fatalf("POST /oauth2/introspect: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode introspection response: %v", err)
+		}
+		return resp, body
+	}
+
+	resp, body := introspect(t, login.Token, "gateway-secret")
+	if resp.StatusCode != http.StatusOK || body["active"] != true || body["sub"] == nil || body["exp"] == nil || body["iat"] == nil ||
+		body["token_type"] != "Bearer" || body["role"] != "customer" || body["tenant_id"] != services.DefaultTenantID {
+		t.Errorf("introspection status = %d, body %v, want the active token with its claims", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Errorf("introspection Cache-Control = %q, want no-store", resp.Header.Get("Cache-Control"))
+	}
+
+	if resp, body := introspect(t, login.Token, "guess"); resp.StatusCode != http.StatusUnauthorized || body["error"] != "invalid_client" {
+		t.Errorf("introspection with a wrong secret status = %d, body %v, want %d invalid_client", resp.StatusCode, body, http.StatusUnauthorized)
+	}
+
+	if resp := s.post(t, "/api/auth/logout", login.Token, models.LogoutRequest{RefreshToken: login.RefreshToken}, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp, body := introspect(t, login.Token, "gateway-secret"); resp.StatusCode != http.StatusOK || len(body) != 1 || body["active"] != false {
+		t.Errorf("introspection after logout status = %d, body %v, want only active: false", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PasswordHandler handles HTTP requests for password resets and changes
+type PasswordHandler struct {
+	authService  *services.AuthService
+	resetService *services.PasswordResetService
+	logger       logger.Logger
+}
+
+// NewPasswordHandler creates a new PasswordHandler with the provided services
+func NewPasswordHandler(authService *services.AuthService, resetService *services.PasswordResetService, logger logger.Logger) *PasswordHandler {
+	return &PasswordHandler{
+		authService:  authService,
+		resetService: resetService,
+		logger:       logger,
+	}
+}
+
+// Forgot handles requests to email a password reset link. The response is the
+// same whether or not an account exists for the address.
+func (h *PasswordHandler) Forgot(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind forgot password request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.resetService.Forgot(ctx, middleware.TenantID(c), req.Email)
+	if errors.Is(err, services.ErrPasswordResetThrottled) {
+		h.logger.Info("Password reset email throttled",
+			zap.String("email", req.Email),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	} else if err != nil {
+		h.logger.Error("Failed to send password reset email",
+			zap.String("email", req.Email),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "If an account exists for the address, a password reset email has been sent",
+	})
+}
+
+// Reset handles requests to set a new password with the token from a reset link
+func (h *PasswordHandler) Reset(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind reset password request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := h.resetService.Reset(ctx, req.Token, req.Password, clientInfo(c))
+	var weak *services.PasswordPolicyError
+	if errors.As(err, &weak) {
+		h.logger.Info("Password reset rejected by password policy",
+			zap.Int("violations", len(weak.Violations)),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "violations": weak.Violations})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidOneTimeToken) {
+		h.logger.Warn("Password reset failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Password reset failed",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	h.logger.Info("Password reset",
+		zap.String("user_id", userID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Password reset, please sign in again"})
+}
+
+// Change handles requests from a signed-in user to change their password
+func (h *PasswordHandler) Change(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind change password request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.authService.ChangePassword(claims.UserID, req.CurrentPassword, req.Password, clientInfo(c))
+	var weak *services.PasswordPolicyError
+	if errors.As(err, &weak) {
+		h.logger.Info("Password change rejected by password policy",
+			zap.String("user_id", claims.UserID),
+			zap.Int("violations", len(weak.Violations)),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "violations": weak.Violations})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		h.logger.Warn("Password change rejected",
+			zap.String("user_id", claims.UserID),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+		return
+	}
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Password change failed",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	h.logger.Info("Password changed",
+		zap.String("user_id", claims.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Password changed, please sign in again"})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SessionHandler handles HTTP requests for listing and ending the caller's sessions
+type SessionHandler struct {
+	authService *services.AuthService
+	logger      logger.Logger
+}
+
+// NewSessionHandler creates a new SessionHandler with the provided auth service
+func NewSessionHandler(authService *services.AuthService, logger logger.Logger) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// List handles requests for the caller's active sessions
+func (h *SessionHandler) List(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	sessions, err := h.authService.ListSessions(claims)
+	if err != nil {
+		h.logger.Error("Failed to list sessions",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// Terminate handles requests to sign one of the caller's sessions out
+func (h *SessionHandler) Terminate(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	sessionID := c.Param("id")
+
+	err := h.authService.TerminateSession(claims.UserID, sessionID, clientInfo(c))
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to terminate session",
+			zap.String("user_id", claims.UserID),
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to terminate session"})
+		return
+	}
+
+	h.logger.Info("Session terminated",
+		zap.String("user_id", claims.UserID),
+		zap.String("session_id", sessionID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Session terminated"})
+}
+
+// TerminateOthers handles requests to sign out every session except the caller's own
+func (h *SessionHandler) TerminateOthers(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	terminated, err := h.authService.TerminateOtherSessions(claims, clientInfo(c))
+	if err != nil {
+		h.logger.Error("Failed to terminate other sessions",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to terminate sessions"})
+		return
+	}
+
+	h.logger.Info("Other sessions terminated",
+		zap.String("user_id", claims.UserID),
+		zap.Int("terminated", terminated),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "terminated": terminated})
+}
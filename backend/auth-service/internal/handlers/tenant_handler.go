@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TenantHandler handles admin requests for the tenants hosted on the deployment
+type TenantHandler struct {
+	tenantService *services.TenantService
+	logger        logger.Logger
+}
+
+// NewTenantHandler creates a new TenantHandler with the provided tenant service
+func NewTenantHandler(tenantService *services.TenantService, logger logger.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+		logger:        logger,
+	}
+}
+
+// ListTenants handles requests to list all tenants
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	tenants, err := h.tenantService.List()
+	if err != nil {
+		h.logger.Error("Failed to list tenants",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tenants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+// CreateTenant handles requests to create a tenant
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	admin := middleware.GetClaims(c)
+
+	var req models.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind tenant request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, err := h.tenantService.Create(req)
+	if errors.Is(err, services.ErrInvalidTenantID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrTenantExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to create tenant",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tenant"})
+		return
+	}
+
+	h.logger.Info("Tenant created",
+		zap.String("tenant_id", tenant.ID),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// UpdateTenant handles requests to change a tenant's name, status or settings
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	tenantID := c.Param("id")
+	admin := middleware.GetClaims(c)
+
+	var req models.UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind tenant request",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tenantID == services.DefaultTenantID && req.Status != services.TenantStatusActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the default tenant cannot be disabled"})
+		return
+	}
+
+	tenant, err := h.tenantService.Update(tenantID, req)
+	if errors.Is(err, services.ErrTenantNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update tenant",
+			zap.String("tenant_id", tenantID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tenant"})
+		return
+	}
+
+	h.logger.Info("Tenant updated",
+		zap.String("tenant_id", tenant.ID),
+		zap.String("status", tenant.Status),
+		zap.String("admin_id", admin.UserID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, tenant)
+}
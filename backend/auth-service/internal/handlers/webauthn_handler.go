@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebAuthnHandler handles HTTP requests for passkey registration and login
+type WebAuthnHandler struct {
+	webAuthnService *services.WebAuthnService
+	authService     *services.AuthService
+	logger          logger.Logger
+}
+
+// NewWebAuthnHandler creates a new WebAuthnHandler with the provided services
+func NewWebAuthnHandler(webAuthnService *services.WebAuthnService, authService *services.AuthService, logger logger.Logger) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: webAuthnService,
+		authService:     authService,
+		logger:          logger,
+	}
+}
+
+// BeginRegistration handles requests to start registering a passkey for the caller
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	user, err := h.authService.GetUser(claims.Tenant(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.webAuthnService.BeginRegistration(ctx, user)
+	if err != nil {
+		h.logger.Error("Failed to begin passkey registration",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to begin registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishRegistration handles the attestation response of a passkey registration
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	var req models.WebAuthnRegisterFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind passkey registration",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	credential, err := h.webAuthnService.FinishRegistration(ctx, claims.Tenant(), claims.UserID, req)
+	if err != nil {
+		h.writeWebAuthnError(c, "Passkey registration failed", claims.UserID, err)
+		return
+	}
+
+	h.logger.Info("Passkey registered",
+		zap.String("user_id", claims.UserID),
+		zap.String("credential_id", credential.ID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusCreated, credential)
+}
+
+// BeginLogin handles requests to start a passkey login
+func (h *WebAuthnHandler) BeginLogin(c *gin.Context) {
+	var req models.WebAuthnLoginBeginRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Unknown emails fall back to a discoverable login so the response does
+	// not reveal which accounts exist.
+	var userID string
+	if req.Email != "" {
+		if user, err := h.authService.GetUserByEmail(middleware.TenantID(c), req.Email); err == nil {
+			userID = user.ID
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.webAuthnService.BeginLogin(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to begin passkey login",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to begin login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishLogin handles the assertion response of a passkey login and issues
+// tokens, or an MFA challenge if the authenticator did not verify the user
+func (h *WebAuthnHandler) FinishLogin(c *gin.Context) {
+	var req models.WebAuthnLoginFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind passkey login",
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	credential, amr, err := h.webAuthnService.FinishLogin(ctx, req, clientInfo(c))
+	if err != nil {
+		h.writeWebAuthnError(c, "Passkey login failed", "", err)
+		return
+	}
+
+	// The passkey signs in to the tenant of its account
+	response, err := h.authService.CompleteLogin(credential.TenantID, credential.UserID, amr, clientInfo(c))
+	var blocked *services.LoginBlockedError
+	if errors.As(err, &blocked) {
+		h.logger.Warn("Passkey login blocked",
+			zap.String("user_id", credential.UserID),
+			zap.Error(err),
+			zap.Duration("retry_after", blocked.RetryAfter),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		setRetryAfter(c, blocked.RetryAfter)
+		c.JSON(loginBlockedStatus(blocked), gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		h.logger.Warn("Passkey login rejected, email not verified",
+			zap.String("user_id", credential.UserID),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to issue tokens for passkey login",
+			zap.String("user_id", credential.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed"})
+		return
+	}
+
+	h.logger.Info("Passkey login successful",
+		zap.String("user_id", credential.UserID),
+		zap.String("status", response.Status),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, response)
+}
+
+// ListCredentials handles requests for the caller's registered passkeys
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	credentials, err := h.webAuthnService.ListCredentials(ctx, claims.UserID)
+	if err != nil {
+		h.logger.Error("Failed to list passkeys",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// DeleteCredential handles requests to remove one of the caller's passkeys
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	credentialID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := h.webAuthnService.DeleteCredential(ctx, claims.UserID, credentialID)
+	if errors.Is(err, services.ErrWebAuthnCredentialNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete passkey",
+			zap.String("user_id", claims.UserID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete credential"})
+		return
+	}
+
+	h.logger.Info("Passkey deleted",
+		zap.String("user_id", claims.UserID),
+		zap.String("credential_id", credentialID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Credential deleted"})
+}
+
+func (h *WebAuthnHandler) writeWebAuthnError(c *gin.Context, message, userID string, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebAuthnVerification),
+		errors.Is(err, services.ErrWebAuthnSessionInvalid),
+		errors.Is(err, services.ErrWebAuthnCredentialNotFound):
+		h.logger.Warn(message,
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "webauthn verification failed"})
+	case errors.Is(err, services.ErrWebAuthnCredentialExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message,
+			zap.String("user_id", userID),
+			zap.Error(err),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "webauthn request failed"})
+	}
+}
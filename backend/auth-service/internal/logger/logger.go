@@ -70,6 +70,11 @@ func NewZapLogger() (Logger, error) {
 	return &zapLogger{logger: logger}, nil
 }
 
+// NewNopLogger creates a logger that discards everything, for tests.
+func NewNopLogger() Logger {
+	return &zapLogger{logger: zap.NewNop()}
+}
+
 // Info logs an info-level message.
 func (l *zapLogger) Info(msg string, fields ...zap.Field) {
 	l.logger.Info(msg, fields...)
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"auth-service/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimsKey is the Gin context key holding the authenticated *services.Claims.
+const ClaimsKey = "claims"
+
+// RequireAuth rejects requests without a valid, unrevoked user bearer token
+// and stores the token claims in the context. Service account tokens are not
+// accepted because every auth-service route acts on a user account, and
+// tokens delegated to OAuth clients are not accepted because they only grant
+// their scope, not the account.
+func RequireAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authenticate(c, authService)
+		if !ok {
+			return
+		}
+		if claims.PrincipalType() != services.PrincipalUser {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "user token required"})
+			return
+		}
+		if claims.IsDelegated() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// RequireDelegatedAuth rejects requests without a valid, unrevoked bearer
+// token issued to an OAuth client on behalf of a user and stores the token
+// claims in the context. It guards the OpenID Connect endpoints clients call
+// with the access tokens they obtained.
+func RequireDelegatedAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authenticate(c, authService)
+		if !ok {
+			return
+		}
+		if !claims.IsDelegated() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// authenticate returns the claims of the request's valid, unrevoked bearer
+// token, or aborts the request and returns false.
+func authenticate(c *gin.Context, authService *services.AuthService) (*services.Claims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if authHeader == "" || tokenString == authHeader {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
+		return nil, false
+	}
+
+	claims, err := authService.Authenticate(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return nil, false
+	}
+	return claims, true
+}
+
+// RequireRole rejects authenticated requests whose user does not have one of
+// the given roles. It must run after RequireAuth.
+func RequireRole(authService *services.AuthService, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
+			return
+		}
+
+		for _, role := range roles {
+			ok, err := authService.HasRole(claims.Tenant(), claims.UserID, role)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check role"})
+				return
+			}
+			if ok {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
+
+// ForbidImpersonation rejects requests made with an impersonation token. It
+// guards operations only the account owner may perform, such as changing the
+// password or the second factors. It must run after RequireAuth.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := GetClaims(c); claims != nil && claims.IsImpersonated() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not allowed while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetClaims returns the claims stored by RequireAuth, or nil.
+func GetClaims(c *gin.Context) *services.Claims {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := value.(*services.Claims)
+	return claims
+}
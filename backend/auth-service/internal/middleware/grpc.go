@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"auth-service/internal/logger"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCZapInterceptor logs every unary gRPC call with its outcome, like
+// ZapMiddleware does for HTTP requests
+func GRPCZapInterceptor(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		clientAddr := ""
+		if p, ok := peer.FromContext(ctx); ok {
+			clientAddr = p.Addr.String()
+		}
+
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", status.Code(err).String()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_addr", clientAddr),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		log.Info("gRPC call completed", fields...)
+
+		return resp, err
+	}
+}
@@ -48,14 +48,23 @@ func ZapMiddleware(log logger.Logger) gin.HandlerFunc {
 
 		// Log response
 		latency := time.Since(start)
-		log.Info("Request completed",
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.Int("status", c.Writer.Status()),
 			zap.Duration("latency", latency),
 			zap.String("client_ip", c.ClientIP()),
 			zap.Int("response_size", c.Writer.Size()),
-		)
+		}
+		// Requests made by an admin impersonating a user name both of them
+		if claims := GetClaims(c); claims != nil && claims.IsImpersonated() {
+			fields = append(fields,
+				zap.Bool("impersonated", true),
+				zap.String("user_id", claims.UserID),
+				zap.String("actor_id", claims.ActorID()),
+			)
+		}
+		log.Info("Request completed", fields...)
 
 		// Log errors if any
 		if len(c.Errors) > 0 {
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// TenantHeader names the tenant of a request. The API gateway sets it
+	// from the request's subdomain when the client does not.
+	TenantHeader = "X-Tenant-ID"
+	// TenantKey is the Gin context key holding the request's *models.Tenant.
+	TenantKey = "tenant"
+)
+
+// ResolveTenant looks up the tenant named by the X-Tenant-ID header, or the
+// default tenant for requests without one, and stores it in the context.
+// Unknown tenants are rejected with 404 and disabled ones with 403.
+func ResolveTenant(tenantService services.TenantDirectory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := strings.ToLower(strings.TrimSpace(c.GetHeader(TenantHeader)))
+		if tenantID == "" {
+			tenantID = services.DefaultTenantID
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		tenant, err := tenantService.Get(ctx, tenantID)
+		if errors.Is(err, services.ErrTenantNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown tenant"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve tenant"})
+			return
+		}
+		if tenant.Status != services.TenantStatusActive {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "tenant is disabled"})
+			return
+		}
+
+		c.Set(TenantKey, tenant)
+		c.Next()
+	}
+}
+
+// RequireTenant rejects authenticated requests whose user belongs to another
+// tenant. It guards deployment-wide resources, such as the tenants
+// themselves, that only admins of the default tenant may manage. It must run
+// after RequireAuth.
+func RequireTenant(tenantID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := GetClaims(c); claims == nil || claims.Tenant() != tenantID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetTenant returns the tenant stored by ResolveTenant, or nil.
+func GetTenant(c *gin.Context) *models.Tenant {
+	value, ok := c.Get(TenantKey)
+	if !ok {
+		return nil
+	}
+	tenant, _ := value.(*models.Tenant)
+	return tenant
+}
+
+// TenantID returns the tenant a request acts in: the tenant of the
+// authenticated user if there is one, otherwise the tenant resolved from the
+// request.
+func TenantID(c *gin.Context) string {
+	if claims := GetClaims(c); claims != nil {
+		return claims.Tenant()
+	}
+	if tenant := GetTenant(c); tenant != nil {
+		return tenant.ID
+	}
+	return services.DefaultTenantID
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveTenant runs the handlers for a request with the X-Tenant-ID header,
+// if given, and returns the response.
+func serveTenant(tenantID string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", handlers...)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if tenantID != "" {
+		req.Header.Set(TenantHeader, tenantID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestResolveTenant(t *testing.T) {
+	tenants := testutil.NewMemoryTenantDirectory(
+		models.Tenant{ID: services.DefaultTenantID, Status: services.TenantStatusActive},
+		models.Tenant{ID: "acme", Status: services.TenantStatusActive},
+		models.Tenant{ID: "closed", Status: services.TenantStatusDisabled},
+	)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		tenant string
+	}{
+		{name: "no header", status: http.StatusOK, tenant: services.DefaultTenantID},
+		{name: "header", header: "acme", status: http.StatusOK, tenant: "acme"},
+		{name: "header in upper case with spaces", header: " ACME ", status: http.StatusOK, tenant: "acme"},
+		{name: "unknown tenant", header: "nowhere", status: http.StatusNotFound},
+		{name: "disabled tenant", header: "closed", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolved string
+			w := serveTenant(tt.header, ResolveTenant(tenants), func(c *gin.Context) {
+				resolved = TenantID(c)
+				c.Status(http.StatusOK)
+			})
+			if w.Code != tt.status || resolved != tt.tenant {
+				t.Errorf("status = %d with tenant %q, want %d with %q", w.Code, resolved, tt.status, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestTenantIDPrefersTheTokenTenant(t *testing.T) {
+	tenants := testutil.NewMemoryTenantDirectory(
+		models.Tenant{ID: services.DefaultTenantID, Status: services.TenantStatusActive},
+		models.Tenant{ID: "acme", Status: services.TenantStatusActive},
+	)
+
+	var resolved string
+	serveTenant("acme", ResolveTenant(tenants), func(c *gin.Context) {
+		c.Set(ClaimsKey, &services.Claims{UserID: "u1", TenantID: "globex"})
+		resolved = TenantID(c)
+	})
+	if resolved != "globex" {
+		t.Errorf("TenantID = %q, want the tenant of the token", resolved)
+	}
+}
+
+func TestRequireTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *services.Claims
+		status int
+	}{
+		{name: "user of the tenant", claims: &services.Claims{UserID: "u1", TenantID: services.DefaultTenantID}, status: http.StatusOK},
+		{name: "token without a tenant", claims: &services.Claims{UserID: "u1"}, status: http.StatusOK},
+		{name: "user of another tenant", claims: &services.Claims{UserID: "u1", TenantID: "acme"}, status: http.StatusForbidden},
+		{name: "not authenticated", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveTenant("", func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set(ClaimsKey, tt.claims)
+				}
+			}, RequireTenant(services.DefaultTenantID), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All holds every migration in version order. Applied migrations are recorded
+// in schema_migrations; never change or renumber one that has shipped.
+var All = []config.Migration{
+	{
+		Version:     1,
+		Description: "backfill normalized emails of accounts",
+		Up:          backfillNormalizedEmails,
+	},
+	{
+		Version:     2,
+		Description: "unique index on normalized account emails",
+		Up:          uniqueNormalizedEmailIndex,
+	},
+	{
+		Version:     3,
+		Description: "create the default tenant",
+		Up:          createDefaultTenant,
+	},
+	{
+		Version:     4,
+		Description: "assign existing accounts and audit events to the default tenant",
+		Up:          backfillTenants,
+	},
+	{
+		Version:     5,
+		Description: "scope unique account emails to their tenant",
+		Up:          tenantScopedEmailIndex,
+	},
+	{
+		Version:     6,
+		Description: "assign passkeys to the tenant of their account",
+		Up:          backfillCredentialTenants,
+	},
+}
+
+// indexNotFound is the server error code for dropping a missing index.
+const indexNotFound = 27
+
+// backfillNormalizedEmails sets emailNormalized, the lowercased and trimmed
+// address accounts are looked up by, on every account.
+func backfillNormalizedEmails(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("auth_users").UpdateMany(ctx, bson.M{}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"emailNormalized": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}},
+		}}},
+	})
+	return err
+}
+
+// uniqueNormalizedEmailIndex makes the database reject a second account with
+// the same address. It fails if existing accounts already share an address;
+// those have to be merged or renamed by hand before the service can start.
+func uniqueNormalizedEmailIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("auth_users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "emailNormalized", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// createDefaultTenant creates the tenant every existing account moves into.
+// It keeps self-service signup open, as it was before tenants existed.
+func createDefaultTenant(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().UTC()
+	_, err := db.Collection("tenants").UpdateOne(ctx,
+		bson.M{"_id": services.DefaultTenantID},
+		bson.M{"$setOnInsert": models.Tenant{
+			ID:        services.DefaultTenantID,
+			Name:      "Default",
+			Status:    services.TenantStatusActive,
+			Settings:  models.TenantSettings{AllowSignup: true},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// backfillTenants assigns accounts and audit events recorded before tenants
+// existed to the default tenant.
+func backfillTenants(ctx context.Context, db *mongo.Database) error {
+	for _, name := range []string{"auth_users", "auth_audit"} {
+		_, err := db.Collection(name).UpdateMany(ctx,
+			bson.M{"tenantId": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"tenantId": services.DefaultTenantID}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantScopedEmailIndex replaces the deployment-wide unique email index with
+// one per tenant, so the same address can hold an account in every tenant.
+func tenantScopedEmailIndex(ctx context.Context, db *mongo.Database) error {
+	indexes := db.Collection("auth_users").Indexes()
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "emailNormalized", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = indexes.DropOne(ctx, "emailNormalized_1")
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexNotFound {
+		return nil
+	}
+	return err
+}
+
+// backfillCredentialTenants records on each passkey the tenant of the account
+// it was registered for, since passkeys only sign in to that tenant.
+// Passkeys of accounts that no longer exist fall back to the default tenant.
+func backfillCredentialTenants(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("webauthn_credentials").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tenantId": bson.M{"$exists": false}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "auth_users",
+			"localField":   "userId",
+			"foreignField": "_id",
+			"as":           "account",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"tenantId": bson.M{"$ifNull": bson.A{
+				bson.M{"$arrayElemAt": bson.A{"$account.tenantId", 0}},
+				services.DefaultTenantID,
+			}},
+		}}},
+		{{Key: "$unset", Value: "account"}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           "webauthn_credentials",
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "discard",
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// AuditEvent is an entry of the append-only authentication audit trail. It is
+// stored in the auth_audit collection and published to Kafka. UserID is the
+// account the event concerns; ActorID is who caused it when that is someone
+// else, such as an admin revoking a user's tokens.
+type AuditEvent struct {
+	ID        string    `json:"event_id" bson:"_id"`
+	TenantID  string    `json:"tenant_id,omitempty" bson:"tenantId,omitempty"`
+	EventType string    `json:"event_type" bson:"eventType"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	Outcome   string    `json:"outcome" bson:"outcome"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	UserID    string    `json:"user_id,omitempty" bson:"userId,omitempty"`
+	Email     string    `json:"email,omitempty" bson:"email,omitempty"`
+	ActorID   string    `json:"actor_id,omitempty" bson:"actorId,omitempty"`
+	ClientID  string    `json:"client_id,omitempty" bson:"clientId,omitempty"`
+	SessionID string    `json:"session_id,omitempty" bson:"sessionId,omitempty"`
+	AMR       []string  `json:"amr,omitempty" bson:"amr,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty" bson:"clientIp,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty" bson:"userAgent,omitempty"`
+}
+
+// AuditQuery represents the filters of an admin audit log request
+type AuditQuery struct {
+	TenantID  string    `form:"-"`
+	UserID    string    `form:"user_id"`
+	EventType string    `form:"event_type"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit     int       `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Format    string    `form:"format" binding:"omitempty,oneof=json ndjson"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Actor identifies who is acting on behalf of the token subject, as in the
+// RFC 8693 "act" claim.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// ImpersonationRequest represents an admin's request to act as another user.
+// Reason is recorded in the audit trail.
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonationResponse carries a short-lived access token for the target
+// user. There is no refresh token; a new impersonation has to be started once
+// it expires.
+type ImpersonationResponse struct {
+	Token     string           `json:"token"`
+	TokenType string           `json:"token_type"`
+	ExpiresIn int64            `json:"expires_in"`
+	User      RegisterUserInfo `json:"user"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginAttempt counts recent failed logins for one account email or client
+// IP. The ID is "email:<address>" or "ip:<address>". Lockouts counts how
+// often the account was locked, so repeated lockouts last longer. Entries
+// are removed by a TTL index once ExpiresAt passes without new failures.
+type LoginAttempt struct {
+	ID            string     `json:"id" bson:"_id"`
+	Failures      int        `json:"failures" bson:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt" bson:"lastFailureAt"`
+	Lockouts      int        `json:"lockouts" bson:"lockouts"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time  `json:"expiresAt" bson:"expiresAt"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// MagicLinkRequest represents a request to email a sign-in link. Nonce is a
+// random value chosen by the browser; the link only works together with it.
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Nonce string `json:"nonce" binding:"required,min=22,max=256"`
+}
+
+// ConsumeMagicLinkRequest represents a request to sign in with the token from
+// a magic link and the nonce of the browser that requested it
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+	Nonce string `json:"nonce" binding:"required"`
+}
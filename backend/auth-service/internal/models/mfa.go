@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// MFAFactor represents a user's TOTP second factor. It is created unconfirmed
+// on enrollment and only enforced once the user has proved they can generate
+// codes. Recovery codes are stored as SHA-256 hashes.
+type MFAFactor struct {
+	UserID        string     `json:"userId" bson:"_id"`
+	Secret        string     `json:"-" bson:"secret"`
+	Confirmed     bool       `json:"confirmed" bson:"confirmed"`
+	LastUsedStep  int64      `json:"-" bson:"lastUsedStep"`
+	RecoveryCodes []string   `json:"-" bson:"recoveryCodes"`
+	CreatedAt     time.Time  `json:"createdAt" bson:"createdAt"`
+	ConfirmedAt   *time.Time `json:"confirmedAt,omitempty" bson:"confirmedAt,omitempty"`
+}
+
+// MFAChallenge is the pending second step of a login. It is stored under the
+// SHA-256 hash of the challenge token handed to the client. TenantID is the
+// tenant of the user and AMR lists the methods of the first step.
+type MFAChallenge struct {
+	ID        string    `bson:"_id"`
+	TenantID  string    `bson:"tenantId"`
+	UserID    string    `bson:"userId"`
+	AMR       []string  `bson:"amr,omitempty"`
+	Attempts  int       `bson:"attempts"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// TOTPEnrollmentResponse represents the secret a user adds to their authenticator app
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFACodeRequest represents a request carrying a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse represents newly generated recovery codes, shown once
+type RecoveryCodesResponse struct {
+	Status        string   `json:"status"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAVerifyRequest represents the second step of an MFA login
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
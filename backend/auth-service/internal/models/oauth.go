@@ -0,0 +1,186 @@
+package models
+
+import "time"
+
+// OAuth 2.0 grant types supported by the token endpoint.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuthClient represents a registered OAuth 2.0 / OpenID Connect client.
+// Clients without a secret are public clients and must use PKCE. Clients
+// allowed the client_credentials grant are service accounts: they obtain
+// tokens for themselves rather than on behalf of a user.
+//
+// While a secret rotation is in progress the previous secret stays valid
+// until PreviousSecretExpiresAt.
+type OAuthClient struct {
+	ID                      string     `json:"client_id" bson:"_id"`
+	Name                    string     `json:"name" bson:"name"`
+	SecretHash              string     `json:"-" bson:"secretHash,omitempty"`
+	PreviousSecretHash      string     `json:"-" bson:"previousSecretHash,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty" bson:"previousSecretExpiresAt,omitempty"`
+	GrantTypes              []string   `json:"grant_types" bson:"grantTypes,omitempty"`
+	RedirectURIs            []string   `json:"redirect_uris" bson:"redirectUris"`
+	AllowedScopes           []string   `json:"allowed_scopes" bson:"allowedScopes"`
+	CreatedAt               time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt               time.Time  `json:"updatedAt" bson:"updatedAt"`
+}
+
+// IsPublic reports whether the client has no secret.
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// AllowsGrant reports whether the client may use the grant type. Clients
+// registered before grant types were recorded use the authorization code flow.
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	if len(c.GrantTypes) == 0 {
+		return grantType == GrantTypeAuthorizationCode
+	}
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServiceAccount reports whether the client may obtain tokens for itself.
+func (c *OAuthClient) IsServiceAccount() bool {
+	return c.AllowsGrant(GrantTypeClientCredentials)
+}
+
+// OAuthClientRequest represents an admin request to create or update a client.
+// grant_types defaults to authorization_code, which requires redirect URIs;
+// service accounts use client_credentials and must be confidential.
+type OAuthClientRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	GrantTypes    []string `json:"grant_types" binding:"omitempty,dive,oneof=authorization_code client_credentials"`
+	RedirectURIs  []string `json:"redirect_uris" binding:"omitempty,dive,url"`
+	AllowedScopes []string `json:"allowed_scopes" binding:"required,min=1"`
+	Public        bool     `json:"public"`
+}
+
+// RotateClientSecretRequest represents an admin request to replace a client
+// secret. The previous secret keeps working for overlap_seconds, or for the
+// configured default when omitted; 0 invalidates it immediately.
+type RotateClientSecretRequest struct {
+	OverlapSeconds *int `json:"overlap_seconds" binding:"omitempty,min=0"`
+}
+
+// OAuthClientResponse represents a client, including its secret when one was just generated
+type OAuthClientResponse struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizationCode is a single-use authorization code. It is stored under the
+// SHA-256 hash of the code handed to the client.
+type AuthorizationCode struct {
+	ID                  string    `bson:"_id"`
+	ClientID            string    `bson:"clientId"`
+	UserID              string    `bson:"userId"`
+	TenantID            string    `bson:"tenantId"`
+	RedirectURI         string    `bson:"redirectUri"`
+	Scope               string    `bson:"scope"`
+	Nonce               string    `bson:"nonce,omitempty"`
+	CodeChallenge       string    `bson:"codeChallenge"`
+	CodeChallengeMethod string    `bson:"codeChallengeMethod"`
+	AMR                 []string  `bson:"amr,omitempty"`
+	AuthTime            time.Time `bson:"authTime"`
+	ExpiresAt           time.Time `bson:"expiresAt"`
+}
+
+// AuthorizeRequest represents the parameters of an authorization request
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// TokenRequest represents the form parameters of a token request
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+}
+
+// TokenResponse represents a successful OAuth 2.0 token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// IntrospectionRequest represents the form parameters of an RFC 7662 token
+// introspection request
+type IntrospectionRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// IntrospectionResponse represents an RFC 7662 token introspection response.
+// Inactive tokens are reported with active=false and no other members.
+type IntrospectionResponse struct {
+	Active        bool     `json:"active"`
+	Subject       string   `json:"sub,omitempty"`
+	ExpiresAt     int64    `json:"exp,omitempty"`
+	IssuedAt      int64    `json:"iat,omitempty"`
+	NotBefore     int64    `json:"nbf,omitempty"`
+	Issuer        string   `json:"iss,omitempty"`
+	Audience      []string `json:"aud,omitempty"`
+	JTI           string   `json:"jti,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	ClientID      string   `json:"client_id,omitempty"`
+	TokenType     string   `json:"token_type,omitempty"`
+	PrincipalType string   `json:"principal_type,omitempty"`
+	UserID        string   `json:"user_id,omitempty"`
+	TenantID      string   `json:"tenant_id,omitempty"`
+	Role          string   `json:"role,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	AMR           []string `json:"amr,omitempty"`
+	SessionID     string   `json:"sid,omitempty"`
+	Actor         *Actor   `json:"act,omitempty"`
+}
+
+// UserInfoResponse represents the OpenID Connect UserInfo response
+type UserInfoResponse struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+}
+
+// OpenIDConfiguration represents the OpenID Provider discovery document
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
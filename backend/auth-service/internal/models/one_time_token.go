@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// OneTimeToken is a single-use token sent to a user out of band, such as an
+// email verification link. It is stored under the SHA-256 hash of the token.
+// Subject identifies who the token was issued for, usually a user ID.
+type OneTimeToken struct {
+	ID        string            `bson:"_id"`
+	Purpose   string            `bson:"purpose"`
+	Subject   string            `bson:"subject"`
+	Data      map[string]string `bson:"data,omitempty"`
+	CreatedAt time.Time         `bson:"createdAt"`
+	ExpiresAt time.Time         `bson:"expiresAt"`
+}
+
+// VerifyEmailRequest represents a request to confirm an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest represents a request to send a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
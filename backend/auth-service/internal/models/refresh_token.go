@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken represents a stored refresh token. Only the SHA-256 hash of the
+// opaque token is persisted. Tokens issued by rotation share the family ID of
+// the token issued at login, and carry the authentication methods used then
+// and the tenant of the account. Tokens issued before tenants existed have no
+// tenant and belong to the default tenant.
+type RefreshToken struct {
+	ID         string     `json:"id" bson:"_id"`
+	FamilyID   string     `json:"familyId" bson:"familyId"`
+	UserID     string     `json:"userId" bson:"userId"`
+	TenantID   string     `json:"tenantId,omitempty" bson:"tenantId,omitempty"`
+	AMR        []string   `json:"amr,omitempty" bson:"amr,omitempty"`
+	TokenHash  string     `json:"-" bson:"tokenHash"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt" bson:"expiresAt"`
+	RotatedAt  *time.Time `json:"rotatedAt,omitempty" bson:"rotatedAt,omitempty"`
+	ReplacedBy string     `json:"replacedBy,omitempty" bson:"replacedBy,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
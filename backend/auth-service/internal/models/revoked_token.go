@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RevokedToken is a denylist entry. Entries either revoke a single access
+// token by its jti or every token issued to a user before RevokedBefore.
+// They are removed by a TTL index once the tokens they cover have expired.
+type RevokedToken struct {
+	ID            string     `json:"id" bson:"_id"`
+	Type          string     `json:"type" bson:"type"`
+	JTI           string     `json:"jti,omitempty" bson:"jti,omitempty"`
+	UserID        string     `json:"userId" bson:"userId"`
+	RevokedBefore *time.Time `json:"revokedBefore,omitempty" bson:"revokedBefore,omitempty"`
+	Reason        string     `json:"reason,omitempty" bson:"reason,omitempty"`
+	RevokedAt     time.Time  `json:"revokedAt" bson:"revokedAt"`
+	ExpiresAt     time.Time  `json:"expiresAt" bson:"expiresAt"`
+}
+
+// LogoutRequest represents a logout request. The access token is taken from
+// the Authorization header; the optional refresh token family is revoked too.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokeTokensRequest represents an admin request to revoke a user's tokens
+type RevokeTokensRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SecurityEvent is published to Kafka for security-relevant account activity
+// such as password changes, so other services can react or alert.
+type SecurityEvent struct {
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	Timestamp time.Time `json:"timestamp"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Session represents a signed-in device. Each session owns one refresh token
+// family; access tokens reference the session through their sid claim.
+// Sessions are removed by a TTL index once they were not refreshed for the
+// refresh token lifetime.
+type Session struct {
+	ID         string    `json:"id" bson:"_id"`
+	UserID     string    `json:"userId" bson:"userId"`
+	FamilyID   string    `json:"-" bson:"familyId"`
+	UserAgent  string    `json:"userAgent" bson:"userAgent"`
+	IPAddress  string    `json:"ipAddress" bson:"ipAddress"`
+	AMR        []string  `json:"amr,omitempty" bson:"amr,omitempty"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt" bson:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt" bson:"expiresAt"`
+	Current    bool      `json:"current" bson:"-"`
+}
+
+// ClientInfo describes the device a request came from and the tenant it was
+// made in
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	TenantID  string
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// SigningKey is a JWT signing key pair persisted so that every auth-service
+// replica signs with the same active key. The newest non-retired key is
+// active; retired keys stay available for verification until VerifyUntil.
+type SigningKey struct {
+	ID            string     `json:"kid" bson:"_id"`
+	Algorithm     string     `json:"alg" bson:"algorithm"`
+	PrivateKeyPEM string     `json:"-" bson:"privateKeyPem"`
+	CreatedAt     time.Time  `json:"createdAt" bson:"createdAt"`
+	RetiredAt     *time.Time `json:"retiredAt,omitempty" bson:"retiredAt,omitempty"`
+	VerifyUntil   *time.Time `json:"verifyUntil,omitempty" bson:"verifyUntil,omitempty"`
+}
+
+// JWK is a public JSON Web Key (RFC 7517) for RSA or Ed25519 keys.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Tenant is a customer organization hosted on the deployment. Accounts,
+// tokens and profiles belong to exactly one tenant; the same email address
+// can be registered once per tenant.
+type Tenant struct {
+	ID        string         `json:"id" bson:"_id"`
+	Name      string         `json:"name" bson:"name"`
+	Status    string         `json:"status" bson:"status"`
+	Settings  TenantSettings `json:"settings" bson:"settings"`
+	CreatedAt time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt" bson:"updatedAt"`
+}
+
+// TenantSettings holds the per-tenant overrides of the deployment settings.
+// They can only make the deployment settings stricter: a zero value keeps the
+// deployment default.
+type TenantSettings struct {
+	AllowSignup    bool                 `json:"allow_signup" bson:"allowSignup"`
+	AccessTokenTTL int64                `json:"access_token_ttl,omitempty" bson:"accessTokenTtl,omitempty" binding:"omitempty,min=60"`
+	PasswordPolicy TenantPasswordPolicy `json:"password_policy" bson:"passwordPolicy"`
+}
+
+// TenantPasswordPolicy tightens the deployment password policy for a tenant
+type TenantPasswordPolicy struct {
+	MinLength        int  `json:"min_length,omitempty" bson:"minLength,omitempty" binding:"omitempty,min=1,max=128"`
+	RequireUppercase bool `json:"require_uppercase" bson:"requireUppercase"`
+	RequireLowercase bool `json:"require_lowercase" bson:"requireLowercase"`
+	RequireDigit     bool `json:"require_digit" bson:"requireDigit"`
+	RequireSymbol    bool `json:"require_symbol" bson:"requireSymbol"`
+}
+
+// CreateTenantRequest represents a request to create a tenant. The ID is
+// also the subdomain the tenant is reached at.
+type CreateTenantRequest struct {
+	ID       string         `json:"id" binding:"required"`
+	Name     string         `json:"name" binding:"required,max=200"`
+	Settings TenantSettings `json:"settings"`
+}
+
+// UpdateTenantRequest represents a request to replace a tenant's name,
+// status and settings
+type UpdateTenantRequest struct {
+	Name     string         `json:"name" binding:"required,max=200"`
+	Status   string         `json:"status" binding:"required,oneof=active disabled"`
+	Settings TenantSettings `json:"settings"`
+}
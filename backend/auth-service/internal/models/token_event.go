@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TokenRevokedEvent is published to Kafka when access tokens are revoked so
+// other services can drop cached validations. Either JTI identifies a single
+// token or RevokedBefore covers every token issued to the user before it.
+type TokenRevokedEvent struct {
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Timestamp     time.Time  `json:"timestamp"`
+	UserID        string     `json:"user_id"`
+	JTI           string     `json:"jti,omitempty"`
+	RevokedBefore *time.Time `json:"revoked_before,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	Reason        string     `json:"reason,omitempty"`
+}
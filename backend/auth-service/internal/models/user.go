@@ -4,14 +4,16 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id" bson:"_id,omitempty"`
-	Name      string    `json:"name" bson:"name"`
-	Email     string    `json:"email" binding:"required,email" bson:"email"`
-	Password  string    `json:"password" binding:"required,min=6" bson:"password"`
-	Status    string    `json:"status" bson:"status"`
-	Role      string    `json:"role" bson:"role"`
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	ID              string    `json:"id" bson:"_id,omitempty"`
+	TenantID        string    `json:"tenant_id" bson:"tenantId"`
+	Name            string    `json:"name" bson:"name"`
+	Email           string    `json:"email" binding:"required,email" bson:"email"`
+	EmailNormalized string    `json:"-" bson:"emailNormalized"`
+	Password        string    `json:"password" binding:"required,min=6" bson:"password"`
+	Status          string    `json:"status" bson:"status"`
+	Role            string    `json:"role" bson:"role"`
+	CreatedAt       time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
 // LoginRequest represents a login request
@@ -20,25 +22,43 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// LoginResponse represents a login response
+// LoginResponse represents a login response. When the account has MFA
+// enabled, Status is "mfa_required" and only MFAToken is set.
 type LoginResponse struct {
-	Status string `json:"status"`
-	Token  string `json:"token"`
+	Status       string `json:"status"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
 
-// RegisterRequest represents a registration request
+// RegisterRequest represents a registration request. InvitationCode is the
+// code of an invitation to the email address, if any.
 type RegisterRequest struct {
 	Name            string `json:"name" binding:"required"`
 	Email           string `json:"email" binding:"required,email"`
-	Password        string `json:"password" binding:"required,min=6"`
+	Password        string `json:"password" binding:"required"`
 	ConfirmPassword string `json:"confirmPassword" binding:"required,eqfield=Password"`
+	InvitationCode  string `json:"invitation_code,omitempty"`
 }
 
 // RegisterResponse represents a registration response (aligned with frontend AuthResponse).
+// New accounts must verify their email first, so no tokens are returned,
+// except to invitees, whose address the invitation already proved.
 type RegisterResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-	Token   string `json:"token"`
+	Status       string           `json:"status"`
+	Message      string           `json:"message"`
+	Token        string           `json:"token,omitempty"`
+	RefreshToken string           `json:"refresh_token,omitempty"`
+	ExpiresIn    int64            `json:"expires_in,omitempty"`
+	User         RegisterUserInfo `json:"user"`
+}
+
+// RegisterUserInfo represents the user summary returned after registration
+type RegisterUserInfo struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
 }
 
 // TokenValidationRequest represents a token validation request
@@ -46,20 +66,35 @@ type TokenValidationRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
-// TokenValidationResponse represents a token validation response
+// TokenValidationResponse represents a token validation response. For valid
+// tokens PrincipalType is "user" or "service"; service account tokens carry a
+// client ID and scope instead of a user ID. ActorID is set when an admin
+// holds the token on behalf of the user.
 type TokenValidationResponse struct {
-	Valid   bool   `json:"valid"`
-	UserID  string `json:"user_id,omitempty"`
-	Message string `json:"message,omitempty"`
+	Valid         bool   `json:"valid"`
+	PrincipalType string `json:"principal_type,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+	TenantID      string `json:"tenant_id,omitempty"`
+	ClientID      string `json:"client_id,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	ActorID       string `json:"actor_id,omitempty"`
+	Message       string `json:"message,omitempty"`
 }
 
 // RefreshTokenRequest represents a token refresh request
 type RefreshTokenRequest struct {
-	Token string `json:"token" binding:"required"`
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // RefreshTokenResponse represents a token refresh response
 type RefreshTokenResponse struct {
-	Status string `json:"status"`
-	Token  string `json:"token"`
+	Status       string `json:"status"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// AssignRoleRequest represents an admin request to change a user's role
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
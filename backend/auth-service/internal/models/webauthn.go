@@ -0,0 +1,135 @@
+package models
+
+import "time"
+
+// WebAuthnCredential represents a passkey registered by a user. The ID is the
+// base64url-encoded credential ID and PublicKey is the COSE-encoded key.
+// TenantID is the tenant of the user's account; the passkey only signs in
+// there.
+type WebAuthnCredential struct {
+	ID         string     `json:"id" bson:"_id"`
+	UserID     string     `json:"userId" bson:"userId"`
+	TenantID   string     `json:"-" bson:"tenantId"`
+	Name       string     `json:"name" bson:"name"`
+	PublicKey  []byte     `json:"-" bson:"publicKey"`
+	Algorithm  int64      `json:"alg" bson:"alg"`
+	SignCount  uint32     `json:"-" bson:"signCount"`
+	AAGUID     string     `json:"aaguid" bson:"aaguid"`
+	Transports []string   `json:"transports,omitempty" bson:"transports,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+}
+
+// WebAuthnSession holds the challenge of a ceremony in progress. It is stored
+// under the SHA-256 hash of the session ID handed to the client.
+type WebAuthnSession struct {
+	ID        string    `bson:"_id"`
+	Ceremony  string    `bson:"ceremony"`
+	UserID    string    `bson:"userId,omitempty"`
+	Challenge string    `bson:"challenge"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// WebAuthnCeremonyResponse carries the options passed to
+// navigator.credentials.create() or .get() and the session ID to send back
+type WebAuthnCeremonyResponse struct {
+	SessionID string      `json:"session_id"`
+	PublicKey interface{} `json:"publicKey"`
+}
+
+// PublicKeyCredentialCreationOptions are the WebAuthn registration options
+type PublicKeyCredentialCreationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RelyingParty           RelyingPartyEntity             `json:"rp"`
+	User                   UserEntity                     `json:"user"`
+	PubKeyCredParams       []CredentialParameter          `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []CredentialDescriptor         `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelectionCriteria `json:"authenticatorSelection"`
+}
+
+// PublicKeyCredentialRequestOptions are the WebAuthn authentication options
+type PublicKeyCredentialRequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RelyingPartyID   string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	UserVerification string                 `json:"userVerification"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+}
+
+// RelyingPartyEntity identifies the relying party
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifies the account a credential is created for
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is an accepted credential type and COSE algorithm
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+// CredentialDescriptor references an existing credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelectionCriteria states the authenticator requirements
+type AuthenticatorSelectionCriteria struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnLoginBeginRequest represents a request to start a passkey login.
+// Without an email, the authenticator offers its discoverable credentials.
+type WebAuthnLoginBeginRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// WebAuthnRegisterFinishRequest represents the result of a registration ceremony
+type WebAuthnRegisterFinishRequest struct {
+	SessionID  string              `json:"session_id" binding:"required"`
+	Name       string              `json:"name"`
+	Credential AttestationResponse `json:"credential" binding:"required"`
+}
+
+// WebAuthnLoginFinishRequest represents the result of an authentication ceremony
+type WebAuthnLoginFinishRequest struct {
+	SessionID  string            `json:"session_id" binding:"required"`
+	Credential AssertionResponse `json:"credential" binding:"required"`
+}
+
+// AttestationResponse is the PublicKeyCredential returned by
+// navigator.credentials.create(), with binary fields base64url-encoded
+type AttestationResponse struct {
+	ID       string `json:"id" binding:"required"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the PublicKeyCredential returned by
+// navigator.credentials.get(), with binary fields base64url-encoded
+type AssertionResponse struct {
+	ID       string `json:"id" binding:"required"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCollection = "auth_audit"
+
+// AuditRepository stores the audit trail. It only ever appends. Queries match
+// every set field of the AuditQuery, with From inclusive and To exclusive.
+// MongoAuditRepository is the production implementation and
+// testutil.MemoryAuditRepository the one for tests.
+type AuditRepository interface {
+	// Insert appends an event.
+	Insert(ctx context.Context, event *models.AuditEvent) error
+	// Find returns at most limit events matching the query, newest first.
+	Find(ctx context.Context, query models.AuditQuery, limit int) ([]models.AuditEvent, error)
+	// Export calls fn for every event matching the query, oldest first, and
+	// stops at the first error from fn.
+	Export(ctx context.Context, query models.AuditQuery, fn func(*models.AuditEvent) error) error
+}
+
+var _ AuditRepository = (*MongoAuditRepository)(nil)
+
+// MongoAuditRepository stores the audit trail in the auth_audit collection.
+type MongoAuditRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoAuditRepository creates a new MongoAuditRepository
+func NewMongoAuditRepository(mongoConfig *config.MongoDBConfig) *MongoAuditRepository {
+	return &MongoAuditRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the indexes used by audit queries.
+func (r *MongoAuditRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(auditCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "eventType", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+// Insert appends an event.
+func (r *MongoAuditRepository) Insert(ctx context.Context, event *models.AuditEvent) error {
+	collection := r.mongoConfig.GetCollection(auditCollection)
+	_, err := collection.InsertOne(ctx, event)
+	return err
+}
+
+// Find returns the most recent events matching the query.
+func (r *MongoAuditRepository) Find(ctx context.Context, query models.AuditQuery, limit int) ([]models.AuditEvent, error) {
+	collection := r.mongoConfig.GetCollection(auditCollection)
+	cursor, err := collection.Find(ctx, auditFilter(query),
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Export calls fn for every event matching the query in chronological order.
+func (r *MongoAuditRepository) Export(ctx context.Context, query models.AuditQuery, fn func(*models.AuditEvent) error) error {
+	collection := r.mongoConfig.GetCollection(auditCollection)
+	cursor, err := collection.Find(ctx, auditFilter(query),
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event models.AuditEvent
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func auditFilter(query models.AuditQuery) bson.M {
+	filter := bson.M{}
+	if query.TenantID != "" {
+		filter["tenantId"] = query.TenantID
+	}
+	if query.UserID != "" {
+		filter["userId"] = query.UserID
+	}
+	if query.EventType != "" {
+		filter["eventType"] = query.EventType
+	}
+
+	timestamp := bson.M{}
+	if !query.From.IsZero() {
+		timestamp["$gte"] = query.From.UTC()
+	}
+	if !query.To.IsZero() {
+		timestamp["$lt"] = query.To.UTC()
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+	return filter
+}
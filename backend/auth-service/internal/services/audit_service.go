@@ -0,0 +1,121 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultAuditLimit is the page size of audit queries without a limit.
+const defaultAuditLimit = 100
+
+// Audit event types.
+const (
+	AuditLogin           = "login"
+	AuditRegister        = "register"
+	AuditTokenRefresh    = "token_refresh"
+	AuditPasswordChange  = "password_change"
+	AuditTokenRevocation = "token_revocation"
+	AuditImpersonation   = "impersonation"
+	AuditAccountDeletion = "account_deletion"
+)
+
+// Audit outcomes.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditRecorder records events in the audit trail on behalf of a client.
+// AuditService is the production implementation and
+// testutil.MemoryAuditRecorder the one for tests.
+type AuditRecorder interface {
+	RecordFor(ctx context.Context, event models.AuditEvent, client models.ClientInfo)
+}
+
+var _ AuditRecorder = (*AuditService)(nil)
+
+// AuditService writes the authentication audit trail. Entries are only ever
+// inserted; the service offers no way to change or delete them.
+type AuditService struct {
+	repository AuditRepository
+	publisher  *KafkaPublisher
+}
+
+// NewAuditService creates a new AuditService
+func NewAuditService(repository AuditRepository, publisher *KafkaPublisher) *AuditService {
+	return &AuditService{
+		repository: repository,
+		publisher:  publisher,
+	}
+}
+
+// Record stores the event and publishes it as audit.auth.v1. The event ID and
+// timestamp are assigned here.
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent) error {
+	event.ID = primitive.NewObjectID().Hex()
+	event.Timestamp = time.Now().UTC()
+
+	if err := s.repository.Insert(ctx, &event); err != nil {
+		return err
+	}
+
+	if err := s.publisher.PublishAudit(ctx, event); err != nil {
+		// The collection is the system of record; the topic feeds SIEM and
+		// alerting pipelines.
+	}
+	return nil
+}
+
+// Find returns the most recent events matching the query, newest first.
+func (s *AuditService) Find(ctx context.Context, query models.AuditQuery) ([]models.AuditEvent, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	return s.repository.Find(ctx, query, limit)
+}
+
+// Export calls fn for every event matching the query in chronological order.
+// The query limit is ignored. Iteration stops at the first error from fn.
+func (s *AuditService) Export(ctx context.Context, query models.AuditQuery, fn func(*models.AuditEvent) error) error {
+	return s.repository.Export(ctx, query, fn)
+}
+
+// RecordFor records an event on behalf of the client. Auditing is best
+// effort: failing to write the trail must not change the decision it
+// describes. The event belongs to the client's tenant unless it names one.
+func (s *AuditService) RecordFor(ctx context.Context, event models.AuditEvent, client models.ClientInfo) {
+	if event.TenantID == "" {
+		event.TenantID = client.TenantID
+	}
+	event.ClientIP = client.IPAddress
+	event.UserAgent = client.UserAgent
+	_ = s.Record(ctx, event)
+}
+
+// loginFailureReason maps a login error to the reason recorded in the audit trail.
+func loginFailureReason(err error) string {
+	var blocked *LoginBlockedError
+	switch {
+	case errors.As(err, &blocked) && errors.Is(err, ErrAccountLocked):
+		return "account_locked"
+	case errors.As(err, &blocked):
+		return "too_many_attempts"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, ErrInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, ErrInvalidMFACode), errors.Is(err, ErrInvalidMFAChallenge):
+		return "invalid_mfa_code"
+	case errors.Is(err, ErrInvalidOneTimeToken), errors.Is(err, ErrMagicLinkNonceMismatch):
+		return "invalid_magic_link"
+	case errors.Is(err, ErrWebAuthnVerification), errors.Is(err, ErrWebAuthnSessionInvalid), errors.Is(err, ErrWebAuthnCredentialNotFound):
+		return "invalid_passkey"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,169 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// auditAt stores an event with the timestamp directly in the repository.
+func auditAt(t *testing.T, repository *testutil.MemoryAuditRepository, event models.AuditEvent, timestamp time.Time) {
+	t.Helper()
+
+	event.ID = event.EventType + "-" + timestamp.Format(time.RFC3339)
+	event.Timestamp = timestamp
+	if err := repository.Insert(context.Background(), &event); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+}
+
+// eventIDs returns the IDs of the events in order.
+func eventIDs(events []models.AuditEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func equalIDs(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAuditRecordFor(t *testing.T) {
+	repository := testutil.NewMemoryAuditRepository()
+	// Kafka being down does not lose the entry.
+	audit := services.NewAuditService(repository, services.NewFailingKafkaPublisher())
+	ctx := context.Background()
+
+	audit.RecordFor(ctx, models.AuditEvent{EventType: services.AuditLogin, Outcome: services.AuditOutcomeSuccess, UserID: "jane"}, client("acme"))
+	audit.RecordFor(ctx, models.AuditEvent{EventType: services.AuditImpersonation, Outcome: services.AuditOutcomeSuccess, TenantID: "other"}, client("acme"))
+
+	events, err := audit.Find(ctx, models.AuditQuery{})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2", events)
+	}
+	login := events[1]
+	if login.ID == "" || login.Timestamp.IsZero() || login.TenantID != "acme" || login.ClientIP != "10.0.0.1" || login.UserAgent != "test" {
+		t.Errorf("event = %+v, want an ID, a timestamp and the client details", login)
+	}
+	if events[0].TenantID != "other" || events[0].ID == login.ID {
+		t.Errorf("event = %+v, want its own ID and the tenant it names", events[0])
+	}
+}
+
+func TestAuditFind(t *testing.T) {
+	repository := testutil.NewMemoryAuditRepository()
+	audit := services.NewAuditService(repository, nil)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	auditAt(t, repository, models.AuditEvent{TenantID: "acme", EventType: services.AuditLogin, UserID: "jane"}, start)
+	auditAt(t, repository, models.AuditEvent{TenantID: "acme", EventType: services.AuditTokenRefresh, UserID: "jane"}, start.Add(time.Minute))
+	auditAt(t, repository, models.AuditEvent{TenantID: "acme", EventType: services.AuditLogin, UserID: "joe"}, start.Add(2*time.Minute))
+	auditAt(t, repository, models.AuditEvent{TenantID: "globex", EventType: services.AuditLogin, UserID: "jane"}, start.Add(3*time.Minute))
+
+	tests := []struct {
+		name  string
+		query models.AuditQuery
+		want  []string
+	}{
+		{
+			name:  "tenant, newest first",
+			query: models.AuditQuery{TenantID: "acme"},
+			want:  []string{"login-2026-03-01T12:02:00Z", "token_refresh-2026-03-01T12:01:00Z", "login-2026-03-01T12:00:00Z"},
+		},
+		{
+			name:  "user",
+			query: models.AuditQuery{TenantID: "acme", UserID: "jane"},
+			want:  []string{"token_refresh-2026-03-01T12:01:00Z", "login-2026-03-01T12:00:00Z"},
+		},
+		{
+			name:  "event type",
+			query: models.AuditQuery{EventType: services.AuditLogin},
+			want:  []string{"login-2026-03-01T12:03:00Z", "login-2026-03-01T12:02:00Z", "login-2026-03-01T12:00:00Z"},
+		},
+		{
+			name:  "time range includes from and excludes to",
+			query: models.AuditQuery{From: start.Add(time.Minute), To: start.Add(3 * time.Minute)},
+			want:  []string{"login-2026-03-01T12:02:00Z", "token_refresh-2026-03-01T12:01:00Z"},
+		},
+		{
+			name:  "limit",
+			query: models.AuditQuery{TenantID: "acme", Limit: 1},
+			want:  []string{"login-2026-03-01T12:02:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := audit.Find(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			if got := eventIDs(events); !equalIDs(got, tt.want...) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditFindDefaultLimit(t *testing.T) {
+	repository := testutil.NewMemoryAuditRepository()
+	audit := services.NewAuditService(repository, nil)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 101; i++ {
+		auditAt(t, repository, models.AuditEvent{EventType: services.AuditLogin}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	events, err := audit.Find(context.Background(), models.AuditQuery{})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(events) != 100 || !events[0].Timestamp.Equal(start.Add(100*time.Second)) {
+		t.Errorf("found %d events starting at %v, want the newest 100", len(events), events[0].Timestamp)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	repository := testutil.NewMemoryAuditRepository()
+	audit := services.NewAuditService(repository, nil)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	auditAt(t, repository, models.AuditEvent{TenantID: "acme", EventType: services.AuditLogin}, start)
+	auditAt(t, repository, models.AuditEvent{TenantID: "globex", EventType: services.AuditLogin}, start.Add(time.Minute))
+	auditAt(t, repository, models.AuditEvent{TenantID: "acme", EventType: services.AuditPasswordChange}, start.Add(2*time.Minute))
+
+	var exported []models.AuditEvent
+	err := audit.Export(context.Background(), models.AuditQuery{TenantID: "acme", Limit: 1}, func(event *models.AuditEvent) error {
+		exported = append(exported, *event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if got, want := eventIDs(exported), []string{"login-2026-03-01T12:00:00Z", "password_change-2026-03-01T12:02:00Z"}; !equalIDs(got, want...) {
+		t.Errorf("exported = %v, want %v in chronological order without the limit", got, want)
+	}
+
+	stop := errors.New("client went away")
+	calls := 0
+	err = audit.Export(context.Background(), models.AuditQuery{}, func(*models.AuditEvent) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Export = %v after %d calls, want the error of the first call", err, calls)
+	}
+}
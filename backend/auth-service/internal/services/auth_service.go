@@ -1,88 +1,250 @@
 package services
 
 import (
-	"auth-service/internal/config"
+	"auth-service/internal/logger"
 	"auth-service/internal/models"
 	"context"
 	"errors"
+	"strings"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUserNotFound is returned when an operation targets an unknown account.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when registering an address that is already taken.
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvalidCredentials is returned when an email and password do not match.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrMFARequired is returned when a second factor is needed but was not provided.
+	ErrMFARequired = errors.New("mfa code required")
+	// ErrEmailNotVerified is returned when a pending account tries to sign in.
+	ErrEmailNotVerified = errors.New("email address not verified")
+	// ErrUnknownRole is returned when assigning a role that is not defined.
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrImpersonationNotAllowed is returned when an admin tries to
+	// impersonate themselves or another admin.
+	ErrImpersonationNotAllowed = errors.New("user cannot be impersonated")
 )
 
 // AuthService handles authentication-related business logic
 type AuthService struct {
-	mongoConfig *config.MongoDBConfig
-	jwtService  *JWTService
-	publisher   *KafkaPublisher
+	users         UserRepository
+	credentials   CredentialRepository
+	transactions  Transactor
+	jwtService    *JWTService
+	passwords     *PasswordService
+	policy        *PasswordPolicy
+	refreshTokens RefreshTokenStore
+	revocations   RevocationList
+	mfa           MFAVerifier
+	verifications VerificationSender
+	invitations   InvitationRedeemer
+	throttle      LoginThrottle
+	sessions      SessionStore
+	audit         AuditRecorder
+	tenants       TenantDirectory
+	events        EventPublisher
+	publisher     *KafkaPublisher
+	logger        logger.Logger
 }
 
-// NewAuthService creates a new AuthService with the provided dependencies
-func NewAuthService(mongoConfig *config.MongoDBConfig, jwtService *JWTService, publisher *KafkaPublisher) *AuthService {
+// NewAuthService creates a new AuthService with the provided dependencies.
+// User lifecycle events are published through events in the transaction of
+// the account change; security events through publisher.
+func NewAuthService(
+	users UserRepository,
+	credentials CredentialRepository,
+	transactions Transactor,
+	jwtService *JWTService,
+	passwords *PasswordService,
+	policy *PasswordPolicy,
+	refreshTokens RefreshTokenStore,
+	revocations RevocationList,
+	mfa MFAVerifier,
+	verifications VerificationSender,
+	invitations InvitationRedeemer,
+	throttle LoginThrottle,
+	sessions SessionStore,
+	audit AuditRecorder,
+	tenants TenantDirectory,
+	events EventPublisher,
+	publisher *KafkaPublisher,
+	log logger.Logger,
+) *AuthService {
 	return &AuthService{
-		mongoConfig: mongoConfig,
-		jwtService:  jwtService,
-		publisher:   publisher,
+		users:         users,
+		credentials:   credentials,
+		transactions:  transactions,
+		jwtService:    jwtService,
+		passwords:     passwords,
+		policy:        policy,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		mfa:           mfa,
+		verifications: verifications,
+		invitations:   invitations,
+		throttle:      throttle,
+		sessions:      sessions,
+		audit:         audit,
+		tenants:       tenants,
+		events:        events,
+		publisher:     publisher,
+		logger:        log,
 	}
 }
 
 // Login authenticates a user with the provided email and password.
-// Returns a JWT token upon successful authentication or an error if credentials are invalid.
-func (s *AuthService) Login(email, password string) (*models.LoginResponse, error) {
-	collection := s.mongoConfig.GetCollection("auth_users")
+// Returns a short-lived JWT and a refresh token upon successful authentication
+// or an error if credentials are invalid. Users with MFA enabled receive an
+// "mfa_required" response carrying a challenge token for VerifyMFA instead.
+// Repeated failures are throttled per account and client IP.
+func (s *AuthService) Login(email, password string, client models.ClientInfo) (*models.LoginResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var user models.User
-	err := collection.FindOne(ctx, bson.M{"email": email, "password": password}).Decode(&user)
+	user, err := s.checkCredentials(ctx, email, password, client)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, err
 	}
 
-	token, err := s.jwtService.GenerateToken(user.ID)
+	response, err := s.beginLogin(ctx, user, []string{AMRPassword}, client)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, err
+	}
+	if response.Status == "success" {
+		s.resetThrottle(ctx, user)
 	}
+	return response, nil
+}
 
-	return &models.LoginResponse{
-		Status: "success",
-		Token:  token,
-	}, nil
+// BeginLogin continues the login of a user who passed a first factor other
+// than a password, such as a magic link. As with Login, users with MFA enabled
+// receive an "mfa_required" response instead of tokens.
+func (s *AuthService) BeginLogin(userID string, amr []string, client models.ClientInfo) (*models.LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.users.FindByID(ctx, client.TenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.beginLogin(ctx, user, amr, client)
+}
+
+// beginLogin finishes the first step of a login: users with MFA enabled get a
+// challenge for VerifyMFA, all others their tokens. amr lists the methods of
+// the first step.
+func (s *AuthService) beginLogin(ctx context.Context, user *models.User, amr []string, client models.ClientInfo) (*models.LoginResponse, error) {
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		mfaToken, err := s.mfa.NewChallenge(ctx, user.TenantID, user.ID, amr)
+		if err != nil {
+			return nil, errors.New("failed to start mfa challenge")
+		}
+		return &models.LoginResponse{
+			Status:   "mfa_required",
+			MFAToken: mfaToken,
+		}, nil
+	}
+
+	return s.loginResponse(ctx, user, amr, client)
 }
 
-// Register creates a new user account with the provided registration details.
-// Returns a success response if registration is successful, or an error if the user already exists.
-func (s *AuthService) Register(req models.RegisterRequest) (*models.RegisterResponse, error) {
-	collection := s.mongoConfig.GetCollection("auth_users")
+// Register creates a new user account in the client's tenant with the
+// provided registration details. The account stays in pending_verification,
+// without tokens, until the emailed verification link is followed.
+//
+// With an invitation code, the account gets the role of the invitation and is
+// active right away, since the invitation was sent to the address; the
+// response carries the tokens of a new session. Invitees can register even
+// where signup is disabled.
+//
+// Returns ErrInvalidInvitation for a code that is not pending for the
+// address, ErrInvitationRequired without a code if registration is
+// invitation-only, ErrSignupDisabled if the tenant does not allow signup and
+// ErrUserExists if the address is already registered in the tenant, whatever
+// its case.
+func (s *AuthService) Register(req models.RegisterRequest, client models.ClientInfo) (*models.RegisterResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Check if user already exists
-	count, err := collection.CountDocuments(ctx, bson.M{"email": req.Email})
+	tenant, err := s.tenants.Get(ctx, client.TenantID)
 	if err != nil {
 		return nil, err
 	}
-	if count > 0 {
-		return nil, errors.New("user already exists")
+
+	var invitation *models.Invitation
+	if req.InvitationCode != "" {
+		invitation, err = s.invitations.Find(ctx, tenant.ID, req.InvitationCode, req.Email)
+		if errors.Is(err, ErrInvalidInvitation) {
+			s.audit.RecordFor(ctx, models.AuditEvent{
+				EventType: AuditRegister,
+				Outcome:   AuditOutcomeFailure,
+				Reason:    "invalid_invitation",
+				Email:     req.Email,
+			}, client)
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else if s.invitations.Required() {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditRegister,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "invitation_required",
+			Email:     req.Email,
+		}, client)
+		return nil, ErrInvitationRequired
+	} else if !tenant.Settings.AllowSignup {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditRegister,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "signup_disabled",
+			Email:     req.Email,
+		}, client)
+		return nil, ErrSignupDisabled
 	}
 
-	// Create new user
-	newUser := models.User{
-		ID:        primitive.NewObjectID().Hex(),
-		Name:      req.Name,
-		Email:     req.Email,
-		Password:  req.Password, // In real app, this would be hashed
-		Status:    "active",
-		Role:      "customer",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	if err := s.policy.ForTenant(tenant.Settings.PasswordPolicy).Validate(req.Password, req.Email, req.Name); err != nil {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditRegister,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "weak_password",
+			Email:     req.Email,
+		}, client)
+		return nil, err
 	}
 
-	// Insert user into database
-	_, err = collection.InsertOne(ctx, newUser)
+	passwordHash, err := s.passwords.Hash(req.Password)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("failed to hash password")
+	}
+
+	// Create new user
+	newUser := models.User{
+		ID:              primitive.NewObjectID().Hex(),
+		TenantID:        tenant.ID,
+		Name:            req.Name,
+		Email:           strings.TrimSpace(req.Email),
+		EmailNormalized: NormalizeEmail(req.Email),
+		Password:        passwordHash,
+		Status:          "pending_verification",
+		Role:            "customer",
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if invitation != nil {
+		newUser.Status = "active"
+		newUser.Role = invitation.Role
 	}
 
 	event := models.UserEvent{
@@ -90,26 +252,73 @@ func (s *AuthService) Register(req models.RegisterRequest) (*models.RegisterResp
 		EventType: "user.created.v1",
 		Timestamp: time.Now().UTC(),
 		UserID:    newUser.ID,
+		TenantID:  newUser.TenantID,
 		Email:     newUser.Email,
 		Name:      newUser.Name,
 		Status:    newUser.Status,
 		Role:      newUser.Role,
 	}
 
-	if err := s.publisher.PublishUserCreated(ctx, event); err != nil {
-		// Registration should succeed even if async sync event publishing fails.
-		// Retry/reconciliation can handle eventual consistency.
+	// The repository rejects a taken address, including one registered
+	// concurrently or spelled with different case. An invitation is spent by
+	// the first account created with it.
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Create(ctx, &newUser); err != nil {
+			return err
+		}
+		if invitation != nil {
+			if err := s.invitations.Accept(ctx, invitation, newUser.ID); err != nil {
+				return err
+			}
+		}
+		return s.events.PublishUserCreated(ctx, event)
+	})
+	if errors.Is(err, ErrUserExists) {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditRegister,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "email_taken",
+			Email:     req.Email,
+		}, client)
+		return nil, ErrUserExists
+	}
+	if errors.Is(err, ErrInvalidInvitation) {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditRegister,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "invalid_invitation",
+			Email:     req.Email,
+		}, client)
+		return nil, err
 	}
-
-	token, err := s.jwtService.GenerateToken(newUser.ID)
 	if err != nil {
-		return nil, errors.New("failed to generate token")
+		return nil, err
+	}
+
+	if invitation != nil {
+		return s.invitedRegisterResponse(ctx, &newUser, invitation, client)
+	}
+
+	// The account exists already; the user can request a new link through
+	// the resend endpoint.
+	if err := s.verifications.Send(ctx, &newUser); err != nil {
+		s.logger.Error("Failed to send verification email",
+			zap.String("user_id", newUser.ID),
+			zap.String("tenant_id", newUser.TenantID),
+			zap.Error(err),
+		)
 	}
 
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditRegister,
+		Outcome:   AuditOutcomeSuccess,
+		UserID:    newUser.ID,
+		Email:     newUser.Email,
+	}, client)
+
 	return &models.RegisterResponse{
-		Status:  "success",
-		Message: "User registered successfully",
-		Token:   token,
+		Status:  "pending_verification",
+		Message: "User registered successfully, check your email to verify your address",
 		User: models.RegisterUserInfo{
 			ID:    newUser.ID,
 			Email: newUser.Email,
@@ -118,9 +327,559 @@ func (s *AuthService) Register(req models.RegisterRequest) (*models.RegisterResp
 	}, nil
 }
 
-// ValidateToken validates a JWT token and returns user information
-func (s *AuthService) ValidateToken(tokenString string) (*models.TokenValidationResponse, error) {
+// invitedRegisterResponse records the registration of an invitee and starts
+// their first session.
+func (s *AuthService) invitedRegisterResponse(ctx context.Context, user *models.User, invitation *models.Invitation, client models.ClientInfo) (*models.RegisterResponse, error) {
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditRegister,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    "invitation",
+		UserID:    user.ID,
+		Email:     user.Email,
+		ActorID:   invitation.InvitedBy,
+	}, client)
+
+	login, err := s.loginResponse(ctx, user, []string{AMRPassword}, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RegisterResponse{
+		Status:       "success",
+		Message:      "User registered successfully",
+		Token:        login.Token,
+		RefreshToken: login.RefreshToken,
+		ExpiresIn:    login.ExpiresIn,
+		User: models.RegisterUserInfo{
+			ID:    user.ID,
+			Email: user.Email,
+			Name:  user.Name,
+		},
+	}, nil
+}
+
+// CheckCredentials verifies an email and password without issuing tokens.
+// The failed attempts of the account are not forgotten until the second
+// factor has passed CheckSecondFactor.
+func (s *AuthService) CheckCredentials(email, password string, client models.ClientInfo) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.checkCredentials(ctx, email, password, client)
+}
+
+// checkCredentials looks the account up by email within the client's tenant
+// and verifies the password in
+// constant time, transparently upgrading plaintext or outdated hashes. Blocked
+// attempts fail with a *LoginBlockedError before the password is checked.
+// Failures are recorded in the audit trail. The throttle is not reset, since
+// the login may still need a second factor.
+func (s *AuthService) checkCredentials(ctx context.Context, email, password string, client models.ClientInfo) (*models.User, error) {
+	email = NormalizeEmail(email)
+	if err := s.throttle.Check(ctx, client.TenantID, email, client.IPAddress); err != nil {
+		s.auditLoginFailure(ctx, "", email, err, client)
+		return nil, err
+	}
+
+	user, err := s.users.FindByEmail(ctx, client.TenantID, email)
+	if err != nil {
+		s.passwords.DummyVerify(password)
+		_ = s.throttle.RecordFailure(ctx, client.TenantID, email, client.IPAddress, "")
+		s.auditLoginFailure(ctx, "", email, ErrInvalidCredentials, client)
+		return nil, ErrInvalidCredentials
+	}
+
+	match, needsRehash, err := s.passwords.Verify(password, user.Password)
+	if err != nil || !match {
+		_ = s.throttle.RecordFailure(ctx, client.TenantID, email, client.IPAddress, user.ID)
+		s.auditLoginFailure(ctx, user.ID, email, ErrInvalidCredentials, client)
+		return nil, ErrInvalidCredentials
+	}
+	if needsRehash {
+		// Upgrading the stored hash is best effort; the login itself succeeded.
+		_ = s.rehashPassword(ctx, user, password)
+	}
+	if user.Status == "pending_verification" {
+		s.auditLoginFailure(ctx, user.ID, email, ErrEmailNotVerified, client)
+		return nil, ErrEmailNotVerified
+	}
+
+	return user, nil
+}
+
+// VerifyMFA completes a login started by Login, BeginLogin or CompleteLogin
+// by checking a TOTP or recovery code against the challenge token, and issues
+// the tokens. The login continues in the tenant it was started in.
+func (s *AuthService) VerifyMFA(mfaToken, code string, client models.ClientInfo) (*models.LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	challenge, method, err := s.mfa.CompleteChallenge(ctx, mfaToken, code)
+	if challenge != nil {
+		// The challenge names the tenant of the login it continues, whatever
+		// tenant this request names.
+		client.TenantID = mfaChallengeTenant(challenge)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		// Wrong codes count as failed logins, so new challenges cannot be
+		// used to keep guessing.
+		if user, findErr := s.users.FindByID(ctx, client.TenantID, challenge.UserID); findErr == nil {
+			_ = s.throttle.RecordFailure(ctx, user.TenantID, user.Email, client.IPAddress, user.ID)
+		}
+		s.auditLoginFailure(ctx, challenge.UserID, "", err, client)
+		return nil, err
+	}
+	if err != nil {
+		s.auditLoginFailure(ctx, "", "", err, client)
+		return nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, client.TenantID, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	s.resetThrottle(ctx, user)
+
+	amr := []string{AMRPassword}
+	if len(challenge.AMR) > 0 {
+		amr = append([]string{}, challenge.AMR...)
+	}
+	return s.loginResponse(ctx, user, append(amr, method, AMRMultiFactor), client)
+}
+
+// CompleteLogin continues the login of a user of the tenant who
+// authenticated by other means than a password, such as a passkey. Locked
+// accounts and accounts whose address is not verified yet are refused as by
+// Login. Unless amr already covers several factors, users with MFA enabled
+// receive an "mfa_required" response carrying a challenge for VerifyMFA
+// instead of tokens.
+func (s *AuthService) CompleteLogin(tenantID, userID string, amr []string, client models.ClientInfo) (*models.LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.users.FindByID(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.throttle.Check(ctx, user.TenantID, user.Email, client.IPAddress); err != nil {
+		s.auditLoginFailure(ctx, user.ID, user.Email, err, client)
+		return nil, err
+	}
+	if user.Status == "pending_verification" {
+		s.auditLoginFailure(ctx, user.ID, user.Email, ErrEmailNotVerified, client)
+		return nil, ErrEmailNotVerified
+	}
+
+	if containsString(amr, AMRMultiFactor) {
+		s.resetThrottle(ctx, user)
+		return s.loginResponse(ctx, user, amr, client)
+	}
+	response, err := s.beginLogin(ctx, user, amr, client)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status == "success" {
+		s.resetThrottle(ctx, user)
+	}
+	return response, nil
+}
+
+// loginResponse starts a session, issues its tokens and wraps them in a
+// successful login response.
+func (s *AuthService) loginResponse(ctx context.Context, user *models.User, amr []string, client models.ClientInfo) (*models.LoginResponse, error) {
+	session, token, refreshToken, ttl, err := s.issueTokens(ctx, user, amr, client)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditLogin,
+		Outcome:   AuditOutcomeSuccess,
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: session.ID,
+		AMR:       amr,
+	}, client)
+
+	return &models.LoginResponse{
+		Status:       "success",
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(ttl.Seconds()),
+	}, nil
+}
+
+// CheckSecondFactor verifies the second factor of a user who already passed
+// CheckCredentials and returns the resulting amr values. Users without MFA
+// need no code; for users with MFA an empty code yields ErrMFARequired.
+// Wrong codes are throttled like wrong passwords, and the failed attempts of
+// the account are forgotten once the second factor has passed.
+func (s *AuthService) CheckSecondFactor(user *models.User, code string, client models.ClientInfo) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !mfaEnabled {
+		s.resetThrottle(ctx, user)
+		return []string{AMRPassword}, nil
+	}
+	if code == "" {
+		return nil, ErrMFARequired
+	}
+
+	method, err := s.mfa.VerifyCode(ctx, user.ID, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			_ = s.throttle.RecordFailure(ctx, user.TenantID, user.Email, client.IPAddress, user.ID)
+		}
+		s.auditLoginFailure(ctx, user.ID, user.Email, err, client)
+		return nil, err
+	}
+	s.resetThrottle(ctx, user)
+	return []string{AMRPassword, method, AMRMultiFactor}, nil
+}
+
+// resetThrottle forgets the failed logins of an account once the user has
+// passed every factor. Counting is best effort; a failed reset must not fail
+// the login.
+func (s *AuthService) resetThrottle(ctx context.Context, user *models.User) {
+	_ = s.throttle.Reset(ctx, user.TenantID, user.Email)
+}
+
+// ChangePassword replaces the password of a signed-in user after checking the
+// current one. All of the user's tokens are revoked, so every device,
+// including the caller's, has to sign in again.
+func (s *AuthService) ChangePassword(userID, currentPassword, newPassword string, client models.ClientInfo) error {
+	user, err := s.GetUser(client.TenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	match, _, err := s.passwords.Verify(currentPassword, user.Password)
+	if err != nil || !match {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditPasswordChange,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "invalid_credentials",
+			UserID:    user.ID,
+			Email:     user.Email,
+		}, client)
+		return ErrInvalidCredentials
+	}
+
+	return s.ReplacePassword(user, newPassword, "password_change", client)
+}
+
+// ValidatePassword returns a *PasswordPolicyError if password may not become
+// the new password of user under the password policy of their tenant.
+func (s *AuthService) ValidatePassword(user *models.User, password string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenant, err := s.tenants.Get(ctx, user.TenantID)
+	if err != nil {
+		return err
+	}
+	return s.policy.ForTenant(tenant.Settings.PasswordPolicy).Validate(password, user.Email, user.Name)
+}
+
+// ReplacePassword sets a new password for the user, ends all of their
+// sessions, revokes their access tokens and publishes security.password_changed.v1. The
+// update only applies if the stored hash still matches user, otherwise
+// ErrInvalidCredentials is returned. Passwords that violate the password
+// policy fail with a *PasswordPolicyError.
+func (s *AuthService) ReplacePassword(user *models.User, newPassword, reason string, client models.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.ValidatePassword(user, newPassword); err != nil {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditPasswordChange,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    "weak_password",
+			UserID:    user.ID,
+			Email:     user.Email,
+		}, client)
+		return err
+	}
+
+	passwordHash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	err = s.credentials.SetPasswordHash(ctx, user.TenantID, user.ID, user.Password, passwordHash)
+	if errors.Is(err, ErrUserNotFound) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.TerminateAll(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeAllForUser(ctx, user.ID, reason); err != nil {
+		return err
+	}
+
+	event := newSecurityEvent("security.password_changed.v1", user.TenantID, user.ID, user.Email, client.IPAddress, reason)
+	// The password is changed and tokens are revoked regardless; the event is
+	// informational.
+	if err := s.publisher.PublishPasswordChanged(ctx, event); err != nil {
+		s.logger.Warn("Failed to publish password change event",
+			zap.String("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditPasswordChange,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    reason,
+		UserID:    user.ID,
+		Email:     user.Email,
+	}, client)
+
+	return nil
+}
+
+// UnlockAccount clears the failed login counters and any lockout of the user
+// in the tenant.
+func (s *AuthService) UnlockAccount(tenantID, userID string) error {
+	user, err := s.GetUser(tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.throttle.Unlock(ctx, user.TenantID, user.Email)
+}
+
+// AssignRole changes the role of a user on behalf of actorID. The change is
+// published as user.updated.v1, and the user's access tokens are revoked so
+// the new permissions apply from the next token refresh.
+func (s *AuthService) AssignRole(userID, role, actorID string, client models.ClientInfo) (*models.User, error) {
+	if !IsKnownRole(role) {
+		return nil, ErrUnknownRole
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user *models.User
+	err := s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.users.SetRole(ctx, client.TenantID, userID, role)
+		if err != nil {
+			return err
+		}
+
+		return s.events.PublishUserUpdated(ctx, models.UserEvent{
+			EventID:   primitive.NewObjectID().Hex(),
+			EventType: "user.updated.v1",
+			Timestamp: time.Now().UTC(),
+			UserID:    user.ID,
+			TenantID:  user.TenantID,
+			Email:     user.Email,
+			Name:      user.Name,
+			Status:    user.Status,
+			Role:      user.Role,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revocations.RevokeAllForUser(ctx, user.ID, "role_change"); err != nil {
+		return nil, err
+	}
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditTokenRevocation,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    "role_change",
+		UserID:    user.ID,
+		Email:     user.Email,
+		ActorID:   actorID,
+	}, client)
+
+	return user, nil
+}
+
+// Impersonate issues a short-lived access token that lets the admin in
+// actor act as the user of the admin's tenant. Admin accounts cannot be
+// impersonated, so an
+// impersonation never grants more than the admin already has. Every
+// impersonation is recorded in the audit trail with the given reason.
+func (s *AuthService) Impersonate(userID string, actor *Claims, reason string, client models.ClientInfo) (*models.ImpersonationResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.users.FindByID(ctx, actor.Tenant(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.UserID || user.Role == "admin" {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	token, err := s.jwtService.GenerateImpersonationToken(user, actor.UserID)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditImpersonation,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    reason,
+		UserID:    user.ID,
+		Email:     user.Email,
+		ActorID:   actor.UserID,
+	}, client)
+
+	return &models.ImpersonationResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresIn: int64(s.jwtService.ImpersonationTTL().Seconds()),
+		User: models.RegisterUserInfo{
+			ID:    user.ID,
+			Email: user.Email,
+			Name:  user.Name,
+		},
+	}, nil
+}
+
+// GetUser returns the account with the given ID in the tenant.
+func (s *AuthService) GetUser(tenantID, userID string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.users.FindByID(ctx, tenantID, userID)
+}
+
+// GetUserByEmail returns the account with the given email in the tenant.
+func (s *AuthService) GetUserByEmail(tenantID, email string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.users.FindByEmail(ctx, tenantID, email)
+}
+
+// AccessTokenTTL returns the lifetime of access tokens issued in the tenant:
+// the tenant's setting if it is shorter than the service default.
+func (s *AuthService) AccessTokenTTL(ctx context.Context, tenantID string) (time.Duration, error) {
+	ttl := s.jwtService.AccessTokenTTL()
+	tenant, err := s.tenants.Get(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if override := time.Duration(tenant.Settings.AccessTokenTTL) * time.Second; override > 0 && override < ttl {
+		ttl = override
+	}
+	return ttl, nil
+}
+
+// NormalizeEmail returns the form of an address accounts are looked up and
+// deduplicated by.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// issueTokens records a new session for the client and issues an access token
+// bound to it along with the first refresh token of its family, and returns
+// the access token's lifetime. amr lists the authentication methods the user
+// just completed.
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, amr []string, client models.ClientInfo) (*models.Session, string, string, time.Duration, error) {
+	ttl, err := s.AccessTokenTTL(ctx, user.TenantID)
+	if err != nil {
+		return nil, "", "", 0, err
+	}
+
+	session, err := s.sessions.Create(ctx, user.ID, amr, client)
+	if err != nil {
+		return nil, "", "", 0, errors.New("failed to start session")
+	}
+
+	token, err := s.jwtService.GenerateToken(user.ID, WithTenant(user.TenantID), WithRole(user.Role), WithAMR(amr), WithSessionID(session.ID), withTTL(ttl))
+	if err != nil {
+		return nil, "", "", 0, errors.New("failed to generate token")
+	}
+
+	refreshToken, err := s.refreshTokens.Issue(ctx, user.TenantID, user.ID, session.FamilyID, amr)
+	if err != nil {
+		return nil, "", "", 0, errors.New("failed to generate refresh token")
+	}
+
+	return session, token, refreshToken, ttl, nil
+}
+
+// rehashPassword replaces a plaintext or outdated password hash with a hash
+// produced by the preferred algorithm. The update only applies if the stored
+// value has not changed since it was verified.
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) error {
+	passwordHash, err := s.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.credentials.SetPasswordHash(ctx, user.TenantID, user.ID, user.Password, passwordHash)
+}
+
+// Authenticate validates a JWT, checks it against the revocation denylist and
+// rejects tokens whose session has been terminated or has expired, and user
+// tokens of a tenant that has been disabled.
+func (s *AuthService) Authenticate(tokenString string) (*Claims, error) {
 	claims, err := s.jwtService.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	if claims.SessionID != "" {
+		active, err := s.sessions.IsActive(ctx, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrSessionNotFound
+		}
+	}
+
+	if claims.PrincipalType() == PrincipalUser {
+		tenant, err := s.tenants.Get(ctx, claims.Tenant())
+		if err != nil {
+			return nil, err
+		}
+		if tenant.Status != TenantStatusActive {
+			return nil, ErrTenantDisabled
+		}
+	}
+
+	return claims, nil
+}
+
+// ValidateToken validates a JWT token and returns the principal it was issued
+// to: a user with their tenant, or a service account with its client ID and
+// scope
+func (s *AuthService) ValidateToken(tokenString string) (*models.TokenValidationResponse, error) {
+	claims, err := s.Authenticate(tokenString)
 	if err != nil {
 		return &models.TokenValidationResponse{
 			Valid:   false,
@@ -128,26 +887,332 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.TokenValidation
 		}, nil
 	}
 
-	return &models.TokenValidationResponse{
-		Valid:  true,
-		UserID: claims.UserID,
-	}, nil
+	response := &models.TokenValidationResponse{
+		Valid:         true,
+		PrincipalType: claims.PrincipalType(),
+		UserID:        claims.UserID,
+		ClientID:      claims.ClientID,
+		Scope:         claims.Scope,
+		ActorID:       claims.ActorID(),
+	}
+	if response.PrincipalType == PrincipalUser {
+		response.TenantID = claims.Tenant()
+	}
+	return response, nil
 }
 
-// RefreshToken generates a new token for the user
-func (s *AuthService) RefreshToken(tokenString string) (*models.RefreshTokenResponse, error) {
-	claims, err := s.jwtService.ValidateToken(tokenString)
+// RefreshToken rotates the refresh token and issues a new access token, and
+// records the activity on the session the token belongs to. Reusing a refresh
+// token that was already rotated revokes its whole family.
+func (s *AuthService) RefreshToken(refreshToken string, client models.ClientInfo) (*models.RefreshTokenResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rotated, newRefreshToken, err := s.refreshTokens.Rotate(ctx, refreshToken)
 	if err != nil {
-		return nil, errors.New("invalid token")
+		reason := "invalid_refresh_token"
+		if errors.Is(err, ErrRefreshTokenReused) {
+			reason = "refresh_token_reused"
+		}
+		s.auditRefreshFailure(ctx, "", reason, client)
+		return nil, err
 	}
 
-	newToken, err := s.jwtService.GenerateToken(claims.UserID)
+	// The account may have been removed since the family was started, and its
+	// role may have changed. The token names the tenant of the account, so a
+	// request without the right tenant header does not burn it.
+	user, err := s.users.FindByID(ctx, refreshTokenTenant(rotated), rotated.UserID)
+	if err != nil {
+		s.auditRefreshFailure(ctx, rotated.UserID, "user_not_found", client)
+		return nil, ErrInvalidRefreshToken
+	}
+	client.TenantID = user.TenantID
+
+	session, err := s.sessions.Touch(ctx, rotated.FamilyID, client)
+	if errors.Is(err, ErrSessionNotFound) {
+		// The session expired or was terminated while the family survived;
+		// retire the family so it cannot be used again.
+		_ = s.refreshTokens.RevokeFamily(ctx, rotated.FamilyID)
+		s.auditRefreshFailure(ctx, user.ID, "session_terminated", client)
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := s.AccessTokenTTL(ctx, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	newToken, err := s.jwtService.GenerateToken(user.ID, WithTenant(user.TenantID), WithRole(user.Role), WithAMR(rotated.AMR), WithSessionID(session.ID), withTTL(ttl))
 	if err != nil {
 		return nil, errors.New("failed to generate new token")
 	}
 
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditTokenRefresh,
+		Outcome:   AuditOutcomeSuccess,
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: session.ID,
+	}, client)
+
 	return &models.RefreshTokenResponse{
-		Status: "success",
-		Token:  newToken,
+		Status:       "success",
+		Token:        newToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(ttl.Seconds()),
 	}, nil
 }
+
+// mfaChallengeTenant returns the tenant of the login a challenge continues.
+func mfaChallengeTenant(challenge *models.MFAChallenge) string {
+	if challenge.TenantID == "" {
+		return DefaultTenantID
+	}
+	return challenge.TenantID
+}
+
+// refreshTokenTenant returns the tenant a refresh token was issued in.
+func refreshTokenTenant(token *models.RefreshToken) string {
+	if token.TenantID == "" {
+		return DefaultTenantID
+	}
+	return token.TenantID
+}
+
+// Logout revokes the access token described by claims, ends its session and,
+// if provided, revokes the refresh token family the client was using.
+func (s *AuthService) Logout(claims *Claims, refreshToken string, client models.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.revocations.RevokeToken(ctx, claims, "logout"); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		err := s.sessions.Terminate(ctx, claims.UserID, claims.SessionID)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		if err := s.refreshTokens.RevokeFamilyOf(ctx, refreshToken, claims.UserID); err != nil {
+			return err
+		}
+	}
+
+	s.auditRevocation(ctx, claims.UserID, claims.SessionID, "logout", claims.ActorID(), client)
+	return nil
+}
+
+// RevokeAllTokens ends every session of the user and revokes all access and
+// refresh tokens issued to them on behalf of actorID.
+func (s *AuthService) RevokeAllTokens(userID, reason, actorID string, client models.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.users.FindByID(ctx, client.TenantID, userID); err != nil {
+		return err
+	}
+
+	if err := s.sessions.TerminateAll(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeAllForUser(ctx, userID, reason); err != nil {
+		return err
+	}
+
+	s.auditRevocation(ctx, userID, "", reason, actorID, client)
+	return nil
+}
+
+// DeleteAccountFromEvent removes the account whose profile was deleted in
+// user-service, ending its sessions and revoking its tokens so the user can
+// neither sign in again nor keep using the tokens they hold. Unknown accounts
+// are ignored, so replayed events are harmless.
+func (s *AuthService) DeleteAccountFromEvent(event models.UserEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenantID := eventTenant(event)
+	err := s.users.Delete(ctx, tenantID, event.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.TerminateAll(ctx, event.UserID); err != nil {
+		return err
+	}
+	if err := s.revocations.RevokeAllForUser(ctx, event.UserID, "account_deleted"); err != nil {
+		return err
+	}
+
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditAccountDeletion,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    "profile_deleted",
+		UserID:    event.UserID,
+		Email:     event.Email,
+	}, models.ClientInfo{TenantID: tenantID})
+	return nil
+}
+
+// UpdateAccountFromEvent applies a name or email change made through
+// user-service to the account. Role and status are owned by auth-service and
+// only echo back what it published, so they are not taken from the event; a
+// stale copy would otherwise undo a newer role change or verification.
+// A new address is not applied right away: the account keeps signing in with
+// its current one, and a confirmation link is sent to the new address. Only
+// events that changed the address send one; later updates still carry the
+// unconfirmed address and would otherwise send it again each time.
+// Returns ErrUserExists if the address belongs to another account of the
+// tenant. Unknown accounts are ignored.
+func (s *AuthService) UpdateAccountFromEvent(event models.UserEvent) error {
+	email := strings.TrimSpace(event.Email)
+	if event.Name == "" && email == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenantID := eventTenant(event)
+	user, err := s.users.FindByID(ctx, tenantID, event.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// A change of case only is the same address and needs no confirmation.
+	var newEmail string
+	if email != "" && NormalizeEmail(email) != user.EmailNormalized {
+		newEmail, email = email, ""
+	}
+	if event.Name != "" || email != "" {
+		err := s.users.UpdateProfile(ctx, tenantID, user.ID, event.Name, email)
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if newEmail == "" || event.PreviousEmail == "" {
+		return nil
+	}
+
+	_, err = s.users.FindByEmail(ctx, tenantID, newEmail)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	return s.verifications.SendEmailChange(ctx, user, newEmail)
+}
+
+// eventTenant returns the tenant a user lifecycle event belongs to. Events
+// published before tenants existed belong to the default tenant.
+func eventTenant(event models.UserEvent) string {
+	if event.TenantID == "" {
+		return DefaultTenantID
+	}
+	return event.TenantID
+}
+
+// ListSessions returns the active sessions of the user described by claims,
+// flagging the one the claims belong to.
+func (s *AuthService) ListSessions(claims *Claims) ([]models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.sessions.List(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+	return sessions, nil
+}
+
+// TerminateSession ends one of the user's sessions. Its refresh tokens stop
+// working immediately and its access tokens are rejected from then on.
+func (s *AuthService) TerminateSession(userID, sessionID string, client models.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.sessions.Terminate(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	s.auditRevocation(ctx, userID, sessionID, "session_terminated", "", client)
+	return nil
+}
+
+// TerminateOtherSessions ends every session of the user except the one the
+// claims belong to and returns how many were ended.
+func (s *AuthService) TerminateOtherSessions(claims *Claims, client models.ClientInfo) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	terminated, err := s.sessions.TerminateOthers(ctx, claims.UserID, claims.SessionID)
+	if terminated > 0 {
+		s.auditRevocation(ctx, claims.UserID, "", "other_sessions_terminated", "", client)
+	}
+	return terminated, err
+}
+
+func (s *AuthService) auditLoginFailure(ctx context.Context, userID, email string, err error, client models.ClientInfo) {
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditLogin,
+		Outcome:   AuditOutcomeFailure,
+		Reason:    loginFailureReason(err),
+		UserID:    userID,
+		Email:     email,
+	}, client)
+}
+
+func (s *AuthService) auditRefreshFailure(ctx context.Context, userID, reason string, client models.ClientInfo) {
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditTokenRefresh,
+		Outcome:   AuditOutcomeFailure,
+		Reason:    reason,
+		UserID:    userID,
+	}, client)
+}
+
+func (s *AuthService) auditRevocation(ctx context.Context, userID, sessionID, reason, actorID string, client models.ClientInfo) {
+	s.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditTokenRevocation,
+		Outcome:   AuditOutcomeSuccess,
+		Reason:    reason,
+		UserID:    userID,
+		SessionID: sessionID,
+		ActorID:   actorID,
+	}, client)
+}
+
+// HasRole reports whether the user of the tenant has the given role.
+func (s *AuthService) HasRole(tenantID, userID, role string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.users.FindByID(ctx, tenantID, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Role == role, nil
+}
@@ -0,0 +1,644 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testPassword = "Correct-Horse-7"
+
+// testAuth is an AuthService wired to in-memory dependencies, with handles on
+// the ones tests inspect.
+type testAuth struct {
+	*services.AuthService
+	users         *testutil.MemoryUserRepository
+	events        *testutil.MemoryEventPublisher
+	audit         *testutil.MemoryAuditRecorder
+	mfa           *testutil.MemoryMFAVerifier
+	invitations   *testutil.MemoryInvitationRedeemer
+	verifications *testutil.MemoryVerificationSender
+	tenants       *testutil.MemoryTenantDirectory
+	sessions      *testutil.MemorySessionStore
+	jwt           *services.JWTService
+	log           *recordingLogger
+}
+
+func newTestAuthService(t *testing.T) *testAuth {
+	t.Helper()
+
+	return newTestAuthServiceWith(t, func(a *testAuth) services.InvitationRedeemer { return a.invitations })
+}
+
+// newTestAuthServiceWith is newTestAuthService redeeming invitations through
+// the redeemer that invitations returns for the testAuth being built.
+func newTestAuthServiceWith(t *testing.T, invitations func(a *testAuth) services.InvitationRedeemer) *testAuth {
+	t.Helper()
+
+	keyStore, err := testutil.NewMemoryKeyStore("EdDSA")
+	if err != nil {
+		t.Fatalf("testutil.NewMemoryKeyStore: %v", err)
+	}
+	jwtService := services.NewJWTService(config.NewJWTConfig("EdDSA", "auth-service", "api", 15*time.Minute, 5*time.Minute, 0, 0), keyStore)
+	// Cheap parameters keep the tests fast; the algorithms are the same.
+	passwords, err := services.NewPasswordService(config.NewPasswordHashConfig("argon2id", 1024, 1, 1, 4), logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewPasswordService: %v", err)
+	}
+	policy, err := services.NewPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 10, RejectPersonalInfo: true})
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+
+	a := &testAuth{
+		users:         testutil.NewMemoryUserRepository(),
+		events:        testutil.NewMemoryEventPublisher(),
+		audit:         testutil.NewMemoryAuditRecorder(),
+		mfa:           testutil.NewMemoryMFAVerifier(),
+		invitations:   testutil.NewMemoryInvitationRedeemer(false),
+		verifications: testutil.NewMemoryVerificationSender(),
+		jwt:           jwtService,
+		log:           &recordingLogger{},
+		tenants: testutil.NewMemoryTenantDirectory(
+			models.Tenant{ID: services.DefaultTenantID, Status: services.TenantStatusActive, Settings: models.TenantSettings{AllowSignup: true}},
+			models.Tenant{ID: "closed", Status: services.TenantStatusActive},
+		),
+	}
+	refreshTokens := services.NewRefreshTokenService(testutil.NewMemoryRefreshTokenRepository(), time.Hour)
+	throttle := services.NewLoginThrottleService(testutil.NewMemoryLoginAttemptRepository(),
+		config.NewLoginThrottleConfig(5, time.Second, time.Minute, 3, 15*time.Minute, 50, time.Hour), nil)
+
+	a.sessions = testutil.NewMemorySessionStore(refreshTokens, time.Hour)
+	a.AuthService = services.NewAuthService(a.users, a.users, testutil.MemoryTransactor{}, jwtService, passwords, policy,
+		refreshTokens, testutil.NewMemoryRevocationList(), a.mfa, a.verifications, invitations(a), throttle,
+		a.sessions, a.audit, a.tenants, a.events, nil, a.log)
+	return a
+}
+
+// addUser stores an active account with testPassword in the tenant.
+func (a *testAuth) addUser(t *testing.T, tenantID, email, role string) *models.User {
+	t.Helper()
+
+	hash, err := a.Passwords().Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	user := &models.User{
+		ID:              email + "@" + tenantID,
+		TenantID:        tenantID,
+		Name:            "Test User",
+		Email:           email,
+		EmailNormalized: services.NormalizeEmail(email),
+		Password:        hash,
+		Status:          "active",
+		Role:            role,
+	}
+	if err := a.users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return user
+}
+
+func (a *testAuth) lastAudit(t *testing.T) models.AuditEvent {
+	t.Helper()
+
+	events := a.audit.Events()
+	if len(events) == 0 {
+		t.Fatal("no audit events recorded")
+	}
+	return events[len(events)-1]
+}
+
+func client(tenantID string) models.ClientInfo {
+	return models.ClientInfo{TenantID: tenantID, IPAddress: "10.0.0.1", UserAgent: "test"}
+}
+
+func registerRequest(email, password string) models.RegisterRequest {
+	return models.RegisterRequest{Name: "Jane Roe", Email: email, Password: password, ConfirmPassword: password}
+}
+
+func TestRegisterLeavesAccountPendingUntilVerified(t *testing.T) {
+	a := newTestAuthService(t)
+
+	response, err := a.Register(registerRequest(" Jane@Example.com ", testPassword), client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if response.Status != "pending_verification" || response.Token != "" {
+		t.Errorf("Register response = %+v, want pending_verification without tokens", response)
+	}
+
+	user, err := a.users.FindByEmail(context.Background(), services.DefaultTenantID, "jane@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if user.Email != "Jane@Example.com" || user.Role != "customer" || user.Password == testPassword {
+		t.Errorf("stored user = %+v, want trimmed email, customer role and a hashed password", user)
+	}
+
+	if sent := a.verifications.Sent(); len(sent) != 1 || sent[0].UserID != user.ID || sent[0].Purpose != services.PurposeEmailVerification {
+		t.Errorf("verification emails = %+v, want one for %s", sent, user.ID)
+	}
+	if events := a.events.Events(); len(events) != 1 || events[0].EventType != "user.created.v1" || events[0].Status != "pending_verification" {
+		t.Errorf("published events = %+v, want one pending user.created.v1", events)
+	}
+	if got := a.lastAudit(t); got.EventType != services.AuditRegister || got.Outcome != services.AuditOutcomeSuccess || got.TenantID != services.DefaultTenantID {
+		t.Errorf("audit event = %+v, want a successful registration in the default tenant", got)
+	}
+
+	if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); !errors.Is(err, services.ErrEmailNotVerified) {
+		t.Errorf("Login before verification error = %v, want services.ErrEmailNotVerified", err)
+	}
+	if _, err := a.users.Activate(context.Background(), user.ID, user.Email); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login after verification: %v", err)
+	}
+}
+
+func TestRegisterLogsFailedVerificationEmail(t *testing.T) {
+	a := newTestAuthService(t)
+	a.verifications.FailWith(errors.New("smtp unavailable"))
+
+	response, err := a.Register(registerRequest("jane@example.com", testPassword), client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if response.Status != "pending_verification" {
+		t.Errorf("Register status = %q, want pending_verification", response.Status)
+	}
+	if errs := a.log.Errors(); len(errs) != 1 || errs[0] != "Failed to send verification email" {
+		t.Errorf("logged errors = %v, want the failed verification email", errs)
+	}
+}
+
+func TestRegisterRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant string
+		req    models.RegisterRequest
+		want   error
+		reason string
+	}{
+		{name: "email taken in another case", tenant: services.DefaultTenantID, req: registerRequest("TAKEN@example.com", testPassword), want: services.ErrUserExists, reason: "email_taken"},
+		{name: "weak password", tenant: services.DefaultTenantID, req: registerRequest("new@example.com", "short"), want: services.ErrWeakPassword, reason: "weak_password"},
+		{name: "signup disabled", tenant: "closed", req: registerRequest("new@example.com", testPassword), want: services.ErrSignupDisabled, reason: "signup_disabled"},
+		{name: "unknown invitation", tenant: services.DefaultTenantID, req: models.RegisterRequest{Name: "New", Email: "new@example.com", Password: testPassword, InvitationCode: "nope"}, want: services.ErrInvalidInvitation, reason: "invalid_invitation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthService(t)
+			a.addUser(t, services.DefaultTenantID, "taken@example.com", "customer")
+
+			if _, err := a.Register(tt.req, client(tt.tenant)); !errors.Is(err, tt.want) {
+				t.Fatalf("Register error = %v, want %v", err, tt.want)
+			}
+			if got := a.lastAudit(t); got.Outcome != services.AuditOutcomeFailure || got.Reason != tt.reason {
+				t.Errorf("audit event = %+v, want failure %q", got, tt.reason)
+			}
+			if len(a.events.Events()) != 0 {
+				t.Errorf("published events = %+v, want none", a.events.Events())
+			}
+		})
+	}
+}
+
+func TestRegisterScopesEmailToTheTenant(t *testing.T) {
+	a := newTestAuthService(t)
+	a.tenants.Put(models.Tenant{ID: "acme", Status: services.TenantStatusActive, Settings: models.TenantSettings{AllowSignup: true}})
+	taken := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	if _, err := a.Register(registerRequest("jane@example.com", testPassword), client("acme")); err != nil {
+		t.Fatalf("Register of an address taken in another tenant: %v", err)
+	}
+	registered, err := a.users.FindByEmail(context.Background(), "acme", "jane@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if registered.ID == taken.ID || registered.TenantID != "acme" {
+		t.Errorf("registered user = %+v, want a new account in acme", registered)
+	}
+
+	// Signing in to acme uses the pending acme account, never the active one
+	// of the default tenant.
+	if _, err := a.Login("jane@example.com", testPassword, client("acme")); err == nil {
+		t.Error("Login in acme before verification succeeded")
+	}
+	if _, err := a.Login("jane@example.com", testPassword, client("unknown")); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("Login in a tenant without the account error = %v, want services.ErrInvalidCredentials", err)
+	}
+}
+
+func TestRegisterWithInvitation(t *testing.T) {
+	a := newTestAuthService(t)
+	a.invitations.Add(models.Invitation{
+		ID:        "invitation-1",
+		TenantID:  "closed",
+		Email:     "invitee@example.com",
+		Role:      "admin",
+		InvitedBy: "admin-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, "code-1")
+
+	req := registerRequest("Invitee@example.com", testPassword)
+	req.InvitationCode = "code-1"
+	response, err := a.Register(req, client("closed"))
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if response.Status != "success" || response.Token == "" || response.RefreshToken == "" {
+		t.Errorf("Register response = %+v, want tokens right away", response)
+	}
+
+	claims, err := a.Authenticate(response.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.Tenant() != "closed" || claims.Role != "admin" {
+		t.Errorf("claims tenant %q role %q, want closed admin", claims.Tenant(), claims.Role)
+	}
+	if len(a.verifications.Sent()) != 0 {
+		t.Errorf("verification emails = %+v, want none for invitees", a.verifications.Sent())
+	}
+
+	// The invitation is spent.
+	req.Email = "invitee@example.com"
+	if _, err := a.Register(req, client("closed")); !errors.Is(err, services.ErrInvalidInvitation) {
+		t.Errorf("second Register error = %v, want services.ErrInvalidInvitation", err)
+	}
+}
+
+func TestRegisterRequiresInvitation(t *testing.T) {
+	a := newTestAuthService(t)
+	a.invitations.SetRequired(true)
+
+	if _, err := a.Register(registerRequest("new@example.com", testPassword), client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvitationRequired) {
+		t.Errorf("Register error = %v, want services.ErrInvitationRequired", err)
+	}
+}
+
+func TestLoginIssuesTokensForTheTenant(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "admin")
+	a.addUser(t, "closed", "jane@example.com", "customer")
+
+	response, err := a.Login("JANE@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if response.Status != "success" || response.RefreshToken == "" || response.ExpiresIn != int64((15*time.Minute).Seconds()) {
+		t.Errorf("Login response = %+v, want tokens valid for 15 minutes", response)
+	}
+
+	claims, err := a.Authenticate(response.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.UserID != user.ID || claims.Tenant() != services.DefaultTenantID || claims.Role != "admin" || claims.SessionID == "" {
+		t.Errorf("claims = %+v, want %s as admin of the default tenant with a session", claims, user.ID)
+	}
+	if len(claims.AMR) != 1 || claims.AMR[0] != services.AMRPassword {
+		t.Errorf("amr = %v, want [%s]", claims.AMR, services.AMRPassword)
+	}
+	if got := a.lastAudit(t); got.EventType != services.AuditLogin || got.SessionID != claims.SessionID || got.ClientIP != "10.0.0.1" {
+		t.Errorf("audit event = %+v, want the login of session %s from 10.0.0.1", got, claims.SessionID)
+	}
+}
+
+func TestLoginHonoursTenantAccessTokenTTL(t *testing.T) {
+	a := newTestAuthService(t)
+	a.tenants.Put(models.Tenant{ID: "short", Status: services.TenantStatusActive, Settings: models.TenantSettings{AccessTokenTTL: 300}})
+	a.addUser(t, "short", "jane@example.com", "customer")
+
+	response, err := a.Login("jane@example.com", testPassword, client("short"))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if response.ExpiresIn != 300 {
+		t.Errorf("ExpiresIn = %d, want 300", response.ExpiresIn)
+	}
+}
+
+func TestLoginFailuresLockTheAccount(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Login("jane@example.com", "wrong-password", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+			t.Fatalf("Login %d error = %v, want services.ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if got := a.lastAudit(t); got.Reason != "invalid_credentials" || got.Outcome != services.AuditOutcomeFailure {
+		t.Errorf("audit event = %+v, want failure invalid_credentials", got)
+	}
+
+	// Even the right password is refused while the account is locked.
+	_, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	var blocked *services.LoginBlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, services.ErrAccountLocked) {
+		t.Fatalf("Login while locked error = %v, want services.ErrAccountLocked", err)
+	}
+	if got := a.lastAudit(t); got.Reason != "account_locked" {
+		t.Errorf("audit reason = %q, want account_locked", got.Reason)
+	}
+
+	// The lockout is per tenant.
+	a.addUser(t, "closed", "jane@example.com", "customer")
+	if _, err := a.Login("jane@example.com", testPassword, client("closed")); err != nil {
+		t.Errorf("Login in another tenant: %v", err)
+	}
+
+	if err := a.UnlockAccount(services.DefaultTenantID, "jane@example.com@"+services.DefaultTenantID); err != nil {
+		t.Fatalf("UnlockAccount: %v", err)
+	}
+	if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login after UnlockAccount: %v", err)
+	}
+}
+
+func TestLoginUnknownEmail(t *testing.T) {
+	a := newTestAuthService(t)
+
+	if _, err := a.Login("nobody@example.com", testPassword, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("Login error = %v, want services.ErrInvalidCredentials", err)
+	}
+}
+
+func TestLoginWithMFA(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	response, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if response.Status != "mfa_required" || response.MFAToken == "" || response.Token != "" {
+		t.Fatalf("Login response = %+v, want an mfa challenge without tokens", response)
+	}
+
+	if _, err := a.VerifyMFA(response.MFAToken, "654321", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA with a wrong code error = %v, want services.ErrInvalidMFACode", err)
+	}
+	verified, err := a.VerifyMFA(response.MFAToken, "123456", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+
+	claims, err := a.Authenticate(verified.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	want := []string{services.AMRPassword, services.AMROneTimeCode, services.AMRMultiFactor}
+	if len(claims.AMR) != len(want) || claims.AMR[0] != want[0] || claims.AMR[1] != want[1] || claims.AMR[2] != want[2] {
+		t.Errorf("amr = %v, want %v", claims.AMR, want)
+	}
+
+	// A challenge completes once.
+	if _, err := a.VerifyMFA(response.MFAToken, "123456", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFAChallenge) {
+		t.Errorf("second VerifyMFA error = %v, want services.ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestVerifyMFAContinuesInTheTenantOfTheLogin(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, "closed", "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	response, err := a.Login("jane@example.com", testPassword, client("closed"))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// The second step names another tenant, which does not matter.
+	if _, err := a.VerifyMFA(response.MFAToken, "654321", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFACode) {
+		t.Fatalf("VerifyMFA with a wrong code error = %v, want services.ErrInvalidMFACode", err)
+	}
+	if got := a.lastAudit(t); got.TenantID != "closed" || got.UserID != user.ID {
+		t.Errorf("audit event = %+v, want the failure of %s in closed", got, user.ID)
+	}
+
+	verified, err := a.VerifyMFA(response.MFAToken, "123456", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	claims, err := a.Authenticate(verified.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.Tenant() != "closed" || claims.UserID != user.ID {
+		t.Errorf("token of %s in %s, want %s in closed", claims.UserID, claims.Tenant(), user.ID)
+	}
+	if got := a.lastAudit(t); got.TenantID != "closed" || got.Outcome != services.AuditOutcomeSuccess {
+		t.Errorf("audit event = %+v, want a successful login in closed", got)
+	}
+}
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// The refresh token names its tenant, so the request's tenant does not matter.
+	refreshed, err := a.RefreshToken(login.RefreshToken, client("closed"))
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("RefreshToken returned the same refresh token")
+	}
+	claims, err := a.Authenticate(refreshed.Token)
+	if err != nil {
+		t.Fatalf("Authenticate of the refreshed token: %v", err)
+	}
+	if claims.Tenant() != services.DefaultTenantID {
+		t.Errorf("refreshed token tenant = %q, want %q", claims.Tenant(), services.DefaultTenantID)
+	}
+
+	if _, err := a.RefreshToken(login.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("replayed RefreshToken error = %v, want services.ErrRefreshTokenReused", err)
+	}
+	if got := a.lastAudit(t); got.EventType != services.AuditTokenRefresh || got.Reason != "refresh_token_reused" {
+		t.Errorf("audit event = %+v, want refresh_token_reused", got)
+	}
+	if _, err := a.RefreshToken(refreshed.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken after reuse error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutRevokesAccessAndRefreshTokens(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	other, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("second Login: %v", err)
+	}
+	claims, err := a.Authenticate(login.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if err := a.Logout(claims, login.RefreshToken, client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate after Logout error = %v, want services.ErrTokenRevoked", err)
+	}
+	if _, err := a.RefreshToken(login.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken after Logout error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	if response, _ := a.ValidateToken(login.Token); response.Valid {
+		t.Error("ValidateToken reports a logged out token as valid")
+	}
+
+	// The user's other session is untouched.
+	if _, err := a.Authenticate(other.Token); err != nil {
+		t.Errorf("Authenticate of another session: %v", err)
+	}
+}
+
+func TestChangePasswordEndsEverySession(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	if err := a.ChangePassword(user.ID, "wrong-password", "Another-Horse-8", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Fatalf("ChangePassword with a wrong password error = %v, want services.ErrInvalidCredentials", err)
+	}
+	if err := a.ChangePassword(user.ID, testPassword, "Jane-Roe-Test-User", client(services.DefaultTenantID)); !errors.Is(err, services.ErrWeakPassword) {
+		t.Fatalf("ChangePassword to a password with the name error = %v, want services.ErrWeakPassword", err)
+	}
+	if err := a.ChangePassword(user.ID, testPassword, "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate after ChangePassword error = %v, want services.ErrTokenRevoked", err)
+	}
+	if _, err := a.RefreshToken(login.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken after ChangePassword error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("Login with the old password error = %v, want services.ErrInvalidCredentials", err)
+	}
+	if _, err := a.Login("jane@example.com", "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login with the new password: %v", err)
+	}
+}
+
+func TestChangePasswordLogsUnpublishedEvent(t *testing.T) {
+	a := newTestAuthService(t)
+	a.SetPublisher(services.NewFailingKafkaPublisher())
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	if err := a.ChangePassword(user.ID, testPassword, "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if warns := a.log.Warnings(); len(warns) != 1 || warns[0] != "Failed to publish password change event" {
+		t.Errorf("logged warnings = %v, want the unpublished password change event", warns)
+	}
+	if _, err := a.Login("jane@example.com", "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login with the new password: %v", err)
+	}
+}
+
+func TestAuthenticateRejectsDisabledTenant(t *testing.T) {
+	a := newTestAuthService(t)
+	a.tenants.Put(models.Tenant{ID: "acme", Status: services.TenantStatusActive})
+	a.addUser(t, "acme", "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client("acme"))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	a.tenants.Put(models.Tenant{ID: "acme", Status: services.TenantStatusDisabled})
+
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrTenantDisabled) {
+		t.Errorf("Authenticate error = %v, want services.ErrTenantDisabled", err)
+	}
+}
+
+func TestUpdateAccountFromEventConfirmsOnlyAddressChanges(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	if err := a.UpdateAccountFromEvent(userServiceEvent(user.ID, "jane.roe@example.com", "jane@example.com")); err != nil {
+		t.Fatalf("UpdateAccountFromEvent: %v", err)
+	}
+	// Later updates of the profile carry the unconfirmed address.
+	if err := a.UpdateAccountFromEvent(userServiceEvent(user.ID, "jane.roe@example.com", "")); err != nil {
+		t.Fatalf("UpdateAccountFromEvent of the name: %v", err)
+	}
+
+	sent := a.verifications.Sent()
+	if len(sent) != 1 || sent[0].Email != "jane.roe@example.com" || sent[0].Purpose != services.PurposeEmailChange {
+		t.Errorf("sent = %+v, want one confirmation for the new address", sent)
+	}
+	account, err := a.GetUser(services.DefaultTenantID, user.ID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if account.Email != "jane@example.com" || account.Name != "Jane Roe" {
+		t.Errorf("account = %s <%s>, want the new name and the confirmed address", account.Name, account.Email)
+	}
+
+	// A change of case only needs no confirmation.
+	if err := a.UpdateAccountFromEvent(userServiceEvent(user.ID, "Jane@example.com", "jane@example.com")); err != nil {
+		t.Fatalf("UpdateAccountFromEvent of the case: %v", err)
+	}
+	if got := len(a.verifications.Sent()); got != 1 {
+		t.Errorf("sent %d confirmations, want still 1", got)
+	}
+}
+
+func TestUpdateAccountFromEventRejectsTakenAddress(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.addUser(t, services.DefaultTenantID, "john@example.com", "customer")
+
+	err := a.UpdateAccountFromEvent(userServiceEvent(user.ID, "JOHN@example.com", "jane@example.com"))
+	if !errors.Is(err, services.ErrUserExists) {
+		t.Errorf("UpdateAccountFromEvent error = %v, want services.ErrUserExists", err)
+	}
+	if got := len(a.verifications.Sent()); got != 0 {
+		t.Errorf("sent %d confirmations, want none", got)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "jane@example.com", want: "jane@example.com"},
+		{email: "Jane@Example.COM", want: "jane@example.com"},
+		{email: "  jane@example.com\t", want: "jane@example.com"},
+		{email: " JANE.ROE+tag@Example.com ", want: "jane.roe+tag@example.com"},
+	}
+	for _, tt := range tests {
+		if got := services.NormalizeEmail(tt.email); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const authorizationCodeCollection = "oauth_authorization_codes"
+
+// ErrAuthorizationCodeNotFound is returned for authorization codes that are
+// unknown, expired or already redeemed.
+var ErrAuthorizationCodeNotFound = errors.New("authorization code not found")
+
+// AuthorizationCodeRepository stores authorization codes by the hash of the
+// code. MongoAuthorizationCodeRepository is the production implementation and
+// testutil.MemoryAuthorizationCodeRepository the one for tests.
+type AuthorizationCodeRepository interface {
+	// Insert stores a new authorization code.
+	Insert(ctx context.Context, code *models.AuthorizationCode) error
+	// Consume removes the code with the ID and returns it, unless it expired
+	// by now; ErrAuthorizationCodeNotFound is returned in that case. Only one
+	// of concurrent calls for the same code succeeds.
+	Consume(ctx context.Context, id string, now time.Time) (*models.AuthorizationCode, error)
+}
+
+var _ AuthorizationCodeRepository = (*MongoAuthorizationCodeRepository)(nil)
+
+// MongoAuthorizationCodeRepository stores authorization codes in the
+// oauth_authorization_codes collection. A TTL index drops them once they
+// expire.
+type MongoAuthorizationCodeRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoAuthorizationCodeRepository creates a new MongoAuthorizationCodeRepository
+func NewMongoAuthorizationCodeRepository(mongoConfig *config.MongoDBConfig) *MongoAuthorizationCodeRepository {
+	return &MongoAuthorizationCodeRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the TTL index for authorization codes.
+func (r *MongoAuthorizationCodeRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(authorizationCodeCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Insert stores a new authorization code.
+func (r *MongoAuthorizationCodeRepository) Insert(ctx context.Context, code *models.AuthorizationCode) error {
+	collection := r.mongoConfig.GetCollection(authorizationCodeCollection)
+	_, err := collection.InsertOne(ctx, code)
+	return err
+}
+
+// Consume atomically removes an unexpired code and returns it.
+func (r *MongoAuthorizationCodeRepository) Consume(ctx context.Context, id string, now time.Time) (*models.AuthorizationCode, error) {
+	collection := r.mongoConfig.GetCollection(authorizationCodeCollection)
+	var code models.AuthorizationCode
+	err := collection.FindOneAndDelete(ctx, bson.M{
+		"_id":       id,
+		"expiresAt": bson.M{"$gt": now},
+	}).Decode(&code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAuthorizationCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// PurposeEmailVerification marks one-time tokens sent to confirm an email address.
+	PurposeEmailVerification = "email_verification"
+	// PurposeEmailChange marks one-time tokens sent to confirm a new email
+	// address of an existing account.
+	PurposeEmailChange = "email_change"
+)
+
+// ErrVerificationThrottled is returned when a verification email was sent too recently.
+var ErrVerificationThrottled = errors.New("verification email sent too recently")
+
+// VerificationSender emails the links that confirm an address.
+// EmailVerificationService is the production implementation and
+// testutil.MemoryVerificationSender the one for tests.
+type VerificationSender interface {
+	Send(ctx context.Context, user *models.User) error
+	SendEmailChange(ctx context.Context, user *models.User, email string) error
+}
+
+var _ VerificationSender = (*EmailVerificationService)(nil)
+
+// EmailVerificationService sends verification links and activates accounts
+// once the owner of the address follows one. It also confirms address
+// changes: an account keeps signing in with its old address until the new
+// one is verified.
+type EmailVerificationService struct {
+	users          UserRepository
+	transactions   Transactor
+	tokens         *OneTimeTokenService
+	mailer         Mailer
+	events         EventPublisher
+	baseURL        string
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// NewEmailVerificationService creates a new EmailVerificationService. Links
+// point at baseURL + "/verify-email" and stay valid for ttl; resendInterval
+// is the minimum time between two emails for the same account.
+func NewEmailVerificationService(
+	users UserRepository,
+	transactions Transactor,
+	tokens *OneTimeTokenService,
+	mailer Mailer,
+	events EventPublisher,
+	baseURL string,
+	ttl time.Duration,
+	resendInterval time.Duration,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		users:          users,
+		transactions:   transactions,
+		tokens:         tokens,
+		mailer:         mailer,
+		events:         events,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		ttl:            ttl,
+		resendInterval: resendInterval,
+	}
+}
+
+// Send emails a verification link to the user. The token is bound to the
+// current email address so it cannot confirm an address changed later.
+func (s *EmailVerificationService) Send(ctx context.Context, user *models.User) error {
+	token, err := s.tokens.Issue(ctx, PurposeEmailVerification, user.ID, s.ttl, map[string]string{"email": user.Email})
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.Name, link, s.ttl),
+	})
+}
+
+// SendEmailChange emails a link confirming email as the new address of the
+// user. The token records the address and tenant, and following it makes the
+// address the one the account signs in with.
+func (s *EmailVerificationService) SendEmailChange(ctx context.Context, user *models.User, email string) error {
+	token, err := s.tokens.Issue(ctx, PurposeEmailChange, user.ID, s.ttl, map[string]string{
+		"email":  email,
+		"tenant": user.TenantID,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, Message{
+		To:      email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your new email address by opening the link below:\n\n%s\n\n"+
+			"Until you do, you keep signing in with your previous address. The link expires in %s. If you did not change your email address, you can ignore this email.\n",
+			user.Name, link, s.ttl),
+	})
+}
+
+// Resend emails a new verification link to a pending account of the tenant.
+// Unknown and
+// already verified addresses are ignored so callers cannot probe which
+// accounts exist; ErrVerificationThrottled is returned if the previous email
+// is more recent than the resend interval.
+func (s *EmailVerificationService) Resend(ctx context.Context, tenantID, email string) error {
+	user, err := s.users.FindByEmail(ctx, tenantID, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status != "pending_verification" {
+		return nil
+	}
+
+	lastSent, err := s.tokens.LastIssued(ctx, PurposeEmailVerification, user.ID)
+	if err != nil {
+		return err
+	}
+	if time.Since(lastSent) < s.resendInterval {
+		return ErrVerificationThrottled
+	}
+
+	return s.Send(ctx, user)
+}
+
+// Verify consumes a verification token and moves the account from
+// pending_verification to active, publishing user.updated.v1. Tokens sent by
+// SendEmailChange are accepted too and confirm the new address.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*models.User, error) {
+	record, err := s.tokens.Consume(ctx, PurposeEmailVerification, token)
+	if errors.Is(err, ErrInvalidOneTimeToken) {
+		return s.confirmEmailChange(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.users.Activate(ctx, record.Subject, record.Data["email"])
+		if err != nil {
+			return err
+		}
+		return s.publishUpdated(ctx, user)
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// confirmEmailChange consumes an email change token and makes its address
+// the one the account signs in with, publishing user.updated.v1. Following
+// the link proves the address, so a pending account is activated as well.
+// Returns ErrUserExists if another account of the tenant took the address
+// in the meantime.
+func (s *EmailVerificationService) confirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	record, err := s.tokens.Consume(ctx, PurposeEmailChange, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		tenantID, email := record.Data["tenant"], record.Data["email"]
+		if err := s.users.UpdateProfile(ctx, tenantID, record.Subject, "", email); err != nil {
+			return err
+		}
+		var err error
+		user, err = s.users.FindByID(ctx, tenantID, record.Subject)
+		if err != nil {
+			return err
+		}
+		if user.Status == "pending_verification" {
+			if user, err = s.users.Activate(ctx, user.ID, user.Email); err != nil {
+				return err
+			}
+		}
+		return s.publishUpdated(ctx, user)
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// publishUpdated publishes user.updated.v1 for the account.
+func (s *EmailVerificationService) publishUpdated(ctx context.Context, user *models.User) error {
+	return s.events.PublishUserUpdated(ctx, models.UserEvent{
+		EventID:   primitive.NewObjectID().Hex(),
+		EventType: "user.updated.v1",
+		Timestamp: time.Now().UTC(),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Status:    user.Status,
+		Role:      user.Role,
+	})
+}
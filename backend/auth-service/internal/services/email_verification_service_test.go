@@ -0,0 +1,294 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVerification is an EmailVerificationService over in-memory
+// dependencies.
+type testVerification struct {
+	*services.EmailVerificationService
+	users  *testutil.MemoryUserRepository
+	tokens *testutil.MemoryOneTimeTokenRepository
+	mailer *testutil.MemoryMailer
+	events *testutil.MemoryEventPublisher
+}
+
+func newTestVerification(resendInterval time.Duration) *testVerification {
+	tokens, repository := testutil.NewMemoryOneTimeTokenService()
+	v := &testVerification{
+		users:  testutil.NewMemoryUserRepository(),
+		tokens: repository,
+		mailer: testutil.NewMemoryMailer(),
+		events: testutil.NewMemoryEventPublisher(),
+	}
+	v.EmailVerificationService = services.NewEmailVerificationService(v.users, testutil.MemoryTransactor{}, tokens,
+		v.mailer, v.events, "https://app.example.com/", time.Hour, resendInterval)
+	return v
+}
+
+// addAccount stores an account of the tenant with the status.
+func addAccount(t *testing.T, users *testutil.MemoryUserRepository, tenantID, email, status string) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		ID:              email + "@" + tenantID,
+		TenantID:        tenantID,
+		Name:            "Jane Roe",
+		Email:           email,
+		EmailNormalized: services.NormalizeEmail(email),
+		Status:          status,
+		Role:            "customer",
+	}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return user
+}
+
+// linkToken returns the token of the link in an email.
+func linkToken(t *testing.T, msg services.Message) string {
+	t.Helper()
+
+	_, rest, ok := strings.Cut(msg.Body, "token=")
+	if !ok {
+		t.Fatalf("email %q has no link with a token", msg.Subject)
+	}
+	token, err := url.QueryUnescape(strings.Fields(rest)[0])
+	if err != nil {
+		t.Fatalf("QueryUnescape: %v", err)
+	}
+	return token
+}
+
+// lastLink returns the token of the link in the last email sent to the
+// address.
+func lastLink(t *testing.T, mailer *testutil.MemoryMailer, to string) string {
+	t.Helper()
+
+	messages := mailer.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].To == to {
+			return linkToken(t, messages[i])
+		}
+	}
+	t.Fatalf("no email sent to %s", to)
+	return ""
+}
+
+func TestEmailVerificationActivatesAccountOnce(t *testing.T) {
+	v := newTestVerification(time.Minute)
+	ctx := context.Background()
+	user := addAccount(t, v.users, services.DefaultTenantID, "jane@example.com", "pending_verification")
+
+	if err := v.Send(ctx, user); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	messages := v.mailer.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Body, "https://app.example.com/verify-email?token=") {
+		t.Fatalf("emails = %+v, want one link to /verify-email", messages)
+	}
+	token := linkToken(t, messages[0])
+
+	verified, err := v.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if verified.ID != user.ID || verified.Status != "active" {
+		t.Errorf("verified user = %+v, want %s active", verified, user.ID)
+	}
+	if events := v.events.Events(); len(events) != 1 || events[0].EventType != "user.updated.v1" || events[0].Status != "active" {
+		t.Errorf("published events = %+v, want one active user.updated.v1", events)
+	}
+
+	if _, err := v.Verify(ctx, token); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Verify of a used token error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+}
+
+func TestEmailVerificationRejects(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T, v *testVerification, user *models.User) string
+	}{
+		{
+			name: "expired token",
+			token: func(t *testing.T, v *testVerification, user *models.User) string {
+				if err := v.Send(ctx, user); err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+				v.tokens.Expire()
+				return lastLink(t, v.mailer, user.Email)
+			},
+		},
+		{
+			name: "token superseded by a newer email",
+			token: func(t *testing.T, v *testVerification, user *models.User) string {
+				if err := v.Send(ctx, user); err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+				token := lastLink(t, v.mailer, user.Email)
+				if err := v.Send(ctx, user); err != nil {
+					t.Fatalf("second Send: %v", err)
+				}
+				return token
+			},
+		},
+		{
+			name: "forged signature",
+			token: func(t *testing.T, v *testVerification, user *models.User) string {
+				if err := v.Send(ctx, user); err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+				value, _, _ := strings.Cut(lastLink(t, v.mailer, user.Email), ".")
+				return value + ".forged"
+			},
+		},
+		{
+			name: "token sent to a previous address",
+			token: func(t *testing.T, v *testVerification, user *models.User) string {
+				if err := v.Send(ctx, user); err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+				if err := v.users.UpdateProfile(ctx, user.TenantID, user.ID, "", "other@example.com"); err != nil {
+					t.Fatalf("UpdateProfile: %v", err)
+				}
+				return lastLink(t, v.mailer, user.Email)
+			},
+		},
+		{
+			name: "malformed token",
+			token: func(t *testing.T, v *testVerification, user *models.User) string {
+				return "not-a-token"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerification(time.Minute)
+			user := addAccount(t, v.users, services.DefaultTenantID, "jane@example.com", "pending_verification")
+
+			if _, err := v.Verify(ctx, tt.token(t, v, user)); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+				t.Errorf("Verify error = %v, want services.ErrInvalidOneTimeToken", err)
+			}
+			stored, err := v.users.FindByID(ctx, user.TenantID, user.ID)
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if stored.Status != "pending_verification" {
+				t.Errorf("status = %q, want still pending_verification", stored.Status)
+			}
+		})
+	}
+}
+
+func TestEmailVerificationRejectsTokensOfOtherPurposes(t *testing.T) {
+	tokens, _ := testutil.NewMemoryOneTimeTokenService()
+	ctx := context.Background()
+
+	token, err := tokens.Issue(ctx, services.PurposePasswordReset, "jane", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := tokens.Consume(ctx, services.PurposeEmailVerification, token); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Consume for another purpose error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+	if _, err := tokens.Peek(ctx, services.PurposePasswordReset, token); err != nil {
+		t.Errorf("Peek for the purpose it was issued for: %v", err)
+	}
+	if err := tokens.Revoke(ctx, services.PurposePasswordReset, "jane"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := tokens.Consume(ctx, services.PurposePasswordReset, token); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Consume of a revoked token error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+}
+
+func TestEmailChangeConfirmsAddressInItsTenant(t *testing.T) {
+	v := newTestVerification(time.Minute)
+	ctx := context.Background()
+	user := addAccount(t, v.users, "acme", "jane@example.com", "active")
+	// The same address in another tenant does not stand in the way.
+	addAccount(t, v.users, services.DefaultTenantID, "new@example.com", "active")
+
+	if err := v.SendEmailChange(ctx, user, "new@example.com"); err != nil {
+		t.Fatalf("SendEmailChange: %v", err)
+	}
+	token := lastLink(t, v.mailer, "new@example.com")
+
+	changed, err := v.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if changed.Email != "new@example.com" || changed.TenantID != "acme" {
+		t.Errorf("user = %+v, want new@example.com in acme", changed)
+	}
+	if events := v.events.Events(); len(events) != 1 || events[0].Email != "new@example.com" || events[0].TenantID != "acme" {
+		t.Errorf("published events = %+v, want the new address in acme", events)
+	}
+	if _, err := v.Verify(ctx, token); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Verify of a used token error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+}
+
+func TestEmailChangeRejectsTakenAddress(t *testing.T) {
+	v := newTestVerification(time.Minute)
+	ctx := context.Background()
+	user := addAccount(t, v.users, services.DefaultTenantID, "jane@example.com", "active")
+
+	if err := v.SendEmailChange(ctx, user, "new@example.com"); err != nil {
+		t.Fatalf("SendEmailChange: %v", err)
+	}
+	addAccount(t, v.users, services.DefaultTenantID, "New@Example.com", "active")
+
+	if _, err := v.Verify(ctx, lastLink(t, v.mailer, "new@example.com")); !errors.Is(err, services.ErrUserExists) {
+		t.Errorf("Verify error = %v, want services.ErrUserExists", err)
+	}
+	if events := v.events.Events(); len(events) != 0 {
+		t.Errorf("published events = %+v, want none", events)
+	}
+}
+
+func TestEmailVerificationResend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ignores unknown and verified addresses", func(t *testing.T) {
+		v := newTestVerification(0)
+		addAccount(t, v.users, services.DefaultTenantID, "active@example.com", "active")
+		addAccount(t, v.users, "acme", "jane@example.com", "pending_verification")
+
+		for _, email := range []string{"unknown@example.com", "active@example.com", "jane@example.com"} {
+			if err := v.Resend(ctx, services.DefaultTenantID, email); err != nil {
+				t.Errorf("Resend(%s): %v", email, err)
+			}
+		}
+		if messages := v.mailer.Messages(); len(messages) != 0 {
+			t.Errorf("emails = %+v, want none", messages)
+		}
+	})
+
+	t.Run("throttles", func(t *testing.T) {
+		v := newTestVerification(time.Hour)
+		user := addAccount(t, v.users, services.DefaultTenantID, "jane@example.com", "pending_verification")
+
+		if err := v.Resend(ctx, services.DefaultTenantID, user.Email); err != nil {
+			t.Fatalf("Resend: %v", err)
+		}
+		if err := v.Resend(ctx, services.DefaultTenantID, user.Email); !errors.Is(err, services.ErrVerificationThrottled) {
+			t.Errorf("second Resend error = %v, want services.ErrVerificationThrottled", err)
+		}
+		if messages := v.mailer.Messages(); len(messages) != 1 {
+			t.Errorf("sent %d emails, want 1", len(messages))
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+)
+
+// EventPublisher publishes user and invitation lifecycle events.
+// OutboxService publishes
+// them in the transaction of the change they describe, KafkaPublisher right
+// away, and testutil.MemoryEventPublisher records them for tests.
+type EventPublisher interface {
+	PublishUserCreated(ctx context.Context, event models.UserEvent) error
+	PublishUserUpdated(ctx context.Context, event models.UserEvent) error
+	PublishUserDeleted(ctx context.Context, event models.UserEvent) error
+	PublishInvitation(ctx context.Context, event models.InvitationEvent) error
+}
+
+var (
+	_ EventPublisher = (*OutboxService)(nil)
+	_ EventPublisher = (*KafkaPublisher)(nil)
+)
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+)
+
+// Internals used by the tests in package services_test.
+
+var (
+	ErrMalformedHash      = errMalformedHash
+	EmailAttemptKey       = emailAttemptKey
+	MatchTOTP             = matchTOTP
+	NewRecoveryCodes      = newRecoveryCodes
+	NormalizeRecoveryCode = normalizeRecoveryCode
+	TOTPCode              = totpCode
+)
+
+const (
+	Argon2idPrefix     = argon2idPrefix
+	MaxLockoutDuration = maxLockoutDuration
+)
+
+func (h *BcryptHasher) Cost() int {
+	return h.cost
+}
+
+func (s *AuthService) Passwords() *PasswordService {
+	return s.passwords
+}
+
+func (s *AuthService) RehashPassword(ctx context.Context, user *models.User, password string) error {
+	return s.rehashPassword(ctx, user, password)
+}
+
+func (s *AuthService) SetPublisher(publisher *KafkaPublisher) {
+	s.publisher = publisher
+}
+
+func (s *LoginThrottleService) Backoff(failures, threshold int) time.Duration {
+	return s.backoff(failures, threshold)
+}
+
+func (s *LoginThrottleService) LockoutDuration(lockouts int) time.Duration {
+	return s.lockoutDuration(lockouts)
+}
+
+func (s *RevocationService) Publish(ctx context.Context, event models.TokenRevokedEvent) {
+	s.publish(ctx, event)
+}
+
+// MessageReader is the part of *kafka.Reader UserEventConsumer uses.
+type MessageReader = messageReader
+
+// NewTestUserEventConsumer returns a consumer reading the user.updated.v1 and
+// user.deleted.v1 topics from readers, which retries failed events after a
+// millisecond.
+func NewTestUserEventConsumer(service *AuthService, log logger.Logger, readers ...MessageReader) *UserEventConsumer {
+	return &UserEventConsumer{
+		logger:           log,
+		service:          service,
+		readers:          readers,
+		topicUserUpdated: "user.updated.v1",
+		topicUserDeleted: "user.deleted.v1",
+		retryBackoff:     time.Millisecond,
+	}
+}
+
+// NewTestOutboxService returns an OutboxService relaying through writer.
+func NewTestOutboxService(repository OutboxRepository, writer MessageWriter, topics KafkaTopics, outboxConfig *config.OutboxConfig) *OutboxService {
+	s := NewOutboxService(repository, nil, topics, outboxConfig, logger.NewNopLogger())
+	s.writer = writer
+	return s
+}
+
+func (s *OutboxService) Relay(ctx context.Context) error {
+	return s.relay(ctx)
+}
+
+func (s *OutboxService) RetryDelay(attempts int) time.Duration {
+	return s.retryDelay(attempts)
+}
+
+// ErrBrokerUnavailable is returned by every request of failingTransport.
+var ErrBrokerUnavailable = errors.New("broker unavailable")
+
+// failingTransport is a kafka.RoundTripper whose brokers are all down.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(ctx context.Context, addr net.Addr, req protocol.Message) (protocol.Message, error) {
+	return nil, ErrBrokerUnavailable
+}
+
+// NewFailingKafkaPublisher returns a publisher with every topic configured
+// whose writes fail without retrying.
+func NewFailingKafkaPublisher() *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:        kafka.TCP("kafka.invalid:9092"),
+			Transport:   failingTransport{},
+			MaxAttempts: 1,
+		},
+		topics: KafkaTopics{
+			UserCreated:     "user.created.v1",
+			UserUpdated:     "user.updated.v1",
+			UserDeleted:     "user.deleted.v1",
+			TokenRevoked:    "token.revoked.v1",
+			PasswordChanged: "security.password_changed.v1",
+			LoginFailed:     "security.login_failed.v1",
+			AccountLocked:   "security.account_locked.v1",
+			Audit:           "audit.auth.v1",
+			Invitation:      "invitation.v1",
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"testing"
+	"time"
+)
+
+// adminClaims signs the admin in and returns the claims of their token.
+func (a *testAuth) adminClaims(t *testing.T, tenantID, email string) *services.Claims {
+	t.Helper()
+
+	a.addUser(t, tenantID, email, "admin")
+	login, err := a.Login(email, testPassword, client(tenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return a.claims(t, login.Token)
+}
+
+func TestImpersonateCarriesActor(t *testing.T) {
+	a := newTestAuthService(t)
+	admin := a.adminClaims(t, services.DefaultTenantID, "admin@example.com")
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	response, err := a.Impersonate(user.ID, admin, "ticket 42", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	if response.User.ID != user.ID || response.TokenType != "Bearer" || response.ExpiresIn <= 0 {
+		t.Errorf("response = %+v, want a bearer token for %s", response, user.ID)
+	}
+	claims := a.claims(t, response.Token)
+	if claims.UserID != user.ID || !claims.IsImpersonated() || claims.ActorID() != admin.UserID || claims.Role != "customer" || claims.SessionID != "" {
+		t.Errorf("claims = %+v, want %s held by %s without a session", claims, user.ID, admin.UserID)
+	}
+	validation, err := a.ValidateToken(response.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !validation.Valid || validation.UserID != user.ID || validation.ActorID != admin.UserID {
+		t.Errorf("validation = %+v, want %s with actor %s", validation, user.ID, admin.UserID)
+	}
+	if got := a.lastAudit(t); got.EventType != services.AuditImpersonation || got.UserID != user.ID || got.ActorID != admin.UserID || got.Reason != "ticket 42" {
+		t.Errorf("audit event = %+v, want the impersonation of %s by %s", got, user.ID, admin.UserID)
+	}
+}
+
+func TestImpersonateRejects(t *testing.T) {
+	a := newTestAuthService(t)
+	admin := a.adminClaims(t, services.DefaultTenantID, "admin@example.com")
+	other := a.addUser(t, services.DefaultTenantID, "root@example.com", "admin")
+	foreign := a.addUser(t, "closed", "jane@example.com", "customer")
+	events := len(a.audit.Events())
+
+	tests := []struct {
+		name   string
+		userID string
+		want   error
+	}{
+		{name: "self", userID: admin.UserID, want: services.ErrImpersonationNotAllowed},
+		{name: "another admin", userID: other.ID, want: services.ErrImpersonationNotAllowed},
+		{name: "user of another tenant", userID: foreign.ID, want: services.ErrUserNotFound},
+		{name: "unknown user", userID: "unknown", want: services.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Impersonate(tt.userID, admin, "ticket 42", client(services.DefaultTenantID)); !errors.Is(err, tt.want) {
+				t.Errorf("Impersonate error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if got := len(a.audit.Events()); got != events {
+		t.Errorf("recorded %d audit events, want none", got-events)
+	}
+}
+
+func TestImpersonationTokenIsRevokedWithTheUser(t *testing.T) {
+	a := newTestAuthService(t)
+	admin := a.adminClaims(t, services.DefaultTenantID, "admin@example.com")
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	response, err := a.Impersonate(user.ID, admin, "ticket 42", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+
+	if err := a.RevokeAllTokens(user.ID, "compromised", admin.UserID, client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("RevokeAllTokens: %v", err)
+	}
+	if _, err := a.Authenticate(response.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate of the impersonation token error = %v, want services.ErrTokenRevoked", err)
+	}
+
+	// A new impersonation after the revocation works again.
+	time.Sleep(2 * time.Millisecond)
+	again, err := a.Impersonate(user.ID, admin, "ticket 43", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	if _, err := a.Authenticate(again.Token); err != nil {
+		t.Errorf("Authenticate of a token issued after the revocation: %v", err)
+	}
+}
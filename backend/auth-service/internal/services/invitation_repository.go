@@ -0,0 +1,239 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const invitationCollection = "invitations"
+
+// InvitationRepository stores invitations. An invitation is pending while its
+// status is InvitationSent; at most one per address and tenant is.
+// MongoInvitationRepository is the production implementation and
+// testutil.MemoryInvitationRepository the one for tests.
+type InvitationRepository interface {
+	// Insert stores a new invitation, or fails with ErrInvitationPending if
+	// the address already has a pending invitation in the tenant.
+	Insert(ctx context.Context, invitation *models.Invitation) error
+	// FindByID returns the invitation of the tenant with the ID, or
+	// ErrInvitationNotFound.
+	FindByID(ctx context.Context, tenantID, id string) (*models.Invitation, error)
+	// FindPending returns the pending invitation of the tenant with the code
+	// hash, sent to the normalized address and not expired at now, or
+	// ErrInvalidInvitation.
+	FindPending(ctx context.Context, tenantID, codeHash, emailNormalized string, now time.Time) (*models.Invitation, error)
+	// Find returns at most limit invitations matching the query at now,
+	// newest first. Pending invitations past their expiry match the expired
+	// status, not the sent one.
+	Find(ctx context.Context, query models.InvitationQuery, limit int, now time.Time) ([]models.Invitation, error)
+	// FindDue returns at most limit pending invitations past their expiry at
+	// now, soonest expiry first. A non-empty tenant ID and normalized address
+	// narrow them down.
+	FindDue(ctx context.Context, tenantID, emailNormalized string, now time.Time, limit int) ([]models.Invitation, error)
+	// Revoke marks the invitation of the tenant revoked by actorID if it is
+	// pending and not expired at now, and returns it. Fails with
+	// ErrInvitationNotPending otherwise.
+	Revoke(ctx context.Context, tenantID, id, actorID string, now time.Time) (*models.Invitation, error)
+	// Accept marks the invitation accepted by the account userID if it is
+	// pending and not expired at now. Fails with ErrInvalidInvitation
+	// otherwise.
+	Accept(ctx context.Context, id, userID string, now time.Time) error
+	// MarkExpired marks the invitation expired if it is still pending, and
+	// reports whether it was.
+	MarkExpired(ctx context.Context, id string, now time.Time) (bool, error)
+}
+
+var _ InvitationRepository = (*MongoInvitationRepository)(nil)
+
+// MongoInvitationRepository stores invitations in the invitations collection.
+type MongoInvitationRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoInvitationRepository creates a new MongoInvitationRepository
+func NewMongoInvitationRepository(mongoConfig *config.MongoDBConfig) *MongoInvitationRepository {
+	return &MongoInvitationRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the lookup indexes for invitations. At most one
+// invitation per address and tenant is pending.
+func (r *MongoInvitationRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "codeHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "emailNormalized", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": InvitationSent}),
+		},
+	})
+	return err
+}
+
+// Insert stores a new invitation.
+func (r *MongoInvitationRepository) Insert(ctx context.Context, invitation *models.Invitation) error {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	_, err := collection.InsertOne(ctx, invitation)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrInvitationPending
+	}
+	return err
+}
+
+// FindByID returns the invitation of the tenant with the ID.
+func (r *MongoInvitationRepository) FindByID(ctx context.Context, tenantID, id string) (*models.Invitation, error) {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	var invitation models.Invitation
+	err := collection.FindOne(ctx, bson.M{"_id": id, "tenantId": tenantID}).Decode(&invitation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPending returns the pending invitation with the code hash sent to the
+// address.
+func (r *MongoInvitationRepository) FindPending(ctx context.Context, tenantID, codeHash, emailNormalized string, now time.Time) (*models.Invitation, error) {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	var invitation models.Invitation
+	err := collection.FindOne(ctx, bson.M{
+		"codeHash":        codeHash,
+		"tenantId":        tenantID,
+		"emailNormalized": emailNormalized,
+		"status":          InvitationSent,
+		"expiresAt":       bson.M{"$gt": now},
+	}).Decode(&invitation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// Find returns the invitations matching the query, newest first.
+func (r *MongoInvitationRepository) Find(ctx context.Context, query models.InvitationQuery, limit int, now time.Time) ([]models.Invitation, error) {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	cursor, err := collection.Find(ctx, invitationFilter(query, now),
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// invitationFilter builds the filter of an invitation query. Pending
+// invitations past their expiry count as expired even before they are marked.
+func invitationFilter(query models.InvitationQuery, now time.Time) bson.M {
+	filter := bson.M{"tenantId": query.TenantID}
+	if query.Email != "" {
+		filter["emailNormalized"] = NormalizeEmail(query.Email)
+	}
+
+	switch query.Status {
+	case "":
+	case InvitationSent:
+		filter["status"] = InvitationSent
+		filter["expiresAt"] = bson.M{"$gt": now}
+	case InvitationExpired:
+		filter["$or"] = bson.A{
+			bson.M{"status": InvitationExpired},
+			bson.M{"status": InvitationSent, "expiresAt": bson.M{"$lte": now}},
+		}
+	default:
+		filter["status"] = query.Status
+	}
+	return filter
+}
+
+// FindDue returns pending invitations past their expiry.
+func (r *MongoInvitationRepository) FindDue(ctx context.Context, tenantID, emailNormalized string, now time.Time, limit int) ([]models.Invitation, error) {
+	due := bson.M{"status": InvitationSent, "expiresAt": bson.M{"$lte": now}}
+	if tenantID != "" {
+		due["tenantId"] = tenantID
+	}
+	if emailNormalized != "" {
+		due["emailNormalized"] = emailNormalized
+	}
+
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	cursor, err := collection.Find(ctx, due,
+		options.Find().SetSort(bson.D{{Key: "expiresAt", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var invitations []models.Invitation
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Revoke marks a pending invitation revoked.
+func (r *MongoInvitationRepository) Revoke(ctx context.Context, tenantID, id, actorID string, now time.Time) (*models.Invitation, error) {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	var invitation models.Invitation
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "tenantId": tenantID, "status": InvitationSent, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": InvitationRevoked, "revokedAt": now, "revokedBy": actorID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invitation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvitationNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// Accept marks a pending invitation accepted.
+func (r *MongoInvitationRepository) Accept(ctx context.Context, id, userID string, now time.Time) error {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": InvitationSent, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": InvitationAccepted, "acceptedAt": now, "userId": userID}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
+
+// MarkExpired marks a pending invitation expired.
+func (r *MongoInvitationRepository) MarkExpired(ctx context.Context, id string, now time.Time) (bool, error) {
+	collection := r.mongoConfig.GetCollection(invitationCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": InvitationSent},
+		bson.M{"$set": bson.M{"status": InvitationExpired, "expiredAt": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
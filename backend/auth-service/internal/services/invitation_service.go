@@ -0,0 +1,348 @@
+package services
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultInvitationLimit is the page size of invitation queries without a limit.
+	defaultInvitationLimit = 100
+	// invitationExpiryInterval is how often invitations past their expiry
+	// are marked expired.
+	invitationExpiryInterval = time.Minute
+	// invitationExpiryBatchSize is the maximum number of invitations marked
+	// expired per run.
+	invitationExpiryBatchSize = 100
+)
+
+// Invitation statuses.
+const (
+	InvitationSent     = "sent"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+var (
+	// ErrInvitationNotFound is returned when the tenant has no invitation with the ID.
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationPending is returned when inviting an address that already
+	// has an invitation waiting to be accepted.
+	ErrInvitationPending = errors.New("email address already has a pending invitation")
+	// ErrInvitationNotPending is returned when revoking an invitation that was
+	// already accepted, revoked or has expired.
+	ErrInvitationNotPending = errors.New("invitation is no longer pending")
+	// ErrInvalidInvitation is returned for unknown, expired, revoked or used
+	// invitation codes, and for codes sent to another address.
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	// ErrInvitationRequired is returned when registering without an
+	// invitation while registration is invitation-only.
+	ErrInvitationRequired = errors.New("registration requires an invitation")
+)
+
+// InvitationRedeemer finds and accepts the invitations presented at
+// registration. InvitationService is the production implementation and
+// testutil.MemoryInvitationRedeemer the one for tests.
+type InvitationRedeemer interface {
+	Required() bool
+	Find(ctx context.Context, tenantID, code, email string) (*models.Invitation, error)
+	Accept(ctx context.Context, invitation *models.Invitation, userID string) error
+}
+
+var _ InvitationRedeemer = (*InvitationService)(nil)
+
+// InvitationService lets admins invite email addresses to register in their
+// tenant with a given role. The invitation code is emailed to the address and
+// only its hash is stored. Every status change, sent, accepted, expired or
+// revoked, is published as an invitation lifecycle event in the same
+// transaction.
+type InvitationService struct {
+	repository InvitationRepository
+	transactor Transactor
+	users      UserRepository
+	mailer     Mailer
+	events     EventPublisher
+	baseURL    string
+	ttl        time.Duration
+	required   bool
+	logger     logger.Logger
+}
+
+// NewInvitationService creates a new InvitationService. Invitation links
+// point at baseURL + "/register" and stay valid for ttl unless the admin
+// chooses otherwise. With required set, registration is invitation-only in
+// every tenant.
+func NewInvitationService(
+	repository InvitationRepository,
+	transactor Transactor,
+	users UserRepository,
+	mailer Mailer,
+	events EventPublisher,
+	baseURL string,
+	ttl time.Duration,
+	required bool,
+	log logger.Logger,
+) *InvitationService {
+	return &InvitationService{
+		repository: repository,
+		transactor: transactor,
+		users:      users,
+		mailer:     mailer,
+		events:     events,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		ttl:        ttl,
+		required:   required,
+		logger:     log,
+	}
+}
+
+// Required reports whether registration is invitation-only.
+func (s *InvitationService) Required() bool {
+	return s.required
+}
+
+// Create invites an email address to register in the tenant with the role
+// and emails it the invitation link once the invitation is stored. Returns
+// ErrUnknownRole for unknown roles, ErrUserExists if the address is already
+// registered in the tenant and ErrInvitationPending if it already has a
+// pending invitation.
+func (s *InvitationService) Create(ctx context.Context, tenantID string, req models.CreateInvitationRequest, actorID string) (*models.Invitation, error) {
+	if !IsKnownRole(req.Role) {
+		return nil, ErrUnknownRole
+	}
+
+	_, err := s.users.FindByEmail(ctx, tenantID, req.Email)
+	if err == nil {
+		return nil, ErrUserExists
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	// A pending invitation that has run out no longer blocks a new one.
+	if err := s.expire(ctx, tenantID, NormalizeEmail(req.Email)); err != nil {
+		return nil, err
+	}
+
+	code, err := NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.ttl
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	now := time.Now().UTC()
+	invitation := models.Invitation{
+		ID:              primitive.NewObjectID().Hex(),
+		TenantID:        tenantID,
+		Email:           strings.TrimSpace(req.Email),
+		EmailNormalized: NormalizeEmail(req.Email),
+		Role:            req.Role,
+		CodeHash:        HashOpaqueToken(code),
+		Status:          InvitationSent,
+		InvitedBy:       actorID,
+		CreatedAt:       now,
+		ExpiresAt:       now.Add(ttl),
+	}
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.Insert(ctx, &invitation); err != nil {
+			return err
+		}
+		return s.events.PublishInvitation(ctx, invitationEvent(&invitation, InvitationSent, actorID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The email goes out only once the invitation is stored, so no one is
+	// sent a code that does not exist. An invitation whose email could not
+	// be sent is revoked again, so the admin can retry.
+	if err := s.send(ctx, &invitation, code); err != nil {
+		if _, revokeErr := s.Revoke(ctx, tenantID, invitation.ID, actorID); revokeErr != nil {
+			s.logger.Error("Failed to revoke unsent invitation",
+				zap.String("invitation_id", invitation.ID),
+				zap.Error(revokeErr),
+			)
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// send emails the invitation link to the invited address.
+func (s *InvitationService) send(ctx context.Context, invitation *models.Invitation, code string) error {
+	link := s.baseURL + "/register?invitation=" + url.QueryEscape(code)
+	return s.mailer.Send(ctx, Message{
+		To:      invitation.Email,
+		Subject: "You have been invited to create an account",
+		Body: fmt.Sprintf("Hi,\n\nYou have been invited to create an account. Register with this email address by opening the link below:\n\n%s\n\n"+
+			"The invitation expires on %s. If you did not expect an invitation, you can ignore this email.\n",
+			link, invitation.ExpiresAt.Format(time.RFC1123)),
+	})
+}
+
+// Get returns the invitation of the tenant with the given ID.
+func (s *InvitationService) Get(ctx context.Context, tenantID, id string) (*models.Invitation, error) {
+	invitation, err := s.repository.FindByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	presentInvitation(invitation, time.Now())
+	return invitation, nil
+}
+
+// List returns the most recent invitations matching the query, newest first.
+func (s *InvitationService) List(ctx context.Context, query models.InvitationQuery) ([]models.Invitation, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultInvitationLimit
+	}
+
+	now := time.Now()
+	invitations, err := s.repository.Find(ctx, query, limit, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	for i := range invitations {
+		presentInvitation(&invitations[i], now)
+	}
+	return invitations, nil
+}
+
+// presentInvitation reports a pending invitation past its expiry as expired.
+func presentInvitation(invitation *models.Invitation, now time.Time) {
+	if invitation.Status == InvitationSent && !invitation.ExpiresAt.After(now) {
+		invitation.Status = InvitationExpired
+	}
+}
+
+// Revoke withdraws a pending invitation of the tenant on behalf of actorID.
+// Returns ErrInvitationNotFound for unknown invitations and
+// ErrInvitationNotPending for invitations that are no longer pending.
+func (s *InvitationService) Revoke(ctx context.Context, tenantID, id, actorID string) (*models.Invitation, error) {
+	var invitation *models.Invitation
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		invitation, err = s.repository.Revoke(ctx, tenantID, id, actorID, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		return s.events.PublishInvitation(ctx, invitationEvent(invitation, InvitationRevoked, actorID))
+	})
+	if errors.Is(err, ErrInvitationNotPending) {
+		if _, err := s.Get(ctx, tenantID, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvitationNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Find returns the pending invitation of the tenant with the code, which must
+// have been sent to email. Returns ErrInvalidInvitation otherwise.
+func (s *InvitationService) Find(ctx context.Context, tenantID, code, email string) (*models.Invitation, error) {
+	return s.repository.FindPending(ctx, tenantID, HashOpaqueToken(code), NormalizeEmail(email), time.Now().UTC())
+}
+
+// Accept marks a pending invitation accepted by the account userID. ctx must
+// carry the transaction that creates the account. Returns
+// ErrInvalidInvitation if the invitation is no longer pending, so an
+// invitation is accepted at most once.
+func (s *InvitationService) Accept(ctx context.Context, invitation *models.Invitation, userID string) error {
+	now := time.Now().UTC()
+	if err := s.repository.Accept(ctx, invitation.ID, userID, now); err != nil {
+		return err
+	}
+
+	accepted := *invitation
+	accepted.Status = InvitationAccepted
+	accepted.UserID = userID
+	accepted.AcceptedAt = &now
+	return s.events.PublishInvitation(ctx, invitationEvent(&accepted, InvitationAccepted, ""))
+}
+
+// Start marks pending invitations past their expiry as expired every minute
+// until ctx is cancelled, publishing invitation.expired.v1 for each.
+func (s *InvitationService) Start(ctx context.Context) {
+	ticker := time.NewTicker(invitationExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if err := s.expire(runCtx, "", ""); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to expire invitations", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// expire marks the pending invitations past their expiry as expired, those
+// of the tenant and normalized address if given. Each invitation is marked in
+// its own transaction with its event; when replicas race, only the one that
+// marks it publishes.
+func (s *InvitationService) expire(ctx context.Context, tenantID, emailNormalized string) error {
+	now := time.Now().UTC()
+	invitations, err := s.repository.FindDue(ctx, tenantID, emailNormalized, now, invitationExpiryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range invitations {
+		invitation := &invitations[i]
+		err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			marked, err := s.repository.MarkExpired(ctx, invitation.ID, now)
+			if err != nil || !marked {
+				return err
+			}
+
+			expired := *invitation
+			expired.Status = InvitationExpired
+			expired.ExpiredAt = &now
+			return s.events.PublishInvitation(ctx, invitationEvent(&expired, InvitationExpired, ""))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invitationEvent builds the lifecycle event of an invitation reaching status.
+func invitationEvent(invitation *models.Invitation, status, actorID string) models.InvitationEvent {
+	return models.InvitationEvent{
+		EventID:      primitive.NewObjectID().Hex(),
+		EventType:    "invitation." + status + ".v1",
+		Timestamp:    time.Now().UTC(),
+		InvitationID: invitation.ID,
+		TenantID:     invitation.TenantID,
+		Email:        invitation.Email,
+		Role:         invitation.Role,
+		Status:       status,
+		ExpiresAt:    invitation.ExpiresAt,
+		UserID:       invitation.UserID,
+		ActorID:      actorID,
+	}
+}
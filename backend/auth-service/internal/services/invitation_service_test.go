@@ -0,0 +1,338 @@
+package services_test
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testInvitations is an InvitationService redeemed by the AuthService of a
+// testAuth.
+type testInvitations struct {
+	*services.InvitationService
+	*testAuth
+	repository *testutil.MemoryInvitationRepository
+	mailer     *testutil.MemoryMailer
+}
+
+func newTestInvitations(t *testing.T, required bool) *testInvitations {
+	t.Helper()
+
+	i := &testInvitations{
+		repository: testutil.NewMemoryInvitationRepository(),
+		mailer:     testutil.NewMemoryMailer(),
+	}
+	i.testAuth = newTestAuthServiceWith(t, func(a *testAuth) services.InvitationRedeemer {
+		i.InvitationService = services.NewInvitationService(i.repository, testutil.MemoryTransactor{}, a.users, i.mailer, a.events,
+			"https://app.example.com", 24*time.Hour, required, logger.NewNopLogger())
+		return i.InvitationService
+	})
+	return i
+}
+
+// invite invites the address to the tenant with the role on behalf of
+// admin-1 and returns the invitation and the code emailed for it.
+func (i *testInvitations) invite(t *testing.T, tenantID, email, role string) (*models.Invitation, string) {
+	t.Helper()
+
+	invitation, err := i.InvitationService.Create(context.Background(), tenantID, models.CreateInvitationRequest{Email: email, Role: role}, "admin-1")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	messages := i.mailer.Messages()
+	if len(messages) == 0 || messages[len(messages)-1].To != email {
+		t.Fatalf("emails = %+v, want the last one to %s", messages, email)
+	}
+	_, rest, ok := strings.Cut(messages[len(messages)-1].Body, "https://app.example.com/register?invitation=")
+	if !ok {
+		t.Fatalf("email body %q has no invitation link", messages[len(messages)-1].Body)
+	}
+	code, err := url.QueryUnescape(strings.Fields(rest)[0])
+	if err != nil {
+		t.Fatalf("QueryUnescape: %v", err)
+	}
+	return invitation, code
+}
+
+// register registers the address in the tenant with the invitation code.
+func (i *testInvitations) register(tenantID, email, code string) (*models.RegisterResponse, error) {
+	req := registerRequest(email, testPassword)
+	req.InvitationCode = code
+	return i.Register(req, client(tenantID))
+}
+
+// invitationEventTypes returns the types of the published invitation events.
+func (i *testInvitations) invitationEventTypes() []string {
+	var types []string
+	for _, event := range i.events.InvitationEvents() {
+		types = append(types, event.EventType)
+	}
+	return types
+}
+
+func TestInvitationRegistersWithTheRole(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	invitation, code := i.invite(t, "closed", "Jane@Example.com", "admin")
+	if invitation.Status != services.InvitationSent || invitation.CodeHash == code || strings.Contains(invitation.CodeHash, code) {
+		t.Errorf("invitation = %+v, want a pending invitation storing only the code hash", invitation)
+	}
+
+	// Signup is closed in the tenant, but the invitation lets the address in.
+	response, err := i.register("closed", "jane@example.com", code)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if response.Status != "success" || response.Token == "" {
+		t.Errorf("Register response = %+v, want tokens without email verification", response)
+	}
+	claims := i.claims(t, response.Token)
+	if claims.Tenant() != "closed" || claims.Role != "admin" {
+		t.Errorf("claims = %+v, want an admin of the closed tenant", claims)
+	}
+
+	accepted, err := i.InvitationService.Get(ctx, "closed", invitation.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if accepted.Status != services.InvitationAccepted || accepted.UserID != claims.UserID || accepted.AcceptedAt == nil {
+		t.Errorf("invitation = %+v, want accepted by %s", accepted, claims.UserID)
+	}
+	if got, want := i.invitationEventTypes(), []string{"invitation.sent.v1", "invitation.accepted.v1"}; !equalIDs(got, want...) {
+		t.Errorf("invitation events = %v, want %v", got, want)
+	}
+
+	// The code is spent.
+	if _, err := i.InvitationService.Find(ctx, "closed", code, "jane@example.com"); !errors.Is(err, services.ErrInvalidInvitation) {
+		t.Errorf("Find of an accepted invitation error = %v, want services.ErrInvalidInvitation", err)
+	}
+}
+
+func TestInvitationRegisterRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant string
+		email  string
+		setup  func(t *testing.T, i *testInvitations, invitation *models.Invitation)
+	}{
+		{
+			name:   "expired",
+			tenant: "closed",
+			email:  "jane@example.com",
+			setup: func(t *testing.T, i *testInvitations, invitation *models.Invitation) {
+				i.repository.Expire()
+			},
+		},
+		{
+			name:   "revoked",
+			tenant: "closed",
+			email:  "jane@example.com",
+			setup: func(t *testing.T, i *testInvitations, invitation *models.Invitation) {
+				if _, err := i.Revoke(context.Background(), "closed", invitation.ID, "admin-1"); err != nil {
+					t.Fatalf("Revoke: %v", err)
+				}
+			},
+		},
+		{name: "in another tenant", tenant: services.DefaultTenantID, email: "jane@example.com"},
+		{name: "for another address", tenant: "closed", email: "joe@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInvitations(t, false)
+			invitation, code := i.invite(t, "closed", "jane@example.com", "admin")
+			if tt.setup != nil {
+				tt.setup(t, i, invitation)
+			}
+
+			if _, err := i.register(tt.tenant, tt.email, code); !errors.Is(err, services.ErrInvalidInvitation) {
+				t.Fatalf("Register error = %v, want services.ErrInvalidInvitation", err)
+			}
+			if got := i.lastAudit(t); got.Outcome != services.AuditOutcomeFailure || got.Reason != "invalid_invitation" {
+				t.Errorf("audit event = %+v, want a failed registration for invalid_invitation", got)
+			}
+			if _, err := i.users.FindByEmail(context.Background(), tt.tenant, tt.email); !errors.Is(err, services.ErrUserNotFound) {
+				t.Errorf("FindByEmail error = %v, want no account", err)
+			}
+		})
+	}
+}
+
+func TestInvitationRequired(t *testing.T) {
+	i := newTestInvitations(t, true)
+	if !i.Required() {
+		t.Error("Required = false, want true")
+	}
+
+	if _, err := i.register(services.DefaultTenantID, "jane@example.com", ""); !errors.Is(err, services.ErrInvitationRequired) {
+		t.Errorf("Register without an invitation error = %v, want services.ErrInvitationRequired", err)
+	}
+	_, code := i.invite(t, services.DefaultTenantID, "jane@example.com", "customer")
+	if _, err := i.register(services.DefaultTenantID, "jane@example.com", code); err != nil {
+		t.Errorf("Register with an invitation: %v", err)
+	}
+}
+
+func TestInvitationCreateRejects(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	i.addUser(t, "closed", "taken@example.com", "customer")
+	i.invite(t, "closed", "jane@example.com", "customer")
+
+	tests := []struct {
+		name string
+		req  models.CreateInvitationRequest
+		want error
+	}{
+		{name: "unknown role", req: models.CreateInvitationRequest{Email: "new@example.com", Role: "superuser"}, want: services.ErrUnknownRole},
+		{name: "registered address", req: models.CreateInvitationRequest{Email: "TAKEN@example.com", Role: "customer"}, want: services.ErrUserExists},
+		{name: "pending invitation", req: models.CreateInvitationRequest{Email: " Jane@example.com", Role: "admin"}, want: services.ErrInvitationPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := i.InvitationService.Create(ctx, "closed", tt.req, "admin-1"); !errors.Is(err, tt.want) {
+				t.Errorf("Create error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Other tenants are not affected.
+	if _, err := i.InvitationService.Create(ctx, services.DefaultTenantID, models.CreateInvitationRequest{Email: "jane@example.com", Role: "customer"}, "admin-1"); err != nil {
+		t.Errorf("Create in another tenant: %v", err)
+	}
+}
+
+func TestInvitationReplacesAnExpiredOne(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	first, oldCode := i.invite(t, "closed", "jane@example.com", "customer")
+	i.repository.Expire()
+
+	second, code := i.invite(t, "closed", "jane@example.com", "admin")
+	if second.ID == first.ID {
+		t.Fatal("Create returned the expired invitation")
+	}
+	if got, err := i.InvitationService.Get(ctx, "closed", first.ID); err != nil || got.Status != services.InvitationExpired || got.ExpiredAt == nil {
+		t.Errorf("first invitation = %+v, %v, want it marked expired", got, err)
+	}
+	if got, want := i.invitationEventTypes(), []string{"invitation.sent.v1", "invitation.expired.v1", "invitation.sent.v1"}; !equalIDs(got, want...) {
+		t.Errorf("invitation events = %v, want %v", got, want)
+	}
+
+	if _, err := i.register("closed", "jane@example.com", oldCode); !errors.Is(err, services.ErrInvalidInvitation) {
+		t.Errorf("Register with the expired code error = %v, want services.ErrInvalidInvitation", err)
+	}
+	if _, err := i.register("closed", "jane@example.com", code); err != nil {
+		t.Errorf("Register with the new code: %v", err)
+	}
+}
+
+func TestInvitationRevoke(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	invitation, _ := i.invite(t, "closed", "jane@example.com", "customer")
+
+	if _, err := i.Revoke(ctx, services.DefaultTenantID, invitation.ID, "admin-2"); !errors.Is(err, services.ErrInvitationNotFound) {
+		t.Errorf("Revoke from another tenant error = %v, want services.ErrInvitationNotFound", err)
+	}
+	if _, err := i.Revoke(ctx, "closed", "unknown", "admin-1"); !errors.Is(err, services.ErrInvitationNotFound) {
+		t.Errorf("Revoke of an unknown invitation error = %v, want services.ErrInvitationNotFound", err)
+	}
+
+	revoked, err := i.Revoke(ctx, "closed", invitation.ID, "admin-1")
+	if err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if revoked.Status != services.InvitationRevoked || revoked.RevokedBy != "admin-1" || revoked.RevokedAt == nil {
+		t.Errorf("invitation = %+v, want revoked by admin-1", revoked)
+	}
+	events := i.events.InvitationEvents()
+	if last := events[len(events)-1]; last.EventType != "invitation.revoked.v1" || last.ActorID != "admin-1" || last.InvitationID != invitation.ID {
+		t.Errorf("last invitation event = %+v, want the revocation by admin-1", last)
+	}
+
+	if _, err := i.Revoke(ctx, "closed", invitation.ID, "admin-1"); !errors.Is(err, services.ErrInvitationNotPending) {
+		t.Errorf("second Revoke error = %v, want services.ErrInvitationNotPending", err)
+	}
+}
+
+func TestInvitationRevokedWhenTheEmailFails(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	i.mailer.FailWith(errors.New("smtp unavailable"))
+
+	if _, err := i.InvitationService.Create(ctx, "closed", models.CreateInvitationRequest{Email: "jane@example.com", Role: "customer"}, "admin-1"); err == nil {
+		t.Fatal("Create with the mailer down succeeded")
+	}
+	list, err := i.List(ctx, models.InvitationQuery{TenantID: "closed"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || list[0].Status != services.InvitationRevoked {
+		t.Errorf("invitations = %+v, want the unsent one revoked", list)
+	}
+
+	// The admin can try again.
+	i.mailer.FailWith(nil)
+	i.invite(t, "closed", "jane@example.com", "customer")
+}
+
+func TestInvitationList(t *testing.T) {
+	i := newTestInvitations(t, false)
+	ctx := context.Background()
+	accepted, code := i.invite(t, "closed", "accepted@example.com", "customer")
+	if _, err := i.register("closed", "accepted@example.com", code); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	expired, _ := i.invite(t, "closed", "expired@example.com", "customer")
+	i.repository.Expire()
+	time.Sleep(time.Millisecond)
+	revoked, _ := i.invite(t, "closed", "revoked@example.com", "customer")
+	if _, err := i.Revoke(ctx, "closed", revoked.ID, "admin-1"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	pending, _ := i.invite(t, "closed", "pending@example.com", "customer")
+	i.invite(t, services.DefaultTenantID, "other@example.com", "customer")
+
+	tests := []struct {
+		name  string
+		query models.InvitationQuery
+		want  []string
+	}{
+		{name: "tenant, newest first", query: models.InvitationQuery{TenantID: "closed"}, want: []string{pending.ID, revoked.ID, expired.ID, accepted.ID}},
+		{name: "pending", query: models.InvitationQuery{TenantID: "closed", Status: services.InvitationSent}, want: []string{pending.ID}},
+		{name: "expired before being marked", query: models.InvitationQuery{TenantID: "closed", Status: services.InvitationExpired}, want: []string{expired.ID}},
+		{name: "accepted", query: models.InvitationQuery{TenantID: "closed", Status: services.InvitationAccepted}, want: []string{accepted.ID}},
+		{name: "address", query: models.InvitationQuery{TenantID: "closed", Email: "Revoked@example.com"}, want: []string{revoked.ID}},
+		{name: "limit", query: models.InvitationQuery{TenantID: "closed", Limit: 2}, want: []string{pending.ID, revoked.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := i.List(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			ids := make([]string, len(list))
+			for n, invitation := range list {
+				ids[n] = invitation.ID
+			}
+			if !equalIDs(ids, tt.want...) {
+				t.Errorf("invitations = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	if got, err := i.InvitationService.Get(ctx, "closed", expired.ID); err != nil || got.Status != services.InvitationExpired {
+		t.Errorf("Get = %+v, %v, want the invitation reported expired", got, err)
+	}
+	if _, err := i.InvitationService.Get(ctx, services.DefaultTenantID, pending.ID); !errors.Is(err, services.ErrInvitationNotFound) {
+		t.Errorf("Get from another tenant error = %v, want services.ErrInvitationNotFound", err)
+	}
+}
@@ -2,59 +2,385 @@ package services
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTService handles JWT token generation and validation using a secret key.
+// JWTService handles JWT token generation and validation using the
+// asymmetric keys managed by the KeyStore.
 type JWTService struct {
-	secretKey []byte
+	keyStore         *KeyStore
+	issuer           string
+	audience         string
+	accessTokenTTL   time.Duration
+	impersonationTTL time.Duration
 }
 
 // NewJWTService creates a new JWTService with the provided JWT configuration.
-func NewJWTService(jwtConfig *config.JWTConfig) *JWTService {
+func NewJWTService(jwtConfig *config.JWTConfig, keyStore *KeyStore) *JWTService {
 	return &JWTService{
-		secretKey: []byte(jwtConfig.SecretKey),
+		keyStore:         keyStore,
+		issuer:           jwtConfig.Issuer,
+		audience:         jwtConfig.Audience,
+		accessTokenTTL:   jwtConfig.AccessTokenTTL,
+		impersonationTTL: jwtConfig.ImpersonationTTL,
 	}
 }
 
+// AccessTokenTTL returns the lifetime of access tokens issued by the service.
+func (s *JWTService) AccessTokenTTL() time.Duration {
+	return s.accessTokenTTL
+}
+
+// ImpersonationTTL returns the lifetime of impersonation tokens.
+func (s *JWTService) ImpersonationTTL() time.Duration {
+	return s.impersonationTTL
+}
+
+// Principal types reported for validated access tokens.
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
 // Claims defines the custom and registered claims for JWT tokens.
+// RegisteredClaims.ID carries the jti used for revocation. Tokens issued to
+// service accounts carry no user_id; their subject is the client ID. Tokens
+// issued to an admin impersonating a user carry the admin in Actor. User
+// tokens name the tenant of the account; service account tokens are not
+// bound to a tenant. IssuedAtMillis repeats iat in milliseconds, so tokens
+// can be ordered against user-wide revocations of the same second.
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID      string        `json:"user_id,omitempty"`
+	TenantID    string        `json:"tenant_id,omitempty"`
+	Role        string        `json:"role,omitempty"`
+	Permissions []string      `json:"permissions,omitempty"`
+	Scope       string        `json:"scope,omitempty"`
+	ClientID    string        `json:"client_id,omitempty"`
+	AMR         []string      `json:"amr,omitempty"`
+	SessionID   string        `json:"sid,omitempty"`
+	Actor       *models.Actor `json:"act,omitempty"`
+	// IssuedAtMillis is absent from tokens issued before it was introduced.
+	IssuedAtMillis int64 `json:"iat_ms,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasPermission reports whether the token grants the permission. Service
+// account tokens grant the permissions listed in their scope.
+func (c *Claims) HasPermission(permission string) bool {
+	if c.PrincipalType() == PrincipalService {
+		return containsString(strings.Fields(c.Scope), permission)
+	}
+	return containsString(c.Permissions, permission)
+}
+
+// IsImpersonated reports whether someone other than the user holds the token.
+func (c *Claims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+// ActorID returns the user ID of the impersonating admin, or "".
+func (c *Claims) ActorID() string {
+	if c.Actor == nil {
+		return ""
+	}
+	return c.Actor.Subject
+}
+
+// Tenant returns the tenant of the user the token was issued to. Tokens
+// issued before tenants existed belong to the default tenant.
+func (c *Claims) Tenant() string {
+	if c.TenantID == "" {
+		return DefaultTenantID
+	}
+	return c.TenantID
+}
+
+// IssuedNoLaterThan reports whether the token was issued at or before t, to
+// millisecond precision. Tokens without iat_ms are compared by the second,
+// and ones without any issue time count as issued before t.
+func (c *Claims) IssuedNoLaterThan(t time.Time) bool {
+	if c.IssuedAtMillis != 0 {
+		return c.IssuedAtMillis <= t.UnixMilli()
+	}
+	if c.IssuedAt == nil {
+		return true
+	}
+	return c.IssuedAt.Unix() <= t.Unix()
+}
+
+// PrincipalType reports whether the token was issued to a user or to a
+// service account.
+func (c *Claims) PrincipalType() string {
+	if c.UserID == "" && c.ClientID != "" {
+		return PrincipalService
+	}
+	return PrincipalUser
+}
+
+// IsDelegated reports whether the token was issued to an OAuth client on
+// behalf of a user through the authorization code flow. Such tokens carry the
+// client's scope instead of a session and only grant what the scope allows.
+func (c *Claims) IsDelegated() bool {
+	return c.UserID != "" && c.ClientID != ""
+}
+
+// TokenOption customizes the claims of a generated access token.
+type TokenOption func(*Claims)
+
+// WithTenant records the tenant of the user the token is issued to.
+func WithTenant(tenantID string) TokenOption {
+	return func(c *Claims) {
+		c.TenantID = tenantID
+	}
+}
+
+// WithScope sets the space-separated scope granted to the token.
+func WithScope(scope string) TokenOption {
+	return func(c *Claims) {
+		c.Scope = scope
+	}
+}
+
+// WithClientID records the OAuth client the token was issued to.
+func WithClientID(clientID string) TokenOption {
+	return func(c *Claims) {
+		c.ClientID = clientID
+	}
+}
+
+// WithRole records the user's role and the permissions it grants.
+func WithRole(role string) TokenOption {
+	return func(c *Claims) {
+		c.Role = role
+		c.Permissions = PermissionsForRole(role)
+	}
+}
+
+// WithAMR records the authentication methods used to obtain the token.
+func WithAMR(amr []string) TokenOption {
+	return func(c *Claims) {
+		c.AMR = amr
+	}
+}
+
+// WithSessionID binds the token to a session; it stops being accepted once
+// the session is terminated.
+func WithSessionID(sessionID string) TokenOption {
+	return func(c *Claims) {
+		c.SessionID = sessionID
+	}
+}
+
+// WithActor marks the token as held by actorID on behalf of the subject.
+func WithActor(actorID string) TokenOption {
+	return func(c *Claims) {
+		c.Actor = &models.Actor{Subject: actorID}
+	}
+}
+
+// withTTL overrides the lifetime of the token.
+func withTTL(ttl time.Duration) TokenOption {
+	return func(c *Claims) {
+		c.ExpiresAt = jwt.NewNumericDate(c.IssuedAt.Add(ttl))
+	}
+}
+
+// IDTokenClaims defines the claims of an OpenID Connect ID token.
+type IDTokenClaims struct {
+	Name            string   `json:"name,omitempty"`
+	Email           string   `json:"email,omitempty"`
+	EmailVerified   *bool    `json:"email_verified,omitempty"`
+	UpdatedAt       int64    `json:"updated_at,omitempty"`
+	Nonce           string   `json:"nonce,omitempty"`
+	AuthTime        int64    `json:"auth_time,omitempty"`
+	AMR             []string `json:"amr,omitempty"`
+	AccessTokenHash string   `json:"at_hash,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken generates a signed JWT token string for the given user ID.
-// The token is valid for 24 hours from the time of issuance.
-func (s *JWTService) GenerateToken(userID string) (string, error) {
+// The token is short-lived; clients renew it with a refresh token.
+func (s *JWTService) GenerateToken(userID string, opts ...TokenOption) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
+		UserID:         userID,
+		IssuedAtMillis: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{s.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
+
+	return s.sign(claims)
+}
+
+// GenerateImpersonationToken generates an access token that lets the admin
+// actorID act as user. It grants the user's permissions, names the admin in
+// the act claim and lives for the impersonation TTL.
+func (s *JWTService) GenerateImpersonationToken(user *models.User, actorID string) (string, error) {
+	return s.GenerateToken(user.ID, WithTenant(user.TenantID), WithRole(user.Role), WithActor(actorID), withTTL(s.impersonationTTL))
+}
+
+// GenerateServiceToken generates an access token for a service account
+// authenticated with the client_credentials grant. The token has no user_id;
+// its subject is the client ID.
+func (s *JWTService) GenerateServiceToken(clientID, scope string) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		ClientID: clientID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			Subject:   clientID,
+			Audience:  jwt.ClaimStrings{s.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	return s.sign(claims)
+}
+
+// GenerateIDToken generates an OpenID Connect ID token for the client. Profile
+// and email claims are only included when the corresponding scope was granted.
+func (s *JWTService) GenerateIDToken(user *models.User, clientID string, scopes []string, nonce string, authTime time.Time, amr []string, accessToken string) (string, error) {
+	now := time.Now()
+	claims := IDTokenClaims{
+		Nonce:           nonce,
+		AuthTime:        authTime.Unix(),
+		AMR:             amr,
+		AccessTokenHash: accessTokenHash(s.keyStore.Algorithm(), accessToken),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    s.issuer,
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{clientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+	for _, scope := range scopes {
+		switch scope {
+		case "profile":
+			claims.Name = user.Name
+			claims.UpdatedAt = user.UpdatedAt.Unix()
+		case "email":
+			verified := user.Status == "active"
+			claims.Email = user.Email
+			claims.EmailVerified = &verified
+		}
+	}
+
+	return s.sign(claims)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+// accessTokenHash computes the at_hash claim: the left half of the access
+// token digest, using the hash function of the signing algorithm.
+func accessTokenHash(algorithm, accessToken string) string {
+	if accessToken == "" {
+		return ""
+	}
+
+	var digest []byte
+	if algorithm == "EdDSA" {
+		sum := sha512.Sum512([]byte(accessToken))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(accessToken))
+		digest = sum[:]
+	}
+	return base64.RawURLEncoding.EncodeToString(digest[:len(digest)/2])
+}
+
+// sign signs the claims with the active key and sets the kid header.
+func (s *JWTService) sign(claims jwt.Claims) (string, error) {
+	kid, algorithm, private, err := s.keyStore.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(algorithm), claims)
+	token.Header["kid"] = kid
+	return token.SignedString(private)
 }
 
 // ValidateToken parses and validates the provided JWT token string.
 // It returns the Claims if the token is valid, or an error otherwise.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil
-	})
+	claims := &Claims{}
+	if err := s.parse(tokenString, claims, s.audience); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
 
+// parse verifies the signature against the key identified by the kid header
+// and enforces the issuer and audience.
+func (s *JWTService) parse(tokenString string, claims jwt.Claims, audience string) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "EdDSA"}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(audience),
+	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if !token.Valid {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (s *JWTService) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownSigningKey
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	public, algorithm, err := s.keyStore.VerificationKey(kid)
+	if err != nil {
+		return nil, err
 	}
+	if token.Method.Alg() != algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return public, nil
+}
 
-	return nil, jwt.ErrSignatureInvalid
+// newTokenID returns a random identifier for the jti claim.
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
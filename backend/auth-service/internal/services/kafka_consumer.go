@@ -0,0 +1,225 @@
+package services
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// UserEventConsumer applies profile changes made through user-service to the
+// accounts: user.deleted.v1 removes the account, user.updated.v1 carries
+// changes of the name and email. Events auth-service published itself are
+// skipped, and applying an event publishes nothing, so changes are never
+// echoed back to user-service.
+type UserEventConsumer struct {
+	logger           logger.Logger
+	service          *AuthService
+	readers          []messageReader
+	topicUserUpdated string
+	topicUserDeleted string
+	retryBackoff     time.Duration
+}
+
+// Events that fail to apply are retried after eventRetryBackoff, doubling up
+// to eventRetryMaxBackoff.
+const (
+	eventRetryBackoff    = 500 * time.Millisecond
+	eventRetryMaxBackoff = 30 * time.Second
+)
+
+// messageReader is the part of *kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewUserEventConsumer creates a Kafka consumer for the user lifecycle topics
+// user-service publishes to. It returns nil without brokers.
+func NewUserEventConsumer(
+	brokers string,
+	groupID string,
+	clientID string,
+	topicUserUpdated string,
+	topicUserDeleted string,
+	service *AuthService,
+	log logger.Logger,
+) (*UserEventConsumer, error) {
+	parsedBrokers := splitBrokers(brokers)
+	if len(parsedBrokers) == 0 {
+		return nil, nil
+	}
+
+	if groupID == "" {
+		return nil, errors.New("kafka group id is required")
+	}
+
+	topics := []string{topicUserUpdated, topicUserDeleted}
+	readers := make([]messageReader, 0, len(topics))
+
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+
+		readers = append(readers, kafka.NewReader(kafka.ReaderConfig{
+			Brokers:  parsedBrokers,
+			GroupID:  groupID,
+			Topic:    topic,
+			MinBytes: 1,
+			MaxBytes: 10e6,
+			Dialer: &kafka.Dialer{
+				ClientID: clientID,
+			},
+		}))
+	}
+
+	if len(readers) == 0 {
+		return nil, nil
+	}
+
+	return &UserEventConsumer{
+		logger:           log,
+		service:          service,
+		readers:          readers,
+		topicUserUpdated: strings.TrimSpace(topicUserUpdated),
+		topicUserDeleted: strings.TrimSpace(topicUserDeleted),
+		retryBackoff:     eventRetryBackoff,
+	}, nil
+}
+
+// Start begins consuming all configured topics until context cancellation.
+func (c *UserEventConsumer) Start(ctx context.Context) {
+	if c == nil || len(c.readers) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(c.readers))
+
+	for _, reader := range c.readers {
+		go func(r messageReader) {
+			defer wg.Done()
+			c.consumeLoop(ctx, r)
+		}(reader)
+	}
+
+	wg.Wait()
+}
+
+// consumeLoop applies the messages of one topic in order. A message is only
+// committed once applied: committing a later offset would also commit it, so
+// a failing message is retried until it succeeds or ctx is cancelled.
+func (c *UserEventConsumer) consumeLoop(ctx context.Context, reader messageReader) {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			c.logger.Error("Failed to fetch Kafka message", zap.Error(err))
+			continue
+		}
+
+		var event models.UserEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			c.logger.Error("Failed to unmarshal user event",
+				zap.Error(err),
+				zap.String("topic", msg.Topic),
+			)
+			if commitErr := reader.CommitMessages(ctx, msg); commitErr != nil {
+				c.logger.Error("Failed to commit malformed message", zap.Error(commitErr))
+			}
+			continue
+		}
+
+		err = c.processEvent(ctx, msg.Topic, event)
+		if errors.Is(err, ErrUserExists) {
+			// Retrying cannot help; user-service enforces the same uniqueness,
+			// so this only happens for profiles that were already out of sync.
+			c.logger.Warn("Skipping user event, email address belongs to another account",
+				zap.String("event_type", event.EventType),
+				zap.String("user_id", event.UserID),
+			)
+		} else if err != nil {
+			// Cancelled; the uncommitted message is delivered again.
+			return
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			c.logger.Error("Failed to commit Kafka message", zap.Error(err))
+		}
+	}
+}
+
+// processEvent applies the event, retrying with exponential backoff until it
+// succeeds or ctx is cancelled. ErrUserExists is returned without retrying.
+func (c *UserEventConsumer) processEvent(ctx context.Context, topic string, event models.UserEvent) error {
+	backoff := c.retryBackoff
+	for {
+		err := c.handleEvent(topic, event)
+		if err == nil || errors.Is(err, ErrUserExists) {
+			return err
+		}
+		c.logger.Error("Failed to process user event, retrying",
+			zap.Error(err),
+			zap.String("topic", topic),
+			zap.String("event_type", event.EventType),
+			zap.String("user_id", event.UserID),
+			zap.Duration("retry_in", backoff),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, eventRetryMaxBackoff)
+	}
+}
+
+func (c *UserEventConsumer) handleEvent(topic string, event models.UserEvent) error {
+	if event.Source == EventSource {
+		return nil
+	}
+
+	switch topic {
+	case c.topicUserDeleted:
+		return c.service.DeleteAccountFromEvent(event)
+	case c.topicUserUpdated:
+		// Events published before sources were recorded may be auth-service's
+		// own; only user-service ever published deletions.
+		if event.Source == "" {
+			return nil
+		}
+		return c.service.UpdateAccountFromEvent(event)
+	default:
+		c.logger.Warn("Ignoring user event from unknown topic", zap.String("topic", topic))
+		return nil
+	}
+}
+
+// Close closes all reader resources.
+func (c *UserEventConsumer) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	var closeErr error
+	for _, reader := range c.readers {
+		if err := reader.Close(); err != nil {
+			closeErr = err
+			c.logger.Error("Failed to close Kafka reader", zap.Error(err))
+		}
+	}
+	return closeErr
+}
@@ -0,0 +1,169 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader hands out its messages in order, then blocks until cancelled,
+// and records the offsets committed.
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []int64
+}
+
+func newFakeReader(t *testing.T, topic string, events ...models.UserEvent) *fakeReader {
+	t.Helper()
+
+	r := &fakeReader{}
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		r.messages = append(r.messages, kafka.Message{Topic: topic, Offset: int64(i), Value: value})
+	}
+	return r
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
+
+func (r *fakeReader) Committed() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int64(nil), r.committed...)
+}
+
+// eventually fails the test unless cond holds within a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// startConsumer runs the consumer until the returned function is called.
+func startConsumer(consumer *services.UserEventConsumer) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		consumer.Start(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func userServiceEvent(userID, email, previousEmail string) models.UserEvent {
+	return models.UserEvent{
+		EventType:     "user.updated.v1",
+		UserID:        userID,
+		TenantID:      services.DefaultTenantID,
+		Email:         email,
+		PreviousEmail: previousEmail,
+		Name:          "Jane Roe",
+		Source:        "user-service",
+	}
+}
+
+func TestUserEventConsumerRetriesUntilApplied(t *testing.T) {
+	a := newTestAuthService(t)
+	jane := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	john := a.addUser(t, services.DefaultTenantID, "john@example.com", "customer")
+	a.verifications.FailWith(errors.New("smtp unavailable"))
+
+	deletion := models.UserEvent{EventType: "user.deleted.v1", UserID: john.ID, TenantID: services.DefaultTenantID, Source: "user-service"}
+	updates := newFakeReader(t, "user.updated.v1", userServiceEvent(jane.ID, "jane.roe@example.com", "jane@example.com"))
+	deletions := newFakeReader(t, "user.deleted.v1", deletion)
+	stop := startConsumer(services.NewTestUserEventConsumer(a.AuthService, a.log, updates, deletions))
+	defer stop()
+
+	eventually(t, "the deletion to be committed", func() bool { return len(deletions.Committed()) == 1 })
+	eventually(t, "a few retries", func() bool { return len(a.log.Errors()) >= 3 })
+	if got := updates.Committed(); len(got) != 0 {
+		t.Fatalf("committed %v before the update was applied, want nothing", got)
+	}
+
+	a.verifications.FailWith(nil)
+	eventually(t, "the update to be committed", func() bool { return len(updates.Committed()) == 1 })
+	if sent := a.verifications.Sent(); len(sent) != 1 || sent[0].Email != "jane.roe@example.com" {
+		t.Errorf("sent = %+v, want one confirmation for the new address", sent)
+	}
+	if _, err := a.GetUser(services.DefaultTenantID, john.ID); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("GetUser of the deleted account error = %v, want services.ErrUserNotFound", err)
+	}
+}
+
+func TestUserEventConsumerLeavesFailedEventUncommittedWhenStopped(t *testing.T) {
+	a := newTestAuthService(t)
+	jane := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.verifications.FailWith(errors.New("smtp unavailable"))
+
+	updates := newFakeReader(t, "user.updated.v1", userServiceEvent(jane.ID, "jane.roe@example.com", "jane@example.com"))
+	stop := startConsumer(services.NewTestUserEventConsumer(a.AuthService, a.log, updates))
+	eventually(t, "a retry", func() bool { return len(a.log.Errors()) >= 1 })
+	stop()
+
+	if got := updates.Committed(); len(got) != 0 {
+		t.Errorf("committed %v, want the failed event left for redelivery", got)
+	}
+}
+
+func TestUserEventConsumerSkipsOwnAndMalformedEvents(t *testing.T) {
+	a := newTestAuthService(t)
+	jane := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	own := models.UserEvent{EventType: "user.deleted.v1", UserID: jane.ID, TenantID: services.DefaultTenantID, Source: services.EventSource}
+	deletions := newFakeReader(t, "user.deleted.v1", own)
+	deletions.messages = append(deletions.messages, kafka.Message{Topic: "user.deleted.v1", Offset: 1, Value: []byte("{")})
+	stop := startConsumer(services.NewTestUserEventConsumer(a.AuthService, a.log, deletions))
+	defer stop()
+
+	eventually(t, "both messages to be committed", func() bool { return len(deletions.Committed()) == 2 })
+	if _, err := a.GetUser(services.DefaultTenantID, jane.ID); err != nil {
+		t.Errorf("GetUser after its own deletion event: %v", err)
+	}
+}
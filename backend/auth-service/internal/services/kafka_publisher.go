@@ -10,24 +10,41 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-// KafkaPublisher publishes user lifecycle events.
+// EventSource is the source of the user lifecycle events auth-service publishes.
+const EventSource = "auth-service"
+
+// KafkaTopics holds the topic names the publisher writes to. An empty topic
+// disables publishing of the corresponding events.
+type KafkaTopics struct {
+	UserCreated     string
+	UserUpdated     string
+	UserDeleted     string
+	TokenRevoked    string
+	PasswordChanged string
+	LoginFailed     string
+	AccountLocked   string
+	Audit           string
+	Invitation      string
+}
+
+// KafkaPublisher publishes user lifecycle and token events.
 type KafkaPublisher struct {
-	writer           *kafka.Writer
-	topicUserCreated string
-	topicUserUpdated string
-	topicUserDeleted string
+	writer *kafka.Writer
+	topics KafkaTopics
 }
 
-// NewKafkaPublisher creates a publisher for user events.
-func NewKafkaPublisher(brokers, clientID, createdTopic, updatedTopic, deletedTopic string) (*KafkaPublisher, error) {
+// NewKafkaPublisher creates a publisher for user and token events.
+func NewKafkaPublisher(brokers, clientID string, topics KafkaTopics) (*KafkaPublisher, error) {
 	parsedBrokers := splitBrokers(brokers)
 	if len(parsedBrokers) == 0 {
 		return nil, nil
 	}
 
+	// Messages are partitioned by key, so the events of a user stay in the
+	// order the outbox relay publishes them.
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(parsedBrokers...),
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		RequiredAcks: kafka.RequireAll,
 		Async:        false,
 		Transport: &kafka.Transport{
@@ -36,10 +53,8 @@ func NewKafkaPublisher(brokers, clientID, createdTopic, updatedTopic, deletedTop
 	}
 
 	return &KafkaPublisher{
-		writer:           writer,
-		topicUserCreated: createdTopic,
-		topicUserUpdated: updatedTopic,
-		topicUserDeleted: deletedTopic,
+		writer: writer,
+		topics: topics,
 	}, nil
 }
 
@@ -55,7 +70,7 @@ func splitBrokers(brokers string) []string {
 	return parsed
 }
 
-func (p *KafkaPublisher) publish(ctx context.Context, topic string, event models.UserEvent) error {
+func (p *KafkaPublisher) publish(ctx context.Context, topic, key string, event interface{}) error {
 	if p == nil || p.writer == nil || topic == "" {
 		return nil
 	}
@@ -65,26 +80,79 @@ func (p *KafkaPublisher) publish(ctx context.Context, topic string, event models
 		return err
 	}
 
+	return p.WriteMessage(ctx, topic, key, payload)
+}
+
+// configuredTopics returns the topics to publish to. The nil publisher used
+// when no brokers are configured has none, so its events are dropped.
+func (p *KafkaPublisher) configuredTopics() KafkaTopics {
+	if p == nil {
+		return KafkaTopics{}
+	}
+	return p.topics
+}
+
+// WriteMessage publishes an already encoded event.
+func (p *KafkaPublisher) WriteMessage(ctx context.Context, topic, key string, payload []byte) error {
 	return p.writer.WriteMessages(ctx, kafka.Message{
 		Topic: topic,
-		Key:   []byte(event.UserID),
+		Key:   []byte(key),
 		Value: payload,
 	})
 }
 
 // PublishUserCreated publishes user.created.v1.
 func (p *KafkaPublisher) PublishUserCreated(ctx context.Context, event models.UserEvent) error {
-	return p.publish(ctx, p.topicUserCreated, event)
+	event.Source = EventSource
+	return p.publish(ctx, p.configuredTopics().UserCreated, event.UserID, event)
 }
 
 // PublishUserUpdated publishes user.updated.v1.
 func (p *KafkaPublisher) PublishUserUpdated(ctx context.Context, event models.UserEvent) error {
-	return p.publish(ctx, p.topicUserUpdated, event)
+	event.Source = EventSource
+	return p.publish(ctx, p.configuredTopics().UserUpdated, event.UserID, event)
 }
 
 // PublishUserDeleted publishes user.deleted.v1.
 func (p *KafkaPublisher) PublishUserDeleted(ctx context.Context, event models.UserEvent) error {
-	return p.publish(ctx, p.topicUserDeleted, event)
+	event.Source = EventSource
+	return p.publish(ctx, p.configuredTopics().UserDeleted, event.UserID, event)
+}
+
+// PublishTokenRevoked publishes token.revoked.v1.
+func (p *KafkaPublisher) PublishTokenRevoked(ctx context.Context, event models.TokenRevokedEvent) error {
+	return p.publish(ctx, p.configuredTopics().TokenRevoked, event.UserID, event)
+}
+
+// PublishPasswordChanged publishes security.password_changed.v1.
+func (p *KafkaPublisher) PublishPasswordChanged(ctx context.Context, event models.SecurityEvent) error {
+	return p.publish(ctx, p.configuredTopics().PasswordChanged, event.UserID, event)
+}
+
+// PublishLoginFailed publishes security.login_failed.v1.
+func (p *KafkaPublisher) PublishLoginFailed(ctx context.Context, event models.SecurityEvent) error {
+	return p.publish(ctx, p.configuredTopics().LoginFailed, event.Email, event)
+}
+
+// PublishAccountLocked publishes security.account_locked.v1.
+func (p *KafkaPublisher) PublishAccountLocked(ctx context.Context, event models.SecurityEvent) error {
+	return p.publish(ctx, p.configuredTopics().AccountLocked, event.Email, event)
+}
+
+// PublishAudit publishes audit.auth.v1, keyed by user ID or, for events
+// without an account, by email.
+func (p *KafkaPublisher) PublishAudit(ctx context.Context, event models.AuditEvent) error {
+	key := event.UserID
+	if key == "" {
+		key = event.Email
+	}
+	return p.publish(ctx, p.configuredTopics().Audit, key, event)
+}
+
+// PublishInvitation publishes an invitation lifecycle event to
+// invitation.lifecycle.v1, keyed by invitation ID.
+func (p *KafkaPublisher) PublishInvitation(ctx context.Context, event models.InvitationEvent) error {
+	return p.publish(ctx, p.configuredTopics().Invitation, event.InvitationID, event)
 }
 
 // Close closes the underlying writer.
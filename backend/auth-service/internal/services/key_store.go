@@ -0,0 +1,288 @@
+package services
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// ErrUnknownSigningKey is returned when a token references a kid that is not
+// (or no longer) in the key store.
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// signingKey is a parsed SigningKey.
+type signingKey struct {
+	id        string
+	algorithm string
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// KeyStore manages the asymmetric keys used to sign and verify JWTs. Keys are
+// stored in a SigningKeyRepository and rotated on a schedule: the newest key
+// signs, older keys remain verifiable for the retention period so outstanding
+// tokens stay valid.
+type KeyStore struct {
+	repository       SigningKeyRepository
+	algorithm        string
+	rotationInterval time.Duration
+	retention        time.Duration
+	logger           logger.Logger
+
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active *signingKey
+}
+
+// NewKeyStore creates a KeyStore. retention must cover the longest lifetime of
+// any token signed with a key.
+func NewKeyStore(repository SigningKeyRepository, algorithm string, rotationInterval, retention time.Duration, log logger.Logger) (*KeyStore, error) {
+	switch algorithm {
+	case "RS256", "EdDSA":
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", algorithm)
+	}
+
+	return &KeyStore{
+		repository:       repository,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		retention:        retention,
+		logger:           log,
+		keys:             make(map[string]*signingKey),
+	}, nil
+}
+
+// Refresh loads the keys from MongoDB and rotates the active key if it is
+// missing, uses a different algorithm, or is older than the rotation interval.
+func (k *KeyStore) Refresh(ctx context.Context) error {
+	if err := k.load(ctx); err != nil {
+		return err
+	}
+
+	k.mu.RLock()
+	active := k.active
+	k.mu.RUnlock()
+
+	if active != nil && active.algorithm == k.algorithm && time.Since(active.createdAt) < k.rotationInterval {
+		return nil
+	}
+
+	if err := k.Rotate(ctx); err != nil {
+		return err
+	}
+	return k.load(ctx)
+}
+
+// Start periodically refreshes the key store until the context is cancelled.
+// Refreshing also picks up keys rotated by other replicas.
+func (k *KeyStore) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			if err := k.Refresh(refreshCtx); err != nil {
+				k.logger.Error("Failed to refresh signing keys", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
+// Rotate generates a new active key and retires the previous ones. Retired
+// keys remain verifiable for the retention period.
+func (k *KeyStore) Rotate(ctx context.Context) error {
+	private, err := generateSigningKey(k.algorithm)
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	record := models.SigningKey{
+		ID:            primitive.NewObjectID().Hex(),
+		Algorithm:     k.algorithm,
+		PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:     now,
+	}
+
+	if err := k.repository.Insert(ctx, &record); err != nil {
+		return err
+	}
+	if err := k.repository.RetireOthers(ctx, record.ID, now, now.Add(k.retention)); err != nil {
+		return err
+	}
+
+	k.logger.Info("Rotated JWT signing key",
+		zap.String("kid", record.ID),
+		zap.String("alg", record.Algorithm),
+	)
+	return nil
+}
+
+func (k *KeyStore) load(ctx context.Context) error {
+	records, err := k.repository.ListVerifiable(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey)
+	var active *signingKey
+	for _, record := range records {
+		parsed, err := parseSigningKey(record)
+		if err != nil {
+			k.logger.Error("Skipping unreadable signing key", zap.String("kid", record.ID), zap.Error(err))
+			continue
+		}
+		keys[parsed.id] = parsed
+
+		if record.RetiredAt == nil && (active == nil || parsed.createdAt.After(active.createdAt)) {
+			active = parsed
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.mu.Unlock()
+	return nil
+}
+
+// Algorithm returns the configured signing algorithm.
+func (k *KeyStore) Algorithm() string {
+	return k.algorithm
+}
+
+// SigningKey returns the active key used to sign new tokens.
+func (k *KeyStore) SigningKey() (kid string, algorithm string, private crypto.Signer, err error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.active == nil {
+		return "", "", nil, errors.New("no active signing key")
+	}
+	return k.active.id, k.active.algorithm, k.active.private, nil
+}
+
+// VerificationKey returns the public key and algorithm for the kid.
+func (k *KeyStore) VerificationKey(kid string) (crypto.PublicKey, string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, "", ErrUnknownSigningKey
+	}
+	return key.private.Public(), key.algorithm, nil
+}
+
+// JWKS returns the public keys that can currently verify tokens.
+func (k *KeyStore) JWKS() models.JWKS {
+	k.mu.RLock()
+	keys := make([]*signingKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	k.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].createdAt.After(keys[j].createdAt)
+	})
+
+	set := models.JWKS{Keys: make([]models.JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk := models.JWK{
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: key.algorithm,
+		}
+
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func generateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case "RS256":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	default:
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", algorithm)
+	}
+}
+
+func parseSigningKey(record models.SigningKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(record.PrivateKeyPEM))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if record.Algorithm != "RS256" {
+			return nil, fmt.Errorf("RSA key stored with algorithm %q", record.Algorithm)
+		}
+		signer = private
+	case ed25519.PrivateKey:
+		if record.Algorithm != "EdDSA" {
+			return nil, fmt.Errorf("Ed25519 key stored with algorithm %q", record.Algorithm)
+		}
+		signer = private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return &signingKey{
+		id:        record.ID,
+		algorithm: record.Algorithm,
+		private:   signer,
+		createdAt: record.CreatedAt,
+	}, nil
+}
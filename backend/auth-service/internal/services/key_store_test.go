@@ -0,0 +1,187 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKeyStore(t *testing.T, repository services.SigningKeyRepository, algorithm string, rotationInterval, retention time.Duration) (*services.KeyStore, *services.JWTService) {
+	t.Helper()
+
+	store, err := services.NewKeyStore(repository, algorithm, rotationInterval, retention, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewKeyStore: %v", err)
+	}
+	if err := store.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	return store, services.NewJWTService(config.NewJWTConfig(algorithm, "auth-service", "api", 15*time.Minute, 5*time.Minute, 0, 0), store)
+}
+
+func activeKeyID(t *testing.T, store *services.KeyStore) string {
+	t.Helper()
+
+	kid, _, _, err := store.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey: %v", err)
+	}
+	return kid
+}
+
+func TestKeyStoreKeepsRetiredKeysVerifiable(t *testing.T) {
+	store, jwtService := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", 24*time.Hour, time.Hour)
+	ctx := context.Background()
+
+	oldKid := activeKeyID(t, store)
+	oldToken, err := jwtService.GenerateToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	if err := store.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := store.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	newKid := activeKeyID(t, store)
+	if newKid == oldKid {
+		t.Fatalf("active kid = %q after rotation, want a new key", newKid)
+	}
+
+	if _, err := jwtService.ValidateToken(oldToken); err != nil {
+		t.Errorf("ValidateToken of a token signed with the retired key: %v", err)
+	}
+	newToken, err := jwtService.GenerateToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &services.Claims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	if parsed.Header["kid"] != newKid {
+		t.Errorf("kid of a new token = %v, want %q", parsed.Header["kid"], newKid)
+	}
+
+	jwks := store.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != newKid || jwks.Keys[1].KeyID != oldKid {
+		t.Fatalf("JWKS = %+v, want the new key followed by the retired one", jwks.Keys)
+	}
+	for _, key := range jwks.Keys {
+		if key.KeyType != "OKP" || key.Curve != "Ed25519" || key.Algorithm != "EdDSA" || key.Use != "sig" || key.X == "" {
+			t.Errorf("JWK = %+v, want a public Ed25519 signing key", key)
+		}
+	}
+}
+
+func TestKeyStoreDropsKeysAfterRetention(t *testing.T) {
+	// Retired keys expire right away.
+	store, jwtService := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", 24*time.Hour, time.Nanosecond)
+	ctx := context.Background()
+
+	oldToken, err := jwtService.GenerateToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if err := store.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := store.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if _, err := jwtService.ValidateToken(oldToken); !errors.Is(err, services.ErrUnknownSigningKey) {
+		t.Errorf("ValidateToken of a token signed with an expired key error = %v, want services.ErrUnknownSigningKey", err)
+	}
+	if jwks := store.JWKS(); len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != activeKeyID(t, store) {
+		t.Errorf("JWKS = %+v, want only the active key", jwks.Keys)
+	}
+}
+
+func TestKeyStoreRefreshRotates(t *testing.T) {
+	t.Run("when the active key is older than the rotation interval", func(t *testing.T) {
+		store, _ := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", time.Nanosecond, time.Hour)
+		first := activeKeyID(t, store)
+
+		if err := store.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if got := activeKeyID(t, store); got == first {
+			t.Errorf("active kid = %q, want a rotated key", got)
+		}
+	})
+
+	t.Run("when the algorithm changed", func(t *testing.T) {
+		repository := testutil.NewMemorySigningKeyRepository()
+		_, edJWT := newTestKeyStore(t, repository, "EdDSA", 24*time.Hour, time.Hour)
+		edToken, err := edJWT.GenerateToken("user-1")
+		if err != nil {
+			t.Fatalf("GenerateToken: %v", err)
+		}
+
+		store, rsJWT := newTestKeyStore(t, repository, "RS256", 24*time.Hour, time.Hour)
+		_, algorithm, _, err := store.SigningKey()
+		if err != nil {
+			t.Fatalf("SigningKey: %v", err)
+		}
+		if algorithm != "RS256" {
+			t.Errorf("active algorithm = %q, want RS256", algorithm)
+		}
+		if _, err := rsJWT.ValidateToken(edToken); err != nil {
+			t.Errorf("ValidateToken of a token signed before the switch: %v", err)
+		}
+		jwks := store.JWKS()
+		if len(jwks.Keys) != 2 || jwks.Keys[0].KeyType != "RSA" || jwks.Keys[0].N == "" || jwks.Keys[0].E == "" {
+			t.Errorf("JWKS = %+v, want the RSA key first", jwks.Keys)
+		}
+	})
+
+	t.Run("not while the active key is current", func(t *testing.T) {
+		store, _ := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", 24*time.Hour, time.Hour)
+		first := activeKeyID(t, store)
+
+		if err := store.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if got := activeKeyID(t, store); got != first {
+			t.Errorf("active kid = %q, want still %q", got, first)
+		}
+	})
+}
+
+func TestValidateTokenRejectsForeignKeys(t *testing.T) {
+	_, jwtService := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", 24*time.Hour, time.Hour)
+	_, otherJWT := newTestKeyStore(t, testutil.NewMemorySigningKeyRepository(), "EdDSA", 24*time.Hour, time.Hour)
+
+	foreign, err := otherJWT.GenerateToken("user-1")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := jwtService.ValidateToken(foreign); !errors.Is(err, services.ErrUnknownSigningKey) {
+		t.Errorf("ValidateToken of a token signed by another key store error = %v, want services.ErrUnknownSigningKey", err)
+	}
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{Subject: "user-1", Issuer: "auth-service", Audience: jwt.ClaimStrings{"api"}})
+	none, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := jwtService.ValidateToken(none); err == nil {
+		t.Error("ValidateToken of an unsigned token succeeded, want an error")
+	}
+}
+
+func TestNewKeyStoreRejectsUnsupportedAlgorithm(t *testing.T) {
+	if _, err := services.NewKeyStore(testutil.NewMemorySigningKeyRepository(), "HS256", time.Hour, time.Hour, logger.NewNopLogger()); err == nil {
+		t.Error("NewKeyStore(HS256) succeeded, want an error")
+	}
+}
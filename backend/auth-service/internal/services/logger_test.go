@@ -0,0 +1,42 @@
+package services_test
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// recordingLogger keeps the messages of warnings and errors, for tests.
+type recordingLogger struct {
+	mu     sync.Mutex
+	warns  []string
+	errors []string
+}
+
+func (l *recordingLogger) Info(msg string, fields ...zap.Field)  {}
+func (l *recordingLogger) Debug(msg string, fields ...zap.Field) {}
+func (l *recordingLogger) Sync() error                           { return nil }
+
+func (l *recordingLogger) Warn(msg string, fields ...zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns = append(l.warns, msg)
+}
+
+func (l *recordingLogger) Error(msg string, fields ...zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+}
+
+func (l *recordingLogger) Warnings() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.warns...)
+}
+
+func (l *recordingLogger) Errors() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.errors...)
+}
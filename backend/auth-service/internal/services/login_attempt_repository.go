@@ -0,0 +1,122 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const loginAttemptCollection = "login_attempts"
+
+// LoginAttemptRepository stores the failed login counters kept by
+// LoginThrottleService, keyed by "email:<tenant>:<address>" or "ip:<address>".
+type LoginAttemptRepository interface {
+	// Find returns the counters stored for any of the keys.
+	Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error)
+	// RecordFailure counts a failure at now, creating the counter if needed,
+	// keeps it at least until expiresAt and returns it as updated.
+	RecordFailure(ctx context.Context, key string, now, expiresAt time.Time) (*models.LoginAttempt, error)
+	// Lock locks the counter until lockedUntil, clearing its failures and
+	// counting the lockout, if it still has the given number of failures.
+	// It reports whether the counter was locked by this call.
+	Lock(ctx context.Context, key string, failures int, lockedUntil, expiresAt time.Time) (bool, error)
+	// ResetFailures clears the failures of a counter and keeps its lockouts.
+	ResetFailures(ctx context.Context, key string) error
+	// Delete removes a counter.
+	Delete(ctx context.Context, key string) error
+}
+
+// MongoLoginAttemptRepository stores failed login counters in the
+// login_attempts collection. A TTL index drops idle counters.
+type MongoLoginAttemptRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoLoginAttemptRepository creates a new MongoLoginAttemptRepository
+func NewMongoLoginAttemptRepository(mongoConfig *config.MongoDBConfig) *MongoLoginAttemptRepository {
+	return &MongoLoginAttemptRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the TTL index that expires idle failure counters.
+func (r *MongoLoginAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Find returns the counters stored for any of the keys.
+func (r *MongoLoginAttemptRepository) Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error) {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []models.LoginAttempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failure and returns the updated counter.
+func (r *MongoLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now, expiresAt time.Time) (*models.LoginAttempt, error) {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+
+	var attempt models.LoginAttempt
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{"lastFailureAt": now},
+			"$max": bson.M{"expiresAt": expiresAt},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Lock locks the counter if it still has the given number of failures.
+func (r *MongoLoginAttemptRepository) Lock(ctx context.Context, key string, failures int, lockedUntil, expiresAt time.Time) (bool, error) {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": key, "failures": failures},
+		bson.M{
+			"$set": bson.M{"failures": 0, "lockedUntil": lockedUntil, "expiresAt": expiresAt},
+			"$inc": bson.M{"lockouts": 1},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ResetFailures clears the failures of a counter.
+func (r *MongoLoginAttemptRepository) ResetFailures(ctx context.Context, key string) error {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{"failures": 0}},
+	)
+	return err
+}
+
+// Delete removes a counter.
+func (r *MongoLoginAttemptRepository) Delete(ctx context.Context, key string) error {
+	collection := r.mongoConfig.GetCollection(loginAttemptCollection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
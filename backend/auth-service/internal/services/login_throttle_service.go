@@ -0,0 +1,208 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxLockoutDuration caps the growth of repeated lockouts.
+const maxLockoutDuration = 24 * time.Hour
+
+var (
+	// ErrTooManyAttempts is returned while a login back-off delay is in effect.
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+	// ErrAccountLocked is returned while an account is temporarily locked.
+	ErrAccountLocked = errors.New("account temporarily locked")
+)
+
+// LoginBlockedError wraps ErrTooManyAttempts or ErrAccountLocked with the
+// time after which the client may try again.
+type LoginBlockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string { return e.Err.Error() }
+
+func (e *LoginBlockedError) Unwrap() error { return e.Err }
+
+// LoginThrottle decides whether a login may be attempted and counts the
+// failures. LoginThrottleService is the implementation; over a
+// testutil.MemoryLoginAttemptRepository it also serves tests.
+type LoginThrottle interface {
+	Check(ctx context.Context, tenantID, email, clientIP string) error
+	RecordFailure(ctx context.Context, tenantID, email, clientIP, userID string) error
+	Reset(ctx context.Context, tenantID, email string) error
+	Unlock(ctx context.Context, tenantID, email string) error
+}
+
+var _ LoginThrottle = (*LoginThrottleService)(nil)
+
+// LoginThrottleService tracks failed logins per account and per client IP.
+// Accounts are identified by tenant and email, since the same address may be
+// registered in several tenants.
+// Beyond a threshold each further attempt has to wait an exponentially
+// growing delay; accounts that keep failing are locked for a while, and
+// each repeated lockout lasts twice as long as the previous one.
+type LoginThrottleService struct {
+	attempts  LoginAttemptRepository
+	config    *config.LoginThrottleConfig
+	publisher *KafkaPublisher
+}
+
+// NewLoginThrottleService creates a new LoginThrottleService
+func NewLoginThrottleService(attempts LoginAttemptRepository, throttleConfig *config.LoginThrottleConfig, publisher *KafkaPublisher) *LoginThrottleService {
+	return &LoginThrottleService{
+		attempts:  attempts,
+		config:    throttleConfig,
+		publisher: publisher,
+	}
+}
+
+// Check returns a *LoginBlockedError if the account or the client IP may not
+// attempt a login right now.
+func (s *LoginThrottleService) Check(ctx context.Context, tenantID, email, clientIP string) error {
+	attempts, err := s.attempts.Find(ctx, emailAttemptKey(tenantID, email), ipAttemptKey(clientIP))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var blocked *LoginBlockedError
+	for _, attempt := range attempts {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			// A lockout outranks any back-off delay.
+			return &LoginBlockedError{Err: ErrAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+		}
+
+		threshold := s.config.BackoffThreshold
+		if strings.HasPrefix(attempt.ID, "ip:") {
+			threshold = s.config.IPBackoffThreshold
+		}
+		wait := attempt.LastFailureAt.Add(s.backoff(attempt.Failures, threshold)).Sub(now)
+		if wait > 0 && (blocked == nil || wait > blocked.RetryAfter) {
+			blocked = &LoginBlockedError{Err: ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login for the account and the client IP,
+// locks the account once it reaches the lockout threshold and publishes
+// security.login_failed.v1 and security.account_locked.v1. userID is empty
+// for unknown accounts.
+func (s *LoginThrottleService) RecordFailure(ctx context.Context, tenantID, email, clientIP, userID string) error {
+	now := time.Now().UTC()
+
+	account, err := s.recordFailure(ctx, emailAttemptKey(tenantID, email), now)
+	if err != nil {
+		return err
+	}
+	if clientIP != "" {
+		if _, err := s.recordFailure(ctx, ipAttemptKey(clientIP), now); err != nil {
+			return err
+		}
+	}
+
+	event := newSecurityEvent("security.login_failed.v1", tenantID, userID, email, clientIP, "invalid_credentials")
+	if err := s.publisher.PublishLoginFailed(ctx, event); err != nil {
+		// Throttling state is authoritative; the events feed monitoring only.
+	}
+
+	if account.Failures < s.config.LockoutThreshold {
+		return nil
+	}
+
+	lockedUntil := now.Add(s.lockoutDuration(account.Lockouts))
+
+	// Only the request that reached the threshold locks the account.
+	locked, err := s.attempts.Lock(ctx, account.ID, account.Failures, lockedUntil, lockedUntil.Add(s.config.FailureWindow))
+	if err != nil {
+		return err
+	}
+	if locked {
+		event := newSecurityEvent("security.account_locked.v1", tenantID, userID, email, clientIP, "too_many_failed_logins")
+		if err := s.publisher.PublishAccountLocked(ctx, event); err != nil {
+			// The lockout is already stored; the event feeds monitoring only.
+		}
+	}
+	return nil
+}
+
+// Reset clears the failure counter of an account after a successful login.
+// The lockout history is kept until it expires so repeated lockouts still
+// escalate.
+func (s *LoginThrottleService) Reset(ctx context.Context, tenantID, email string) error {
+	return s.attempts.ResetFailures(ctx, emailAttemptKey(tenantID, email))
+}
+
+// Unlock removes all failure and lockout state of an account.
+func (s *LoginThrottleService) Unlock(ctx context.Context, tenantID, email string) error {
+	return s.attempts.Delete(ctx, emailAttemptKey(tenantID, email))
+}
+
+func (s *LoginThrottleService) recordFailure(ctx context.Context, key string, now time.Time) (*models.LoginAttempt, error) {
+	return s.attempts.RecordFailure(ctx, key, now, now.Add(s.config.FailureWindow))
+}
+
+// backoff returns the delay required after the latest of failures failed
+// attempts: nothing below threshold, then the base delay doubling with each
+// further failure up to the configured maximum.
+func (s *LoginThrottleService) backoff(failures, threshold int) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+	exponent := failures - threshold
+	if exponent > 30 {
+		return s.config.BackoffMax
+	}
+	delay := s.config.BackoffBase << exponent
+	if delay <= 0 || delay > s.config.BackoffMax {
+		return s.config.BackoffMax
+	}
+	return delay
+}
+
+// lockoutDuration returns how long an account is locked after it was already
+// locked the given number of times: the configured duration, doubling with
+// each earlier lockout up to maxLockoutDuration.
+func (s *LoginThrottleService) lockoutDuration(lockouts int) time.Duration {
+	if lockouts > 30 {
+		return maxLockoutDuration
+	}
+	lockout := s.config.LockoutDuration << lockouts
+	if lockout <= 0 || lockout > maxLockoutDuration {
+		return maxLockoutDuration
+	}
+	return lockout
+}
+
+func newSecurityEvent(eventType, tenantID, userID, email, clientIP, reason string) models.SecurityEvent {
+	return models.SecurityEvent{
+		EventID:   primitive.NewObjectID().Hex(),
+		EventType: eventType,
+		Timestamp: time.Now().UTC(),
+		TenantID:  tenantID,
+		UserID:    userID,
+		Email:     email,
+		ClientIP:  clientIP,
+		Reason:    reason,
+	}
+}
+
+func emailAttemptKey(tenantID, email string) string {
+	return "email:" + tenantID + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(clientIP string) string {
+	return "ip:" + clientIP
+}
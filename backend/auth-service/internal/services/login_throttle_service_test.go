@@ -0,0 +1,177 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestLoginThrottle() (*services.LoginThrottleService, *testutil.MemoryLoginAttemptRepository) {
+	attempts := testutil.NewMemoryLoginAttemptRepository()
+	throttleConfig := config.NewLoginThrottleConfig(3, time.Minute, 10*time.Minute, 5, 15*time.Minute, 10, time.Hour)
+	return services.NewLoginThrottleService(attempts, throttleConfig, nil), attempts
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	s, _ := newTestLoginThrottle()
+
+	tests := []struct {
+		failures  int
+		threshold int
+		want      time.Duration
+	}{
+		{failures: 0, threshold: 3, want: 0},
+		{failures: 2, threshold: 3, want: 0},
+		{failures: 3, threshold: 3, want: time.Minute},
+		{failures: 4, threshold: 3, want: 2 * time.Minute},
+		{failures: 6, threshold: 3, want: 8 * time.Minute},
+		{failures: 7, threshold: 3, want: 10 * time.Minute},
+		{failures: 100, threshold: 3, want: 10 * time.Minute},
+		{failures: 5, threshold: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := s.Backoff(tt.failures, tt.threshold); got != tt.want {
+			t.Errorf("backoff(%d, %d) = %v, want %v", tt.failures, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleLockoutDuration(t *testing.T) {
+	s, _ := newTestLoginThrottle()
+
+	tests := []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{lockouts: 0, want: 15 * time.Minute},
+		{lockouts: 1, want: 30 * time.Minute},
+		{lockouts: 3, want: 2 * time.Hour},
+		{lockouts: 7, want: services.MaxLockoutDuration},
+		{lockouts: 64, want: services.MaxLockoutDuration},
+	}
+	for _, tt := range tests {
+		if got := s.LockoutDuration(tt.lockouts); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleBacksOffAtThreshold(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLoginThrottle()
+
+	for i := 1; i <= 3; i++ {
+		if err := s.Check(ctx, services.DefaultTenantID, "jane@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Check before failure %d: %v", i, err)
+		}
+		if err := s.RecordFailure(ctx, services.DefaultTenantID, "jane@example.com", "10.0.0.1", "user-1"); err != nil {
+			t.Fatalf("RecordFailure %d: %v", i, err)
+		}
+	}
+
+	err := s.Check(ctx, services.DefaultTenantID, "jane@example.com", "10.0.0.1")
+	var blocked *services.LoginBlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, services.ErrTooManyAttempts) {
+		t.Fatalf("Check at the back-off threshold error = %v, want services.ErrTooManyAttempts", err)
+	}
+	if blocked.RetryAfter <= 0 || blocked.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %v, want up to one minute", blocked.RetryAfter)
+	}
+
+	// The counter belongs to the account in its tenant only.
+	if err := s.Check(ctx, "other", "jane@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check of the same email in another tenant: %v", err)
+	}
+
+	if err := s.Reset(ctx, services.DefaultTenantID, "jane@example.com"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if err := s.Check(ctx, services.DefaultTenantID, "Jane@Example.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check after Reset: %v", err)
+	}
+}
+
+func TestLoginThrottleBacksOffPerClientIP(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLoginThrottle()
+
+	// Spread below the account threshold, but not below the IP threshold.
+	for i := 0; i < 10; i++ {
+		email := string(rune('a'+i)) + "@example.com"
+		if err := s.RecordFailure(ctx, services.DefaultTenantID, email, "10.0.0.1", ""); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	if err := s.Check(ctx, services.DefaultTenantID, "z@example.com", "10.0.0.1"); !errors.Is(err, services.ErrTooManyAttempts) {
+		t.Errorf("Check from the failing IP error = %v, want services.ErrTooManyAttempts", err)
+	}
+	if err := s.Check(ctx, services.DefaultTenantID, "z@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check from another IP: %v", err)
+	}
+}
+
+func TestLoginThrottleLocksAndEscalates(t *testing.T) {
+	ctx := context.Background()
+	s, attempts := newTestLoginThrottle()
+	key := services.EmailAttemptKey(services.DefaultTenantID, "jane@example.com")
+
+	lockedFor := func() time.Duration {
+		t.Helper()
+		found, err := attempts.Find(ctx, key)
+		if err != nil || len(found) != 1 || found[0].LockedUntil == nil {
+			t.Fatalf("Find = %+v, %v, want a locked counter", found, err)
+		}
+		return time.Until(*found[0].LockedUntil)
+	}
+
+	for round, want := range []time.Duration{15 * time.Minute, 30 * time.Minute} {
+		for i := 0; i < 5; i++ {
+			if err := s.RecordFailure(ctx, services.DefaultTenantID, "jane@example.com", "", "user-1"); err != nil {
+				t.Fatalf("RecordFailure: %v", err)
+			}
+		}
+
+		err := s.Check(ctx, services.DefaultTenantID, "jane@example.com", "")
+		if !errors.Is(err, services.ErrAccountLocked) {
+			t.Fatalf("lockout %d: Check error = %v, want services.ErrAccountLocked", round+1, err)
+		}
+		if got := lockedFor(); got <= want-time.Minute || got > want {
+			t.Errorf("lockout %d lasts %v, want %v", round+1, got, want)
+		}
+	}
+
+	if err := s.Unlock(ctx, services.DefaultTenantID, "jane@example.com"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := s.Check(ctx, services.DefaultTenantID, "jane@example.com", ""); err != nil {
+		t.Errorf("Check after Unlock: %v", err)
+	}
+}
+
+func TestLoginThrottleLocksOnce(t *testing.T) {
+	ctx := context.Background()
+	s, attempts := newTestLoginThrottle()
+	key := services.EmailAttemptKey(services.DefaultTenantID, "jane@example.com")
+
+	for i := 0; i < 5; i++ {
+		if err := s.RecordFailure(ctx, services.DefaultTenantID, "jane@example.com", "", ""); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	// A concurrent request that saw the same failure count must not lock
+	// the account a second time.
+	locked, err := attempts.Lock(ctx, key, 5, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+	if err != nil || locked {
+		t.Errorf("second Lock = %v, %v, want false", locked, err)
+	}
+	found, _ := attempts.Find(ctx, key)
+	if len(found) != 1 || found[0].Lockouts != 1 {
+		t.Errorf("counter = %+v, want one lockout", found)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PurposeMagicLink marks one-time tokens sent to sign in without a password.
+const PurposeMagicLink = "magic_link"
+
+// AMREmailLink is the amr value of a login through an emailed link.
+const AMREmailLink = "email"
+
+var (
+	// ErrMagicLinkThrottled is returned when a magic link was sent too recently.
+	ErrMagicLinkThrottled = errors.New("magic link email sent too recently")
+	// ErrMagicLinkNonceMismatch is returned when a magic link is opened in a
+	// browser other than the one that requested it.
+	ErrMagicLinkNonceMismatch = errors.New("magic link was requested from another browser")
+)
+
+// MagicLinkService emails single-use sign-in links and logs the user in when
+// one is followed. Each link is bound to a nonce kept by the browser that
+// asked for it, so a link forwarded or intercepted elsewhere is useless.
+type MagicLinkService struct {
+	users          UserRepository
+	authService    *AuthService
+	tokens         *OneTimeTokenService
+	mailer         Mailer
+	baseURL        string
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// NewMagicLinkService creates a new MagicLinkService. Links point at
+// baseURL + "/magic-link" and stay valid for ttl; resendInterval is the
+// minimum time between two emails for the same account.
+func NewMagicLinkService(
+	users UserRepository,
+	authService *AuthService,
+	tokens *OneTimeTokenService,
+	mailer Mailer,
+	baseURL string,
+	ttl time.Duration,
+	resendInterval time.Duration,
+) *MagicLinkService {
+	return &MagicLinkService{
+		users:          users,
+		authService:    authService,
+		tokens:         tokens,
+		mailer:         mailer,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		ttl:            ttl,
+		resendInterval: resendInterval,
+	}
+}
+
+// Send emails a sign-in link bound to nonce to the active account of the
+// tenant with the given address. A new link invalidates the previous one. Unknown and
+// unverified addresses are ignored so callers cannot probe which accounts
+// exist; ErrMagicLinkThrottled is returned if the previous email is more
+// recent than the resend interval.
+func (s *MagicLinkService) Send(ctx context.Context, tenantID, email, nonce string) error {
+	user, err := s.users.FindByEmail(ctx, tenantID, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status != "active" {
+		return nil
+	}
+
+	lastSent, err := s.tokens.LastIssued(ctx, PurposeMagicLink, user.ID)
+	if err != nil {
+		return err
+	}
+	if time.Since(lastSent) < s.resendInterval {
+		return ErrMagicLinkThrottled
+	}
+
+	token, err := s.tokens.Issue(ctx, PurposeMagicLink, user.ID, s.ttl, map[string]string{
+		"email":  user.Email,
+		"nonce":  HashOpaqueToken(nonce),
+		"tenant": user.TenantID,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/magic-link?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below in the same browser you asked for it from to sign in:\n\n%s\n\n"+
+			"The link expires in %s and works once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, link, s.ttl),
+	})
+}
+
+// Consume signs the user in with a magic link token and the nonce of the
+// browser that requested it. A nonce mismatch fails with
+// ErrMagicLinkNonceMismatch and leaves the link usable in the right browser.
+// Users with MFA enabled receive an "mfa_required" response. The user signs
+// in to the tenant the link was issued in, whatever tenant the request names.
+func (s *MagicLinkService) Consume(ctx context.Context, token, nonce string, client models.ClientInfo) (*models.LoginResponse, error) {
+	record, err := s.tokens.Peek(ctx, PurposeMagicLink, token)
+	if err != nil {
+		s.authService.auditLoginFailure(ctx, "", "", err, client)
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(HashOpaqueToken(nonce)), []byte(record.Data["nonce"])) != 1 {
+		s.authService.auditLoginFailure(ctx, record.Subject, record.Data["email"], ErrMagicLinkNonceMismatch, client)
+		return nil, ErrMagicLinkNonceMismatch
+	}
+
+	// The account may have changed its address or been disabled since the
+	// link was sent.
+	user, err := s.authService.GetUser(tokenTenant(record, client), record.Subject)
+	if err != nil || user.Email != record.Data["email"] || user.Status != "active" {
+		s.authService.auditLoginFailure(ctx, record.Subject, record.Data["email"], ErrInvalidOneTimeToken, client)
+		return nil, ErrInvalidOneTimeToken
+	}
+	client.TenantID = user.TenantID
+
+	if _, err := s.tokens.Consume(ctx, PurposeMagicLink, token); err != nil {
+		return nil, err
+	}
+
+	return s.authService.BeginLogin(user.ID, []string{AMREmailLink}, client)
+}
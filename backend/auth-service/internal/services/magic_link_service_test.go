@@ -0,0 +1,224 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNonce = "browser-nonce"
+
+// testMagicLink is a MagicLinkService over the testAuth of the tests.
+type testMagicLink struct {
+	*services.MagicLinkService
+	*testAuth
+	tokens *testutil.MemoryOneTimeTokenRepository
+	mailer *testutil.MemoryMailer
+}
+
+func newTestMagicLink(t *testing.T, resendInterval time.Duration) *testMagicLink {
+	t.Helper()
+
+	tokens, repository := testutil.NewMemoryOneTimeTokenService()
+	m := &testMagicLink{
+		testAuth: newTestAuthService(t),
+		tokens:   repository,
+		mailer:   testutil.NewMemoryMailer(),
+	}
+	m.MagicLinkService = services.NewMagicLinkService(m.users, m.AuthService, tokens, m.mailer,
+		"https://app.example.com", 15*time.Minute, resendInterval)
+	return m
+}
+
+// send asks for a sign-in link from the browser with testNonce and returns
+// its token.
+func (m *testMagicLink) send(t *testing.T, tenantID, email string) string {
+	t.Helper()
+
+	if err := m.Send(context.Background(), tenantID, email, testNonce); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	return lastLink(t, m.mailer, email)
+}
+
+func TestMagicLinkSignsInOnce(t *testing.T) {
+	m := newTestMagicLink(t, time.Minute)
+	user := m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	token := m.send(t, services.DefaultTenantID, "jane@example.com")
+	if body := m.mailer.Messages()[0].Body; !strings.Contains(body, "https://app.example.com/magic-link?token=") {
+		t.Errorf("email body = %q, want a link to /magic-link", body)
+	}
+
+	login, err := m.Consume(context.Background(), token, testNonce, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	claims := m.claims(t, login.Token)
+	if claims.UserID != user.ID || len(claims.AMR) != 1 || claims.AMR[0] != services.AMREmailLink {
+		t.Errorf("claims = %+v, want %s signed in by email link", claims, user.ID)
+	}
+	if got := m.lastAudit(t); got.EventType != services.AuditLogin || got.Outcome != services.AuditOutcomeSuccess {
+		t.Errorf("audit event = %+v, want a successful login", got)
+	}
+
+	if _, err := m.Consume(context.Background(), token, testNonce, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Consume of a used link error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+	if got := m.lastAudit(t); got.Outcome != services.AuditOutcomeFailure || got.Reason != "invalid_magic_link" {
+		t.Errorf("audit event = %+v, want a failed login for invalid_magic_link", got)
+	}
+}
+
+func TestMagicLinkFromAnotherBrowser(t *testing.T) {
+	m := newTestMagicLink(t, time.Minute)
+	user := m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	token := m.send(t, services.DefaultTenantID, "jane@example.com")
+
+	if _, err := m.Consume(context.Background(), token, "other-nonce", client(services.DefaultTenantID)); !errors.Is(err, services.ErrMagicLinkNonceMismatch) {
+		t.Fatalf("Consume with another nonce error = %v, want services.ErrMagicLinkNonceMismatch", err)
+	}
+	if got := m.lastAudit(t); got.UserID != user.ID || got.Outcome != services.AuditOutcomeFailure || got.Reason != "invalid_magic_link" {
+		t.Errorf("audit event = %+v, want a failed login of %s", got, user.ID)
+	}
+
+	// The link still works in the browser that asked for it.
+	if _, err := m.Consume(context.Background(), token, testNonce, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Consume in the requesting browser: %v", err)
+	}
+}
+
+func TestMagicLinkRequiresSecondFactor(t *testing.T) {
+	m := newTestMagicLink(t, time.Minute)
+	user := m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	m.mfa.Enable(user.ID, "123456")
+	token := m.send(t, services.DefaultTenantID, "jane@example.com")
+
+	login, err := m.Consume(context.Background(), token, testNonce, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if login.Status != "mfa_required" || login.Token != "" || login.MFAToken == "" {
+		t.Fatalf("login = %+v, want mfa_required without tokens", login)
+	}
+	verified, err := m.VerifyMFA(login.MFAToken, "123456", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	if amr := m.claims(t, verified.Token).AMR; len(amr) < 2 || amr[0] != services.AMREmailLink || amr[len(amr)-1] != services.AMRMultiFactor {
+		t.Errorf("amr = %v, want the email link and mfa", amr)
+	}
+}
+
+func TestMagicLinkRejects(t *testing.T) {
+	ctx := context.Background()
+	userID := "jane@example.com@" + services.DefaultTenantID
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, m *testMagicLink, token string) string
+	}{
+		{
+			name: "expired link",
+			setup: func(t *testing.T, m *testMagicLink, token string) string {
+				m.tokens.Expire()
+				return token
+			},
+		},
+		{
+			name: "link superseded by a newer one",
+			setup: func(t *testing.T, m *testMagicLink, token string) string {
+				m.send(t, services.DefaultTenantID, "jane@example.com")
+				return token
+			},
+		},
+		{
+			name: "address changed since",
+			setup: func(t *testing.T, m *testMagicLink, token string) string {
+				if err := m.users.UpdateProfile(ctx, services.DefaultTenantID, userID, "", "other@example.com"); err != nil {
+					t.Fatalf("UpdateProfile: %v", err)
+				}
+				return token
+			},
+		},
+		{
+			name: "account deleted since",
+			setup: func(t *testing.T, m *testMagicLink, token string) string {
+				if err := m.users.Delete(ctx, services.DefaultTenantID, userID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+				return token
+			},
+		},
+		{
+			name: "forged signature",
+			setup: func(t *testing.T, m *testMagicLink, token string) string {
+				value, _, _ := strings.Cut(token, ".")
+				return value + ".forged"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMagicLink(t, 0)
+			m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+			token := tt.setup(t, m, m.send(t, services.DefaultTenantID, "jane@example.com"))
+
+			if _, err := m.Consume(ctx, token, testNonce, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+				t.Errorf("Consume error = %v, want services.ErrInvalidOneTimeToken", err)
+			}
+		})
+	}
+}
+
+func TestMagicLinkStaysInTheTenantOfTheLink(t *testing.T) {
+	m := newTestMagicLink(t, time.Minute)
+	m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	closed := m.addUser(t, "closed", "jane@example.com", "customer")
+	token := m.send(t, "closed", "jane@example.com")
+
+	// The link is followed on the host of the default tenant.
+	login, err := m.Consume(context.Background(), token, testNonce, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if claims := m.claims(t, login.Token); claims.UserID != closed.ID || claims.Tenant() != "closed" {
+		t.Errorf("claims = %+v, want the account of the closed tenant", claims)
+	}
+}
+
+func TestMagicLinkSend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ignores unknown, inactive and other tenants' addresses", func(t *testing.T) {
+		m := newTestMagicLink(t, 0)
+		addAccount(t, m.users, services.DefaultTenantID, "pending@example.com", "pending_verification")
+		m.addUser(t, "closed", "jane@example.com", "customer")
+
+		for _, email := range []string{"unknown@example.com", "pending@example.com", "jane@example.com"} {
+			if err := m.Send(ctx, services.DefaultTenantID, email, testNonce); err != nil {
+				t.Errorf("Send(%s): %v", email, err)
+			}
+		}
+		if messages := m.mailer.Messages(); len(messages) != 0 {
+			t.Errorf("emails = %+v, want none", messages)
+		}
+	})
+
+	t.Run("throttles", func(t *testing.T) {
+		m := newTestMagicLink(t, time.Hour)
+		m.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+		m.send(t, services.DefaultTenantID, "jane@example.com")
+		if err := m.Send(ctx, services.DefaultTenantID, "jane@example.com", testNonce); !errors.Is(err, services.ErrMagicLinkThrottled) {
+			t.Errorf("second Send error = %v, want services.ErrMagicLinkThrottled", err)
+		}
+		if messages := m.mailer.Messages(); len(messages) != 1 {
+			t.Errorf("sent %d emails, want 1", len(messages))
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer creates the Mailer selected by the configured driver: "smtp"
+// delivers through an SMTP server, "file" writes messages to an outbox
+// directory for local development and tests.
+func NewMailer(mailConfig *config.MailConfig) (Mailer, error) {
+	switch mailConfig.Driver {
+	case "smtp":
+		if mailConfig.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mail driver")
+		}
+		return NewSMTPMailer(mailConfig), nil
+	case "file":
+		return NewFileMailer(mailConfig.OutboxDir, mailConfig.From)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", mailConfig.Driver)
+	}
+}
+
+// SMTPMailer delivers email through an SMTP server, upgrading to TLS with
+// STARTTLS when the server supports it.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPMailer creates a new SMTPMailer.
+func NewSMTPMailer(mailConfig *config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(mailConfig.SMTPHost, strconv.Itoa(mailConfig.SMTPPort)),
+		host:     mailConfig.SMTPHost,
+		from:     mailConfig.From,
+		username: mailConfig.SMTPUsername,
+		password: mailConfig.SMTPPassword,
+	}
+}
+
+// Send delivers the message.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := formatMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	// formatMessage has already validated both addresses.
+	sender, _ := mail.ParseAddress(m.from)
+	recipient, _ := mail.ParseAddress(msg.To)
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	// net/smtp has no context support, so bound the call from the outside.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, auth, sender.Address, []string{recipient.Address}, raw)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FileMailer writes each message as an .eml file to a directory instead of
+// sending it.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a FileMailer, creating the outbox directory if needed.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create mail outbox: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes the message to the outbox.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := formatMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	suffix, err := newTokenID()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), suffix[:8])
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o600)
+}
+
+// formatMessage renders an RFC 5322 message. Addresses are parsed and the
+// subject is encoded so header values cannot inject extra headers.
+func formatMessage(from string, msg Message) ([]byte, error) {
+	fromAddress, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	toAddress, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", fromAddress.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", toAddress.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,435 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	mfaFactorCollection    = "mfa_factors"
+	mfaChallengeCollection = "mfa_challenges"
+
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is the number of periods accepted on either side of now to
+	// tolerate clock drift between the server and the authenticator.
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+// A login challenge expires after MFAChallengeTTL and accepts at most
+// MFAChallengeMaxAttempts codes.
+const (
+	MFAChallengeTTL         = 5 * time.Minute
+	MFAChallengeMaxAttempts = 5
+)
+
+// Authentication method references recorded in the amr claim (RFC 8176).
+const (
+	AMRPassword     = "pwd"
+	AMROneTimeCode  = "otp"
+	AMRMultiFactor  = "mfa"
+	AMRRecoveryCode = "rec"
+)
+
+var (
+	// ErrMFANotEnrolled is returned when no TOTP enrollment is in progress or confirmed.
+	ErrMFANotEnrolled = errors.New("mfa is not enrolled")
+	// ErrMFAAlreadyEnabled is returned when enrolling while MFA is already active.
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	// ErrInvalidMFACode is returned for wrong, reused or expired codes.
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+	// ErrInvalidMFAChallenge is returned for unknown, expired or exhausted challenges.
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+)
+
+// MFAVerifier checks the second factor of users who enabled MFA and keeps the
+// challenges between the two steps of a login. MFAService is the production
+// implementation and testutil.MemoryMFAVerifier the one for tests.
+type MFAVerifier interface {
+	Enabled(ctx context.Context, userID string) (bool, error)
+	VerifyCode(ctx context.Context, userID, code string) (string, error)
+	NewChallenge(ctx context.Context, tenantID, userID string, amr []string) (string, error)
+	CompleteChallenge(ctx context.Context, token, code string) (*models.MFAChallenge, string, error)
+}
+
+var _ MFAVerifier = (*MFAService)(nil)
+
+// MFAService manages TOTP second factors, recovery codes and the challenges
+// that bridge the password step and the second step of a login.
+type MFAService struct {
+	mongoConfig *config.MongoDBConfig
+	issuer      string
+}
+
+// NewMFAService creates a new MFAService. issuer is the account issuer shown
+// in authenticator apps.
+func NewMFAService(mongoConfig *config.MongoDBConfig, issuer string) *MFAService {
+	return &MFAService{
+		mongoConfig: mongoConfig,
+		issuer:      issuer,
+	}
+}
+
+// EnsureIndexes creates the TTL index for login challenges.
+func (s *MFAService) EnsureIndexes(ctx context.Context) error {
+	collection := s.mongoConfig.GetCollection(mfaChallengeCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Enabled reports whether the user has a confirmed TOTP factor.
+func (s *MFAService) Enabled(ctx context.Context, userID string) (bool, error) {
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": userID, "confirmed": true})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Enroll generates a new TOTP secret for the user. Any unconfirmed enrollment
+// is replaced; an active factor must be disabled first.
+func (s *MFAService) Enroll(ctx context.Context, userID, accountName string) (*models.TOTPEnrollmentResponse, error) {
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"_id": userID, "confirmed": false},
+		models.MFAFactor{
+			UserID:    userID,
+			Secret:    secret,
+			CreatedAt: time.Now().UTC(),
+		},
+		options.Replace().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// The upsert collided with a confirmed factor.
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: s.otpauthURI(secret, accountName),
+	}, nil
+}
+
+// otpauthURI builds the Key URI understood by authenticator apps.
+func (s *MFAService) otpauthURI(secret, accountName string) string {
+	params := url.Values{
+		"secret":    {secret},
+		"issuer":    {s.issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(s.issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Confirm activates a pending enrollment once the user proves they can
+// generate codes, and returns the initial recovery codes.
+func (s *MFAService) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+
+	var factor models.MFAFactor
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&factor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.Confirmed {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(factor.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "confirmed": false, "secret": factor.Secret},
+		bson.M{"$set": bson.M{
+			"confirmed":     true,
+			"confirmedAt":   now,
+			"lastUsedStep":  step,
+			"recoveryCodes": hashes,
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		// Re-enrolled or confirmed concurrently.
+		return nil, ErrInvalidMFACode
+	}
+
+	return codes, nil
+}
+
+// Disable removes the user's factor after verifying a TOTP or recovery code.
+func (s *MFAService) Disable(ctx context.Context, userID, code string) error {
+	if _, err := s.VerifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	factor, err := s.confirmedFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.useTOTP(ctx, factor, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"recoveryCodes": hashes}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyCode checks a TOTP or recovery code for a user with MFA enabled and
+// returns the amr value of the method used. TOTP codes cannot be replayed and
+// recovery codes are consumed.
+func (s *MFAService) VerifyCode(ctx context.Context, userID, code string) (string, error) {
+	factor, err := s.confirmedFactor(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		if err := s.useTOTP(ctx, factor, code); err != nil {
+			return "", err
+		}
+		return AMROneTimeCode, nil
+	}
+
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+	codeHash := HashOpaqueToken(normalizeRecoveryCode(code))
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "confirmed": true, "recoveryCodes": codeHash},
+		bson.M{"$pull": bson.M{"recoveryCodes": codeHash}},
+	)
+	if err != nil {
+		return "", err
+	}
+	if result.ModifiedCount == 0 {
+		return "", ErrInvalidMFACode
+	}
+	return AMRRecoveryCode, nil
+}
+
+func (s *MFAService) confirmedFactor(ctx context.Context, userID string) (*models.MFAFactor, error) {
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+
+	var factor models.MFAFactor
+	err := collection.FindOne(ctx, bson.M{"_id": userID, "confirmed": true}).Decode(&factor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &factor, nil
+}
+
+// useTOTP verifies a TOTP code and records its time step so the same code
+// cannot be used again.
+func (s *MFAService) useTOTP(ctx context.Context, factor *models.MFAFactor, code string) error {
+	step, ok := matchTOTP(factor.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	collection := s.mongoConfig.GetCollection(mfaFactorCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": factor.UserID, "lastUsedStep": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"lastUsedStep": step}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// NewChallenge starts the second step of a login and returns the opaque
+// challenge token handed to the client. amr lists the methods the user of the
+// tenant completed in the first step.
+func (s *MFAService) NewChallenge(ctx context.Context, tenantID, userID string, amr []string) (string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	collection := s.mongoConfig.GetCollection(mfaChallengeCollection)
+	_, err = collection.InsertOne(ctx, models.MFAChallenge{
+		ID:        HashOpaqueToken(token),
+		TenantID:  tenantID,
+		UserID:    userID,
+		AMR:       amr,
+		ExpiresAt: time.Now().UTC().Add(MFAChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CompleteChallenge verifies the code for a login challenge and returns the
+// challenge and the amr value of the method used. Each challenge allows a
+// limited number of attempts and is deleted once it succeeds. A wrong code
+// yields ErrInvalidMFACode along with the challenge, so the failure can be
+// counted against its user.
+func (s *MFAService) CompleteChallenge(ctx context.Context, token, code string) (*models.MFAChallenge, string, error) {
+	collection := s.mongoConfig.GetCollection(mfaChallengeCollection)
+	challengeID := HashOpaqueToken(token)
+
+	var challenge models.MFAChallenge
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":       challengeID,
+			"attempts":  bson.M{"$lt": MFAChallengeMaxAttempts},
+			"expiresAt": bson.M{"$gt": time.Now().UTC()},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+	).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, "", ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	method, err := s.VerifyCode(ctx, challenge.UserID, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		return &challenge, "", err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": challengeID})
+	if err != nil {
+		return nil, "", err
+	}
+	if result.DeletedCount == 0 {
+		// Completed concurrently with another request.
+		return nil, "", ErrInvalidMFAChallenge
+	}
+
+	return &challenge, method, nil
+}
+
+// matchTOTP checks the code against the time steps around now and returns
+// the matching step.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the RFC 6238 code for a time step (HOTP with SHA-1).
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// recoveryCodeAlphabet is the lowercase base32 alphabet; its 32 symbols map
+// evenly onto random bytes.
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// newRecoveryCodes returns formatted recovery codes and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for j, b := range buf {
+			buf[j] = recoveryCodeAlphabet[b&31]
+		}
+		codes[i] = string(buf[:5]) + "-" + string(buf[5:])
+		hashes[i] = HashOpaqueToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case and separator insensitive.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
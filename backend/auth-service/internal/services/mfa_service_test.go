@@ -0,0 +1,210 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"encoding/base32"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors.
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	// The RFC lists eight digits; codes are the last six.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		if got := services.TOTPCode(key, tt.unix/30); got != tt.want {
+			t.Errorf("TOTPCode at %d = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTPToleratesOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / 30
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name   string
+		code   string
+		want   bool
+		wantAt int64
+	}{
+		{name: "current step", code: services.TOTPCode(key, step), want: true, wantAt: step},
+		{name: "previous step", code: services.TOTPCode(key, step-1), want: true, wantAt: step - 1},
+		{name: "next step", code: services.TOTPCode(key, step+1), want: true, wantAt: step + 1},
+		{name: "two steps old", code: services.TOTPCode(key, step-2)},
+		{name: "wrong length", code: "12345"},
+		{name: "empty", code: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := services.MatchTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.want {
+				t.Fatalf("MatchTOTP ok = %v, want %v", ok, tt.want)
+			}
+			if ok && got != tt.wantAt {
+				t.Errorf("MatchTOTP step = %d, want %d", got, tt.wantAt)
+			}
+		})
+	}
+
+	if _, ok := services.MatchTOTP("not base32!", services.TOTPCode(key, step), now); ok {
+		t.Error("MatchTOTP accepted a code for an undecodable secret")
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := services.NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("NewRecoveryCodes: %v", err)
+	}
+	if len(codes) != 10 || len(hashes) != len(codes) {
+		t.Fatalf("got %d codes and %d hashes, want 10 of each", len(codes), len(hashes))
+	}
+
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not look like xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+		// Only hashes are stored, and they match however the code is typed.
+		if hashes[i] == code || hashes[i] != services.HashOpaqueToken(services.NormalizeRecoveryCode(code)) {
+			t.Errorf("hash of %q = %q, want the hash of the normalized code", code, hashes[i])
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	for _, typed := range []string{"abcde-fghij", "ABCDE-FGHIJ", "abcde fghij", "abcdefghij"} {
+		if got := services.NormalizeRecoveryCode(typed); got != "abcdefghij" {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want abcdefghij", typed, got)
+		}
+	}
+}
+
+func TestMFAChallengeAllowsLimitedAttempts(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	response, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	for i := 0; i < services.MFAChallengeMaxAttempts; i++ {
+		if _, err := a.VerifyMFA(response.MFAToken, "000000", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA %d error = %v, want services.ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	// The right code no longer helps once the attempts are used up.
+	if _, err := a.VerifyMFA(response.MFAToken, "123456", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFAChallenge) {
+		t.Errorf("VerifyMFA after the last attempt error = %v, want services.ErrInvalidMFAChallenge", err)
+	}
+	if _, err := a.VerifyMFA("unknown-token", "123456", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFAChallenge) {
+		t.Errorf("VerifyMFA with an unknown challenge error = %v, want services.ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestWrongMFACodesLockTheAccount(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	// Each challenge allows a few attempts, but every wrong code counts
+	// against the account, so new challenges do not reset the budget.
+	for i := 0; i < 3; i++ {
+		response, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+		if err != nil {
+			t.Fatalf("Login %d: %v", i+1, err)
+		}
+		if _, err := a.VerifyMFA(response.MFAToken, "000000", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA %d error = %v, want services.ErrInvalidMFACode", i+1, err)
+		}
+	}
+	if got := a.lastAudit(t); got.UserID != user.ID || got.Outcome != services.AuditOutcomeFailure {
+		t.Errorf("audit event = %+v, want a failure of %s", got, user.ID)
+	}
+
+	if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); !errors.Is(err, services.ErrAccountLocked) {
+		t.Errorf("Login after wrong codes error = %v, want services.ErrAccountLocked", err)
+	}
+}
+
+func TestCheckSecondFactorThrottlesWrongCodes(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	for i := 0; i < 3; i++ {
+		checked, err := a.CheckCredentials("jane@example.com", testPassword, client(services.DefaultTenantID))
+		if err != nil {
+			t.Fatalf("CheckCredentials %d: %v", i+1, err)
+		}
+		if _, err := a.CheckSecondFactor(checked, "000000", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidMFACode) {
+			t.Fatalf("CheckSecondFactor %d error = %v, want services.ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	if _, err := a.CheckCredentials("jane@example.com", testPassword, client(services.DefaultTenantID)); !errors.Is(err, services.ErrAccountLocked) {
+		t.Errorf("CheckCredentials after wrong codes error = %v, want services.ErrAccountLocked", err)
+	}
+}
+
+func TestCheckSecondFactorResetsThrottleOnlyWhenPassed(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	failPassword := func() {
+		t.Helper()
+		if _, err := a.CheckCredentials("jane@example.com", "wrong-password", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+			t.Fatalf("CheckCredentials error = %v, want services.ErrInvalidCredentials", err)
+		}
+	}
+
+	// The right password alone does not forget earlier failures.
+	failPassword()
+	failPassword()
+	checked, err := a.CheckCredentials("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("CheckCredentials: %v", err)
+	}
+	if _, err := a.CheckSecondFactor(checked, "", client(services.DefaultTenantID)); !errors.Is(err, services.ErrMFARequired) {
+		t.Fatalf("CheckSecondFactor without a code error = %v, want services.ErrMFARequired", err)
+	}
+	amr, err := a.CheckSecondFactor(checked, "123456", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("CheckSecondFactor: %v", err)
+	}
+	if len(amr) != 3 || amr[0] != services.AMRPassword || amr[1] != services.AMROneTimeCode || amr[2] != services.AMRMultiFactor {
+		t.Errorf("amr = %v, want pwd otp mfa", amr)
+	}
+
+	// Passing both factors does: two more failures stay below the threshold.
+	failPassword()
+	failPassword()
+	if _, err := a.CheckCredentials("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("CheckCredentials after a complete login: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const oauthClientCollection = "oauth_clients"
+
+// OAuthClientRepository stores the registered OAuth clients. Unknown client
+// IDs yield ErrOAuthClientNotFound. MongoOAuthClientRepository is the
+// production implementation and testutil.MemoryOAuthClientRepository the one
+// for tests.
+type OAuthClientRepository interface {
+	// Insert stores a new client.
+	Insert(ctx context.Context, client *models.OAuthClient) error
+	// FindByID returns the client with the ID.
+	FindByID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	// List returns every client.
+	List(ctx context.Context) ([]models.OAuthClient, error)
+	// UpdateRegistration replaces the name, grant types, redirect URIs,
+	// allowed scopes and update time of the client with the ID of client.
+	UpdateRegistration(ctx context.Context, client *models.OAuthClient) error
+	// Delete removes a client.
+	Delete(ctx context.Context, clientID string) error
+	// ReplaceSecret sets the secret hash of the client if its current hash is
+	// still currentHash. The current secret stays valid as the previous one
+	// until previousExpiresAt, or is dropped if previousExpiresAt is nil.
+	ReplaceSecret(ctx context.Context, clientID, currentHash, secretHash string, previousExpiresAt *time.Time, now time.Time) error
+}
+
+var _ OAuthClientRepository = (*MongoOAuthClientRepository)(nil)
+
+// MongoOAuthClientRepository stores OAuth clients in the oauth_clients
+// collection.
+type MongoOAuthClientRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoOAuthClientRepository creates a new MongoOAuthClientRepository
+func NewMongoOAuthClientRepository(mongoConfig *config.MongoDBConfig) *MongoOAuthClientRepository {
+	return &MongoOAuthClientRepository{mongoConfig: mongoConfig}
+}
+
+// Insert stores a new client.
+func (r *MongoOAuthClientRepository) Insert(ctx context.Context, client *models.OAuthClient) error {
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	_, err := collection.InsertOne(ctx, client)
+	return err
+}
+
+// FindByID returns the client with the ID.
+func (r *MongoOAuthClientRepository) FindByID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	var client models.OAuthClient
+	err := collection.FindOne(ctx, bson.M{"_id": clientID}).Decode(&client)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// List returns every client.
+func (r *MongoOAuthClientRepository) List(ctx context.Context) ([]models.OAuthClient, error) {
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	clients := []models.OAuthClient{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// UpdateRegistration replaces the registration of a client.
+func (r *MongoOAuthClientRepository) UpdateRegistration(ctx context.Context, client *models.OAuthClient) error {
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": client.ID}, bson.M{
+		"$set": bson.M{
+			"name":          client.Name,
+			"grantTypes":    client.GrantTypes,
+			"redirectUris":  client.RedirectURIs,
+			"allowedScopes": client.AllowedScopes,
+			"updatedAt":     client.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// Delete removes a client.
+func (r *MongoOAuthClientRepository) Delete(ctx context.Context, clientID string) error {
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": clientID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// ReplaceSecret sets the secret hash of a client whose hash is still
+// currentHash.
+func (r *MongoOAuthClientRepository) ReplaceSecret(ctx context.Context, clientID, currentHash, secretHash string, previousExpiresAt *time.Time, now time.Time) error {
+	set := bson.M{"secretHash": secretHash, "updatedAt": now}
+	update := bson.M{"$set": set}
+	if previousExpiresAt != nil {
+		set["previousSecretHash"] = currentHash
+		set["previousSecretExpiresAt"] = *previousExpiresAt
+	} else {
+		update["$unset"] = bson.M{"previousSecretHash": "", "previousSecretExpiresAt": ""}
+	}
+
+	collection := r.mongoConfig.GetCollection(oauthClientCollection)
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": clientID, "secretHash": currentHash}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
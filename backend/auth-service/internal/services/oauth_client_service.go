@@ -0,0 +1,249 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrOAuthClientNotFound is returned for unknown client IDs.
+	ErrOAuthClientNotFound = errors.New("oauth client not found")
+	// ErrInvalidClientCredentials is returned when client authentication fails.
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+	// ErrInvalidOAuthClient is returned when a client registration is inconsistent.
+	ErrInvalidOAuthClient = errors.New("invalid oauth client")
+	// ErrPublicOAuthClient is returned when rotating the secret of a public client.
+	ErrPublicOAuthClient = errors.New("public clients have no secret")
+)
+
+// OAuthClientService manages the registry of OAuth clients, including the
+// service accounts that use the client_credentials grant.
+type OAuthClientService struct {
+	repository      OAuthClientRepository
+	passwords       *PasswordService
+	rotationOverlap time.Duration
+}
+
+// NewOAuthClientService creates a new OAuthClientService. Client secrets are
+// hashed with the password hasher; after a rotation the previous secret stays
+// valid for rotationOverlap unless the request says otherwise.
+func NewOAuthClientService(repository OAuthClientRepository, passwords *PasswordService, rotationOverlap time.Duration) *OAuthClientService {
+	return &OAuthClientService{
+		repository:      repository,
+		passwords:       passwords,
+		rotationOverlap: rotationOverlap,
+	}
+}
+
+// Create registers a client. For confidential clients the generated secret is
+// returned once and only its hash is stored.
+func (s *OAuthClientService) Create(req models.OAuthClientRequest) (*models.OAuthClientResponse, error) {
+	grantTypes := grantTypesOf(req)
+	if err := validateClient(grantTypes, req.RedirectURIs, req.AllowedScopes, req.Public); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	client := models.OAuthClient{
+		ID:            primitive.NewObjectID().Hex(),
+		Name:          req.Name,
+		GrantTypes:    grantTypes,
+		RedirectURIs:  req.RedirectURIs,
+		AllowedScopes: req.AllowedScopes,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	var secret string
+	if !req.Public {
+		var err error
+		secret, err = NewOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		client.SecretHash, err = s.passwords.Hash(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repository.Insert(ctx, &client); err != nil {
+		return nil, err
+	}
+
+	return &models.OAuthClientResponse{OAuthClient: client, ClientSecret: secret}, nil
+}
+
+// Get returns the client with the given ID.
+func (s *OAuthClientService) Get(clientID string) (*models.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.repository.FindByID(ctx, clientID)
+}
+
+// List returns all registered clients.
+func (s *OAuthClientService) List() ([]models.OAuthClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.repository.List(ctx)
+}
+
+// Update replaces the name, grant types, redirect URIs and scopes of a
+// client. The secret is left unchanged.
+func (s *OAuthClientService) Update(clientID string, req models.OAuthClientRequest) (*models.OAuthClient, error) {
+	existing, err := s.Get(clientID)
+	if err != nil {
+		return nil, err
+	}
+	grantTypes := grantTypesOf(req)
+	if err := validateClient(grantTypes, req.RedirectURIs, req.AllowedScopes, existing.IsPublic()); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existing.Name = req.Name
+	existing.GrantTypes = grantTypes
+	existing.RedirectURIs = req.RedirectURIs
+	existing.AllowedScopes = req.AllowedScopes
+	existing.UpdatedAt = time.Now().UTC()
+	if err := s.repository.UpdateRegistration(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	return s.Get(clientID)
+}
+
+// Delete removes a client.
+func (s *OAuthClientService) Delete(clientID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.repository.Delete(ctx, clientID)
+}
+
+// RotateSecret generates a new secret for a confidential client and returns
+// it once. The previous secret keeps working for overlap, or for the
+// configured default if overlap is nil, so deployments can switch over
+// without downtime.
+func (s *OAuthClientService) RotateSecret(clientID string, overlap *time.Duration) (*models.OAuthClientResponse, error) {
+	client, err := s.Get(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() {
+		return nil, ErrPublicOAuthClient
+	}
+
+	secret, err := NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	secretHash, err := s.passwords.Hash(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	window := s.rotationOverlap
+	if overlap != nil {
+		window = *overlap
+	}
+
+	now := time.Now().UTC()
+	var previousExpiresAt *time.Time
+	if window > 0 {
+		expiresAt := now.Add(window)
+		previousExpiresAt = &expiresAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Only rotate from the secret that was read, so concurrent rotations
+	// cannot both keep a previous secret alive.
+	if err := s.repository.ReplaceSecret(ctx, clientID, client.SecretHash, secretHash, previousExpiresAt, now); err != nil {
+		return nil, err
+	}
+
+	rotated, err := s.Get(clientID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OAuthClientResponse{OAuthClient: *rotated, ClientSecret: secret}, nil
+}
+
+// Authenticate verifies the client credentials. Public clients authenticate
+// with their client ID only and must not present a secret. During a secret
+// rotation both the current and the previous secret are accepted.
+func (s *OAuthClientService) Authenticate(clientID, secret string) (*models.OAuthClient, error) {
+	client, err := s.Get(clientID)
+	if errors.Is(err, ErrOAuthClientNotFound) {
+		return nil, ErrInvalidClientCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, ErrInvalidClientCredentials
+		}
+		return client, nil
+	}
+
+	if s.verifySecret(secret, client.SecretHash) {
+		return client, nil
+	}
+	if client.PreviousSecretHash != "" && client.PreviousSecretExpiresAt != nil &&
+		time.Now().Before(*client.PreviousSecretExpiresAt) && s.verifySecret(secret, client.PreviousSecretHash) {
+		return client, nil
+	}
+	return nil, ErrInvalidClientCredentials
+}
+
+func (s *OAuthClientService) verifySecret(secret, secretHash string) bool {
+	match, _, err := s.passwords.Verify(secret, secretHash)
+	return err == nil && match
+}
+
+// grantTypesOf returns the requested grant types, defaulting to the
+// authorization code flow.
+func grantTypesOf(req models.OAuthClientRequest) []string {
+	if len(req.GrantTypes) == 0 {
+		return []string{models.GrantTypeAuthorizationCode}
+	}
+	return req.GrantTypes
+}
+
+// validateClient checks that the registration fits its grant types:
+// authorization code clients need redirect URIs, and service accounts must be
+// confidential and may only be granted service scopes.
+func validateClient(grantTypes, redirectURIs, allowedScopes []string, public bool) error {
+	if containsString(grantTypes, models.GrantTypeAuthorizationCode) && len(redirectURIs) == 0 {
+		return fmt.Errorf("%w: redirect_uris are required for the authorization_code grant", ErrInvalidOAuthClient)
+	}
+	if !containsString(grantTypes, models.GrantTypeClientCredentials) {
+		return nil
+	}
+
+	if public {
+		return fmt.Errorf("%w: the client_credentials grant requires a confidential client", ErrInvalidOAuthClient)
+	}
+	for _, scope := range allowedScopes {
+		if !IsServiceScope(scope) && !containsString(supportedScopes, scope) {
+			return fmt.Errorf("%w: scope %s cannot be granted to a service account", ErrInvalidOAuthClient, scope)
+		}
+	}
+	return nil
+}
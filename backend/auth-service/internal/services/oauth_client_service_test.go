@@ -0,0 +1,270 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testOAuthClients is an OAuthClientService and an OIDCService over the
+// testAuth of the tests.
+type testOAuthClients struct {
+	*services.OAuthClientService
+	*testAuth
+	repository *testutil.MemoryOAuthClientRepository
+	oidc       *services.OIDCService
+}
+
+func newTestOAuthClients(t *testing.T, rotationOverlap time.Duration) *testOAuthClients {
+	t.Helper()
+
+	c := &testOAuthClients{
+		testAuth:   newTestAuthService(t),
+		repository: testutil.NewMemoryOAuthClientRepository(),
+	}
+	c.OAuthClientService = services.NewOAuthClientService(c.repository, c.Passwords(), rotationOverlap)
+	c.oidc = services.NewOIDCService(testutil.NewMemoryAuthorizationCodeRepository(), c.AuthService, c.jwt,
+		c.OAuthClientService, "http://auth.example.com")
+	return c
+}
+
+// addServiceAccount registers a confidential client_credentials client with
+// the scopes.
+func (c *testOAuthClients) addServiceAccount(t *testing.T, scopes ...string) *models.OAuthClientResponse {
+	t.Helper()
+
+	created, err := c.Create(models.OAuthClientRequest{
+		Name:          "billing",
+		GrantTypes:    []string{models.GrantTypeClientCredentials},
+		AllowedScopes: scopes,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return created
+}
+
+// wantOAuthError fails the test unless err is an *OAuthError with the status
+// and code.
+func wantOAuthError(t *testing.T, err error, status int, code string) {
+	t.Helper()
+
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code || oauthErr.Status != status {
+		t.Errorf("error = %v, want the OAuth error %s with status %d", err, code, status)
+	}
+}
+
+func TestCreateOAuthClientReturnsSecretOnce(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+
+	created := c.addServiceAccount(t, services.PermissionUsersReadAny)
+	if created.ClientSecret == "" {
+		t.Fatal("Create returned no secret for a confidential client")
+	}
+	stored, err := c.Get(created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.SecretHash == "" || stored.SecretHash == created.ClientSecret {
+		t.Errorf("stored secret hash = %q, want a hash of the secret", stored.SecretHash)
+	}
+	if _, err := c.Authenticate(created.ID, created.ClientSecret); err != nil {
+		t.Errorf("Authenticate with the secret: %v", err)
+	}
+
+	public, err := c.Create(models.OAuthClientRequest{Name: "spa", RedirectURIs: []string{"https://app.example.com/callback"}, AllowedScopes: []string{"openid"}, Public: true})
+	if err != nil {
+		t.Fatalf("Create of a public client: %v", err)
+	}
+	if public.ClientSecret != "" || !public.IsPublic() {
+		t.Errorf("public client = %+v, want no secret", public)
+	}
+	if _, err := c.Authenticate(public.ID, ""); err != nil {
+		t.Errorf("Authenticate of the public client: %v", err)
+	}
+	if _, err := c.Authenticate(public.ID, "guess"); !errors.Is(err, services.ErrInvalidClientCredentials) {
+		t.Errorf("Authenticate of the public client with a secret error = %v, want services.ErrInvalidClientCredentials", err)
+	}
+}
+
+func TestCreateOAuthClientRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.OAuthClientRequest
+	}{
+		{
+			name: "authorization_code without redirect URIs",
+			req:  models.OAuthClientRequest{Name: "web", AllowedScopes: []string{"openid"}},
+		},
+		{
+			name: "public service account",
+			req:  models.OAuthClientRequest{Name: "billing", GrantTypes: []string{models.GrantTypeClientCredentials}, AllowedScopes: []string{services.PermissionUsersReadAny}, Public: true},
+		},
+		{
+			name: "service account with a self-service scope",
+			req:  models.OAuthClientRequest{Name: "billing", GrantTypes: []string{models.GrantTypeClientCredentials}, AllowedScopes: []string{services.PermissionUsersWrite}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestOAuthClients(t, time.Hour)
+
+			if _, err := c.Create(tt.req); !errors.Is(err, services.ErrInvalidOAuthClient) {
+				t.Errorf("Create error = %v, want services.ErrInvalidOAuthClient", err)
+			}
+			if clients, err := c.List(); err != nil || len(clients) != 0 {
+				t.Errorf("List = %+v, %v, want no clients", clients, err)
+			}
+		})
+	}
+}
+
+func TestRotateOAuthClientSecret(t *testing.T) {
+	none := time.Duration(0)
+
+	t.Run("previous secret works during the overlap", func(t *testing.T) {
+		c := newTestOAuthClients(t, time.Hour)
+		created := c.addServiceAccount(t, services.PermissionUsersReadAny)
+
+		rotated, err := c.RotateSecret(created.ID, nil)
+		if err != nil {
+			t.Fatalf("RotateSecret: %v", err)
+		}
+		if rotated.ClientSecret == "" || rotated.ClientSecret == created.ClientSecret || rotated.PreviousSecretExpiresAt == nil {
+			t.Fatalf("rotated client = %+v, want a new secret and an overlap", rotated)
+		}
+		for _, secret := range []string{created.ClientSecret, rotated.ClientSecret} {
+			if _, err := c.Authenticate(created.ID, secret); err != nil {
+				t.Errorf("Authenticate during the overlap: %v", err)
+			}
+		}
+
+		c.repository.ExpirePreviousSecrets()
+		if _, err := c.Authenticate(created.ID, created.ClientSecret); !errors.Is(err, services.ErrInvalidClientCredentials) {
+			t.Errorf("Authenticate with the previous secret after the overlap error = %v, want services.ErrInvalidClientCredentials", err)
+		}
+		if _, err := c.Authenticate(created.ID, rotated.ClientSecret); err != nil {
+			t.Errorf("Authenticate with the new secret after the overlap: %v", err)
+		}
+	})
+
+	t.Run("without an overlap", func(t *testing.T) {
+		c := newTestOAuthClients(t, time.Hour)
+		created := c.addServiceAccount(t, services.PermissionUsersReadAny)
+
+		rotated, err := c.RotateSecret(created.ID, &none)
+		if err != nil {
+			t.Fatalf("RotateSecret: %v", err)
+		}
+		if _, err := c.Authenticate(created.ID, created.ClientSecret); !errors.Is(err, services.ErrInvalidClientCredentials) {
+			t.Errorf("Authenticate with the previous secret error = %v, want services.ErrInvalidClientCredentials", err)
+		}
+		if _, err := c.Authenticate(created.ID, rotated.ClientSecret); err != nil {
+			t.Errorf("Authenticate with the new secret: %v", err)
+		}
+	})
+
+	t.Run("a second rotation ends the first overlap", func(t *testing.T) {
+		c := newTestOAuthClients(t, time.Hour)
+		created := c.addServiceAccount(t, services.PermissionUsersReadAny)
+
+		if _, err := c.RotateSecret(created.ID, nil); err != nil {
+			t.Fatalf("RotateSecret: %v", err)
+		}
+		if _, err := c.RotateSecret(created.ID, nil); err != nil {
+			t.Fatalf("second RotateSecret: %v", err)
+		}
+		if _, err := c.Authenticate(created.ID, created.ClientSecret); !errors.Is(err, services.ErrInvalidClientCredentials) {
+			t.Errorf("Authenticate with the original secret error = %v, want services.ErrInvalidClientCredentials", err)
+		}
+	})
+
+	t.Run("public and unknown clients", func(t *testing.T) {
+		c := newTestOAuthClients(t, time.Hour)
+		public, err := c.Create(models.OAuthClientRequest{Name: "spa", RedirectURIs: []string{"https://app.example.com/callback"}, Public: true})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if _, err := c.RotateSecret(public.ID, nil); !errors.Is(err, services.ErrPublicOAuthClient) {
+			t.Errorf("RotateSecret of a public client error = %v, want services.ErrPublicOAuthClient", err)
+		}
+		if _, err := c.RotateSecret("unknown", nil); !errors.Is(err, services.ErrOAuthClientNotFound) {
+			t.Errorf("RotateSecret of an unknown client error = %v, want services.ErrOAuthClientNotFound", err)
+		}
+		if _, err := c.Authenticate("unknown", "secret"); !errors.Is(err, services.ErrInvalidClientCredentials) {
+			t.Errorf("Authenticate of an unknown client error = %v, want services.ErrInvalidClientCredentials", err)
+		}
+	})
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+	created := c.addServiceAccount(t, services.PermissionUsersReadAny, services.PermissionUsersDelete)
+
+	token, err := c.oidc.Exchange(models.TokenRequest{GrantType: models.GrantTypeClientCredentials, Scope: services.PermissionUsersReadAny}, created.ID, created.ClientSecret)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if token.Scope != services.PermissionUsersReadAny || token.IDToken != "" {
+		t.Errorf("token response = %+v, want only the requested scope and no ID token", token)
+	}
+
+	validation, err := c.ValidateToken(token.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !validation.Valid || validation.PrincipalType != services.PrincipalService || validation.ClientID != created.ID ||
+		validation.UserID != "" || validation.TenantID != "" || validation.Scope != services.PermissionUsersReadAny {
+		t.Errorf("validation = %+v, want the service account without a user or tenant", validation)
+	}
+	claims := c.claims(t, token.AccessToken)
+	if !claims.HasPermission(services.PermissionUsersReadAny) || claims.HasPermission(services.PermissionUsersDelete) {
+		t.Errorf("claims = %+v, want only the granted scope", claims)
+	}
+
+	// Without a scope parameter every allowed scope is granted.
+	all, err := c.oidc.Exchange(models.TokenRequest{GrantType: models.GrantTypeClientCredentials}, created.ID, created.ClientSecret)
+	if err != nil {
+		t.Fatalf("Exchange without a scope: %v", err)
+	}
+	if all.Scope != services.PermissionUsersReadAny+" "+services.PermissionUsersDelete {
+		t.Errorf("scope = %q, want every allowed scope", all.Scope)
+	}
+}
+
+func TestClientCredentialsGrantRejects(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+	service := c.addServiceAccount(t, services.PermissionUsersReadAny)
+	web, err := c.Create(models.OAuthClientRequest{Name: "web", RedirectURIs: []string{"https://app.example.com/callback"}, AllowedScopes: []string{"openid"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	grant := models.TokenRequest{GrantType: models.GrantTypeClientCredentials}
+
+	tests := []struct {
+		name     string
+		req      models.TokenRequest
+		clientID string
+		secret   string
+		code     string
+		status   int
+	}{
+		{name: "wrong secret", req: grant, clientID: service.ID, secret: "guess", code: "invalid_client", status: http.StatusUnauthorized},
+		{name: "scope not allowed", req: models.TokenRequest{GrantType: models.GrantTypeClientCredentials, Scope: services.PermissionUsersDelete}, clientID: service.ID, secret: service.ClientSecret, code: "invalid_scope", status: http.StatusBadRequest},
+		{name: "self-service scope", req: models.TokenRequest{GrantType: models.GrantTypeClientCredentials, Scope: services.PermissionUsersRead}, clientID: service.ID, secret: service.ClientSecret, code: "invalid_scope", status: http.StatusBadRequest},
+		{name: "client without the grant", req: grant, clientID: web.ID, secret: web.ClientSecret, code: "unauthorized_client", status: http.StatusBadRequest},
+		{name: "unsupported grant", req: models.TokenRequest{GrantType: "password"}, clientID: service.ID, secret: service.ClientSecret, code: "unsupported_grant_type", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.oidc.Exchange(tt.req, tt.clientID, tt.secret)
+			wantOAuthError(t, err, tt.status, tt.code)
+		})
+	}
+}
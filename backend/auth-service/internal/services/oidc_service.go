@@ -0,0 +1,430 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// authorizationCodeTTL bounds how long a client may take to redeem a code.
+const authorizationCodeTTL = time.Minute
+
+// supportedScopes are the scopes the provider knows how to honour.
+var supportedScopes = []string{"openid", "profile", "email"}
+
+// OAuthError is an OAuth 2.0 error response as defined by RFC 6749 section 5.2.
+type OAuthError struct {
+	Code        string
+	Description string
+	Status      int
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(status int, code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description, Status: status}
+}
+
+// OAuthClientDirectory looks OAuth clients up and authenticates them.
+// OAuthClientService is the production implementation and
+// testutil.MemoryOAuthClientDirectory the one for tests.
+type OAuthClientDirectory interface {
+	Get(clientID string) (*models.OAuthClient, error)
+	Authenticate(clientID, secret string) (*models.OAuthClient, error)
+}
+
+var _ OAuthClientDirectory = (*OAuthClientService)(nil)
+
+// OIDCService implements a minimal OpenID Connect provider on top of the
+// account store: the authorization code flow with PKCE, the token endpoint
+// with the client_credentials grant for service accounts, and UserInfo.
+type OIDCService struct {
+	codes       AuthorizationCodeRepository
+	authService *AuthService
+	jwtService  *JWTService
+	clients     OAuthClientDirectory
+	issuer      string
+}
+
+// NewOIDCService creates a new OIDCService for the given issuer URL.
+func NewOIDCService(
+	codes AuthorizationCodeRepository,
+	authService *AuthService,
+	jwtService *JWTService,
+	clients OAuthClientDirectory,
+	issuer string,
+) *OIDCService {
+	return &OIDCService{
+		codes:       codes,
+		authService: authService,
+		jwtService:  jwtService,
+		clients:     clients,
+		issuer:      strings.TrimRight(issuer, "/"),
+	}
+}
+
+// Discovery returns the OpenID Provider configuration document.
+func (s *OIDCService) Discovery() models.OpenIDConfiguration {
+	return models.OpenIDConfiguration{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth2/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth2/token",
+		UserInfoEndpoint:                  s.issuer + "/oauth2/userinfo",
+		IntrospectionEndpoint:             s.issuer + "/oauth2/introspect",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{models.GrantTypeAuthorizationCode, models.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.jwtService.keyStore.Algorithm()},
+		ScopesSupported:                   supportedScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "amr", "name", "email", "email_verified", "updated_at"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+// ValidateClient checks the client ID and redirect URI of an authorization
+// request. Errors from this step must be shown to the user rather than sent
+// to the redirect URI, which cannot be trusted yet.
+func (s *OIDCService) ValidateClient(req models.AuthorizeRequest) (*models.OAuthClient, error) {
+	client, err := s.clients.Get(req.ClientID)
+	if errors.Is(err, ErrOAuthClientNotFound) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "unknown client_id")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uri := range client.RedirectURIs {
+		if uri == req.RedirectURI {
+			return client, nil
+		}
+	}
+	return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for this client")
+}
+
+// ValidateAuthorizeRequest checks the remaining authorization parameters for a
+// client that passed ValidateClient.
+func (s *OIDCService) ValidateAuthorizeRequest(client *models.OAuthClient, req models.AuthorizeRequest) error {
+	if req.ResponseType != "code" {
+		return newOAuthError(http.StatusBadRequest, "unsupported_response_type", "only response_type=code is supported")
+	}
+	if !client.AllowsGrant(models.GrantTypeAuthorizationCode) {
+		return newOAuthError(http.StatusBadRequest, "unauthorized_client", "the client may not use the authorization_code grant")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if !containsString(scopes, "openid") {
+		return newOAuthError(http.StatusBadRequest, "invalid_scope", "the openid scope is required")
+	}
+	for _, scope := range scopes {
+		if !containsString(supportedScopes, scope) || !containsString(client.AllowedScopes, scope) {
+			return newOAuthError(http.StatusBadRequest, "invalid_scope", "scope "+scope+" is not allowed for this client")
+		}
+	}
+
+	if req.CodeChallenge == "" {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", "code_challenge is required")
+	}
+	if req.CodeChallengeMethod != "S256" {
+		return newOAuthError(http.StatusBadRequest, "invalid_request", "code_challenge_method must be S256")
+	}
+
+	return nil
+}
+
+// IssueCode creates a single-use authorization code for the authenticated
+// user. The request must have been validated first; amr lists the
+// authentication methods the user completed. The sign-in is recorded in the
+// audit trail.
+func (s *OIDCService) IssueCode(req models.AuthorizeRequest, userID string, amr []string, client models.ClientInfo) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	code, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	record := models.AuthorizationCode{
+		ID:                  HashOpaqueToken(code),
+		ClientID:            req.ClientID,
+		UserID:              userID,
+		TenantID:            client.TenantID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		AMR:                 amr,
+		AuthTime:            now,
+		ExpiresAt:           now.Add(authorizationCodeTTL),
+	}
+	if err := s.codes.Insert(ctx, &record); err != nil {
+		return "", err
+	}
+
+	s.authService.audit.RecordFor(ctx, models.AuditEvent{
+		EventType: AuditLogin,
+		Outcome:   AuditOutcomeSuccess,
+		UserID:    userID,
+		ClientID:  req.ClientID,
+		AMR:       amr,
+	}, client)
+
+	return code, nil
+}
+
+// Exchange handles a token request. Authorization codes are redeemed for an
+// access token and ID token; service accounts using the client_credentials
+// grant receive an access token for themselves. clientID and clientSecret are
+// the credentials presented by the client, from either HTTP Basic
+// authentication or the request body.
+func (s *OIDCService) Exchange(req models.TokenRequest, clientID, clientSecret string) (*models.TokenResponse, error) {
+	if req.GrantType != models.GrantTypeAuthorizationCode && req.GrantType != models.GrantTypeClientCredentials {
+		return nil, newOAuthError(http.StatusBadRequest, "unsupported_grant_type", "only authorization_code and client_credentials are supported")
+	}
+
+	client, err := s.clients.Authenticate(clientID, clientSecret)
+	if errors.Is(err, ErrInvalidClientCredentials) {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AllowsGrant(req.GrantType) {
+		return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "the client may not use the "+req.GrantType+" grant")
+	}
+	if req.GrantType == models.GrantTypeClientCredentials {
+		return s.clientCredentials(client, req.Scope)
+	}
+
+	return s.exchangeCode(client, req)
+}
+
+// exchangeCode redeems an authorization code issued to the client.
+func (s *OIDCService) exchangeCode(client *models.OAuthClient, req models.TokenRequest) (*models.TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "code and code_verifier are required")
+	}
+
+	code, err := s.consumeCode(req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code was not issued to this client or redirect_uri")
+	}
+	if !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	user, err := s.authService.GetUser(code.TenantID, code.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "the account no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ttl, err := s.authService.AccessTokenTTL(ctx, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.jwtService.GenerateToken(user.ID, WithTenant(user.TenantID), WithScope(code.Scope), WithClientID(client.ID), WithAMR(code.AMR), withTTL(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := s.jwtService.GenerateIDToken(user, client.ID, strings.Fields(code.Scope), code.Nonce, code.AuthTime, code.AMR, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       code.Scope,
+		IDToken:     idToken,
+	}, nil
+}
+
+// clientCredentials issues an access token to a service account. The
+// requested scope must be a subset of the client's allowed service scopes;
+// without a scope parameter all of them are granted.
+func (s *OIDCService) clientCredentials(client *models.OAuthClient, scope string) (*models.TokenResponse, error) {
+	if client.IsPublic() {
+		return nil, newOAuthError(http.StatusBadRequest, "unauthorized_client", "public clients may not use the client_credentials grant")
+	}
+
+	var granted []string
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, sc := range requested {
+			if !IsServiceScope(sc) || !containsString(client.AllowedScopes, sc) {
+				return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", "scope "+sc+" is not allowed for this client")
+			}
+			if !containsString(granted, sc) {
+				granted = append(granted, sc)
+			}
+		}
+	} else {
+		for _, sc := range client.AllowedScopes {
+			if IsServiceScope(sc) {
+				granted = append(granted, sc)
+			}
+		}
+	}
+	if len(granted) == 0 {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_scope", "no service scopes are allowed for this client")
+	}
+
+	grantedScope := strings.Join(granted, " ")
+	accessToken, err := s.jwtService.GenerateServiceToken(client.ID, grantedScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.jwtService.AccessTokenTTL().Seconds()),
+		Scope:       grantedScope,
+	}, nil
+}
+
+// consumeCode atomically removes the code so it can only be redeemed once.
+func (s *OIDCService) consumeCode(code string) (*models.AuthorizationCode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	record, err := s.codes.Consume(ctx, HashOpaqueToken(code), time.Now().UTC())
+	if errors.Is(err, ErrAuthorizationCodeNotFound) {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_grant", "authorization code is invalid or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Introspect reports whether an access token is active and describes it, as
+// defined by RFC 7662. The caller must be a service account allowed the
+// tokens:introspect scope. A token is active only if its signature, issuer,
+// audience and lifetime are valid, it has not been revoked and its session
+// has not been terminated.
+func (s *OIDCService) Introspect(req models.IntrospectionRequest, clientID, clientSecret string) (*models.IntrospectionResponse, error) {
+	client, err := s.clients.Authenticate(clientID, clientSecret)
+	if errors.Is(err, ErrInvalidClientCredentials) {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() || !client.IsServiceAccount() || !containsString(client.AllowedScopes, PermissionTokensIntrospect) {
+		return nil, newOAuthError(http.StatusForbidden, "insufficient_scope", "the tokens:introspect scope is required")
+	}
+
+	if req.Token == "" {
+		return nil, newOAuthError(http.StatusBadRequest, "invalid_request", "token is required")
+	}
+
+	// Refresh tokens and anything else that is not a valid access token are
+	// simply reported as inactive.
+	claims, err := s.authService.Authenticate(req.Token)
+	if err != nil {
+		return &models.IntrospectionResponse{Active: false}, nil
+	}
+
+	response := &models.IntrospectionResponse{
+		Active:        true,
+		Subject:       claims.Subject,
+		Issuer:        claims.Issuer,
+		Audience:      claims.Audience,
+		JTI:           claims.ID,
+		Scope:         claims.Scope,
+		ClientID:      claims.ClientID,
+		TokenType:     "Bearer",
+		PrincipalType: claims.PrincipalType(),
+		UserID:        claims.UserID,
+		Role:          claims.Role,
+		Permissions:   claims.Permissions,
+		AMR:           claims.AMR,
+		SessionID:     claims.SessionID,
+		Actor:         claims.Actor,
+	}
+	if claims.PrincipalType() == PrincipalUser {
+		response.TenantID = claims.Tenant()
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		response.NotBefore = claims.NotBefore.Unix()
+	}
+	return response, nil
+}
+
+// UserInfo returns the claims about the user that the access token's scope
+// allows the client to see.
+func (s *OIDCService) UserInfo(claims *Claims) (*models.UserInfoResponse, error) {
+	scopes := strings.Fields(claims.Scope)
+	if !containsString(scopes, "openid") {
+		return nil, newOAuthError(http.StatusForbidden, "insufficient_scope", "the openid scope is required")
+	}
+
+	user, err := s.authService.GetUser(claims.Tenant(), claims.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, newOAuthError(http.StatusUnauthorized, "invalid_token", "the account no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.UserInfoResponse{Subject: user.ID}
+	for _, scope := range scopes {
+		switch scope {
+		case "profile":
+			info.Name = user.Name
+			info.UpdatedAt = user.UpdatedAt.Unix()
+		case "email":
+			verified := user.Status == "active"
+			info.Email = user.Email
+			info.EmailVerified = &verified
+		}
+	}
+	return info, nil
+}
+
+// verifyCodeChallenge checks an S256 PKCE code verifier against the challenge.
+func verifyCodeChallenge(challenge, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIntrospectUserToken(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+	introspector := c.addServiceAccount(t, services.PermissionTokensIntrospect)
+	user := c.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	login := c.loginOn(t, "jane@example.com", "laptop")
+
+	response, err := c.oidc.Introspect(models.IntrospectionRequest{Token: login.Token}, introspector.ID, introspector.ClientSecret)
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if !response.Active || response.Subject != user.ID || response.UserID != user.ID || response.TenantID != services.DefaultTenantID ||
+		response.PrincipalType != services.PrincipalUser || response.TokenType != "Bearer" || response.Role != "customer" || response.SessionID == "" {
+		t.Errorf("introspection = %+v, want the active token of %s", response, user.ID)
+	}
+	if response.ExpiresAt <= response.IssuedAt || !containsPermission(response.Permissions, services.PermissionUsersRead) {
+		t.Errorf("introspection = %+v, want exp after iat and the customer permissions", response)
+	}
+}
+
+func TestIntrospectServiceToken(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+	introspector := c.addServiceAccount(t, services.PermissionTokensIntrospect)
+	billing := c.addServiceAccount(t, services.PermissionUsersReadAny)
+	token, err := c.oidc.Exchange(models.TokenRequest{GrantType: models.GrantTypeClientCredentials}, billing.ID, billing.ClientSecret)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	response, err := c.oidc.Introspect(models.IntrospectionRequest{Token: token.AccessToken}, introspector.ID, introspector.ClientSecret)
+	if err != nil {
+		t.Fatalf("Introspect: %v", err)
+	}
+	if !response.Active || response.PrincipalType != services.PrincipalService || response.ClientID != billing.ID ||
+		response.UserID != "" || response.TenantID != "" || response.Scope != services.PermissionUsersReadAny {
+		t.Errorf("introspection = %+v, want the service account of %s without a tenant", response, billing.ID)
+	}
+}
+
+func TestIntrospectInactiveTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(t *testing.T, c *testOAuthClients) string
+	}{
+		{
+			name: "revoked by logout",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				login := c.loginOn(t, "jane@example.com", "laptop")
+				if err := c.Logout(c.claims(t, login.Token), login.RefreshToken, client(services.DefaultTenantID)); err != nil {
+					t.Fatalf("Logout: %v", err)
+				}
+				return login.Token
+			},
+		},
+		{
+			name: "revoked for the user",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				login := c.loginOn(t, "jane@example.com", "laptop")
+				if err := c.RevokeAllTokens("jane@example.com@"+services.DefaultTenantID, "compromised", "admin-1", client(services.DefaultTenantID)); err != nil {
+					t.Fatalf("RevokeAllTokens: %v", err)
+				}
+				return login.Token
+			},
+		},
+		{
+			name: "session terminated",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				login := c.loginOn(t, "jane@example.com", "laptop")
+				if err := c.TerminateSession("jane@example.com@"+services.DefaultTenantID, c.claims(t, login.Token).SessionID, client(services.DefaultTenantID)); err != nil {
+					t.Fatalf("TerminateSession: %v", err)
+				}
+				return login.Token
+			},
+		},
+		{
+			name: "session expired",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				login := c.loginOn(t, "jane@example.com", "laptop")
+				c.sessions.Expire()
+				return login.Token
+			},
+		},
+		{
+			name: "refresh token",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				return c.loginOn(t, "jane@example.com", "laptop").RefreshToken
+			},
+		},
+		{
+			name: "malformed token",
+			token: func(t *testing.T, c *testOAuthClients) string {
+				return "not-a-token"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestOAuthClients(t, time.Hour)
+			introspector := c.addServiceAccount(t, services.PermissionTokensIntrospect)
+			c.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+			response, err := c.oidc.Introspect(models.IntrospectionRequest{Token: tt.token(t, c)}, introspector.ID, introspector.ClientSecret)
+			if err != nil {
+				t.Fatalf("Introspect: %v", err)
+			}
+			if response.Active || response.Subject != "" || response.UserID != "" {
+				t.Errorf("introspection = %+v, want only active: false", response)
+			}
+		})
+	}
+}
+
+func TestIntrospectRequiresIntrospectionScope(t *testing.T) {
+	c := newTestOAuthClients(t, time.Hour)
+	introspector := c.addServiceAccount(t, services.PermissionTokensIntrospect)
+	billing := c.addServiceAccount(t, services.PermissionUsersReadAny)
+	web, err := c.Create(models.OAuthClientRequest{Name: "spa", RedirectURIs: []string{"https://app.example.com/callback"}, AllowedScopes: []string{"openid"}, Public: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	token := c.loginOn(t, "jane@example.com", "laptop").Token
+
+	tests := []struct {
+		name     string
+		clientID string
+		secret   string
+		code     string
+		status   int
+	}{
+		{name: "wrong secret", clientID: introspector.ID, secret: "guess", code: "invalid_client", status: http.StatusUnauthorized},
+		{name: "unknown client", clientID: "unknown", secret: "guess", code: "invalid_client", status: http.StatusUnauthorized},
+		{name: "service account without the scope", clientID: billing.ID, secret: billing.ClientSecret, code: "insufficient_scope", status: http.StatusForbidden},
+		{name: "public client", clientID: web.ID, code: "insufficient_scope", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := c.oidc.Introspect(models.IntrospectionRequest{Token: token}, tt.clientID, tt.secret)
+			if response != nil {
+				t.Errorf("introspection = %+v, want none", response)
+			}
+			wantOAuthError(t, err, tt.status, tt.code)
+		})
+	}
+
+	_, err = c.oidc.Introspect(models.IntrospectionRequest{}, introspector.ID, introspector.ClientSecret)
+	wantOAuthError(t, err, http.StatusBadRequest, "invalid_request")
+}
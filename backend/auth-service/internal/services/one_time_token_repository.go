@@ -0,0 +1,126 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const oneTimeTokenCollection = "one_time_tokens"
+
+// OneTimeTokenRepository stores one-time tokens by the hash of the token.
+// Unknown, expired and cross-purpose hashes yield ErrInvalidOneTimeToken.
+// MongoOneTimeTokenRepository is the production implementation and
+// testutil.MemoryOneTimeTokenRepository the one for tests.
+type OneTimeTokenRepository interface {
+	// Insert stores a new token.
+	Insert(ctx context.Context, token *models.OneTimeToken) error
+	// Take deletes the token with the hash and returns it, unless it is of
+	// another purpose or expired at now. Only one of concurrent calls for the
+	// same token succeeds.
+	Take(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error)
+	// Find returns the token with the hash like Take but keeps it.
+	Find(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error)
+	// LastIssued returns when the most recent token for the purpose and
+	// subject was created, or the zero time if there is none.
+	LastIssued(ctx context.Context, purpose, subject string) (time.Time, error)
+	// DeleteSubject deletes the tokens for the purpose and subject.
+	DeleteSubject(ctx context.Context, purpose, subject string) error
+}
+
+var _ OneTimeTokenRepository = (*MongoOneTimeTokenRepository)(nil)
+
+// MongoOneTimeTokenRepository stores one-time tokens in the one_time_tokens
+// collection. A TTL index drops them once they expire.
+type MongoOneTimeTokenRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoOneTimeTokenRepository creates a new MongoOneTimeTokenRepository
+func NewMongoOneTimeTokenRepository(mongoConfig *config.MongoDBConfig) *MongoOneTimeTokenRepository {
+	return &MongoOneTimeTokenRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the lookup and TTL indexes for one-time tokens.
+func (r *MongoOneTimeTokenRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "purpose", Value: 1}, {Key: "subject", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Insert stores a new token.
+func (r *MongoOneTimeTokenRepository) Insert(ctx context.Context, token *models.OneTimeToken) error {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+// Take deletes a usable token and returns it.
+func (r *MongoOneTimeTokenRepository) Take(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error) {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	var token models.OneTimeToken
+	err := collection.FindOneAndDelete(ctx, usableOneTimeToken(tokenHash, purpose, now)).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Find returns a usable token.
+func (r *MongoOneTimeTokenRepository) Find(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error) {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	var token models.OneTimeToken
+	err := collection.FindOne(ctx, usableOneTimeToken(tokenHash, purpose, now)).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// LastIssued returns when the most recent token for the purpose and subject
+// was created.
+func (r *MongoOneTimeTokenRepository) LastIssued(ctx context.Context, purpose, subject string) (time.Time, error) {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	var token models.OneTimeToken
+	err := collection.FindOne(ctx,
+		bson.M{"purpose": purpose, "subject": subject},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return token.CreatedAt, nil
+}
+
+// DeleteSubject deletes the tokens for the purpose and subject.
+func (r *MongoOneTimeTokenRepository) DeleteSubject(ctx context.Context, purpose, subject string) error {
+	collection := r.mongoConfig.GetCollection(oneTimeTokenCollection)
+	_, err := collection.DeleteMany(ctx, bson.M{"purpose": purpose, "subject": subject})
+	return err
+}
+
+func usableOneTimeToken(tokenHash, purpose string, now time.Time) bson.M {
+	return bson.M{
+		"_id":       tokenHash,
+		"purpose":   purpose,
+		"expiresAt": bson.M{"$gt": now},
+	}
+}
@@ -0,0 +1,273 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var outboxTopics = services.KafkaTopics{
+	UserCreated: "user.created.v1",
+	UserUpdated: "user.updated.v1",
+	UserDeleted: "user.deleted.v1",
+	Invitation:  "invitation.v1",
+}
+
+// written is a message handed to a recordingWriter.
+type written struct {
+	Topic string
+	Key   string
+	Event models.UserEvent
+}
+
+// recordingWriter records the messages it writes and fails those of the keys
+// marked down.
+type recordingWriter struct {
+	mu      sync.Mutex
+	written []written
+	down    map[string]bool
+}
+
+func newRecordingWriter() *recordingWriter {
+	return &recordingWriter{down: make(map[string]bool)}
+}
+
+func (w *recordingWriter) WriteMessage(ctx context.Context, topic, key string, payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.down[key] {
+		return errors.New("partition unavailable")
+	}
+	var event models.UserEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+	w.written = append(w.written, written{Topic: topic, Key: key, Event: event})
+	return nil
+}
+
+func (w *recordingWriter) SetDown(key string, down bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.down[key] = down
+}
+
+func (w *recordingWriter) Written() []written {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]written(nil), w.written...)
+}
+
+// Retries are due right away, so a second relay retries them.
+var testOutboxConfig = config.NewOutboxConfig(time.Second, time.Nanosecond, time.Nanosecond, time.Hour)
+
+func backlog(t *testing.T, outbox *services.OutboxService) int64 {
+	t.Helper()
+
+	n, err := outbox.Backlog(context.Background())
+	if err != nil {
+		t.Fatalf("Backlog: %v", err)
+	}
+	return n
+}
+
+func TestOutboxWritesEventsWithoutPublisher(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	outbox := services.NewOutboxService(repository, nil, outboxTopics, testOutboxConfig, logger.NewNopLogger())
+
+	ctx := context.Background()
+	if err := outbox.PublishUserCreated(ctx, models.UserEvent{UserID: "u1"}); err != nil {
+		t.Fatalf("PublishUserCreated: %v", err)
+	}
+	if err := outbox.PublishInvitation(ctx, models.InvitationEvent{InvitationID: "i1"}); err != nil {
+		t.Fatalf("PublishInvitation: %v", err)
+	}
+
+	// The messages wait for a replica that can relay them.
+	if n := backlog(t, outbox); n != 2 {
+		t.Errorf("backlog = %d, want 2", n)
+	}
+	messages := repository.Messages()
+	if len(messages) != 2 || messages[0].Topic != "user.created.v1" || messages[0].Key != "u1" || messages[1].Key != "i1" {
+		t.Fatalf("messages = %+v, want user.created.v1 for u1 and the invitation i1", messages)
+	}
+	var event models.UserEvent
+	if err := json.Unmarshal(messages[0].Payload, &event); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if event.Source != services.EventSource {
+		t.Errorf("source = %q, want %q", event.Source, services.EventSource)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		outbox.Start(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start without a publisher did not return")
+	}
+}
+
+func TestOutboxSkipsUnconfiguredTopics(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	outbox := services.NewOutboxService(repository, nil, services.KafkaTopics{UserCreated: "user.created.v1"}, testOutboxConfig, logger.NewNopLogger())
+
+	if err := outbox.PublishUserDeleted(context.Background(), models.UserEvent{UserID: "u1"}); err != nil {
+		t.Fatalf("PublishUserDeleted: %v", err)
+	}
+	if n := backlog(t, outbox); n != 0 {
+		t.Errorf("backlog = %d, want nothing for a topic that is not configured", n)
+	}
+}
+
+func TestOutboxRelayPublishesInOrder(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	writer := newRecordingWriter()
+	outbox := services.NewTestOutboxService(repository, writer, outboxTopics, testOutboxConfig)
+
+	ctx := context.Background()
+	for _, event := range []models.UserEvent{
+		{UserID: "u1", Name: "first"},
+		{UserID: "u2", Name: "first"},
+		{UserID: "u1", Name: "second"},
+	} {
+		if err := outbox.PublishUserUpdated(ctx, event); err != nil {
+			t.Fatalf("PublishUserUpdated: %v", err)
+		}
+	}
+
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	got := writer.Written()
+	if len(got) != 3 || got[0].Key != "u1" || got[1].Key != "u2" || got[2].Event.Name != "second" {
+		t.Fatalf("written = %+v, want the three events in order", got)
+	}
+	if n := backlog(t, outbox); n != 0 {
+		t.Errorf("backlog = %d, want 0", n)
+	}
+	for _, message := range repository.Messages() {
+		if message.DeliveredAt == nil || message.ExpiresAt == nil || message.Attempts != 1 {
+			t.Errorf("message = %+v, want delivered on the first attempt and set to expire", message)
+		}
+	}
+
+	// Delivered messages are not published again.
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("second Relay: %v", err)
+	}
+	if got := len(writer.Written()); got != 3 {
+		t.Errorf("written %d messages, want still 3", got)
+	}
+}
+
+func TestOutboxRelayHoldsBackKeyOfFailedMessage(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	writer := newRecordingWriter()
+	outbox := services.NewTestOutboxService(repository, writer, outboxTopics, testOutboxConfig)
+
+	ctx := context.Background()
+	for _, event := range []models.UserEvent{
+		{UserID: "u1", Name: "first"},
+		{UserID: "u2", Name: "first"},
+		{UserID: "u1", Name: "second"},
+	} {
+		if err := outbox.PublishUserUpdated(ctx, event); err != nil {
+			t.Fatalf("PublishUserUpdated: %v", err)
+		}
+	}
+
+	writer.SetDown("u1", true)
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	if got := writer.Written(); len(got) != 1 || got[0].Key != "u2" {
+		t.Fatalf("written = %+v, want only u2 while u1 fails", got)
+	}
+	first := repository.Messages()[0]
+	if first.Attempts != 1 || first.LastError == "" || first.DeliveredAt != nil {
+		t.Errorf("failed message = %+v, want one failed attempt recorded", first)
+	}
+
+	writer.SetDown("u1", false)
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("second Relay: %v", err)
+	}
+	got := writer.Written()
+	if len(got) != 3 || got[1].Event.Name != "first" || got[2].Event.Name != "second" {
+		t.Errorf("written = %+v, want the events of u1 in order after u2", got)
+	}
+}
+
+func TestOutboxRelayRequiresLease(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	writer := newRecordingWriter()
+	first := services.NewTestOutboxService(repository, writer, outboxTopics, testOutboxConfig)
+	second := services.NewTestOutboxService(repository, writer, outboxTopics, testOutboxConfig)
+
+	ctx := context.Background()
+	if err := first.Relay(ctx); err != nil {
+		t.Fatalf("Relay: %v", err)
+	}
+	if err := second.PublishUserCreated(ctx, models.UserEvent{UserID: "u1"}); err != nil {
+		t.Fatalf("PublishUserCreated: %v", err)
+	}
+
+	// Only the replica holding the lease relays.
+	if err := second.Relay(ctx); err != nil {
+		t.Fatalf("Relay without the lease: %v", err)
+	}
+	if got := len(writer.Written()); got != 0 {
+		t.Fatalf("written %d messages without the lease, want 0", got)
+	}
+
+	// Another replica takes over once the lease runs out.
+	repository.ExpireLease()
+	if err := second.Relay(ctx); err != nil {
+		t.Fatalf("Relay after the lease expired: %v", err)
+	}
+	if got := len(writer.Written()); got != 1 {
+		t.Errorf("written %d messages, want 1", got)
+	}
+	if err := first.Relay(ctx); err != nil {
+		t.Fatalf("Relay of the previous holder: %v", err)
+	}
+	if n := backlog(t, first); n != 0 {
+		t.Errorf("backlog = %d, want 0", n)
+	}
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	outbox := services.NewTestOutboxService(testutil.NewMemoryOutboxRepository(), newRecordingWriter(), outboxTopics,
+		config.NewOutboxConfig(time.Second, time.Second, time.Minute, time.Hour))
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 6, want: 32 * time.Second},
+		{attempts: 7, want: time.Minute},
+		{attempts: 100, want: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outbox.RetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
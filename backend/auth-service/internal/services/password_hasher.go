@@ -0,0 +1,264 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes and verifies passwords. Implementations encode the
+// algorithm and its cost parameters in the hash they produce so stored hashes
+// remain verifiable after the configuration changes.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password.
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash.
+	Verify(password, encodedHash string) (bool, error)
+	// Supports reports whether the encoded hash was produced by this algorithm.
+	Supports(encodedHash string) bool
+	// NeedsRehash reports whether the encoded hash uses outdated parameters.
+	NeedsRehash(encodedHash string) bool
+}
+
+const argon2idPrefix = "$argon2id$"
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// Argon2idHasher hashes passwords with argon2id using PHC string encoding.
+type Argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// NewArgon2idHasher creates an Argon2idHasher with the given cost parameters.
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		memory:      memory,
+		iterations:  iterations,
+		parallelism: parallelism,
+		saltLength:  16,
+		keyLength:   32,
+	}
+}
+
+// Hash returns a hash in the form $argon2id$v=19$m=...,t=...,p=...$salt$key.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, h.keyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.memory,
+		h.iterations,
+		h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify recomputes the key with the parameters stored in the hash.
+func (h *Argon2idHasher) Verify(password, encodedHash string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+// Supports reports whether the hash is an argon2id PHC string.
+func (h *Argon2idHasher) Supports(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, argon2idPrefix)
+}
+
+// NeedsRehash reports whether the hash was produced with weaker parameters
+// than the ones currently configured.
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.memory < h.memory ||
+		params.iterations < h.iterations ||
+		params.parallelism < h.parallelism ||
+		uint32(len(salt)) < h.saltLength ||
+		uint32(len(key)) < h.keyLength
+}
+
+func decodeArgon2id(encodedHash string) (*Argon2idHasher, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, errMalformedHash
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, errMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errMalformedHash
+	}
+
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a BcryptHasher with the given cost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash returns a modular crypt formatted bcrypt hash.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify compares the password against the bcrypt hash.
+func (h *BcryptHasher) Verify(password, encodedHash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Supports reports whether the hash is a bcrypt hash.
+func (h *BcryptHasher) Supports(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+// NeedsRehash reports whether the hash cost is below the configured cost.
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return true
+	}
+	return cost < h.cost
+}
+
+// PasswordService hashes new passwords with the preferred algorithm and
+// verifies stored hashes produced by any supported algorithm, including
+// legacy plaintext records. Every use of a plaintext record is logged, so the
+// fallback can be removed once they stop appearing.
+type PasswordService struct {
+	preferred PasswordHasher
+	hashers   []PasswordHasher
+	dummyHash string
+	logger    logger.Logger
+}
+
+// NewPasswordService creates a PasswordService from the hashing configuration.
+func NewPasswordService(hashConfig *config.PasswordHashConfig, log logger.Logger) (*PasswordService, error) {
+	argon2idHasher := NewArgon2idHasher(hashConfig.Argon2Memory, hashConfig.Argon2Iterations, hashConfig.Argon2Parallelism)
+	bcryptHasher := NewBcryptHasher(hashConfig.BcryptCost)
+
+	var preferred PasswordHasher
+	switch hashConfig.Algorithm {
+	case "", "argon2id":
+		preferred = argon2idHasher
+	case "bcrypt":
+		preferred = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", hashConfig.Algorithm)
+	}
+
+	service := &PasswordService{
+		preferred: preferred,
+		hashers:   []PasswordHasher{argon2idHasher, bcryptHasher},
+		logger:    log,
+	}
+
+	// Used to spend comparable time when no account matches the login email.
+	dummyHash, err := preferred.Hash("dummy-password-for-timing")
+	if err != nil {
+		return nil, err
+	}
+	service.dummyHash = dummyHash
+
+	return service, nil
+}
+
+// Hash hashes the password with the preferred algorithm.
+func (s *PasswordService) Hash(password string) (string, error) {
+	return s.preferred.Hash(password)
+}
+
+// Verify checks the password against a stored value. needsRehash is true when
+// the stored value is plaintext, uses a non-preferred algorithm, or uses
+// outdated cost parameters, in which case callers should store a fresh hash.
+func (s *PasswordService) Verify(password, stored string) (match bool, needsRehash bool, err error) {
+	for _, hasher := range s.hashers {
+		if !hasher.Supports(stored) {
+			continue
+		}
+
+		match, err = hasher.Verify(password, stored)
+		if err != nil || !match {
+			return false, false, err
+		}
+		return true, hasher != s.preferred || hasher.NeedsRehash(stored), nil
+	}
+
+	// Legacy records stored the password verbatim.
+	if stored == "" {
+		return false, false, nil
+	}
+	match = subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1
+	if match {
+		s.logger.Warn("Verified a legacy plaintext password record")
+	}
+	return match, match, nil
+}
+
+// DummyVerify performs a throwaway verification so that unknown accounts take
+// roughly as long to reject as known accounts with a wrong password.
+func (s *PasswordService) DummyVerify(password string) {
+	_, _ = s.preferred.Verify(password, s.dummyHash)
+}
@@ -0,0 +1,265 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestPasswordService(t *testing.T, algorithm string) (*services.PasswordService, *recordingLogger) {
+	t.Helper()
+
+	log := &recordingLogger{}
+	s, err := services.NewPasswordService(config.NewPasswordHashConfig(algorithm, 1024, 1, 1, bcrypt.MinCost+1), log)
+	if err != nil {
+		t.Fatalf("NewPasswordService: %v", err)
+	}
+	return s, log
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := services.NewArgon2idHasher(1024, 2, 1)
+	hash, err := h.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=2,p=1$") {
+		t.Errorf("Hash = %q, want a PHC string with m=1024,t=2,p=1", hash)
+	}
+	if other, _ := h.Hash(testPassword); other == hash {
+		t.Error("Hash returned the same hash twice, want a fresh salt")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+		wantErr  error
+	}{
+		{name: "right password", password: testPassword, hash: hash, want: true},
+		{name: "wrong password", password: "wrong-password", hash: hash},
+		{name: "too few fields", password: testPassword, hash: "$argon2id$v=19$m=1024,t=2,p=1$salt", wantErr: services.ErrMalformedHash},
+		{name: "bad parameters", password: testPassword, hash: "$argon2id$v=19$m=x$c2FsdA$a2V5", wantErr: services.ErrMalformedHash},
+		{name: "bad salt", password: testPassword, hash: "$argon2id$v=19$m=1024,t=2,p=1$!!$a2V5", wantErr: services.ErrMalformedHash},
+		{name: "empty key", password: testPassword, hash: "$argon2id$v=19$m=1024,t=2,p=1$c2FsdA$", wantErr: services.ErrMalformedHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Verify(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	configured := services.NewArgon2idHasher(2048, 2, 2)
+
+	tests := []struct {
+		name   string
+		hasher *services.Argon2idHasher
+		want   bool
+	}{
+		{name: "same parameters", hasher: services.NewArgon2idHasher(2048, 2, 2)},
+		{name: "stronger parameters", hasher: services.NewArgon2idHasher(4096, 3, 2)},
+		{name: "less memory", hasher: services.NewArgon2idHasher(1024, 2, 2), want: true},
+		{name: "fewer iterations", hasher: services.NewArgon2idHasher(2048, 1, 2), want: true},
+		{name: "less parallelism", hasher: services.NewArgon2idHasher(2048, 2, 1), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash(testPassword)
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if got := configured.NeedsRehash(hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !configured.NeedsRehash("$argon2id$garbage") {
+		t.Error("NeedsRehash of a malformed hash = false, want true")
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	h := services.NewBcryptHasher(bcrypt.MinCost + 1)
+	hash, err := h.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	cheaper, err := services.NewBcryptHasher(bcrypt.MinCost).Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		hash        string
+		want        bool
+		wantErr     bool
+		needsRehash bool
+	}{
+		{name: "right password", password: testPassword, hash: hash, want: true},
+		{name: "wrong password", password: "wrong-password", hash: hash},
+		{name: "lower cost", password: testPassword, hash: cheaper, want: true, needsRehash: true},
+		{name: "truncated hash", password: testPassword, hash: hash[:20], wantErr: true, needsRehash: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !h.Supports(tt.hash) {
+				t.Fatalf("Supports(%q) = false, want true", tt.hash)
+			}
+			got, err := h.Verify(tt.password, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+			if got := h.NeedsRehash(tt.hash); got != tt.needsRehash {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.needsRehash)
+			}
+		})
+	}
+
+	if got := services.NewBcryptHasher(bcrypt.MaxCost + 1).Cost(); got != bcrypt.DefaultCost {
+		t.Errorf("cost out of range = %d, want the default %d", got, bcrypt.DefaultCost)
+	}
+}
+
+func TestPasswordServiceVerify(t *testing.T) {
+	argon2idHash, err := services.NewArgon2idHasher(1024, 1, 1).Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	weakArgon2idHash, err := services.NewArgon2idHasher(512, 1, 1).Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	bcryptHash, err := services.NewBcryptHasher(bcrypt.MinCost + 1).Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		algorithm   string
+		password    string
+		stored      string
+		match       bool
+		needsRehash bool
+		warned      bool
+	}{
+		{name: "argon2id", algorithm: "argon2id", password: testPassword, stored: argon2idHash, match: true},
+		{name: "argon2id wrong password", algorithm: "argon2id", password: "wrong-password", stored: argon2idHash},
+		{name: "argon2id weaker parameters", algorithm: "argon2id", password: testPassword, stored: weakArgon2idHash, match: true, needsRehash: true},
+		{name: "bcrypt when argon2id is preferred", algorithm: "argon2id", password: testPassword, stored: bcryptHash, match: true, needsRehash: true},
+		{name: "bcrypt wrong password", algorithm: "argon2id", password: "wrong-password", stored: bcryptHash},
+		{name: "bcrypt when bcrypt is preferred", algorithm: "bcrypt", password: testPassword, stored: bcryptHash, match: true},
+		{name: "argon2id when bcrypt is preferred", algorithm: "bcrypt", password: testPassword, stored: argon2idHash, match: true, needsRehash: true},
+		{name: "plaintext", algorithm: "argon2id", password: testPassword, stored: testPassword, match: true, needsRehash: true, warned: true},
+		{name: "plaintext wrong password", algorithm: "argon2id", password: "wrong-password", stored: testPassword},
+		{name: "empty stored value", algorithm: "argon2id", password: "", stored: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, log := newTestPasswordService(t, tt.algorithm)
+
+			match, needsRehash, err := s.Verify(tt.password, tt.stored)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if match != tt.match || needsRehash != tt.needsRehash {
+				t.Errorf("Verify = (%v, %v), want (%v, %v)", match, needsRehash, tt.match, tt.needsRehash)
+			}
+			if warned := len(log.Warnings()) > 0; warned != tt.warned {
+				t.Errorf("warnings = %v, want a warning %v", log.Warnings(), tt.warned)
+			}
+		})
+	}
+}
+
+func TestNewPasswordServiceRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := services.NewPasswordService(config.NewPasswordHashConfig("md5", 1024, 1, 1, 4), &recordingLogger{}); err == nil {
+		t.Error("services.NewPasswordService with md5 succeeded, want an error")
+	}
+}
+
+func TestLoginRehashesOutdatedPasswords(t *testing.T) {
+	bcryptHash, err := services.NewBcryptHasher(bcrypt.MinCost).Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{name: "plaintext", stored: testPassword},
+		{name: "bcrypt", stored: bcryptHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			a := newTestAuthService(t)
+			user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+			if err := a.users.SetPasswordHash(ctx, services.DefaultTenantID, user.ID, user.Password, tt.stored); err != nil {
+				t.Fatalf("SetPasswordHash: %v", err)
+			}
+
+			if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+				t.Fatalf("Login: %v", err)
+			}
+
+			stored, err := a.users.FindByID(ctx, services.DefaultTenantID, user.ID)
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if !strings.HasPrefix(stored.Password, services.Argon2idPrefix) {
+				t.Errorf("stored password = %q, want an argon2id hash", stored.Password)
+			}
+			if _, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+				t.Errorf("Login with the upgraded hash: %v", err)
+			}
+		})
+	}
+}
+
+func TestRehashPasswordKeepsConcurrentChange(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	// The password changes between verifying the stale snapshot and rehashing.
+	changed, err := a.Passwords().Hash("Another-Horse-8")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if err := a.users.SetPasswordHash(ctx, services.DefaultTenantID, user.ID, user.Password, changed); err != nil {
+		t.Fatalf("SetPasswordHash: %v", err)
+	}
+
+	if err := a.RehashPassword(ctx, user, testPassword); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("rehashPassword of a stale user error = %v, want services.ErrUserNotFound", err)
+	}
+	stored, err := a.users.FindByID(ctx, services.DefaultTenantID, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.Password != changed {
+		t.Error("rehashPassword overwrote a concurrent password change")
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy rules reported in violations.
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRuleMaxRepeats   = "max_repeats"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+)
+
+const (
+	// breachedPrefixLength is the number of hex digits of the SHA-1 hash used
+	// to pick a bucket, as in the Pwned Passwords range API.
+	breachedPrefixLength = 5
+	// minPersonalInfoLength is the shortest email or name part that counts
+	// as personal information inside a password.
+	minPersonalInfoLength = 3
+)
+
+// ErrWeakPassword is returned when a password violates the password policy.
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// PasswordPolicyError wraps ErrWeakPassword with every rule the password failed.
+type PasswordPolicyError struct {
+	Violations []models.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string { return ErrWeakPassword.Error() }
+
+func (e *PasswordPolicyError) Unwrap() error { return ErrWeakPassword }
+
+// PasswordPolicy checks new passwords against the configured rules and an
+// optional corpus of breached passwords loaded from disk, so the check works
+// without network access.
+type PasswordPolicy struct {
+	config   *config.PasswordPolicyConfig
+	breached *breachedPasswords
+}
+
+// NewPasswordPolicy creates a new PasswordPolicy, loading the breached
+// password file if one is configured.
+func NewPasswordPolicy(policyConfig *config.PasswordPolicyConfig) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{config: policyConfig}
+	if policyConfig.BreachedPasswordFile != "" {
+		breached, err := loadBreachedPasswords(policyConfig.BreachedPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+	return policy, nil
+}
+
+// BreachedPasswordCount returns the number of hashes in the breached password
+// corpus.
+func (p *PasswordPolicy) BreachedPasswordCount() int {
+	if p.breached == nil {
+		return 0
+	}
+	return p.breached.count
+}
+
+// ForTenant returns the policy with a tenant's overrides applied. Overrides
+// can only add requirements: a shorter minimum length or a rule the tenant
+// does not require keeps the deployment setting. The breached password corpus
+// is shared.
+func (p *PasswordPolicy) ForTenant(overrides models.TenantPasswordPolicy) *PasswordPolicy {
+	merged := *p.config
+	if overrides.MinLength > merged.MinLength {
+		merged.MinLength = overrides.MinLength
+	}
+	if merged.MaxLength > 0 && merged.MinLength > merged.MaxLength {
+		merged.MinLength = merged.MaxLength
+	}
+	merged.RequireUppercase = merged.RequireUppercase || overrides.RequireUppercase
+	merged.RequireLowercase = merged.RequireLowercase || overrides.RequireLowercase
+	merged.RequireDigit = merged.RequireDigit || overrides.RequireDigit
+	merged.RequireSymbol = merged.RequireSymbol || overrides.RequireSymbol
+	return &PasswordPolicy{config: &merged, breached: p.breached}
+}
+
+// Validate returns a *PasswordPolicyError if the password of the account with
+// the given email and name violates the policy.
+func (p *PasswordPolicy) Validate(password, email, name string) error {
+	if violations := p.Check(password, email, name); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Check returns every rule the password violates, in a stable order.
+func (p *PasswordPolicy) Check(password, email, name string) []models.PasswordViolation {
+	var violations []models.PasswordViolation
+	violate := func(rule, format string, args ...interface{}) {
+		violations = append(violations, models.PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		violate(PasswordRuleMinLength, "Password must be at least %d characters long", p.config.MinLength)
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violate(PasswordRuleMaxLength, "Password must be at most %d characters long", p.config.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.config.RequireUppercase && !upper {
+		violate(PasswordRuleUppercase, "Password must contain an uppercase letter")
+	}
+	if p.config.RequireLowercase && !lower {
+		violate(PasswordRuleLowercase, "Password must contain a lowercase letter")
+	}
+	if p.config.RequireDigit && !digit {
+		violate(PasswordRuleDigit, "Password must contain a digit")
+	}
+	if p.config.RequireSymbol && !symbol {
+		violate(PasswordRuleSymbol, "Password must contain a symbol")
+	}
+
+	if p.config.MaxRepeats > 0 && longestRun(password) > p.config.MaxRepeats {
+		violate(PasswordRuleMaxRepeats, "Password must not repeat a character more than %d times in a row", p.config.MaxRepeats)
+	}
+
+	if p.config.RejectPersonalInfo && containsPersonalInfo(password, email, name) {
+		violate(PasswordRulePersonalInfo, "Password must not contain your name or email address")
+	}
+
+	if p.breached != nil && p.breached.contains(password) {
+		violate(PasswordRuleBreached, "Password has appeared in a data breach, please choose another one")
+	}
+
+	return violations
+}
+
+// longestRun returns the length of the longest run of one repeated character.
+func longestRun(password string) int {
+	longest, run := 0, 0
+	var previous rune = -1
+	for _, r := range password {
+		if r == previous {
+			run++
+		} else {
+			run = 1
+			previous = r
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// containsPersonalInfo reports whether the password contains the email
+// address, its local part or a word of the name, ignoring case. Parts shorter
+// than minPersonalInfoLength are ignored.
+func containsPersonalInfo(password, email, name string) bool {
+	password = strings.ToLower(password)
+
+	parts := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		parts = append(parts, email, local)
+	}
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// breachedPasswords holds SHA-1 hashes of breached passwords bucketed by the
+// first breachedPrefixLength hex digits, the layout of the Pwned Passwords
+// range files.
+type breachedPasswords struct {
+	buckets map[string][]string
+	count   int
+}
+
+// loadBreachedPasswords reads a file with one uppercase or lowercase hex
+// SHA-1 hash per line, optionally followed by ":count" as in the Pwned
+// Passwords downloads. Blank lines and lines starting with # are skipped.
+func loadBreachedPasswords(path string) (*breachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	defer file.Close()
+
+	corpus := &breachedPasswords{buckets: make(map[string][]string)}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("breached password file line %d: not a SHA-1 hash", lineNumber)
+		}
+		prefix := hash[:breachedPrefixLength]
+		corpus.buckets[prefix] = append(corpus.buckets[prefix], hash[breachedPrefixLength:])
+		corpus.count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password file: %w", err)
+	}
+
+	for _, suffixes := range corpus.buckets {
+		sort.Strings(suffixes)
+	}
+	return corpus, nil
+}
+
+func (b *breachedPasswords) contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes := b.buckets[hash[:breachedPrefixLength]]
+	suffix := hash[breachedPrefixLength:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
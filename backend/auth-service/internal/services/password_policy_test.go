@@ -0,0 +1,152 @@
+package services_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strictPolicyConfig() *config.PasswordPolicyConfig {
+	return &config.PasswordPolicyConfig{
+		MinLength:          10,
+		MaxLength:          64,
+		RequireUppercase:   true,
+		RequireLowercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		MaxRepeats:         3,
+		RejectPersonalInfo: true,
+	}
+}
+
+func violatedRules(violations []models.PasswordViolation) []string {
+	var rules []string
+	for _, violation := range violations {
+		rules = append(rules, violation.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy, err := services.NewPasswordPolicy(strictPolicyConfig())
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "compliant", password: "Correct-Horse-7", want: nil},
+		{name: "too short", password: "Sh0rt-pw", want: []string{services.PasswordRuleMinLength}},
+		{name: "too long", password: "Aa1-" + strings.Repeat("xy", 31), want: []string{services.PasswordRuleMaxLength}},
+		{name: "no uppercase", password: "correct-horse-7", want: []string{services.PasswordRuleUppercase}},
+		{name: "no lowercase", password: "CORRECT-HORSE-7", want: []string{services.PasswordRuleLowercase}},
+		{name: "no digit", password: "Correct-Horse-x", want: []string{services.PasswordRuleDigit}},
+		{name: "no symbol", password: "CorrectHorse77", want: []string{services.PasswordRuleSymbol}},
+		{name: "repeated characters", password: "Correct-Hoooorse-7", want: []string{services.PasswordRuleMaxRepeats}},
+		{name: "email local part", password: "Jane.Doe-2024!", want: []string{services.PasswordRulePersonalInfo}},
+		{name: "name word", password: "Smithfield-99", want: []string{services.PasswordRulePersonalInfo}},
+		{
+			name:     "several rules in a stable order",
+			password: "aaaa",
+			want:     []string{services.PasswordRuleMinLength, services.PasswordRuleUppercase, services.PasswordRuleDigit, services.PasswordRuleSymbol, services.PasswordRuleMaxRepeats},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violatedRules(policy.Check(tt.password, "jane.doe@example.com", "Jane Smith"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidateWrapsViolations(t *testing.T) {
+	policy, err := services.NewPasswordPolicy(strictPolicyConfig())
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+
+	err = policy.Validate("short", "", "")
+	if !errors.Is(err, services.ErrWeakPassword) {
+		t.Fatalf("Validate error = %v, want services.ErrWeakPassword", err)
+	}
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) == 0 {
+		t.Fatalf("Validate error = %#v, want a *services.PasswordPolicyError with violations", err)
+	}
+	if err := policy.Validate("Correct-Horse-7", "", ""); err != nil {
+		t.Errorf("Validate of a compliant password: %v", err)
+	}
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1 of "Password-123!" and "password", in Pwned Passwords format.
+	corpus := "# breached passwords\n\n586E4469603871FD64E290F4D7C1D8B46B81CD33:3\n5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8\n"
+	if err := os.WriteFile(path, []byte(corpus), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg := &config.PasswordPolicyConfig{MinLength: 8, BreachedPasswordFile: path}
+	policy, err := services.NewPasswordPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+	if got := policy.BreachedPasswordCount(); got != 2 {
+		t.Errorf("BreachedPasswordCount = %d, want 2", got)
+	}
+
+	if got := violatedRules(policy.Check("password", "", "")); !reflect.DeepEqual(got, []string{services.PasswordRuleBreached}) {
+		t.Errorf("Check of a breached password = %v, want [%s]", got, services.PasswordRuleBreached)
+	}
+	if got := policy.Check("not-in-the-corpus", "", ""); len(got) != 0 {
+		t.Errorf("Check of an unknown password = %v, want no violations", got)
+	}
+}
+
+func TestPasswordPolicyRejectsMalformedCorpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := services.NewPasswordPolicy(&config.PasswordPolicyConfig{BreachedPasswordFile: path}); err == nil {
+		t.Error("services.NewPasswordPolicy accepted a malformed breached password file")
+	}
+}
+
+func TestPasswordPolicyForTenantOnlyTightens(t *testing.T) {
+	policy, err := services.NewPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 10, MaxLength: 12, RequireDigit: true})
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides models.TenantPasswordPolicy
+		password  string
+		want      []string
+	}{
+		{name: "shorter minimum is ignored", overrides: models.TenantPasswordPolicy{MinLength: 6}, password: "abcdefg1", want: []string{services.PasswordRuleMinLength}},
+		{name: "longer minimum applies", overrides: models.TenantPasswordPolicy{MinLength: 11}, password: "abcdefghi1", want: []string{services.PasswordRuleMinLength}},
+		{name: "minimum is capped at the maximum", overrides: models.TenantPasswordPolicy{MinLength: 20}, password: "abcdefghijk1", want: nil},
+		{name: "extra rule applies", overrides: models.TenantPasswordPolicy{RequireSymbol: true}, password: "abcdefghi1", want: []string{services.PasswordRuleSymbol}},
+		{name: "deployment rule is kept", overrides: models.TenantPasswordPolicy{}, password: "abcdefghij", want: []string{services.PasswordRuleDigit}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violatedRules(policy.ForTenant(tt.overrides).Check(tt.password, "", ""))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
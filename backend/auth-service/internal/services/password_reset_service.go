@@ -0,0 +1,131 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PurposePasswordReset marks one-time tokens sent to reset a forgotten password.
+const PurposePasswordReset = "password_reset"
+
+// ErrPasswordResetThrottled is returned when a reset email was sent too recently.
+var ErrPasswordResetThrottled = errors.New("password reset email sent too recently")
+
+// PasswordResetService emails password reset links and sets the new password
+// when one is followed.
+type PasswordResetService struct {
+	users          UserRepository
+	authService    *AuthService
+	tokens         *OneTimeTokenService
+	mailer         Mailer
+	baseURL        string
+	ttl            time.Duration
+	resendInterval time.Duration
+}
+
+// NewPasswordResetService creates a new PasswordResetService. Links point at
+// baseURL + "/reset-password" and stay valid for ttl; resendInterval is the
+// minimum time between two emails for the same account.
+func NewPasswordResetService(
+	users UserRepository,
+	authService *AuthService,
+	tokens *OneTimeTokenService,
+	mailer Mailer,
+	baseURL string,
+	ttl time.Duration,
+	resendInterval time.Duration,
+) *PasswordResetService {
+	return &PasswordResetService{
+		users:          users,
+		authService:    authService,
+		tokens:         tokens,
+		mailer:         mailer,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		ttl:            ttl,
+		resendInterval: resendInterval,
+	}
+}
+
+// Forgot emails a reset link to the account of the tenant with the given
+// address. Unknown
+// addresses are ignored so callers cannot probe which accounts exist;
+// ErrPasswordResetThrottled is returned if the previous email is more recent
+// than the resend interval.
+func (s *PasswordResetService) Forgot(ctx context.Context, tenantID, email string) error {
+	user, err := s.users.FindByEmail(ctx, tenantID, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lastSent, err := s.tokens.LastIssued(ctx, PurposePasswordReset, user.ID)
+	if err != nil {
+		return err
+	}
+	if time.Since(lastSent) < s.resendInterval {
+		return ErrPasswordResetThrottled
+	}
+
+	// Binding the token to the current password hash invalidates it as soon
+	// as the password changes by any means.
+	token, err := s.tokens.Issue(ctx, PurposePasswordReset, user.ID, s.ttl, map[string]string{
+		"password": HashOpaqueToken(user.Password),
+		"tenant":   user.TenantID,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open the link below:\n\n%s\n\n"+
+			"The link expires in %s. If you did not ask for this, you can ignore this email; your password stays the same.\n",
+			user.Name, link, s.ttl),
+	})
+}
+
+// Reset consumes a reset token and sets the new password, revoking every
+// token the user holds. It returns the user ID. The account is looked up in
+// the tenant the token was issued in, whatever tenant the request names. A password that violates the
+// password policy fails with a *PasswordPolicyError and leaves the token
+// usable for another attempt.
+func (s *PasswordResetService) Reset(ctx context.Context, token, newPassword string, client models.ClientInfo) (string, error) {
+	record, err := s.tokens.Peek(ctx, PurposePasswordReset, token)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.authService.GetUser(tokenTenant(record, client), record.Subject)
+	if err != nil {
+		return "", ErrInvalidOneTimeToken
+	}
+	client.TenantID = user.TenantID
+	if HashOpaqueToken(user.Password) != record.Data["password"] {
+		return "", ErrInvalidOneTimeToken
+	}
+	if err := s.authService.ValidatePassword(user, newPassword); err != nil {
+		return "", err
+	}
+
+	if _, err := s.tokens.Consume(ctx, PurposePasswordReset, token); err != nil {
+		return "", err
+	}
+
+	err = s.authService.ReplacePassword(user, newPassword, "password_reset", client)
+	if errors.Is(err, ErrInvalidCredentials) {
+		return "", ErrInvalidOneTimeToken
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
@@ -0,0 +1,190 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPasswordReset is a PasswordResetService over the testAuth of the
+// tests.
+type testPasswordReset struct {
+	*services.PasswordResetService
+	*testAuth
+	tokens *testutil.MemoryOneTimeTokenRepository
+	mailer *testutil.MemoryMailer
+}
+
+func newTestPasswordReset(t *testing.T, resendInterval time.Duration) *testPasswordReset {
+	t.Helper()
+
+	tokens, repository := testutil.NewMemoryOneTimeTokenService()
+	r := &testPasswordReset{
+		testAuth: newTestAuthService(t),
+		tokens:   repository,
+		mailer:   testutil.NewMemoryMailer(),
+	}
+	r.PasswordResetService = services.NewPasswordResetService(r.users, r.AuthService, tokens, r.mailer,
+		"https://app.example.com", time.Hour, resendInterval)
+	return r
+}
+
+// forgot asks for a reset link for the address and returns its token.
+func (r *testPasswordReset) forgot(t *testing.T, tenantID, email string) string {
+	t.Helper()
+
+	if err := r.Forgot(context.Background(), tenantID, email); err != nil {
+		t.Fatalf("Forgot: %v", err)
+	}
+	return lastLink(t, r.mailer, email)
+}
+
+func TestPasswordResetSetsPasswordAndEndsSessions(t *testing.T) {
+	r := newTestPasswordReset(t, time.Minute)
+	user := r.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	login, err := r.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	token := r.forgot(t, services.DefaultTenantID, "jane@example.com")
+	if body := r.mailer.Messages()[0].Body; !strings.Contains(body, "https://app.example.com/reset-password?token=") {
+		t.Errorf("email body = %q, want a link to /reset-password", body)
+	}
+
+	userID, err := r.Reset(context.Background(), token, "Another-Horse-8", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if userID != user.ID {
+		t.Errorf("Reset user = %q, want %q", userID, user.ID)
+	}
+	if got := r.lastAudit(t); got.EventType != services.AuditPasswordChange || got.Outcome != services.AuditOutcomeSuccess || got.Reason != "password_reset" {
+		t.Errorf("audit event = %+v, want a successful password change for password_reset", got)
+	}
+	if _, err := r.Authenticate(login.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate after the reset error = %v, want services.ErrTokenRevoked", err)
+	}
+	if _, err := r.RefreshToken(login.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken after the reset error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	if _, err := r.Login("jane@example.com", "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login with the new password: %v", err)
+	}
+
+	if _, err := r.Reset(context.Background(), token, "Third-Horse-9", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+		t.Errorf("Reset with a used token error = %v, want services.ErrInvalidOneTimeToken", err)
+	}
+}
+
+func TestPasswordResetKeepsTokenAfterWeakPassword(t *testing.T) {
+	r := newTestPasswordReset(t, time.Minute)
+	r.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	token := r.forgot(t, services.DefaultTenantID, "jane@example.com")
+
+	var policyErr *services.PasswordPolicyError
+	if _, err := r.Reset(context.Background(), token, "short", client(services.DefaultTenantID)); !errors.As(err, &policyErr) {
+		t.Fatalf("Reset with a weak password error = %v, want a *services.PasswordPolicyError", err)
+	}
+	if _, err := r.Reset(context.Background(), token, "Another-Horse-8", client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Reset after a weak password: %v", err)
+	}
+}
+
+func TestPasswordResetRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, r *testPasswordReset, token string)
+	}{
+		{
+			name: "expired token",
+			setup: func(t *testing.T, r *testPasswordReset, token string) {
+				r.tokens.Expire()
+			},
+		},
+		{
+			name: "token issued before the password changed",
+			setup: func(t *testing.T, r *testPasswordReset, token string) {
+				if err := r.ChangePassword("jane@example.com@"+services.DefaultTenantID, testPassword, "Changed-Horse-8", client(services.DefaultTenantID)); err != nil {
+					t.Fatalf("ChangePassword: %v", err)
+				}
+			},
+		},
+		{
+			name: "token of a deleted account",
+			setup: func(t *testing.T, r *testPasswordReset, token string) {
+				if err := r.users.Delete(context.Background(), services.DefaultTenantID, "jane@example.com@"+services.DefaultTenantID); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestPasswordReset(t, time.Minute)
+			r.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+			token := r.forgot(t, services.DefaultTenantID, "jane@example.com")
+			tt.setup(t, r, token)
+
+			if _, err := r.Reset(context.Background(), token, "Another-Horse-8", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidOneTimeToken) {
+				t.Errorf("Reset error = %v, want services.ErrInvalidOneTimeToken", err)
+			}
+		})
+	}
+}
+
+func TestPasswordResetStaysInTheTenantOfTheToken(t *testing.T) {
+	r := newTestPasswordReset(t, time.Minute)
+	r.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	closed := r.addUser(t, "closed", "jane@example.com", "customer")
+
+	token := r.forgot(t, "closed", "jane@example.com")
+	// The link is followed on the host of the default tenant.
+	userID, err := r.Reset(context.Background(), token, "Another-Horse-8", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if userID != closed.ID {
+		t.Errorf("Reset user = %q, want the account of the closed tenant", userID)
+	}
+	if _, err := r.Login("jane@example.com", testPassword, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("Login to the default tenant with its unchanged password: %v", err)
+	}
+	if _, err := r.Login("jane@example.com", "Another-Horse-8", client("closed")); err != nil {
+		t.Errorf("Login to the closed tenant with the new password: %v", err)
+	}
+}
+
+func TestPasswordResetForgot(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ignores addresses of other tenants", func(t *testing.T) {
+		r := newTestPasswordReset(t, 0)
+		r.addUser(t, "closed", "jane@example.com", "customer")
+
+		for _, email := range []string{"unknown@example.com", "jane@example.com"} {
+			if err := r.Forgot(ctx, services.DefaultTenantID, email); err != nil {
+				t.Errorf("Forgot(%s): %v", email, err)
+			}
+		}
+		if messages := r.mailer.Messages(); len(messages) != 0 {
+			t.Errorf("emails = %+v, want none", messages)
+		}
+	})
+
+	t.Run("throttles", func(t *testing.T) {
+		r := newTestPasswordReset(t, time.Hour)
+		r.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+		if err := r.Forgot(ctx, services.DefaultTenantID, "jane@example.com"); err != nil {
+			t.Fatalf("Forgot: %v", err)
+		}
+		if err := r.Forgot(ctx, services.DefaultTenantID, "jane@example.com"); !errors.Is(err, services.ErrPasswordResetThrottled) {
+			t.Errorf("second Forgot error = %v, want services.ErrPasswordResetThrottled", err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package services
+
+import "sort"
+
+// Permissions granted through roles and embedded in access tokens. A plain
+// permission covers the caller's own resources; the ":any" variant covers
+// every user's.
+const (
+	PermissionUsersRead     = "users:read"
+	PermissionUsersReadAny  = "users:read:any"
+	PermissionUsersWrite    = "users:write"
+	PermissionUsersWriteAny = "users:write:any"
+	PermissionUsersDelete   = "users:delete"
+	PermissionRolesAssign   = "roles:assign"
+	// PermissionTokensIntrospect lets a service account call the token
+	// introspection endpoint. It is never granted through a role.
+	PermissionTokensIntrospect = "tokens:introspect"
+)
+
+// rolePermissions maps each known role to the permissions it grants.
+var rolePermissions = map[string][]string{
+	"customer": {
+		PermissionUsersRead,
+		PermissionUsersWrite,
+	},
+	"admin": {
+		PermissionUsersRead,
+		PermissionUsersReadAny,
+		PermissionUsersWrite,
+		PermissionUsersWriteAny,
+		PermissionUsersDelete,
+		PermissionRolesAssign,
+	},
+}
+
+// serviceScopes are the permissions that can be granted to service accounts
+// as client_credentials scopes. Permissions scoped to the caller's own
+// resources are excluded because a service account is not a user.
+var serviceScopes = []string{
+	PermissionUsersReadAny,
+	PermissionUsersWriteAny,
+	PermissionUsersDelete,
+	PermissionTokensIntrospect,
+}
+
+// PermissionsForRole returns the permissions granted by the role, or nil for
+// unknown roles.
+func PermissionsForRole(role string) []string {
+	permissions := rolePermissions[role]
+	if permissions == nil {
+		return nil
+	}
+	return append([]string(nil), permissions...)
+}
+
+// IsKnownRole reports whether the role is defined.
+func IsKnownRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsServiceScope reports whether the scope may be granted to a service account.
+func IsServiceScope(scope string) bool {
+	return containsString(serviceScopes, scope)
+}
+
+// Roles returns every known role with its permissions.
+func Roles() map[string][]string {
+	roles := make(map[string][]string, len(rolePermissions))
+	for role := range rolePermissions {
+		roles[role] = PermissionsForRole(role)
+	}
+	return roles
+}
+
+// RoleNames returns the names of all known roles in sorted order.
+func RoleNames() []string {
+	names := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	return names
+}
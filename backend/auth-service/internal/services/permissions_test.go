@@ -0,0 +1,132 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"errors"
+	"testing"
+)
+
+func TestPermissionsForRole(t *testing.T) {
+	customer := services.PermissionsForRole("customer")
+	if !containsPermission(customer, services.PermissionUsersRead) || containsPermission(customer, services.PermissionUsersReadAny) || containsPermission(customer, services.PermissionUsersDelete) {
+		t.Errorf("customer permissions = %v, want only the own-profile ones", customer)
+	}
+	admin := services.PermissionsForRole("admin")
+	for _, permission := range []string{services.PermissionUsersReadAny, services.PermissionUsersWriteAny, services.PermissionUsersDelete, services.PermissionRolesAssign} {
+		if !containsPermission(admin, permission) {
+			t.Errorf("admin permissions = %v, want %s", admin, permission)
+		}
+	}
+	if got := services.PermissionsForRole("owner"); got != nil {
+		t.Errorf("PermissionsForRole(owner) = %v, want nil", got)
+	}
+
+	// Callers get a copy.
+	customer[0] = services.PermissionUsersDelete
+	if got := services.PermissionsForRole("customer"); containsPermission(got, services.PermissionUsersDelete) {
+		t.Errorf("PermissionsForRole(customer) = %v after changing a previous result", got)
+	}
+
+	for role, permissions := range services.Roles() {
+		if containsPermission(permissions, services.PermissionTokensIntrospect) {
+			t.Errorf("role %s grants %s, which is only for service accounts", role, services.PermissionTokensIntrospect)
+		}
+	}
+}
+
+func TestIsServiceScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{scope: services.PermissionUsersReadAny, want: true},
+		{scope: services.PermissionTokensIntrospect, want: true},
+		{scope: services.PermissionUsersRead},
+		{scope: services.PermissionRolesAssign},
+		{scope: "openid"},
+	}
+	for _, tt := range tests {
+		if got := services.IsServiceScope(tt.scope); got != tt.want {
+			t.Errorf("IsServiceScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAccessTokenCarriesRolePermissions(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	claims, err := a.Authenticate(login.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.Role != "customer" || !claims.HasPermission(services.PermissionUsersWrite) || claims.HasPermission(services.PermissionUsersWriteAny) {
+		t.Errorf("claims = %+v, want the customer role and its permissions", claims)
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	updated, err := a.AssignRole(user.ID, "admin", "admin-1", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if updated.Role != "admin" {
+		t.Errorf("role = %q, want admin", updated.Role)
+	}
+	events := a.events.Events()
+	if len(events) != 1 || events[0].EventType != "user.updated.v1" || events[0].Role != "admin" || events[0].UserID != user.ID {
+		t.Errorf("published events = %+v, want user.updated.v1 with the admin role", events)
+	}
+	if got := a.lastAudit(t); got.Reason != "role_change" || got.ActorID != "admin-1" || got.UserID != user.ID {
+		t.Errorf("audit event = %+v, want the role change by admin-1", got)
+	}
+	// Tokens with the old permissions stop working.
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate of a token issued before the change error = %v, want services.ErrTokenRevoked", err)
+	}
+	if ok, err := a.HasRole(services.DefaultTenantID, user.ID, "admin"); err != nil || !ok {
+		t.Errorf("HasRole(admin) = %v, %v, want true", ok, err)
+	}
+}
+
+func TestAssignRoleRejects(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	other := a.addUser(t, "closed", "joe@example.com", "customer")
+
+	if _, err := a.AssignRole(user.ID, "owner", "admin-1", client(services.DefaultTenantID)); !errors.Is(err, services.ErrUnknownRole) {
+		t.Errorf("AssignRole of an unknown role error = %v, want services.ErrUnknownRole", err)
+	}
+	if _, err := a.AssignRole(other.ID, "admin", "admin-1", client(services.DefaultTenantID)); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("AssignRole to a user of another tenant error = %v, want services.ErrUserNotFound", err)
+	}
+	if ok, err := a.HasRole("closed", other.ID, "admin"); err != nil || ok {
+		t.Errorf("HasRole(admin) of the other tenant's user = %v, %v, want false", ok, err)
+	}
+	if ok, err := a.HasRole(services.DefaultTenantID, other.ID, "customer"); err != nil || ok {
+		t.Errorf("HasRole in a tenant the user is not in = %v, %v, want false", ok, err)
+	}
+	if events := a.events.Events(); len(events) != 0 {
+		t.Errorf("published events = %+v, want none", events)
+	}
+}
+
+func containsPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const refreshTokenCollection = "refresh_tokens"
+
+// RefreshTokenRepository stores refresh tokens by the hash of the opaque
+// token. Unknown hashes yield ErrInvalidRefreshToken.
+type RefreshTokenRepository interface {
+	// Insert stores a new refresh token.
+	Insert(ctx context.Context, token *models.RefreshToken) error
+	// MarkRotated marks the token with the hash as rotated at now and returns
+	// it as it was before, unless it was already rotated, revoked or expired
+	// by then; ErrInvalidRefreshToken is returned in that case. Only one of
+	// concurrent calls for the same token succeeds.
+	MarkRotated(ctx context.Context, tokenHash string, now time.Time) (*models.RefreshToken, error)
+	// SetReplacedBy records the hash of the token that replaced a rotated one.
+	SetReplacedBy(ctx context.Context, id, replacedBy string) error
+	// FindByHash returns the token with the hash, whatever its state.
+	FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// RevokeFamily revokes the tokens of the family that are not revoked yet.
+	RevokeFamily(ctx context.Context, familyID string, now time.Time) error
+	// RevokeUser revokes the tokens of the user that are not revoked yet.
+	RevokeUser(ctx context.Context, userID string, now time.Time) error
+}
+
+// MongoRefreshTokenRepository stores refresh tokens in the refresh_tokens
+// collection. A TTL index drops them once they expire.
+type MongoRefreshTokenRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoRefreshTokenRepository creates a new MongoRefreshTokenRepository
+func NewMongoRefreshTokenRepository(mongoConfig *config.MongoDBConfig) *MongoRefreshTokenRepository {
+	return &MongoRefreshTokenRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the lookup and TTL indexes for refresh tokens.
+func (r *MongoRefreshTokenRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Insert stores a new refresh token.
+func (r *MongoRefreshTokenRepository) Insert(ctx context.Context, token *models.RefreshToken) error {
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	_, err := collection.InsertOne(ctx, token)
+	return err
+}
+
+// MarkRotated marks a usable token as rotated and returns it as it was before.
+func (r *MongoRefreshTokenRepository) MarkRotated(ctx context.Context, tokenHash string, now time.Time) (*models.RefreshToken, error) {
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	var token models.RefreshToken
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"tokenHash": tokenHash,
+			"rotatedAt": bson.M{"$exists": false},
+			"revokedAt": bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"rotatedAt": now}},
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// SetReplacedBy records the hash of the token that replaced a rotated one.
+func (r *MongoRefreshTokenRepository) SetReplacedBy(ctx context.Context, id, replacedBy string) error {
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"replacedBy": replacedBy}},
+	)
+	return err
+}
+
+// FindByHash returns the token with the hash, whatever its state.
+func (r *MongoRefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	var token models.RefreshToken
+	err := collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeFamily revokes the tokens of the family that are not revoked yet.
+func (r *MongoRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	return r.revoke(ctx, bson.M{"familyId": familyID}, now)
+}
+
+// RevokeUser revokes the tokens of the user that are not revoked yet.
+func (r *MongoRefreshTokenRepository) RevokeUser(ctx context.Context, userID string, now time.Time) error {
+	return r.revoke(ctx, bson.M{"userId": userID}, now)
+}
+
+func (r *MongoRefreshTokenRepository) revoke(ctx context.Context, filter bson.M, now time.Time) error {
+	filter["revokedAt"] = bson.M{"$exists": false}
+	collection := r.mongoConfig.GetCollection(refreshTokenCollection)
+	_, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": now}})
+	return err
+}
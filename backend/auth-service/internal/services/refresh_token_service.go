@@ -0,0 +1,167 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented again. The whole token family is revoked when this happens.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenStore issues, rotates and revokes refresh token families.
+// RefreshTokenService is the production implementation; over a
+// testutil.MemoryRefreshTokenRepository it also serves tests.
+type RefreshTokenStore interface {
+	Issue(ctx context.Context, tenantID, userID, familyID string, amr []string) (string, error)
+	Rotate(ctx context.Context, token string) (*models.RefreshToken, string, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeFamilyOf(ctx context.Context, token, userID string) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+var _ RefreshTokenStore = (*RefreshTokenService)(nil)
+
+// RefreshTokenService issues and rotates opaque refresh tokens.
+type RefreshTokenService struct {
+	tokens RefreshTokenRepository
+	ttl    time.Duration
+}
+
+// NewRefreshTokenService creates a new RefreshTokenService storing tokens in
+// the repository with the given token lifetime.
+func NewRefreshTokenService(tokens RefreshTokenRepository, ttl time.Duration) *RefreshTokenService {
+	return &RefreshTokenService{
+		tokens: tokens,
+		ttl:    ttl,
+	}
+}
+
+// Issue creates the first refresh token of a token family for a user of the
+// tenant. amr records how the user authenticated; it and the tenant are
+// carried over to rotated tokens.
+func (s *RefreshTokenService) Issue(ctx context.Context, tenantID, userID, familyID string, amr []string) (string, error) {
+	return s.issue(ctx, tenantID, userID, familyID, amr)
+}
+
+func (s *RefreshTokenService) issue(ctx context.Context, tenantID, userID, familyID string, amr []string) (string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	record := models.RefreshToken{
+		ID:        primitive.NewObjectID().Hex(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TenantID:  tenantID,
+		AMR:       amr,
+		TokenHash: HashOpaqueToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	if err := s.tokens.Insert(ctx, &record); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Rotate exchanges a refresh token for a new one in the same family and
+// returns the rotated record. Presenting a token that was already rotated
+// revokes the family and returns ErrRefreshTokenReused.
+func (s *RefreshTokenService) Rotate(ctx context.Context, token string) (*models.RefreshToken, string, error) {
+	tokenHash := HashOpaqueToken(token)
+
+	current, err := s.tokens.MarkRotated(ctx, tokenHash, time.Now().UTC())
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		return nil, "", s.classifyRejected(ctx, tokenHash)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	newToken, err := s.issue(ctx, current.TenantID, current.UserID, current.FamilyID, current.AMR)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.tokens.SetReplacedBy(ctx, current.ID, HashOpaqueToken(newToken)); err != nil {
+		return nil, "", err
+	}
+
+	return current, newToken, nil
+}
+
+// classifyRejected determines why a refresh token could not be rotated and
+// revokes its family if the token is being replayed.
+func (s *RefreshTokenService) classifyRejected(ctx context.Context, tokenHash string) error {
+	existing, err := s.tokens.FindByHash(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+
+	if existing.RotatedAt != nil && existing.RevokedAt == nil {
+		if err := s.RevokeFamily(ctx, existing.FamilyID); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	return ErrInvalidRefreshToken
+}
+
+// RevokeFamily revokes every token in a refresh token family.
+func (s *RefreshTokenService) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.tokens.RevokeFamily(ctx, familyID, time.Now().UTC())
+}
+
+// RevokeFamilyOf revokes the family of the given refresh token if it belongs
+// to the user. Unknown tokens are ignored.
+func (s *RefreshTokenService) RevokeFamilyOf(ctx context.Context, token, userID string) error {
+	existing, err := s.tokens.FindByHash(ctx, HashOpaqueToken(token))
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return nil
+	}
+
+	return s.RevokeFamily(ctx, existing.FamilyID)
+}
+
+// RevokeAllForUser revokes every refresh token issued to the user.
+func (s *RefreshTokenService) RevokeAllForUser(ctx context.Context, userID string) error {
+	return s.tokens.RevokeUser(ctx, userID, time.Now().UTC())
+}
+
+// NewOpaqueToken returns 256 bits of randomness encoded as URL-safe base64.
+func NewOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 digest used to store opaque tokens.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,170 @@
+package services_test
+
+import (
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestRefreshTokenService() *services.RefreshTokenService {
+	return services.NewRefreshTokenService(testutil.NewMemoryRefreshTokenRepository(), time.Hour)
+}
+
+// newExpiredRefreshTokenService issues refresh tokens that have expired
+// already.
+func newExpiredRefreshTokenService() *services.RefreshTokenService {
+	return services.NewRefreshTokenService(testutil.NewMemoryRefreshTokenRepository(), -time.Second)
+}
+
+func TestRefreshTokenRotateCarriesFamilyOver(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRefreshTokenService()
+
+	token, err := s.Issue(ctx, "acme", "user-1", "family-1", []string{services.AMRPassword})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	rotated, next, err := s.Rotate(ctx, token)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if next == "" || next == token {
+		t.Fatalf("Rotate returned token %q, want a new token", next)
+	}
+	if rotated.FamilyID != "family-1" || rotated.UserID != "user-1" || rotated.TenantID != "acme" {
+		t.Errorf("rotated token = %+v, want family-1 of user-1 in acme", rotated)
+	}
+	if len(rotated.AMR) != 1 || rotated.AMR[0] != services.AMRPassword {
+		t.Errorf("rotated AMR = %v, want [%s]", rotated.AMR, services.AMRPassword)
+	}
+
+	again, _, err := s.Rotate(ctx, next)
+	if err != nil {
+		t.Fatalf("Rotate of the new token: %v", err)
+	}
+	if again.FamilyID != "family-1" || again.TenantID != "acme" {
+		t.Errorf("second rotation = %+v, want family-1 in acme", again)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRefreshTokenService()
+
+	first, err := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-1", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	other, err := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-2", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	_, second, err := s.Rotate(ctx, first)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// Replaying the rotated token is reuse and takes the family down.
+	if _, _, err := s.Rotate(ctx, first); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("replayed Rotate error = %v, want services.ErrRefreshTokenReused", err)
+	}
+	if _, _, err := s.Rotate(ctx, second); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("Rotate of the family's current token error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	// A revoked family is not reported as reuse again.
+	if _, _, err := s.Rotate(ctx, first); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("second replay error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+
+	// Other families of the user are not affected.
+	if _, _, err := s.Rotate(ctx, other); err != nil {
+		t.Errorf("Rotate of another family: %v", err)
+	}
+}
+
+func TestRefreshTokenRotateRejects(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		expired bool
+		setup   func(s *services.RefreshTokenService) string
+	}{
+		{
+			name: "unknown token",
+			setup: func(s *services.RefreshTokenService) string {
+				return "not-a-token"
+			},
+		},
+		{
+			name:    "expired token",
+			expired: true,
+			setup: func(s *services.RefreshTokenService) string {
+				token, _ := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-1", nil)
+				return token
+			},
+		},
+		{
+			name: "revoked family",
+			setup: func(s *services.RefreshTokenService) string {
+				token, _ := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-1", nil)
+				_ = s.RevokeFamily(ctx, "family-1")
+				return token
+			},
+		},
+		{
+			name: "revoked user",
+			setup: func(s *services.RefreshTokenService) string {
+				token, _ := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-1", nil)
+				_ = s.RevokeAllForUser(ctx, "user-1")
+				return token
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRefreshTokenService()
+			if tt.expired {
+				s = newExpiredRefreshTokenService()
+			}
+			token := tt.setup(s)
+
+			if _, _, err := s.Rotate(ctx, token); !errors.Is(err, services.ErrInvalidRefreshToken) {
+				t.Errorf("Rotate error = %v, want services.ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenRevokeFamilyOf(t *testing.T) {
+	ctx := context.Background()
+	s := newTestRefreshTokenService()
+
+	token, err := s.Issue(ctx, services.DefaultTenantID, "user-1", "family-1", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// Another user cannot end the family, and unknown tokens are ignored.
+	if err := s.RevokeFamilyOf(ctx, token, "user-2"); err != nil {
+		t.Fatalf("RevokeFamilyOf by another user: %v", err)
+	}
+	if err := s.RevokeFamilyOf(ctx, "not-a-token", "user-1"); err != nil {
+		t.Fatalf("RevokeFamilyOf of an unknown token: %v", err)
+	}
+	_, token, err = s.Rotate(ctx, token)
+	if err != nil {
+		t.Fatalf("Rotate after foreign RevokeFamilyOf: %v", err)
+	}
+
+	if err := s.RevokeFamilyOf(ctx, token, "user-1"); err != nil {
+		t.Fatalf("RevokeFamilyOf: %v", err)
+	}
+	if _, _, err := s.Rotate(ctx, token); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("Rotate after RevokeFamilyOf error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+}
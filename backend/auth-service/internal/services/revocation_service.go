@@ -0,0 +1,189 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const revokedTokenCollection = "revoked_tokens"
+
+// ErrTokenRevoked is returned when a token is on the denylist.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationList is the access token denylist. RevocationService is the
+// production implementation and testutil.MemoryRevocationList the one for
+// tests.
+type RevocationList interface {
+	RevokeToken(ctx context.Context, claims *Claims, reason string) error
+	RevokeAllForUser(ctx context.Context, userID, reason string) error
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+var _ RevocationList = (*RevocationService)(nil)
+
+// RevocationService maintains the access token denylist.
+type RevocationService struct {
+	mongoConfig *config.MongoDBConfig
+	publisher   *KafkaPublisher
+	tokenTTL    time.Duration
+	logger      logger.Logger
+}
+
+// NewRevocationService creates a new RevocationService. tokenTTL is the
+// longest lifetime of any access token, impersonation tokens included; it
+// bounds how long user-wide revocations must be retained. Tenant settings
+// only ever shorten token lifetimes, so they need no longer retention.
+func NewRevocationService(mongoConfig *config.MongoDBConfig, publisher *KafkaPublisher, tokenTTL time.Duration, log logger.Logger) *RevocationService {
+	return &RevocationService{
+		mongoConfig: mongoConfig,
+		publisher:   publisher,
+		tokenTTL:    tokenTTL,
+		logger:      log,
+	}
+}
+
+// EnsureIndexes creates the TTL index that drops entries once the revoked
+// tokens would have expired anyway.
+func (s *RevocationService) EnsureIndexes(ctx context.Context) error {
+	collection := s.mongoConfig.GetCollection(revokedTokenCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// RevokeToken adds a single access token to the denylist until it expires.
+func (s *RevocationService) RevokeToken(ctx context.Context, claims *Claims, reason string) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return errors.New("token cannot be revoked individually")
+	}
+
+	entry := models.RevokedToken{
+		ID:        "jti:" + claims.ID,
+		Type:      "token",
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		Reason:    reason,
+		RevokedAt: time.Now().UTC(),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	collection := s.mongoConfig.GetCollection(revokedTokenCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": entry.ID},
+		bson.M{"$setOnInsert": entry},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, models.TokenRevokedEvent{
+		UserID:    entry.UserID,
+		JTI:       entry.JTI,
+		ExpiresAt: entry.ExpiresAt,
+		Reason:    reason,
+	})
+	return nil
+}
+
+// RevokeAllForUser revokes every access token issued to the user up to now.
+func (s *RevocationService) RevokeAllForUser(ctx context.Context, userID, reason string) error {
+	now := time.Now().UTC()
+	revokedBefore := now
+	expiresAt := now.Add(s.tokenTTL)
+
+	collection := s.mongoConfig.GetCollection(revokedTokenCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": "user:" + userID},
+		bson.M{"$set": models.RevokedToken{
+			ID:            "user:" + userID,
+			Type:          "user",
+			UserID:        userID,
+			RevokedBefore: &revokedBefore,
+			Reason:        reason,
+			RevokedAt:     now,
+			ExpiresAt:     expiresAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, models.TokenRevokedEvent{
+		UserID:        userID,
+		RevokedBefore: &revokedBefore,
+		ExpiresAt:     expiresAt,
+		Reason:        reason,
+	})
+	return nil
+}
+
+// IsRevoked reports whether the token is on the denylist, either by its jti
+// or by a user-wide revocation issued after the token.
+//
+// Tokens are ordered against user-wide revocations by their iat_ms claim,
+// since MongoDB stores the revocation time in milliseconds. A token issued in
+// the same millisecond as a revocation, or without iat_ms in the same second,
+// is revoked.
+func (s *RevocationService) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	var ids []string
+	if claims.UserID != "" {
+		ids = append(ids, "user:"+claims.UserID)
+	}
+	if claims.ID != "" {
+		ids = append(ids, "jti:"+claims.ID)
+	}
+
+	collection := s.mongoConfig.GetCollection(revokedTokenCollection)
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry models.RevokedToken
+		if err := cursor.Decode(&entry); err != nil {
+			return false, err
+		}
+
+		switch entry.Type {
+		case "token":
+			return true, nil
+		case "user":
+			if entry.RevokedBefore == nil || claims.IssuedNoLaterThan(*entry.RevokedBefore) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, cursor.Err()
+}
+
+func (s *RevocationService) publish(ctx context.Context, event models.TokenRevokedEvent) {
+	event.EventID = primitive.NewObjectID().Hex()
+	event.EventType = "token.revoked.v1"
+	event.Timestamp = time.Now().UTC()
+
+	// The denylist is authoritative; consumers only use the event to drop caches.
+	if err := s.publisher.PublishTokenRevoked(ctx, event); err != nil {
+		s.logger.Warn("Failed to publish token revocation event",
+			zap.String("user_id", event.UserID),
+			zap.String("jti", event.JTI),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRevokeAllForUserCoversTokensOfTheSameSecond(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+
+	login, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	// The login and the revocation almost always fall into the same second.
+	if _, err := a.AssignRole(user.ID, "admin", "admin-1", client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("Authenticate of a token issued before the revocation error = %v, want services.ErrTokenRevoked", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	again, err := a.Login("jane@example.com", testPassword, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("Login after the revocation: %v", err)
+	}
+	if _, err := a.Authenticate(again.Token); err != nil {
+		t.Errorf("Authenticate of a token issued after the revocation: %v", err)
+	}
+}
+
+func TestClaimsIssuedNoLaterThan(t *testing.T) {
+	revokedAt := time.Date(2026, 3, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+	second := revokedAt.Truncate(time.Second)
+
+	tests := []struct {
+		name   string
+		claims services.Claims
+		want   bool
+	}{
+		{name: "earlier in the same second", claims: services.Claims{IssuedAtMillis: second.UnixMilli()}, want: true},
+		{name: "same millisecond", claims: services.Claims{IssuedAtMillis: revokedAt.UnixMilli()}, want: true},
+		{name: "next millisecond", claims: services.Claims{IssuedAtMillis: revokedAt.UnixMilli() + 1}},
+		{name: "without iat_ms in the same second", claims: services.Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(second)}}, want: true},
+		{name: "without iat_ms in the next second", claims: services.Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(second.Add(time.Second))}}},
+		{name: "without issue time", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.IssuedNoLaterThan(revokedAt); got != tt.want {
+				t.Errorf("IssuedNoLaterThan = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationServiceLogsUnpublishedEvent(t *testing.T) {
+	log := &recordingLogger{}
+	s := services.NewRevocationService(nil, services.NewFailingKafkaPublisher(), time.Hour, log)
+
+	s.Publish(context.Background(), models.TokenRevokedEvent{UserID: "user-1", JTI: "token-1", Reason: "logout"})
+	if warns := log.Warnings(); len(warns) != 1 || warns[0] != "Failed to publish token revocation event" {
+		t.Errorf("logged warnings = %v, want the unpublished revocation event", warns)
+	}
+}
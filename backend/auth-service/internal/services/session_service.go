@@ -0,0 +1,208 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sessionCollection = "sessions"
+
+// maxUserAgentLength bounds the stored user agent of a session.
+const maxUserAgentLength = 512
+
+// ErrSessionNotFound is returned for unknown, expired or terminated sessions.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore records the sessions of signed-in users. SessionService is the
+// production implementation and testutil.MemorySessionStore the one for tests.
+type SessionStore interface {
+	Create(ctx context.Context, userID string, amr []string, client models.ClientInfo) (*models.Session, error)
+	Touch(ctx context.Context, familyID string, client models.ClientInfo) (*models.Session, error)
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+	List(ctx context.Context, userID string) ([]models.Session, error)
+	Terminate(ctx context.Context, userID, sessionID string) error
+	TerminateOthers(ctx context.Context, userID, keepSessionID string) (int, error)
+	TerminateAll(ctx context.Context, userID string) error
+}
+
+var _ SessionStore = (*SessionService)(nil)
+
+// SessionService records the devices a user is signed in on. Terminating a
+// session revokes its refresh token family, and access tokens carrying its
+// ID are rejected from then on.
+type SessionService struct {
+	mongoConfig   *config.MongoDBConfig
+	refreshTokens RefreshTokenStore
+	ttl           time.Duration
+}
+
+// NewSessionService creates a new SessionService. Sessions expire after ttl
+// without a token refresh.
+func NewSessionService(mongoConfig *config.MongoDBConfig, refreshTokens RefreshTokenStore, ttl time.Duration) *SessionService {
+	return &SessionService{
+		mongoConfig:   mongoConfig,
+		refreshTokens: refreshTokens,
+		ttl:           ttl,
+	}
+}
+
+// EnsureIndexes creates the lookup and TTL indexes for sessions.
+func (s *SessionService) EnsureIndexes(ctx context.Context) error {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Create records a new session with a fresh refresh token family.
+func (s *SessionService) Create(ctx context.Context, userID string, amr []string, client models.ClientInfo) (*models.Session, error) {
+	now := time.Now().UTC()
+	session := models.Session{
+		ID:         primitive.NewObjectID().Hex(),
+		UserID:     userID,
+		FamilyID:   primitive.NewObjectID().Hex(),
+		UserAgent:  TruncateUserAgent(client.UserAgent),
+		IPAddress:  client.IPAddress,
+		AMR:        amr,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Touch records activity on the session owning the refresh token family and
+// extends its lifetime.
+func (s *SessionService) Touch(ctx context.Context, familyID string, client models.ClientInfo) (*models.Session, error) {
+	now := time.Now().UTC()
+	set := bson.M{"lastSeenAt": now, "expiresAt": now.Add(s.ttl)}
+	if client.IPAddress != "" {
+		set["ipAddress"] = client.IPAddress
+	}
+	if client.UserAgent != "" {
+		set["userAgent"] = TruncateUserAgent(client.UserAgent)
+	}
+
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	var session models.Session
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"familyId": familyID, "expiresAt": bson.M{"$gt": now}},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// IsActive reports whether the session exists and has not expired.
+func (s *SessionService) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": sessionID, "expiresAt": bson.M{"$gt": time.Now().UTC()}})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// List returns the user's active sessions, most recently used first.
+func (s *SessionService) List(ctx context.Context, userID string) ([]models.Session, error) {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	cursor, err := collection.Find(ctx,
+		bson.M{"userId": userID, "expiresAt": bson.M{"$gt": time.Now().UTC()}},
+		options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Terminate ends one of the user's sessions and revokes its refresh tokens.
+func (s *SessionService) Terminate(ctx context.Context, userID, sessionID string) error {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+
+	var session models.Session
+	err := collection.FindOneAndDelete(ctx, bson.M{"_id": sessionID, "userId": userID}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.refreshTokens.RevokeFamily(ctx, session.FamilyID)
+}
+
+// TerminateOthers ends every session of the user except keepSessionID and
+// returns how many were ended.
+func (s *SessionService) TerminateOthers(ctx context.Context, userID, keepSessionID string) (int, error) {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	filter := bson.M{"userId": userID, "_id": bson.M{"$ne": keepSessionID}}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return 0, err
+	}
+
+	terminated := 0
+	for _, session := range sessions {
+		result, err := collection.DeleteOne(ctx, bson.M{"_id": session.ID})
+		if err != nil {
+			return terminated, err
+		}
+		if err := s.refreshTokens.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return terminated, err
+		}
+		terminated += int(result.DeletedCount)
+	}
+	return terminated, nil
+}
+
+// TerminateAll ends every session of the user and revokes all of their
+// refresh tokens.
+func (s *SessionService) TerminateAll(ctx context.Context, userID string) error {
+	collection := s.mongoConfig.GetCollection(sessionCollection)
+	if _, err := collection.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeAllForUser(ctx, userID)
+}
+
+// TruncateUserAgent shortens a user agent to the length stored with sessions.
+func TruncateUserAgent(userAgent string) string {
+	if len(userAgent) > maxUserAgentLength {
+		return userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"errors"
+	"testing"
+)
+
+// loginOn signs the user in from another device.
+func (a *testAuth) loginOn(t *testing.T, email, userAgent string) *models.LoginResponse {
+	t.Helper()
+
+	info := client(services.DefaultTenantID)
+	info.UserAgent = userAgent
+	login, err := a.Login(email, testPassword, info)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return login
+}
+
+func (a *testAuth) claims(t *testing.T, token string) *services.Claims {
+	t.Helper()
+
+	claims, err := a.Authenticate(token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	return claims
+}
+
+func TestListSessionsFlagsCurrent(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.addUser(t, services.DefaultTenantID, "joe@example.com", "customer")
+	laptop := a.loginOn(t, "jane@example.com", "laptop")
+	a.loginOn(t, "jane@example.com", "phone")
+	a.loginOn(t, "joe@example.com", "laptop")
+
+	current := a.claims(t, laptop.Token)
+	sessions, err := a.ListSessions(current)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %+v, want the two of jane", sessions)
+	}
+	for _, session := range sessions {
+		if session.UserID != current.UserID {
+			t.Errorf("session %+v belongs to another user", session)
+		}
+		if want := session.ID == current.SessionID; session.Current != want || (want && session.UserAgent != "laptop") {
+			t.Errorf("session %+v, want only the laptop flagged current", session)
+		}
+	}
+}
+
+func TestTerminateSessionEndsItsTokens(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	laptop := a.loginOn(t, "jane@example.com", "laptop")
+	phone := a.loginOn(t, "jane@example.com", "phone")
+	phoneSession := a.claims(t, phone.Token).SessionID
+
+	if err := a.TerminateSession(user.ID, phoneSession, client(services.DefaultTenantID)); err != nil {
+		t.Fatalf("TerminateSession: %v", err)
+	}
+	if _, err := a.Authenticate(phone.Token); !errors.Is(err, services.ErrSessionNotFound) {
+		t.Errorf("Authenticate of the terminated session error = %v, want services.ErrSessionNotFound", err)
+	}
+	if _, err := a.RefreshToken(phone.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken of the terminated session error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	if got := a.lastAudit(t); got.Reason != "invalid_refresh_token" {
+		t.Errorf("audit event = %+v, want the rejected refresh", got)
+	}
+
+	// The other device stays signed in.
+	if _, err := a.Authenticate(laptop.Token); err != nil {
+		t.Errorf("Authenticate of the other session: %v", err)
+	}
+	if _, err := a.RefreshToken(laptop.RefreshToken, client(services.DefaultTenantID)); err != nil {
+		t.Errorf("RefreshToken of the other session: %v", err)
+	}
+
+	if err := a.TerminateSession(user.ID, phoneSession, client(services.DefaultTenantID)); !errors.Is(err, services.ErrSessionNotFound) {
+		t.Errorf("second TerminateSession error = %v, want services.ErrSessionNotFound", err)
+	}
+}
+
+func TestTerminateSessionOfAnotherUser(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	joe := a.addUser(t, services.DefaultTenantID, "joe@example.com", "customer")
+	jane := a.loginOn(t, "jane@example.com", "laptop")
+	janeSession := a.claims(t, jane.Token).SessionID
+
+	if err := a.TerminateSession(joe.ID, janeSession, client(services.DefaultTenantID)); !errors.Is(err, services.ErrSessionNotFound) {
+		t.Errorf("TerminateSession of another user's session error = %v, want services.ErrSessionNotFound", err)
+	}
+	if _, err := a.Authenticate(jane.Token); err != nil {
+		t.Errorf("Authenticate after another user tried to end the session: %v", err)
+	}
+}
+
+func TestTerminateOtherSessions(t *testing.T) {
+	a := newTestAuthService(t)
+	a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.addUser(t, services.DefaultTenantID, "joe@example.com", "customer")
+	laptop := a.loginOn(t, "jane@example.com", "laptop")
+	phone := a.loginOn(t, "jane@example.com", "phone")
+	tablet := a.loginOn(t, "jane@example.com", "tablet")
+	joe := a.loginOn(t, "joe@example.com", "laptop")
+
+	terminated, err := a.TerminateOtherSessions(a.claims(t, laptop.Token), client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("TerminateOtherSessions: %v", err)
+	}
+	if terminated != 2 {
+		t.Errorf("terminated = %d, want 2", terminated)
+	}
+	if got := a.lastAudit(t); got.Reason != "other_sessions_terminated" {
+		t.Errorf("audit event = %+v, want other_sessions_terminated", got)
+	}
+	for _, login := range []*models.LoginResponse{phone, tablet} {
+		if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrSessionNotFound) {
+			t.Errorf("Authenticate of an ended session error = %v, want services.ErrSessionNotFound", err)
+		}
+	}
+	for _, login := range []*models.LoginResponse{laptop, joe} {
+		if _, err := a.Authenticate(login.Token); err != nil {
+			t.Errorf("Authenticate of a kept session: %v", err)
+		}
+	}
+
+	// Nothing left to end: no audit event.
+	events := len(a.audit.Events())
+	if terminated, err := a.TerminateOtherSessions(a.claims(t, laptop.Token), client(services.DefaultTenantID)); err != nil || terminated != 0 {
+		t.Errorf("second TerminateOtherSessions = %d, %v, want 0", terminated, err)
+	}
+	if got := len(a.audit.Events()); got != events {
+		t.Errorf("recorded %d audit events, want none", got-events)
+	}
+}
+
+func TestExpiredSessionCannotBeRefreshed(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	login := a.loginOn(t, "jane@example.com", "laptop")
+
+	a.sessions.Expire()
+
+	if _, err := a.Authenticate(login.Token); !errors.Is(err, services.ErrSessionNotFound) {
+		t.Errorf("Authenticate of an expired session error = %v, want services.ErrSessionNotFound", err)
+	}
+	if _, err := a.RefreshToken(login.RefreshToken, client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken of an expired session error = %v, want services.ErrInvalidRefreshToken", err)
+	}
+	if got := a.lastAudit(t); got.Reason != "session_terminated" {
+		t.Errorf("audit event = %+v, want session_terminated", got)
+	}
+	if sessions, err := a.ListSessions(&services.Claims{UserID: user.ID}); err != nil || len(sessions) != 0 {
+		t.Errorf("ListSessions = %+v, %v, want none", sessions, err)
+	}
+}
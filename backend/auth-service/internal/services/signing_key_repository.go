@@ -0,0 +1,90 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const signingKeyCollection = "signing_keys"
+
+// SigningKeyRepository stores the JWT signing keys shared by all replicas.
+// MongoSigningKeyRepository is the production implementation and
+// testutil.MemorySigningKeyRepository the one for tests.
+type SigningKeyRepository interface {
+	// Insert stores a new key.
+	Insert(ctx context.Context, key *models.SigningKey) error
+	// RetireOthers retires every key other than keepID that is not retired
+	// yet. Retired keys stay verifiable until verifyUntil.
+	RetireOthers(ctx context.Context, keepID string, retiredAt, verifyUntil time.Time) error
+	// ListVerifiable returns the keys that are not retired or are still
+	// verifiable at now.
+	ListVerifiable(ctx context.Context, now time.Time) ([]models.SigningKey, error)
+}
+
+var _ SigningKeyRepository = (*MongoSigningKeyRepository)(nil)
+
+// MongoSigningKeyRepository stores signing keys in the signing_keys
+// collection. A TTL index drops them once no token signed with them can
+// still be valid.
+type MongoSigningKeyRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoSigningKeyRepository creates a new MongoSigningKeyRepository
+func NewMongoSigningKeyRepository(mongoConfig *config.MongoDBConfig) *MongoSigningKeyRepository {
+	return &MongoSigningKeyRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the TTL index for retired keys.
+func (r *MongoSigningKeyRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(signingKeyCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "verifyUntil", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Insert stores a new key.
+func (r *MongoSigningKeyRepository) Insert(ctx context.Context, key *models.SigningKey) error {
+	collection := r.mongoConfig.GetCollection(signingKeyCollection)
+	_, err := collection.InsertOne(ctx, key)
+	return err
+}
+
+// RetireOthers retires every active key other than keepID.
+func (r *MongoSigningKeyRepository) RetireOthers(ctx context.Context, keepID string, retiredAt, verifyUntil time.Time) error {
+	collection := r.mongoConfig.GetCollection(signingKeyCollection)
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$ne": keepID}, "retiredAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"retiredAt": retiredAt, "verifyUntil": verifyUntil}},
+	)
+	return err
+}
+
+// ListVerifiable returns the keys that can still verify tokens at now.
+func (r *MongoSigningKeyRepository) ListVerifiable(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	collection := r.mongoConfig.GetCollection(signingKeyCollection)
+	cursor, err := collection.Find(ctx, bson.M{
+		"$or": []bson.M{
+			{"verifyUntil": bson.M{"$exists": false}},
+			{"verifyUntil": bson.M{"$gt": now}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []models.SigningKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
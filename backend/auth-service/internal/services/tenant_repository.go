@@ -0,0 +1,106 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const tenantCollection = "tenants"
+
+// TenantRepository stores the tenants. Unknown tenant IDs yield
+// ErrTenantNotFound. MongoTenantRepository is the production implementation
+// and testutil.MemoryTenantRepository the one for tests.
+type TenantRepository interface {
+	// Insert stores a new tenant, or fails with ErrTenantExists if its ID is
+	// taken.
+	Insert(ctx context.Context, tenant *models.Tenant) error
+	// FindByID returns the tenant with the ID.
+	FindByID(ctx context.Context, tenantID string) (*models.Tenant, error)
+	// List returns every tenant, ordered by ID.
+	List(ctx context.Context) ([]models.Tenant, error)
+	// Update replaces the name, status and settings of a tenant and returns
+	// it as updated.
+	Update(ctx context.Context, tenantID string, req models.UpdateTenantRequest, now time.Time) (*models.Tenant, error)
+}
+
+var _ TenantRepository = (*MongoTenantRepository)(nil)
+
+// MongoTenantRepository stores tenants in the tenants collection.
+type MongoTenantRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoTenantRepository creates a new MongoTenantRepository
+func NewMongoTenantRepository(mongoConfig *config.MongoDBConfig) *MongoTenantRepository {
+	return &MongoTenantRepository{mongoConfig: mongoConfig}
+}
+
+// Insert stores a new tenant.
+func (r *MongoTenantRepository) Insert(ctx context.Context, tenant *models.Tenant) error {
+	collection := r.mongoConfig.GetCollection(tenantCollection)
+	_, err := collection.InsertOne(ctx, tenant)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTenantExists
+	}
+	return err
+}
+
+// FindByID returns the tenant with the ID.
+func (r *MongoTenantRepository) FindByID(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	collection := r.mongoConfig.GetCollection(tenantCollection)
+	var tenant models.Tenant
+	err := collection.FindOne(ctx, bson.M{"_id": tenantID}).Decode(&tenant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// List returns every tenant, ordered by ID.
+func (r *MongoTenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	collection := r.mongoConfig.GetCollection(tenantCollection)
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tenants := []models.Tenant{}
+	if err := cursor.All(ctx, &tenants); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// Update replaces the name, status and settings of a tenant.
+func (r *MongoTenantRepository) Update(ctx context.Context, tenantID string, req models.UpdateTenantRequest, now time.Time) (*models.Tenant, error) {
+	collection := r.mongoConfig.GetCollection(tenantCollection)
+	var tenant models.Tenant
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": tenantID},
+		bson.M{"$set": bson.M{
+			"name":      req.Name,
+			"status":    req.Status,
+			"settings":  req.Settings,
+			"updatedAt": now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&tenant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// tenantCacheTTL bounds how long a replica keeps using tenant settings after
+// another replica changed them.
+const tenantCacheTTL = 30 * time.Second
+
+// DefaultTenantID is the tenant of requests that name no tenant and of
+// accounts and tokens created before tenants existed. Its admins manage the
+// deployment-wide resources such as tenants and OAuth clients.
+const DefaultTenantID = "default"
+
+// Tenant statuses.
+const (
+	TenantStatusActive   = "active"
+	TenantStatusDisabled = "disabled"
+)
+
+var (
+	// ErrTenantNotFound is returned for unknown tenant IDs.
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantExists is returned when creating a tenant whose ID is taken.
+	ErrTenantExists = errors.New("tenant already exists")
+	// ErrInvalidTenantID is returned when a tenant ID cannot be used as a
+	// subdomain.
+	ErrInvalidTenantID = errors.New("tenant id must be 2-63 lowercase letters, digits or hyphens")
+	// ErrTenantDisabled is returned for tokens of a disabled tenant.
+	ErrTenantDisabled = errors.New("tenant is disabled")
+	// ErrSignupDisabled is returned when registering in a tenant that does
+	// not allow self-service signup.
+	ErrSignupDisabled = errors.New("signup is disabled for this tenant")
+)
+
+// tenantIDPattern matches IDs that are valid DNS labels.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// TenantDirectory looks tenants up by ID. TenantService is the production
+// implementation and testutil.MemoryTenantDirectory the one for tests.
+type TenantDirectory interface {
+	Get(ctx context.Context, tenantID string) (*models.Tenant, error)
+}
+
+var _ TenantDirectory = (*TenantService)(nil)
+
+// TenantService manages the customer organizations hosted on the deployment
+// and their settings. Tenants are looked up on every request, so they are
+// cached briefly in memory.
+type TenantService struct {
+	repository TenantRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+type cachedTenant struct {
+	tenant    models.Tenant
+	expiresAt time.Time
+}
+
+// NewTenantService creates a new TenantService
+func NewTenantService(repository TenantRepository) *TenantService {
+	return &TenantService{
+		repository: repository,
+		cache:      make(map[string]cachedTenant),
+	}
+}
+
+// Get returns the tenant with the given ID.
+func (s *TenantService) Get(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[tenantID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		tenant := cached.tenant
+		return &tenant, nil
+	}
+
+	tenant, err := s.repository.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.remember(*tenant)
+	return tenant, nil
+}
+
+// List returns all tenants.
+func (s *TenantService) List() ([]models.Tenant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.repository.List(ctx)
+}
+
+// Create registers an active tenant.
+func (s *TenantService) Create(req models.CreateTenantRequest) (*models.Tenant, error) {
+	if !tenantIDPattern.MatchString(req.ID) {
+		return nil, ErrInvalidTenantID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	tenant := models.Tenant{
+		ID:        req.ID,
+		Name:      req.Name,
+		Status:    TenantStatusActive,
+		Settings:  req.Settings,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repository.Insert(ctx, &tenant); err != nil {
+		return nil, err
+	}
+
+	s.remember(tenant)
+	return &tenant, nil
+}
+
+// Update replaces the name, status and settings of a tenant. Other replicas
+// pick up the change within the cache TTL.
+func (s *TenantService) Update(tenantID string, req models.UpdateTenantRequest) (*models.Tenant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tenant, err := s.repository.Update(ctx, tenantID, req, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.remember(*tenant)
+	return tenant, nil
+}
+
+func (s *TenantService) remember(tenant models.Tenant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[tenant.ID] = cachedTenant{tenant: tenant, expiresAt: time.Now().Add(tenantCacheTTL)}
+}
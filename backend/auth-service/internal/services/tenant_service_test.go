@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTenantServiceCreate(t *testing.T) {
+	tenants := services.NewTenantService(testutil.NewMemoryTenantRepository())
+
+	created, err := tenants.Create(models.CreateTenantRequest{ID: "acme", Name: "Acme", Settings: models.TenantSettings{AllowSignup: true}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.Status != services.TenantStatusActive || created.CreatedAt.IsZero() || !created.Settings.AllowSignup {
+		t.Errorf("tenant = %+v, want an active tenant with its settings", created)
+	}
+	got, err := tenants.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Acme" {
+		t.Errorf("tenant = %+v, want Acme", got)
+	}
+
+	if _, err := tenants.Create(models.CreateTenantRequest{ID: "acme", Name: "Other"}); !errors.Is(err, services.ErrTenantExists) {
+		t.Errorf("Create of a taken ID error = %v, want services.ErrTenantExists", err)
+	}
+}
+
+func TestTenantServiceCreateRejectsIDs(t *testing.T) {
+	tenants := services.NewTenantService(testutil.NewMemoryTenantRepository())
+
+	for _, id := range []string{"", "a", "Acme", "acme.corp", "-acme", "acme-", "acme_corp", strings.Repeat("a", 64)} {
+		if _, err := tenants.Create(models.CreateTenantRequest{ID: id, Name: "Acme"}); !errors.Is(err, services.ErrInvalidTenantID) {
+			t.Errorf("Create(%q) error = %v, want services.ErrInvalidTenantID", id, err)
+		}
+	}
+	if list, err := tenants.List(); err != nil || len(list) != 0 {
+		t.Errorf("List = %+v, %v, want no tenants", list, err)
+	}
+}
+
+func TestTenantServiceUpdate(t *testing.T) {
+	tenants := services.NewTenantService(testutil.NewMemoryTenantRepository())
+	if _, err := tenants.Create(models.CreateTenantRequest{ID: "acme", Name: "Acme"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := tenants.Get(context.Background(), "acme"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	updated, err := tenants.Update("acme", models.UpdateTenantRequest{Name: "Acme Corp", Status: services.TenantStatusDisabled})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "Acme Corp" || updated.Status != services.TenantStatusDisabled {
+		t.Errorf("tenant = %+v, want the disabled Acme Corp", updated)
+	}
+	// The replica that made the change sees it at once.
+	if got, err := tenants.Get(context.Background(), "acme"); err != nil || got.Status != services.TenantStatusDisabled {
+		t.Errorf("Get after Update = %+v, %v, want the disabled tenant", got, err)
+	}
+
+	if _, err := tenants.Update("unknown", models.UpdateTenantRequest{Name: "Unknown", Status: services.TenantStatusActive}); !errors.Is(err, services.ErrTenantNotFound) {
+		t.Errorf("Update of an unknown tenant error = %v, want services.ErrTenantNotFound", err)
+	}
+}
+
+func TestTenantServiceCachesTenants(t *testing.T) {
+	repository := testutil.NewMemoryTenantRepository()
+	tenants := services.NewTenantService(repository)
+	ctx := context.Background()
+	if _, err := tenants.Create(models.CreateTenantRequest{ID: "acme", Name: "Acme"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Another replica disables the tenant.
+	if _, err := repository.Update(ctx, "acme", models.UpdateTenantRequest{Name: "Acme", Status: services.TenantStatusDisabled}, time.Now()); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, err := tenants.Get(ctx, "acme"); err != nil || got.Status != services.TenantStatusActive {
+		t.Errorf("Get within the cache TTL = %+v, %v, want the cached active tenant", got, err)
+	}
+	// A fresh replica reads the change.
+	if got, err := services.NewTenantService(repository).Get(ctx, "acme"); err != nil || got.Status != services.TenantStatusDisabled {
+		t.Errorf("Get on another replica = %+v, %v, want the disabled tenant", got, err)
+	}
+
+	if _, err := tenants.Get(ctx, "unknown"); !errors.Is(err, services.ErrTenantNotFound) {
+		t.Errorf("Get of an unknown tenant error = %v, want services.ErrTenantNotFound", err)
+	}
+}
+
+func TestTenantServiceList(t *testing.T) {
+	tenants := services.NewTenantService(testutil.NewMemoryTenantRepository())
+	for _, id := range []string{"globex", "acme", "initech"} {
+		if _, err := tenants.Create(models.CreateTenantRequest{ID: id, Name: id}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	list, err := tenants.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 3 || list[0].ID != "acme" || list[1].ID != "globex" || list[2].ID != "initech" {
+		t.Errorf("List = %+v, want acme, globex and initech", list)
+	}
+}
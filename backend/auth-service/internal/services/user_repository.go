@@ -0,0 +1,187 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const userCollection = "auth_users"
+
+// UserRepository stores the accounts. Lookups by ID or address are scoped to
+// a tenant, so an ID or address from one tenant never resolves to an account
+// of another. Missing accounts yield ErrUserNotFound, and an address already
+// registered in the tenant, whatever its case, yields ErrUserExists.
+type UserRepository interface {
+	// Create stores a new account.
+	Create(ctx context.Context, user *models.User) error
+	// FindByID returns the account of the tenant with the given ID.
+	FindByID(ctx context.Context, tenantID, userID string) (*models.User, error)
+	// FindByEmail returns the account of the tenant with the given address.
+	FindByEmail(ctx context.Context, tenantID, email string) (*models.User, error)
+	// SetRole changes the role of an account and returns the updated account.
+	SetRole(ctx context.Context, tenantID, userID, role string) (*models.User, error)
+	// Activate moves the pending_verification account with the given ID and
+	// address to active and returns the updated account. The ID comes from
+	// a verification token, which is not bound to a tenant.
+	Activate(ctx context.Context, userID, email string) (*models.User, error)
+	// UpdateProfile changes the name and address of an account. Empty values
+	// are left unchanged.
+	UpdateProfile(ctx context.Context, tenantID, userID, name, email string) error
+	// Delete removes an account.
+	Delete(ctx context.Context, tenantID, userID string) error
+}
+
+// CredentialRepository stores the password hashes of the accounts.
+type CredentialRepository interface {
+	// SetPasswordHash replaces the password hash of an account if it still
+	// equals previous, and returns ErrUserNotFound otherwise.
+	SetPasswordHash(ctx context.Context, tenantID, userID, previous, hash string) error
+}
+
+// Transactor runs a function in a transaction. *config.MongoDBConfig is the
+// production implementation.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoUserRepository stores accounts, including their password hashes, in
+// the auth_users collection. The unique index on tenantId and emailNormalized
+// enforces one account per address and tenant.
+type MongoUserRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoUserRepository creates a new MongoUserRepository
+func NewMongoUserRepository(mongoConfig *config.MongoDBConfig) *MongoUserRepository {
+	return &MongoUserRepository{mongoConfig: mongoConfig}
+}
+
+// Create stores a new account.
+func (r *MongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	_, err := collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUserExists
+	}
+	return err
+}
+
+// FindByID returns the account of the tenant with the given ID.
+func (r *MongoUserRepository) FindByID(ctx context.Context, tenantID, userID string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": userID, "tenantId": tenantID})
+}
+
+// FindByEmail returns the account of the tenant with the given address.
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, tenantID, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"tenantId": tenantID, "emailNormalized": NormalizeEmail(email)})
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	var user models.User
+	err := collection.FindOne(ctx, filter).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetRole changes the role of an account and returns the updated account.
+func (r *MongoUserRepository) SetRole(ctx context.Context, tenantID, userID, role string) (*models.User, error) {
+	return r.findOneAndSet(ctx,
+		bson.M{"_id": userID, "tenantId": tenantID},
+		bson.M{"role": role, "updatedAt": time.Now()},
+	)
+}
+
+// Activate moves a pending_verification account to active and returns the
+// updated account.
+func (r *MongoUserRepository) Activate(ctx context.Context, userID, email string) (*models.User, error) {
+	return r.findOneAndSet(ctx,
+		bson.M{"_id": userID, "email": email, "status": "pending_verification"},
+		bson.M{"status": "active", "updatedAt": time.Now()},
+	)
+}
+
+func (r *MongoUserRepository) findOneAndSet(ctx context.Context, filter, set bson.M) (*models.User, error) {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	var user models.User
+	err := collection.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateProfile changes the name and address of an account.
+func (r *MongoUserRepository) UpdateProfile(ctx context.Context, tenantID, userID, name, email string) error {
+	set := bson.M{"updatedAt": time.Now()}
+	if name != "" {
+		set["name"] = name
+	}
+	if email = strings.TrimSpace(email); email != "" {
+		set["email"] = email
+		set["emailNormalized"] = NormalizeEmail(email)
+	}
+
+	collection := r.mongoConfig.GetCollection(userCollection)
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": userID, "tenantId": tenantID}, bson.M{"$set": set})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUserExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Delete removes an account.
+func (r *MongoUserRepository) Delete(ctx context.Context, tenantID, userID string) error {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": userID, "tenantId": tenantID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetPasswordHash replaces the password hash of an account if it still
+// equals previous.
+func (r *MongoUserRepository) SetPasswordHash(ctx context.Context, tenantID, userID, previous, hash string) error {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "tenantId": tenantID, "password": previous},
+		bson.M{"$set": bson.M{"password": hash, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
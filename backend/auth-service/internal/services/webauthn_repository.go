@@ -0,0 +1,167 @@
+package services
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webAuthnCredentialCollection = "webauthn_credentials"
+	webAuthnSessionCollection    = "webauthn_sessions"
+)
+
+// WebAuthnRepository stores passkeys and the sessions of ceremonies in
+// progress. Unknown credentials yield ErrWebAuthnCredentialNotFound and
+// unknown, expired or already used sessions ErrWebAuthnSessionInvalid.
+// MongoWebAuthnRepository is the production implementation and
+// testutil.MemoryWebAuthnRepository the one for tests.
+type WebAuthnRepository interface {
+	// InsertCredential stores a new credential, or returns
+	// ErrWebAuthnCredentialExists if its ID is already registered.
+	InsertCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	// FindCredential returns the credential with the given ID.
+	FindCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error)
+	// ListCredentials returns the user's credentials, oldest first.
+	ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error)
+	// UpdateSignCount records a use of the credential if its sign count is
+	// still oldCount, and reports whether it was.
+	UpdateSignCount(ctx context.Context, id string, oldCount, newCount uint32, usedAt time.Time) (bool, error)
+	// DeleteCredential removes one of the user's credentials.
+	DeleteCredential(ctx context.Context, userID, id string) error
+	// InsertSession stores the session of a new ceremony.
+	InsertSession(ctx context.Context, session *models.WebAuthnSession) error
+	// ConsumeSession removes and returns the unexpired session with the given
+	// ID of the ceremony, so each challenge is used once.
+	ConsumeSession(ctx context.Context, id, ceremony string, now time.Time) (*models.WebAuthnSession, error)
+}
+
+var _ WebAuthnRepository = (*MongoWebAuthnRepository)(nil)
+
+// MongoWebAuthnRepository stores passkeys in the webauthn_credentials
+// collection and ceremony sessions in webauthn_sessions, where a TTL index
+// drops them once expired.
+type MongoWebAuthnRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoWebAuthnRepository creates a new MongoWebAuthnRepository
+func NewMongoWebAuthnRepository(mongoConfig *config.MongoDBConfig) *MongoWebAuthnRepository {
+	return &MongoWebAuthnRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the credential lookup index and the session TTL index.
+func (r *MongoWebAuthnRepository) EnsureIndexes(ctx context.Context) error {
+	credentials := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	if _, err := credentials.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}},
+	}); err != nil {
+		return err
+	}
+
+	sessions := r.mongoConfig.GetCollection(webAuthnSessionCollection)
+	_, err := sessions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// InsertCredential stores a new credential.
+func (r *MongoWebAuthnRepository) InsertCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	collection := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	_, err := collection.InsertOne(ctx, credential)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrWebAuthnCredentialExists
+	}
+	return err
+}
+
+// FindCredential returns the credential with the given ID.
+func (r *MongoWebAuthnRepository) FindCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	collection := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	var credential models.WebAuthnCredential
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&credential)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebAuthnCredentialNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ListCredentials returns the user's credentials, oldest first.
+func (r *MongoWebAuthnRepository) ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	collection := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	cursor, err := collection.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	credentials := []models.WebAuthnCredential{}
+	if err := cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// UpdateSignCount records a use of the credential if its sign count is still
+// oldCount.
+func (r *MongoWebAuthnRepository) UpdateSignCount(ctx context.Context, id string, oldCount, newCount uint32, usedAt time.Time) (bool, error) {
+	collection := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "signCount": oldCount},
+		bson.M{"$set": bson.M{"signCount": newCount, "lastUsedAt": usedAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// DeleteCredential removes one of the user's credentials.
+func (r *MongoWebAuthnRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	collection := r.mongoConfig.GetCollection(webAuthnCredentialCollection)
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebAuthnCredentialNotFound
+	}
+	return nil
+}
+
+// InsertSession stores the session of a new ceremony.
+func (r *MongoWebAuthnRepository) InsertSession(ctx context.Context, session *models.WebAuthnSession) error {
+	collection := r.mongoConfig.GetCollection(webAuthnSessionCollection)
+	_, err := collection.InsertOne(ctx, session)
+	return err
+}
+
+// ConsumeSession atomically removes and returns an unexpired session.
+func (r *MongoWebAuthnRepository) ConsumeSession(ctx context.Context, id, ceremony string, now time.Time) (*models.WebAuthnSession, error) {
+	collection := r.mongoConfig.GetCollection(webAuthnSessionCollection)
+
+	var session models.WebAuthnSession
+	err := collection.FindOneAndDelete(ctx, bson.M{
+		"_id":       id,
+		"ceremony":  ceremony,
+		"expiresAt": bson.M{"$gt": now},
+	}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebAuthnSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
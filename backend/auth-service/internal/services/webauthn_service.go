@@ -0,0 +1,565 @@
+package services
+
+import (
+	"auth-service/internal/models"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	webAuthnCeremonyRegistration   = "registration"
+	webAuthnCeremonyAuthentication = "authentication"
+
+	// webAuthnTimeout is the ceremony timeout suggested to the browser; the
+	// session outlives it so slow users still get a clear error from us.
+	webAuthnTimeout    = time.Minute
+	webAuthnSessionTTL = 5 * time.Minute
+)
+
+// COSE algorithm identifiers accepted for credentials.
+const (
+	coseAlgES256 int64 = -7
+	coseAlgEdDSA int64 = -8
+	coseAlgRS256 int64 = -257
+)
+
+// Authenticator data flags (WebAuthn section 6.1).
+const (
+	authDataUserPresent   = 0x01
+	authDataUserVerified  = 0x04
+	authDataAttestedCred  = 0x40
+	authDataHasExtensions = 0x80
+)
+
+// AMRHardwareKey is the amr value recorded for passkey logins.
+const AMRHardwareKey = "hwk"
+
+var (
+	// ErrWebAuthnVerification is returned when a ceremony response does not
+	// verify. The wrapped message says which check failed.
+	ErrWebAuthnVerification = errors.New("webauthn verification failed")
+	// ErrWebAuthnSessionInvalid is returned for unknown or expired ceremony sessions.
+	ErrWebAuthnSessionInvalid = errors.New("invalid or expired webauthn session")
+	// ErrWebAuthnCredentialNotFound is returned for unknown credentials.
+	ErrWebAuthnCredentialNotFound = errors.New("webauthn credential not found")
+	// ErrWebAuthnCredentialExists is returned when a credential is registered twice.
+	ErrWebAuthnCredentialExists = errors.New("webauthn credential already registered")
+)
+
+// WebAuthnService implements the WebAuthn relying party: registration with
+// "none" attestation and authentication with sign counter checks.
+type WebAuthnService struct {
+	repository WebAuthnRepository
+	rpID       string
+	rpName     string
+	origins    []string
+	audit      AuditRecorder
+}
+
+// NewWebAuthnService creates a new WebAuthnService for the relying party ID
+// (the site's domain) and the origins the browser may report.
+func NewWebAuthnService(repository WebAuthnRepository, rpID, rpName string, origins []string, audit AuditRecorder) *WebAuthnService {
+	return &WebAuthnService{
+		repository: repository,
+		rpID:       rpID,
+		rpName:     rpName,
+		origins:    origins,
+		audit:      audit,
+	}
+}
+
+// BeginRegistration starts a registration ceremony for the user.
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, user *models.User) (*models.WebAuthnCeremonyResponse, error) {
+	existing, err := s.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, challenge, err := s.newSession(ctx, webAuthnCeremonyRegistration, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebAuthnCeremonyResponse{
+		SessionID: sessionID,
+		PublicKey: models.PublicKeyCredentialCreationOptions{
+			Challenge:    challenge,
+			RelyingParty: models.RelyingPartyEntity{ID: s.rpID, Name: s.rpName},
+			User: models.UserEntity{
+				ID:          base64.RawURLEncoding.EncodeToString([]byte(user.ID)),
+				Name:        user.Email,
+				DisplayName: user.Name,
+			},
+			PubKeyCredParams: []models.CredentialParameter{
+				{Type: "public-key", Algorithm: coseAlgES256},
+				{Type: "public-key", Algorithm: coseAlgEdDSA},
+				{Type: "public-key", Algorithm: coseAlgRS256},
+			},
+			Timeout:            webAuthnTimeout.Milliseconds(),
+			Attestation:        "none",
+			ExcludeCredentials: credentialDescriptors(existing),
+			AuthenticatorSelection: models.AuthenticatorSelectionCriteria{
+				ResidentKey:      "preferred",
+				UserVerification: "preferred",
+			},
+		},
+	}, nil
+}
+
+// FinishRegistration verifies the attestation response and stores the new
+// credential for the user of the tenant.
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, tenantID, userID string, req models.WebAuthnRegisterFinishRequest) (*models.WebAuthnCredential, error) {
+	session, err := s.consumeSession(ctx, req.SessionID, webAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrWebAuthnSessionInvalid
+	}
+
+	credential := req.Credential
+	if credential.Type != "public-key" {
+		return nil, verificationError("unexpected credential type")
+	}
+
+	clientDataJSON, err := decodeBase64URL(credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, verificationError("malformed clientDataJSON")
+	}
+	if err := s.verifyClientData(clientDataJSON, "webauthn.create", session.Challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := decodeBase64URL(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, verificationError("malformed attestationObject")
+	}
+	var attestation struct {
+		Format   string                 `codec:"fmt"`
+		AttStmt  map[string]interface{} `codec:"attStmt"`
+		AuthData []byte                 `codec:"authData"`
+	}
+	if err := codec.NewDecoderBytes(rawAttestation, cborHandle()).Decode(&attestation); err != nil {
+		return nil, verificationError("malformed attestationObject")
+	}
+	// Only "none" attestation is requested, so authenticator provenance is
+	// not verified and no other statement format is accepted.
+	if attestation.Format != "none" || len(attestation.AttStmt) != 0 {
+		return nil, verificationError("unsupported attestation format " + attestation.Format)
+	}
+
+	authData, err := parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.flags&authDataAttestedCred == 0 {
+		return nil, verificationError("no attested credential data")
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(authData.credentialID)
+	if rawID, err := decodeBase64URL(credential.ID); err != nil || !bytes.Equal(rawID, authData.credentialID) {
+		return nil, verificationError("credential ID mismatch")
+	}
+
+	_, algorithm, err := parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	record := models.WebAuthnCredential{
+		ID:         credentialID,
+		UserID:     userID,
+		TenantID:   tenantID,
+		Name:       name,
+		PublicKey:  authData.publicKey,
+		Algorithm:  algorithm,
+		SignCount:  authData.signCount,
+		AAGUID:     hex.EncodeToString(authData.aaguid),
+		Transports: credential.Response.Transports,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := s.repository.InsertCredential(ctx, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// BeginLogin starts an authentication ceremony. With a user ID, only that
+// user's credentials are allowed; without one, the authenticator chooses a
+// discoverable credential.
+func (s *WebAuthnService) BeginLogin(ctx context.Context, userID string) (*models.WebAuthnCeremonyResponse, error) {
+	allowed := []models.CredentialDescriptor{}
+	if userID != "" {
+		credentials, err := s.ListCredentials(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		allowed = credentialDescriptors(credentials)
+	}
+
+	sessionID, challenge, err := s.newSession(ctx, webAuthnCeremonyAuthentication, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebAuthnCeremonyResponse{
+		SessionID: sessionID,
+		PublicKey: models.PublicKeyCredentialRequestOptions{
+			Challenge:        challenge,
+			RelyingPartyID:   s.rpID,
+			Timeout:          webAuthnTimeout.Milliseconds(),
+			UserVerification: "preferred",
+			AllowCredentials: allowed,
+		},
+	}, nil
+}
+
+// FinishLogin verifies an assertion and returns the credential used and the
+// amr values of the passkey step. Only credentials of the client's tenant are
+// accepted. Failed assertions are recorded in the audit trail; the successful
+// login is recorded once tokens are issued.
+func (s *WebAuthnService) FinishLogin(ctx context.Context, req models.WebAuthnLoginFinishRequest, client models.ClientInfo) (*models.WebAuthnCredential, []string, error) {
+	credential, amr, err := s.finishLogin(ctx, req, client.TenantID)
+	if err != nil {
+		s.audit.RecordFor(ctx, models.AuditEvent{
+			EventType: AuditLogin,
+			Outcome:   AuditOutcomeFailure,
+			Reason:    loginFailureReason(err),
+		}, client)
+		return nil, nil, err
+	}
+	return credential, amr, nil
+}
+
+func (s *WebAuthnService) finishLogin(ctx context.Context, req models.WebAuthnLoginFinishRequest, tenantID string) (*models.WebAuthnCredential, []string, error) {
+	session, err := s.consumeSession(ctx, req.SessionID, webAuthnCeremonyAuthentication)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assertion := req.Credential
+	if assertion.Type != "public-key" {
+		return nil, nil, verificationError("unexpected credential type")
+	}
+
+	credential, err := s.repository.FindCredential(ctx, normalizeBase64URL(assertion.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if credential.TenantID != tenantID {
+		return nil, nil, verificationError("credential belongs to another tenant")
+	}
+	if session.UserID != "" && session.UserID != credential.UserID {
+		return nil, nil, verificationError("credential belongs to another user")
+	}
+	if assertion.Response.UserHandle != "" {
+		userHandle, err := decodeBase64URL(assertion.Response.UserHandle)
+		if err != nil || string(userHandle) != credential.UserID {
+			return nil, nil, verificationError("user handle mismatch")
+		}
+	}
+
+	clientDataJSON, err := decodeBase64URL(assertion.Response.ClientDataJSON)
+	if err != nil {
+		return nil, nil, verificationError("malformed clientDataJSON")
+	}
+	if err := s.verifyClientData(clientDataJSON, "webauthn.get", session.Challenge); err != nil {
+		return nil, nil, err
+	}
+
+	rawAuthData, err := decodeBase64URL(assertion.Response.AuthenticatorData)
+	if err != nil {
+		return nil, nil, verificationError("malformed authenticatorData")
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.verifyAuthenticatorData(authData); err != nil {
+		return nil, nil, err
+	}
+
+	signature, err := decodeBase64URL(assertion.Response.Signature)
+	if err != nil {
+		return nil, nil, verificationError("malformed signature")
+	}
+	publicKey, algorithm, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !verifyWebAuthnSignature(publicKey, algorithm, signed, signature) {
+		return nil, nil, verificationError("invalid signature")
+	}
+
+	// A counter that does not increase indicates a cloned authenticator.
+	// Authenticators that do not implement counters always report zero.
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return nil, nil, verificationError("sign count did not increase")
+	}
+
+	updated, err := s.repository.UpdateSignCount(ctx, credential.ID, credential.SignCount, authData.signCount, time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !updated {
+		return nil, nil, verificationError("credential used concurrently")
+	}
+
+	amr := []string{AMRHardwareKey}
+	if authData.flags&authDataUserVerified != 0 {
+		amr = append(amr, AMRMultiFactor)
+	}
+	return credential, amr, nil
+}
+
+// ListCredentials returns the credentials registered by the user.
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	return s.repository.ListCredentials(ctx, userID)
+}
+
+// DeleteCredential removes one of the user's credentials.
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, userID, credentialID string) error {
+	return s.repository.DeleteCredential(ctx, userID, credentialID)
+}
+
+// newSession stores a fresh challenge and returns the session ID and the
+// base64url challenge sent to the browser.
+func (s *WebAuthnService) newSession(ctx context.Context, ceremony, userID string) (string, string, error) {
+	sessionID, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	challenge, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.repository.InsertSession(ctx, &models.WebAuthnSession{
+		ID:        HashOpaqueToken(sessionID),
+		Ceremony:  ceremony,
+		UserID:    userID,
+		Challenge: challenge,
+		ExpiresAt: time.Now().UTC().Add(webAuthnSessionTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return sessionID, challenge, nil
+}
+
+// consumeSession atomically removes the session so each challenge is used once.
+func (s *WebAuthnService) consumeSession(ctx context.Context, sessionID, ceremony string) (*models.WebAuthnSession, error) {
+	return s.repository.ConsumeSession(ctx, HashOpaqueToken(sessionID), ceremony, time.Now().UTC())
+}
+
+// verifyClientData checks the ceremony type, challenge and origin reported by the browser.
+func (s *WebAuthnService) verifyClientData(raw []byte, ceremonyType, challenge string) error {
+	var clientData struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return verificationError("malformed clientDataJSON")
+	}
+
+	if clientData.Type != ceremonyType {
+		return verificationError("unexpected client data type " + clientData.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(normalizeBase64URL(clientData.Challenge)), []byte(challenge)) != 1 {
+		return verificationError("challenge mismatch")
+	}
+	if !containsString(s.origins, clientData.Origin) {
+		return verificationError("origin " + clientData.Origin + " is not allowed")
+	}
+	return nil
+}
+
+// verifyAuthenticatorData checks the relying party ID hash and user presence.
+func (s *WebAuthnService) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(s.rpID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return verificationError("relying party ID mismatch")
+	}
+	if authData.flags&authDataUserPresent == 0 {
+		return verificationError("user presence flag not set")
+	}
+	return nil
+}
+
+// authenticatorData is the parsed authenticator data structure.
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, verificationError("authenticator data too short")
+	}
+
+	parsed := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if parsed.flags&authDataAttestedCred == 0 {
+		return parsed, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, verificationError("attested credential data too short")
+	}
+	parsed.aaguid = rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return nil, verificationError("credential ID truncated")
+	}
+	parsed.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// The COSE key is followed by extensions when the ED flag is set, so its
+	// length is only known after decoding it.
+	var key map[int64]interface{}
+	decoder := codec.NewDecoderBytes(rest, cborHandle())
+	if err := decoder.Decode(&key); err != nil {
+		return nil, verificationError("malformed credential public key")
+	}
+	parsed.publicKey = rest[:decoder.NumBytesRead()]
+	if parsed.flags&authDataHasExtensions == 0 && decoder.NumBytesRead() != len(rest) {
+		return nil, verificationError("unexpected trailing authenticator data")
+	}
+
+	return parsed, nil
+}
+
+// parseCOSEKey decodes a COSE_Key into a public key and its algorithm.
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	var key map[int64]interface{}
+	if err := codec.NewDecoderBytes(raw, cborHandle()).Decode(&key); err != nil {
+		return nil, 0, verificationError("malformed credential public key")
+	}
+
+	keyType, _ := key[1].(int64)
+	algorithm, _ := key[3].(int64)
+	curve, _ := key[-1].(int64)
+
+	switch {
+	case algorithm == coseAlgES256 && keyType == 2 && curve == 1:
+		x, _ := key[-2].([]byte)
+		y, _ := key[-3].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, 0, verificationError("invalid P-256 public key")
+		}
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, 0, verificationError("invalid P-256 public key")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, algorithm, nil
+
+	case algorithm == coseAlgEdDSA && keyType == 1 && curve == 6:
+		x, _ := key[-2].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, verificationError("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), algorithm, nil
+
+	case algorithm == coseAlgRS256 && keyType == 3:
+		n, _ := key[-1].([]byte)
+		e, _ := key[-2].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, verificationError("invalid RSA public key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, algorithm, nil
+
+	default:
+		return nil, 0, verificationError(fmt.Sprintf("unsupported credential algorithm %d", algorithm))
+	}
+}
+
+func verifyWebAuthnSignature(publicKey crypto.PublicKey, algorithm int64, data, signature []byte) bool {
+	switch algorithm {
+	case coseAlgES256:
+		digest := sha256.Sum256(data)
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		return ok && ecdsa.VerifyASN1(key, digest[:], signature)
+	case coseAlgEdDSA:
+		key, ok := publicKey.(ed25519.PublicKey)
+		return ok && ed25519.Verify(key, data, signature)
+	case coseAlgRS256:
+		digest := sha256.Sum256(data)
+		key, ok := publicKey.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+func credentialDescriptors(credentials []models.WebAuthnCredential) []models.CredentialDescriptor {
+	descriptors := make([]models.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptors = append(descriptors, models.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.ID,
+			Transports: credential.Transports,
+		})
+	}
+	return descriptors
+}
+
+func cborHandle() *codec.CborHandle {
+	handle := &codec.CborHandle{}
+	handle.SignedInteger = true
+	return handle
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers differ.
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+func normalizeBase64URL(value string) string {
+	return strings.TrimRight(value, "=")
+}
+
+func verificationError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrWebAuthnVerification, reason)
+}
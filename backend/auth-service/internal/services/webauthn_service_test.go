@@ -0,0 +1,464 @@
+package services_test
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"auth-service/internal/testutil"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://app.example.com"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedCred = 0x40
+)
+
+// testAuthenticator is a software passkey holding one Ed25519 credential.
+type testAuthenticator struct {
+	t            *testing.T
+	credentialID []byte
+	privateKey   ed25519.PrivateKey
+	publicKey    ed25519.PublicKey
+	signCount    uint32
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	return &testAuthenticator{t: t, credentialID: credentialID, privateKey: privateKey, publicKey: publicKey}
+}
+
+func (a *testAuthenticator) id() string {
+	return base64.RawURLEncoding.EncodeToString(a.credentialID)
+}
+
+func (a *testAuthenticator) cbor(value interface{}) []byte {
+	a.t.Helper()
+
+	var out []byte
+	if err := codec.NewEncoderBytes(&out, &codec.CborHandle{}).Encode(value); err != nil {
+		a.t.Fatalf("encode CBOR: %v", err)
+	}
+	return out
+}
+
+func (a *testAuthenticator) authData(flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, signCount)
+}
+
+func clientData(t *testing.T, ceremonyType, challenge, origin string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]string{"type": ceremonyType, "challenge": challenge, "origin": origin})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return raw
+}
+
+// attest answers a registration ceremony with "none" attestation.
+func (a *testAuthenticator) attest(sessionID, challenge string) models.WebAuthnRegisterFinishRequest {
+	coseKey := a.cbor(map[int64]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(a.publicKey)})
+	authData := a.authData(flagUserPresent|flagUserVerified|flagAttestedCred, 0)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, coseKey...)
+
+	req := models.WebAuthnRegisterFinishRequest{SessionID: sessionID, Name: "Laptop"}
+	req.Credential.ID = a.id()
+	req.Credential.Type = "public-key"
+	req.Credential.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData(a.t, "webauthn.create", challenge, testOrigin))
+	req.Credential.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(a.cbor(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	}))
+	return req
+}
+
+// assertion options; the zero value is a valid assertion.
+type assertOptions struct {
+	skipUV       bool
+	origin       string
+	challenge    string
+	ceremonyType string
+	badSignature bool
+}
+
+// assert answers an authentication ceremony, increasing the sign count.
+func (a *testAuthenticator) assert(sessionID, challenge string, opts assertOptions) models.WebAuthnLoginFinishRequest {
+	if opts.origin == "" {
+		opts.origin = testOrigin
+	}
+	if opts.challenge != "" {
+		challenge = opts.challenge
+	}
+	if opts.ceremonyType == "" {
+		opts.ceremonyType = "webauthn.get"
+	}
+	flags := byte(flagUserPresent | flagUserVerified)
+	if opts.skipUV {
+		flags = flagUserPresent
+	}
+
+	a.signCount++
+	authData := a.authData(flags, a.signCount)
+	rawClientData := clientData(a.t, opts.ceremonyType, challenge, opts.origin)
+	clientDataHash := sha256.Sum256(rawClientData)
+	signature := ed25519.Sign(a.privateKey, append(append([]byte{}, authData...), clientDataHash[:]...))
+	if opts.badSignature {
+		signature[0] ^= 0xff
+	}
+
+	req := models.WebAuthnLoginFinishRequest{SessionID: sessionID}
+	req.Credential.ID = a.id()
+	req.Credential.Type = "public-key"
+	req.Credential.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(rawClientData)
+	req.Credential.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	req.Credential.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	return req
+}
+
+type testWebAuthn struct {
+	*services.WebAuthnService
+	repository *testutil.MemoryWebAuthnRepository
+	audit      *testutil.MemoryAuditRecorder
+}
+
+func newTestWebAuthn() *testWebAuthn {
+	repository := testutil.NewMemoryWebAuthnRepository()
+	audit := testutil.NewMemoryAuditRecorder()
+	return &testWebAuthn{
+		WebAuthnService: services.NewWebAuthnService(repository, testRPID, "Example", []string{testOrigin}, audit),
+		repository:      repository,
+		audit:           audit,
+	}
+}
+
+// register registers the authenticator's credential for the user.
+func (w *testWebAuthn) register(t *testing.T, authenticator *testAuthenticator, user *models.User) *models.WebAuthnCredential {
+	t.Helper()
+
+	ceremony, err := w.BeginRegistration(context.Background(), user)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	options := ceremony.PublicKey.(models.PublicKeyCredentialCreationOptions)
+	credential, err := w.FinishRegistration(context.Background(), user.TenantID, user.ID, authenticator.attest(ceremony.SessionID, options.Challenge))
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	return credential
+}
+
+// beginLogin starts an authentication ceremony and returns its session ID
+// and challenge.
+func (w *testWebAuthn) beginLogin(t *testing.T, userID string) (string, string) {
+	t.Helper()
+
+	ceremony, err := w.BeginLogin(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	return ceremony.SessionID, ceremony.PublicKey.(models.PublicKeyCredentialRequestOptions).Challenge
+}
+
+func passkeyUser(tenantID string) *models.User {
+	return &models.User{ID: "jane@" + tenantID, TenantID: tenantID, Email: "jane@example.com", Name: "Jane Roe"}
+}
+
+func TestWebAuthnRegistrationAndLogin(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	user := passkeyUser("acme")
+
+	credential := w.register(t, authenticator, user)
+	if credential.ID != authenticator.id() || credential.UserID != user.ID || credential.TenantID != "acme" {
+		t.Errorf("credential = %+v, want %s of %s in acme", credential, authenticator.id(), user.ID)
+	}
+
+	// The registered credential is excluded from further registrations
+	// and allowed for logins naming the user.
+	ceremony, err := w.BeginLogin(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	options := ceremony.PublicKey.(models.PublicKeyCredentialRequestOptions)
+	if len(options.AllowCredentials) != 1 || options.AllowCredentials[0].ID != credential.ID {
+		t.Errorf("allowCredentials = %+v, want the registered credential", options.AllowCredentials)
+	}
+
+	used, amr, err := w.FinishLogin(context.Background(), authenticator.assert(ceremony.SessionID, options.Challenge, assertOptions{}), client("acme"))
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if used.UserID != user.ID || used.TenantID != "acme" {
+		t.Errorf("credential = %+v, want the one of %s in acme", used, user.ID)
+	}
+	if len(amr) != 2 || amr[0] != services.AMRHardwareKey || amr[1] != services.AMRMultiFactor {
+		t.Errorf("amr = %v, want hwk mfa for a user-verifying authenticator", amr)
+	}
+
+	// Without user verification the passkey is a single factor.
+	sessionID, challenge := w.beginLogin(t, "")
+	_, amr, err = w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, assertOptions{skipUV: true}), client("acme"))
+	if err != nil {
+		t.Fatalf("FinishLogin without user verification: %v", err)
+	}
+	if len(amr) != 1 || amr[0] != services.AMRHardwareKey {
+		t.Errorf("amr = %v, want only hwk", amr)
+	}
+}
+
+func TestWebAuthnRegistrationRejectsForeignSessionAndDuplicates(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	user := passkeyUser("acme")
+
+	ceremony, err := w.BeginRegistration(context.Background(), user)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	options := ceremony.PublicKey.(models.PublicKeyCredentialCreationOptions)
+	_, err = w.FinishRegistration(context.Background(), "acme", "someone-else", authenticator.attest(ceremony.SessionID, options.Challenge))
+	if !errors.Is(err, services.ErrWebAuthnSessionInvalid) {
+		t.Fatalf("FinishRegistration for another user error = %v, want services.ErrWebAuthnSessionInvalid", err)
+	}
+
+	w.register(t, authenticator, user)
+	ceremony, err = w.BeginRegistration(context.Background(), user)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	options = ceremony.PublicKey.(models.PublicKeyCredentialCreationOptions)
+	if len(options.ExcludeCredentials) != 1 {
+		t.Errorf("excludeCredentials = %+v, want the registered credential", options.ExcludeCredentials)
+	}
+	_, err = w.FinishRegistration(context.Background(), "acme", user.ID, authenticator.attest(ceremony.SessionID, options.Challenge))
+	if !errors.Is(err, services.ErrWebAuthnCredentialExists) {
+		t.Errorf("second FinishRegistration error = %v, want services.ErrWebAuthnCredentialExists", err)
+	}
+}
+
+func TestWebAuthnLoginRejectsReplay(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	w.register(t, authenticator, passkeyUser("acme"))
+
+	sessionID, challenge := w.beginLogin(t, "")
+	assertion := authenticator.assert(sessionID, challenge, assertOptions{})
+	if _, _, err := w.FinishLogin(context.Background(), assertion, client("acme")); err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+
+	// The session is used up with the first assertion.
+	if _, _, err := w.FinishLogin(context.Background(), assertion, client("acme")); !errors.Is(err, services.ErrWebAuthnSessionInvalid) {
+		t.Errorf("replayed FinishLogin error = %v, want services.ErrWebAuthnSessionInvalid", err)
+	}
+
+	// A fresh session does not make an old assertion acceptable either:
+	// the challenge differs, and so would a replayed sign count.
+	sessionID, _ = w.beginLogin(t, "")
+	assertion.SessionID = sessionID
+	if _, _, err := w.FinishLogin(context.Background(), assertion, client("acme")); !errors.Is(err, services.ErrWebAuthnVerification) {
+		t.Errorf("FinishLogin of an old assertion error = %v, want services.ErrWebAuthnVerification", err)
+	}
+
+	sessionID, challenge = w.beginLogin(t, "")
+	authenticator.signCount--
+	if _, _, err := w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, assertOptions{}), client("acme")); !errors.Is(err, services.ErrWebAuthnVerification) {
+		t.Errorf("FinishLogin with a stale sign count error = %v, want services.ErrWebAuthnVerification", err)
+	}
+
+	if got := w.audit.Events(); len(got) != 3 || got[2].Outcome != services.AuditOutcomeFailure {
+		t.Errorf("audit events = %+v, want three failed logins", got)
+	}
+}
+
+func TestWebAuthnLoginRejectsExpiredSession(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	w.register(t, authenticator, passkeyUser("acme"))
+
+	sessionID, challenge := w.beginLogin(t, "")
+	w.repository.ExpireSessions()
+	if _, _, err := w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, assertOptions{}), client("acme")); !errors.Is(err, services.ErrWebAuthnSessionInvalid) {
+		t.Errorf("FinishLogin error = %v, want services.ErrWebAuthnSessionInvalid", err)
+	}
+}
+
+func TestWebAuthnLoginRejectsOtherTenantAndUser(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	w.register(t, authenticator, passkeyUser("acme"))
+
+	sessionID, challenge := w.beginLogin(t, "")
+	_, _, err := w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, assertOptions{}), client("globex"))
+	if !errors.Is(err, services.ErrWebAuthnVerification) {
+		t.Errorf("FinishLogin in another tenant error = %v, want services.ErrWebAuthnVerification", err)
+	}
+
+	sessionID, challenge = w.beginLogin(t, "someone-else")
+	_, _, err = w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, assertOptions{}), client("acme"))
+	if !errors.Is(err, services.ErrWebAuthnVerification) {
+		t.Errorf("FinishLogin for a ceremony of another user error = %v, want services.ErrWebAuthnVerification", err)
+	}
+}
+
+func TestWebAuthnLoginVerifiesAssertion(t *testing.T) {
+	tests := []struct {
+		name string
+		opts assertOptions
+	}{
+		{name: "foreign origin", opts: assertOptions{origin: "https://evil.example.net"}},
+		{name: "other challenge", opts: assertOptions{challenge: "another-challenge"}},
+		{name: "registration client data", opts: assertOptions{ceremonyType: "webauthn.create"}},
+		{name: "bad signature", opts: assertOptions{badSignature: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWebAuthn()
+			authenticator := newTestAuthenticator(t)
+			w.register(t, authenticator, passkeyUser("acme"))
+
+			sessionID, challenge := w.beginLogin(t, "")
+			_, _, err := w.FinishLogin(context.Background(), authenticator.assert(sessionID, challenge, tt.opts), client("acme"))
+			if !errors.Is(err, services.ErrWebAuthnVerification) {
+				t.Errorf("FinishLogin error = %v, want services.ErrWebAuthnVerification", err)
+			}
+		})
+	}
+
+	w := newTestWebAuthn()
+	sessionID, challenge := w.beginLogin(t, "")
+	_, _, err := w.FinishLogin(context.Background(), newTestAuthenticator(t).assert(sessionID, challenge, assertOptions{}), client("acme"))
+	if !errors.Is(err, services.ErrWebAuthnCredentialNotFound) {
+		t.Errorf("FinishLogin with an unregistered credential error = %v, want services.ErrWebAuthnCredentialNotFound", err)
+	}
+}
+
+func TestWebAuthnDeleteCredential(t *testing.T) {
+	w := newTestWebAuthn()
+	authenticator := newTestAuthenticator(t)
+	user := passkeyUser("acme")
+	credential := w.register(t, authenticator, user)
+
+	if err := w.DeleteCredential(context.Background(), "someone-else", credential.ID); !errors.Is(err, services.ErrWebAuthnCredentialNotFound) {
+		t.Errorf("DeleteCredential of another user error = %v, want services.ErrWebAuthnCredentialNotFound", err)
+	}
+	if err := w.DeleteCredential(context.Background(), user.ID, credential.ID); err != nil {
+		t.Fatalf("DeleteCredential: %v", err)
+	}
+	if credentials, _ := w.ListCredentials(context.Background(), user.ID); len(credentials) != 0 {
+		t.Errorf("credentials after delete = %+v, want none", credentials)
+	}
+}
+
+func TestCompleteLoginAfterPasskey(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, "closed", "jane@example.com", "customer")
+
+	// The tenant of the passkey counts, not the one of the request.
+	response, err := a.CompleteLogin("closed", user.ID, []string{services.AMRHardwareKey, services.AMRMultiFactor}, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	claims, err := a.Authenticate(response.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.Tenant() != "closed" || claims.UserID != user.ID {
+		t.Errorf("token of %s in %s, want %s in closed", claims.UserID, claims.Tenant(), user.ID)
+	}
+
+	if _, err := a.CompleteLogin(services.DefaultTenantID, user.ID, []string{services.AMRHardwareKey}, client(services.DefaultTenantID)); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("CompleteLogin in the wrong tenant error = %v, want services.ErrUserNotFound", err)
+	}
+}
+
+func TestCompleteLoginRequiresMFAWithoutUserVerification(t *testing.T) {
+	a := newTestAuthService(t)
+	user := a.addUser(t, services.DefaultTenantID, "jane@example.com", "customer")
+	a.mfa.Enable(user.ID, "123456")
+
+	// A user-verifying passkey covers both factors.
+	response, err := a.CompleteLogin(services.DefaultTenantID, user.ID, []string{services.AMRHardwareKey, services.AMRMultiFactor}, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("CompleteLogin with user verification: %v", err)
+	}
+	if response.Status != "success" {
+		t.Errorf("status = %q, want success", response.Status)
+	}
+
+	response, err = a.CompleteLogin(services.DefaultTenantID, user.ID, []string{services.AMRHardwareKey}, client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("CompleteLogin without user verification: %v", err)
+	}
+	if response.Status != "mfa_required" || response.MFAToken == "" || response.Token != "" {
+		t.Fatalf("response = %+v, want an mfa challenge without tokens", response)
+	}
+
+	verified, err := a.VerifyMFA(response.MFAToken, "123456", client(services.DefaultTenantID))
+	if err != nil {
+		t.Fatalf("VerifyMFA: %v", err)
+	}
+	claims, err := a.Authenticate(verified.Token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	want := []string{services.AMRHardwareKey, services.AMROneTimeCode, services.AMRMultiFactor}
+	if len(claims.AMR) != len(want) || claims.AMR[0] != want[0] || claims.AMR[1] != want[1] || claims.AMR[2] != want[2] {
+		t.Errorf("amr = %v, want %v", claims.AMR, want)
+	}
+}
+
+func TestCompleteLoginRefusesPendingAndLockedAccounts(t *testing.T) {
+	a := newTestAuthService(t)
+	amr := []string{services.AMRHardwareKey, services.AMRMultiFactor}
+
+	pending := &models.User{ID: "pending", TenantID: services.DefaultTenantID, Email: "pending@example.com", Status: "pending_verification", Role: "customer"}
+	if err := a.users.Create(context.Background(), pending); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := a.CompleteLogin(services.DefaultTenantID, pending.ID, amr, client(services.DefaultTenantID)); !errors.Is(err, services.ErrEmailNotVerified) {
+		t.Errorf("CompleteLogin of a pending account error = %v, want services.ErrEmailNotVerified", err)
+	}
+
+	locked := a.addUser(t, services.DefaultTenantID, "locked@example.com", "customer")
+	for i := 0; i < 3; i++ {
+		if _, err := a.Login("locked@example.com", "wrong-password", client(services.DefaultTenantID)); !errors.Is(err, services.ErrInvalidCredentials) {
+			t.Fatalf("Login %d error = %v, want services.ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if _, err := a.CompleteLogin(services.DefaultTenantID, locked.ID, amr, client(services.DefaultTenantID)); !errors.Is(err, services.ErrAccountLocked) {
+		t.Errorf("CompleteLogin of a locked account error = %v, want services.ErrAccountLocked", err)
+	}
+	if got := a.lastAudit(t); got.UserID != locked.ID || got.Reason != "account_locked" {
+		t.Errorf("audit event = %+v, want account_locked for %s", got, locked.ID)
+	}
+}
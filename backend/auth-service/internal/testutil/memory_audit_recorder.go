@@ -0,0 +1,41 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sync"
+)
+
+// MemoryAuditRecorder records audit events in memory, for tests. Events are
+// completed with the client details as AuditService does.
+type MemoryAuditRecorder struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+// NewMemoryAuditRecorder creates an empty MemoryAuditRecorder.
+func NewMemoryAuditRecorder() *MemoryAuditRecorder {
+	return &MemoryAuditRecorder{}
+}
+
+// RecordFor records the event with the client details.
+func (r *MemoryAuditRecorder) RecordFor(ctx context.Context, event models.AuditEvent, client models.ClientInfo) {
+	if event.TenantID == "" {
+		event.TenantID = client.TenantID
+	}
+	event.ClientIP = client.IPAddress
+	event.UserAgent = client.UserAgent
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// Events returns the recorded audit events in the order they were recorded.
+func (r *MemoryAuditRecorder) Events() []models.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]models.AuditEvent(nil), r.events...)
+}
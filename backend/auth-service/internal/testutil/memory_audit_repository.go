@@ -0,0 +1,72 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryAuditRepository keeps the audit trail in memory with the semantics of
+// MongoAuditRepository.
+type MemoryAuditRepository struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+// NewMemoryAuditRepository creates an empty MemoryAuditRepository.
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+// Insert appends a copy of the event.
+func (r *MemoryAuditRepository) Insert(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// Find returns at most limit events matching the query, newest first.
+func (r *MemoryAuditRepository) Find(ctx context.Context, query models.AuditQuery, limit int) ([]models.AuditEvent, error) {
+	events := r.matching(query)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// Export calls fn for every event matching the query, oldest first.
+func (r *MemoryAuditRepository) Export(ctx context.Context, query models.AuditQuery, fn func(*models.AuditEvent) error) error {
+	for _, event := range r.matching(query) {
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matching returns the events matching the query in chronological order.
+func (r *MemoryAuditRepository) matching(query models.AuditQuery) []models.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []models.AuditEvent{}
+	for _, event := range r.events {
+		switch {
+		case query.TenantID != "" && event.TenantID != query.TenantID,
+			query.UserID != "" && event.UserID != query.UserID,
+			query.EventType != "" && event.EventType != query.EventType,
+			!query.From.IsZero() && event.Timestamp.Before(query.From),
+			!query.To.IsZero() && !event.Timestamp.Before(query.To):
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	return events
+}
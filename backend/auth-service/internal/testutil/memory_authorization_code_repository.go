@@ -0,0 +1,43 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryAuthorizationCodeRepository keeps authorization codes in memory with
+// the semantics of MongoAuthorizationCodeRepository, for tests.
+type MemoryAuthorizationCodeRepository struct {
+	mu    sync.Mutex
+	codes map[string]models.AuthorizationCode
+}
+
+// NewMemoryAuthorizationCodeRepository creates an empty MemoryAuthorizationCodeRepository.
+func NewMemoryAuthorizationCodeRepository() *MemoryAuthorizationCodeRepository {
+	return &MemoryAuthorizationCodeRepository{codes: make(map[string]models.AuthorizationCode)}
+}
+
+// Insert stores a copy of a new authorization code.
+func (r *MemoryAuthorizationCodeRepository) Insert(ctx context.Context, code *models.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.codes[code.ID] = *code
+	return nil
+}
+
+// Consume removes an unexpired code and returns it.
+func (r *MemoryAuthorizationCodeRepository) Consume(ctx context.Context, id string, now time.Time) (*models.AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok || !code.ExpiresAt.After(now) {
+		return nil, services.ErrAuthorizationCodeNotFound
+	}
+	delete(r.codes, id)
+	return &code, nil
+}
@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+)
+
+// MemoryEventPublisher records the lifecycle events published through it, so
+// tests can check them without a broker.
+type MemoryEventPublisher struct {
+	mu          sync.Mutex
+	events      []models.UserEvent
+	invitations []models.InvitationEvent
+}
+
+// NewMemoryEventPublisher creates an empty MemoryEventPublisher.
+func NewMemoryEventPublisher() *MemoryEventPublisher {
+	return &MemoryEventPublisher{}
+}
+
+// PublishUserCreated records user.created.v1.
+func (p *MemoryEventPublisher) PublishUserCreated(ctx context.Context, event models.UserEvent) error {
+	return p.record(event)
+}
+
+// PublishUserUpdated records user.updated.v1.
+func (p *MemoryEventPublisher) PublishUserUpdated(ctx context.Context, event models.UserEvent) error {
+	return p.record(event)
+}
+
+// PublishUserDeleted records user.deleted.v1.
+func (p *MemoryEventPublisher) PublishUserDeleted(ctx context.Context, event models.UserEvent) error {
+	return p.record(event)
+}
+
+// PublishInvitation records an invitation lifecycle event.
+func (p *MemoryEventPublisher) PublishInvitation(ctx context.Context, event models.InvitationEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.invitations = append(p.invitations, event)
+	return nil
+}
+
+func (p *MemoryEventPublisher) record(event models.UserEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	event.Source = services.EventSource
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the recorded user lifecycle events in the order they were
+// published.
+func (p *MemoryEventPublisher) Events() []models.UserEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]models.UserEvent(nil), p.events...)
+}
+
+// InvitationEvents returns the recorded invitation lifecycle events in the
+// order they were published.
+func (p *MemoryEventPublisher) InvitationEvents() []models.InvitationEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]models.InvitationEvent(nil), p.invitations...)
+}
+
+// Reset forgets the recorded events.
+func (p *MemoryEventPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = nil
+	p.invitations = nil
+}
@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryInvitationRedeemer holds pending invitations in memory with the
+// semantics of InvitationService at registration, for tests. No events are
+// published.
+type MemoryInvitationRedeemer struct {
+	required bool
+
+	mu          sync.Mutex
+	invitations map[string]models.Invitation
+}
+
+// NewMemoryInvitationRedeemer creates a MemoryInvitationRedeemer without
+// invitations. With required set, registration is invitation-only.
+func NewMemoryInvitationRedeemer(required bool) *MemoryInvitationRedeemer {
+	return &MemoryInvitationRedeemer{
+		required:    required,
+		invitations: make(map[string]models.Invitation),
+	}
+}
+
+// Add stores a pending invitation that is redeemed with code.
+func (r *MemoryInvitationRedeemer) Add(invitation models.Invitation, code string) {
+	invitation.EmailNormalized = services.NormalizeEmail(invitation.Email)
+	invitation.CodeHash = services.HashOpaqueToken(code)
+	invitation.Status = services.InvitationSent
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.invitations[invitation.ID] = invitation
+}
+
+// SetRequired makes registration invitation-only or opens it again.
+func (r *MemoryInvitationRedeemer) SetRequired(required bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.required = required
+}
+
+// Required reports whether registration is invitation-only.
+func (r *MemoryInvitationRedeemer) Required() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.required
+}
+
+// Find returns the pending invitation of the tenant with the code, which must
+// have been sent to email.
+func (r *MemoryInvitationRedeemer) Find(ctx context.Context, tenantID, code, email string) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, invitation := range r.invitations {
+		if invitation.CodeHash == services.HashOpaqueToken(code) &&
+			invitation.TenantID == tenantID &&
+			invitation.EmailNormalized == services.NormalizeEmail(email) &&
+			invitation.Status == services.InvitationSent &&
+			invitation.ExpiresAt.After(now) {
+			return &invitation, nil
+		}
+	}
+	return nil, services.ErrInvalidInvitation
+}
+
+// Accept marks a pending invitation accepted by the account userID.
+func (r *MemoryInvitationRedeemer) Accept(ctx context.Context, invitation *models.Invitation, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	stored, ok := r.invitations[invitation.ID]
+	if !ok || stored.Status != services.InvitationSent || !stored.ExpiresAt.After(now) {
+		return services.ErrInvalidInvitation
+	}
+	stored.Status = services.InvitationAccepted
+	stored.UserID = userID
+	stored.AcceptedAt = &now
+	r.invitations[invitation.ID] = stored
+	return nil
+}
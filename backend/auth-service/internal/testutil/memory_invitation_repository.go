@@ -0,0 +1,182 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryInvitationRepository keeps invitations in memory with the semantics
+// of MongoInvitationRepository.
+type MemoryInvitationRepository struct {
+	mu          sync.Mutex
+	invitations map[string]models.Invitation
+}
+
+// NewMemoryInvitationRepository creates an empty MemoryInvitationRepository.
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{invitations: make(map[string]models.Invitation)}
+}
+
+// Insert stores a copy of a new invitation unless the address already has a
+// pending one in the tenant.
+func (r *MemoryInvitationRepository) Insert(ctx context.Context, invitation *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.invitations {
+		if existing.Status == services.InvitationSent && existing.TenantID == invitation.TenantID && existing.EmailNormalized == invitation.EmailNormalized {
+			return services.ErrInvitationPending
+		}
+	}
+	r.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+// FindByID returns the invitation of the tenant with the ID.
+func (r *MemoryInvitationRepository) FindByID(ctx context.Context, tenantID, id string) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.TenantID != tenantID {
+		return nil, services.ErrInvitationNotFound
+	}
+	return &invitation, nil
+}
+
+// FindPending returns the pending invitation with the code hash sent to the
+// address.
+func (r *MemoryInvitationRepository) FindPending(ctx context.Context, tenantID, codeHash, emailNormalized string, now time.Time) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, invitation := range r.invitations {
+		if invitation.CodeHash == codeHash && invitation.TenantID == tenantID && invitation.EmailNormalized == emailNormalized &&
+			invitation.Status == services.InvitationSent && invitation.ExpiresAt.After(now) {
+			return &invitation, nil
+		}
+	}
+	return nil, services.ErrInvalidInvitation
+}
+
+// Find returns the invitations matching the query, newest first.
+func (r *MemoryInvitationRepository) Find(ctx context.Context, query models.InvitationQuery, limit int, now time.Time) ([]models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitations := []models.Invitation{}
+	for _, invitation := range r.invitations {
+		if invitation.TenantID != query.TenantID {
+			continue
+		}
+		if query.Email != "" && invitation.EmailNormalized != services.NormalizeEmail(query.Email) {
+			continue
+		}
+		due := invitation.Status == services.InvitationSent && !invitation.ExpiresAt.After(now)
+		switch query.Status {
+		case "":
+		case services.InvitationSent:
+			if invitation.Status != services.InvitationSent || due {
+				continue
+			}
+		case services.InvitationExpired:
+			if invitation.Status != services.InvitationExpired && !due {
+				continue
+			}
+		default:
+			if invitation.Status != query.Status {
+				continue
+			}
+		}
+		invitations = append(invitations, invitation)
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.After(invitations[j].CreatedAt) })
+	if len(invitations) > limit {
+		invitations = invitations[:limit]
+	}
+	return invitations, nil
+}
+
+// FindDue returns pending invitations past their expiry.
+func (r *MemoryInvitationRepository) FindDue(ctx context.Context, tenantID, emailNormalized string, now time.Time, limit int) ([]models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invitations []models.Invitation
+	for _, invitation := range r.invitations {
+		if invitation.Status != services.InvitationSent || invitation.ExpiresAt.After(now) ||
+			(tenantID != "" && invitation.TenantID != tenantID) ||
+			(emailNormalized != "" && invitation.EmailNormalized != emailNormalized) {
+			continue
+		}
+		invitations = append(invitations, invitation)
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ExpiresAt.Before(invitations[j].ExpiresAt) })
+	if len(invitations) > limit {
+		invitations = invitations[:limit]
+	}
+	return invitations, nil
+}
+
+// Revoke marks a pending invitation revoked.
+func (r *MemoryInvitationRepository) Revoke(ctx context.Context, tenantID, id, actorID string, now time.Time) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.TenantID != tenantID || invitation.Status != services.InvitationSent || !invitation.ExpiresAt.After(now) {
+		return nil, services.ErrInvitationNotPending
+	}
+	invitation.Status = services.InvitationRevoked
+	invitation.RevokedAt = &now
+	invitation.RevokedBy = actorID
+	r.invitations[id] = invitation
+	return &invitation, nil
+}
+
+// Accept marks a pending invitation accepted.
+func (r *MemoryInvitationRepository) Accept(ctx context.Context, id, userID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.Status != services.InvitationSent || !invitation.ExpiresAt.After(now) {
+		return services.ErrInvalidInvitation
+	}
+	invitation.Status = services.InvitationAccepted
+	invitation.AcceptedAt = &now
+	invitation.UserID = userID
+	r.invitations[id] = invitation
+	return nil
+}
+
+// MarkExpired marks a pending invitation expired.
+func (r *MemoryInvitationRepository) MarkExpired(ctx context.Context, id string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.Status != services.InvitationSent {
+		return false, nil
+	}
+	invitation.Status = services.InvitationExpired
+	invitation.ExpiredAt = &now
+	r.invitations[id] = invitation
+	return true, nil
+}
+
+// Expire lets every stored invitation run out, as if its lifetime had passed.
+// Their status is left for InvitationService to mark.
+func (r *MemoryInvitationRepository) Expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, invitation := range r.invitations {
+		invitation.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		r.invitations[id] = invitation
+	}
+}
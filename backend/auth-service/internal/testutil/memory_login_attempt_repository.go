@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLoginAttemptRepository keeps failed login counters in memory with the
+// semantics of MongoLoginAttemptRepository, for tests. Expired counters are
+// kept, as if the TTL index had not run yet.
+type MemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+// NewMemoryLoginAttemptRepository creates an empty MemoryLoginAttemptRepository.
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{attempts: make(map[string]models.LoginAttempt)}
+}
+
+// Find returns the counters stored for any of the keys.
+func (r *MemoryLoginAttemptRepository) Find(ctx context.Context, keys ...string) ([]models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var attempts []models.LoginAttempt
+	for _, key := range keys {
+		if attempt, ok := r.attempts[key]; ok {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failure and returns the updated counter.
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now, expiresAt time.Time) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.attempts[key]
+	attempt.ID = key
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	if expiresAt.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = expiresAt
+	}
+	r.attempts[key] = attempt
+	return &attempt, nil
+}
+
+// Lock locks the counter if it still has the given number of failures.
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, key string, failures int, lockedUntil, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.Failures != failures {
+		return false, nil
+	}
+	attempt.Failures = 0
+	attempt.LockedUntil = &lockedUntil
+	attempt.ExpiresAt = expiresAt
+	attempt.Lockouts++
+	r.attempts[key] = attempt
+	return true, nil
+}
+
+// ResetFailures clears the failures of a counter.
+func (r *MemoryLoginAttemptRepository) ResetFailures(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.Failures = 0
+		r.attempts[key] = attempt
+	}
+	return nil
+}
+
+// Delete removes a counter.
+func (r *MemoryLoginAttemptRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"auth-service/internal/services"
+	"context"
+	"sync"
+)
+
+// MemoryMailer records the messages it is asked to send instead of sending
+// them.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []services.Message
+	err      error
+}
+
+// NewMemoryMailer creates a MemoryMailer without messages.
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records the message, or fails with the error set by FailWith.
+func (m *MemoryMailer) Send(ctx context.Context, msg services.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// FailWith makes every later Send fail with err without recording the
+// message.
+func (m *MemoryMailer) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// Messages returns the recorded messages in the order they were sent.
+func (m *MemoryMailer) Messages() []services.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]services.Message(nil), m.messages...)
+}
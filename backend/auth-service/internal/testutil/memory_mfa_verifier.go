@@ -0,0 +1,113 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryMFAVerifier stands in for MFAService in tests. Instead of TOTP
+// secrets, each user with MFA enabled has one fixed code that is accepted as
+// a one-time code; challenges behave as those of MFAService.
+type MemoryMFAVerifier struct {
+	mu         sync.Mutex
+	codes      map[string]string
+	challenges map[string]models.MFAChallenge
+}
+
+// NewMemoryMFAVerifier creates a MemoryMFAVerifier without any user having
+// MFA enabled.
+func NewMemoryMFAVerifier() *MemoryMFAVerifier {
+	return &MemoryMFAVerifier{
+		codes:      make(map[string]string),
+		challenges: make(map[string]models.MFAChallenge),
+	}
+}
+
+// Enable turns MFA on for the user, accepting code as their second factor.
+func (v *MemoryMFAVerifier) Enable(userID, code string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.codes[userID] = code
+}
+
+// Enabled reports whether the user has MFA enabled.
+func (v *MemoryMFAVerifier) Enabled(ctx context.Context, userID string) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, ok := v.codes[userID]
+	return ok, nil
+}
+
+// VerifyCode checks the user's code and returns AMROneTimeCode.
+func (v *MemoryMFAVerifier) VerifyCode(ctx context.Context, userID, code string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.verify(userID, code)
+}
+
+func (v *MemoryMFAVerifier) verify(userID, code string) (string, error) {
+	expected, ok := v.codes[userID]
+	if !ok {
+		return "", services.ErrMFANotEnrolled
+	}
+	if strings.TrimSpace(code) != expected {
+		return "", services.ErrInvalidMFACode
+	}
+	return services.AMROneTimeCode, nil
+}
+
+// NewChallenge starts the second step of a login and returns the challenge
+// token.
+func (v *MemoryMFAVerifier) NewChallenge(ctx context.Context, tenantID, userID string, amr []string) (string, error) {
+	token, err := services.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	id := services.HashOpaqueToken(token)
+	v.challenges[id] = models.MFAChallenge{
+		ID:        id,
+		TenantID:  tenantID,
+		UserID:    userID,
+		AMR:       amr,
+		ExpiresAt: time.Now().UTC().Add(services.MFAChallengeTTL),
+	}
+	return token, nil
+}
+
+// CompleteChallenge verifies the code for a login challenge. Each challenge
+// allows services.MFAChallengeMaxAttempts attempts and is removed once it
+// succeeds; a wrong code is returned along with the challenge.
+func (v *MemoryMFAVerifier) CompleteChallenge(ctx context.Context, token, code string) (*models.MFAChallenge, string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	id := services.HashOpaqueToken(token)
+	challenge, ok := v.challenges[id]
+	if !ok || challenge.Attempts >= services.MFAChallengeMaxAttempts || !challenge.ExpiresAt.After(time.Now().UTC()) {
+		return nil, "", services.ErrInvalidMFAChallenge
+	}
+	challenge.Attempts++
+	v.challenges[id] = challenge
+
+	method, err := v.verify(challenge.UserID, code)
+	if errors.Is(err, services.ErrInvalidMFACode) {
+		return &challenge, "", err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	delete(v.challenges, id)
+	return &challenge, method, nil
+}
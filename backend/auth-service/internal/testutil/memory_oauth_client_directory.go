@@ -0,0 +1,65 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"crypto/subtle"
+	"sync"
+)
+
+// MemoryOAuthClientDirectory holds OAuth clients in memory, for tests. Client
+// secrets are stored as plain SHA-256 hashes rather than password hashes.
+type MemoryOAuthClientDirectory struct {
+	mu      sync.Mutex
+	clients map[string]models.OAuthClient
+}
+
+// NewMemoryOAuthClientDirectory creates an empty MemoryOAuthClientDirectory.
+func NewMemoryOAuthClientDirectory() *MemoryOAuthClientDirectory {
+	return &MemoryOAuthClientDirectory{clients: make(map[string]models.OAuthClient)}
+}
+
+// Put adds a client or replaces the client with the same ID. The client is
+// confidential if secret is not empty and public otherwise.
+func (d *MemoryOAuthClientDirectory) Put(client models.OAuthClient, secret string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	client.SecretHash = ""
+	if secret != "" {
+		client.SecretHash = services.HashOpaqueToken(secret)
+	}
+	d.clients[client.ID] = client
+}
+
+// Get returns the client with the given ID.
+func (d *MemoryOAuthClientDirectory) Get(clientID string) (*models.OAuthClient, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	client, ok := d.clients[clientID]
+	if !ok {
+		return nil, services.ErrOAuthClientNotFound
+	}
+	return &client, nil
+}
+
+// Authenticate verifies the client credentials like OAuthClientService does,
+// without secret rotation.
+func (d *MemoryOAuthClientDirectory) Authenticate(clientID, secret string) (*models.OAuthClient, error) {
+	client, err := d.Get(clientID)
+	if err != nil {
+		return nil, services.ErrInvalidClientCredentials
+	}
+
+	if client.IsPublic() {
+		if secret != "" {
+			return nil, services.ErrInvalidClientCredentials
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(services.HashOpaqueToken(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, services.ErrInvalidClientCredentials
+	}
+	return client, nil
+}
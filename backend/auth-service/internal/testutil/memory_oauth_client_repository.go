@@ -0,0 +1,124 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryOAuthClientRepository keeps OAuth clients in memory with the
+// semantics of MongoOAuthClientRepository.
+type MemoryOAuthClientRepository struct {
+	mu      sync.Mutex
+	clients map[string]models.OAuthClient
+}
+
+// NewMemoryOAuthClientRepository creates an empty MemoryOAuthClientRepository.
+func NewMemoryOAuthClientRepository() *MemoryOAuthClientRepository {
+	return &MemoryOAuthClientRepository{clients: make(map[string]models.OAuthClient)}
+}
+
+// Insert stores a copy of a new client.
+func (r *MemoryOAuthClientRepository) Insert(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clients[client.ID] = *client
+	return nil
+}
+
+// FindByID returns the client with the ID.
+func (r *MemoryOAuthClientRepository) FindByID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, services.ErrOAuthClientNotFound
+	}
+	return &client, nil
+}
+
+// List returns every client, ordered by ID.
+func (r *MemoryOAuthClientRepository) List(ctx context.Context) ([]models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := []models.OAuthClient{}
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	return clients, nil
+}
+
+// UpdateRegistration replaces the registration of a client.
+func (r *MemoryOAuthClientRepository) UpdateRegistration(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.clients[client.ID]
+	if !ok {
+		return services.ErrOAuthClientNotFound
+	}
+	stored.Name = client.Name
+	stored.GrantTypes = client.GrantTypes
+	stored.RedirectURIs = client.RedirectURIs
+	stored.AllowedScopes = client.AllowedScopes
+	stored.UpdatedAt = client.UpdatedAt
+	r.clients[client.ID] = stored
+	return nil
+}
+
+// Delete removes a client.
+func (r *MemoryOAuthClientRepository) Delete(ctx context.Context, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[clientID]; !ok {
+		return services.ErrOAuthClientNotFound
+	}
+	delete(r.clients, clientID)
+	return nil
+}
+
+// ReplaceSecret sets the secret hash of a client whose hash is still
+// currentHash.
+func (r *MemoryOAuthClientRepository) ReplaceSecret(ctx context.Context, clientID, currentHash, secretHash string, previousExpiresAt *time.Time, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[clientID]
+	if !ok || client.SecretHash != currentHash {
+		return services.ErrOAuthClientNotFound
+	}
+	client.SecretHash = secretHash
+	client.UpdatedAt = now
+	client.PreviousSecretHash = ""
+	client.PreviousSecretExpiresAt = nil
+	if previousExpiresAt != nil {
+		expiresAt := *previousExpiresAt
+		client.PreviousSecretHash = currentHash
+		client.PreviousSecretExpiresAt = &expiresAt
+	}
+	r.clients[clientID] = client
+	return nil
+}
+
+// ExpirePreviousSecrets ends the overlap of every rotation in progress, as if
+// it had run out.
+func (r *MemoryOAuthClientRepository) ExpirePreviousSecrets() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, client := range r.clients {
+		if client.PreviousSecretExpiresAt != nil {
+			expiresAt := time.Now().UTC().Add(-time.Second)
+			client.PreviousSecretExpiresAt = &expiresAt
+			r.clients[id] = client
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryOneTimeTokenRepository keeps one-time tokens in memory with the
+// semantics of MongoOneTimeTokenRepository.
+type MemoryOneTimeTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.OneTimeToken
+}
+
+// NewMemoryOneTimeTokenRepository creates an empty MemoryOneTimeTokenRepository.
+func NewMemoryOneTimeTokenRepository() *MemoryOneTimeTokenRepository {
+	return &MemoryOneTimeTokenRepository{tokens: make(map[string]models.OneTimeToken)}
+}
+
+// NewMemoryOneTimeTokenService creates a OneTimeTokenService over an empty
+// MemoryOneTimeTokenRepository.
+func NewMemoryOneTimeTokenService() (*services.OneTimeTokenService, *MemoryOneTimeTokenRepository) {
+	repository := NewMemoryOneTimeTokenRepository()
+	return services.NewOneTimeTokenService(repository, []byte("test-one-time-token-secret")), repository
+}
+
+// Insert stores a copy of a new token.
+func (r *MemoryOneTimeTokenRepository) Insert(ctx context.Context, token *models.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+// Take deletes a usable token and returns it.
+func (r *MemoryOneTimeTokenRepository) Take(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || !token.ExpiresAt.After(now) {
+		return nil, services.ErrInvalidOneTimeToken
+	}
+	delete(r.tokens, tokenHash)
+	return &token, nil
+}
+
+// Find returns a usable token.
+func (r *MemoryOneTimeTokenRepository) Find(ctx context.Context, tokenHash, purpose string, now time.Time) (*models.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || !token.ExpiresAt.After(now) {
+		return nil, services.ErrInvalidOneTimeToken
+	}
+	return &token, nil
+}
+
+// LastIssued returns when the most recent token for the purpose and subject
+// was created.
+func (r *MemoryOneTimeTokenRepository) LastIssued(ctx context.Context, purpose, subject string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last time.Time
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.Subject == subject && token.CreatedAt.After(last) {
+			last = token.CreatedAt
+		}
+	}
+	return last, nil
+}
+
+// DeleteSubject deletes the tokens for the purpose and subject.
+func (r *MemoryOneTimeTokenRepository) DeleteSubject(ctx context.Context, purpose, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.Purpose == purpose && token.Subject == subject {
+			delete(r.tokens, id)
+		}
+	}
+	return nil
+}
+
+// Expire lets every stored token run out, as if its lifetime had passed.
+func (r *MemoryOneTimeTokenRepository) Expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		token.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		r.tokens[id] = token
+	}
+}
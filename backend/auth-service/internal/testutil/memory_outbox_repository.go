@@ -0,0 +1,147 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryOutboxRepository keeps outbox messages and the relay lease in memory
+// with the semantics of MongoOutboxRepository, for tests.
+type MemoryOutboxRepository struct {
+	mu             sync.Mutex
+	messages       []models.OutboxMessage
+	leaseOwner     string
+	leaseExpiresAt time.Time
+}
+
+// NewMemoryOutboxRepository creates an empty MemoryOutboxRepository.
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+// Insert stores a copy of a new message.
+func (r *MemoryOutboxRepository) Insert(ctx context.Context, message *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, *message)
+	return nil
+}
+
+// Due returns up to limit undelivered messages due at now, oldest first.
+func (r *MemoryOutboxRepository) Due(ctx context.Context, now time.Time, limit int64) ([]models.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []models.OutboxMessage
+	for _, message := range r.sorted() {
+		if message.DeliveredAt == nil && !message.NextAttemptAt.After(now) && int64(len(due)) < limit {
+			due = append(due, message)
+		}
+	}
+	return due, nil
+}
+
+// FirstPending returns the oldest undelivered message of a key, or
+// mongo.ErrNoDocuments as the driver would.
+func (r *MemoryOutboxRepository) FirstPending(ctx context.Context, key string) (*models.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, message := range r.sorted() {
+		if message.Key == key && message.DeliveredAt == nil {
+			return &message, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// MarkDelivered records the delivery of a message.
+func (r *MemoryOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == id {
+			r.messages[i].DeliveredAt = &deliveredAt
+			r.messages[i].ExpiresAt = &expiresAt
+			r.messages[i].Attempts++
+		}
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt.
+func (r *MemoryOutboxRepository) MarkFailed(ctx context.Context, id string, attempts int, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == id {
+			r.messages[i].Attempts = attempts
+			r.messages[i].LastError = lastError
+			r.messages[i].NextAttemptAt = nextAttemptAt
+		}
+	}
+	return nil
+}
+
+// Backlog returns the number of undelivered messages.
+func (r *MemoryOutboxRepository) Backlog(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var backlog int64
+	for _, message := range r.messages {
+		if message.DeliveredAt == nil {
+			backlog++
+		}
+	}
+	return backlog, nil
+}
+
+// AcquireLease takes the lease if it is free or expired, or renews it if
+// owner already holds it.
+func (r *MemoryOutboxRepository) AcquireLease(ctx context.Context, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaseOwner != "" && r.leaseOwner != owner && !r.leaseExpiresAt.Before(now) {
+		return false, nil
+	}
+	r.leaseOwner = owner
+	r.leaseExpiresAt = now.Add(ttl)
+	return true, nil
+}
+
+// ExpireLease lets the current lease run out, as if its holder had stopped.
+func (r *MemoryOutboxRepository) ExpireLease() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.leaseExpiresAt = time.Time{}
+}
+
+// Messages returns copies of the stored messages, oldest first.
+func (r *MemoryOutboxRepository) Messages() []models.OutboxMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sorted()
+}
+
+func (r *MemoryOutboxRepository) sorted() []models.OutboxMessage {
+	messages := append([]models.OutboxMessage(nil), r.messages...)
+	sort.SliceStable(messages, func(i, j int) bool {
+		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+	return messages
+}
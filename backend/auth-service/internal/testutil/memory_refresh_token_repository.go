@@ -0,0 +1,96 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRefreshTokenRepository keeps refresh tokens in memory with the
+// semantics of MongoRefreshTokenRepository, for tests. Expired tokens are
+// kept, as if the TTL index had not run yet.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository creates an empty MemoryRefreshTokenRepository.
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[string]models.RefreshToken)}
+}
+
+// Insert stores a copy of a new refresh token.
+func (r *MemoryRefreshTokenRepository) Insert(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+// MarkRotated marks a usable token as rotated and returns it as it was before.
+func (r *MemoryRefreshTokenRepository) MarkRotated(ctx context.Context, tokenHash string, now time.Time) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.RotatedAt != nil || token.RevokedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, services.ErrInvalidRefreshToken
+	}
+	rotated := token
+	rotated.RotatedAt = &now
+	r.tokens[tokenHash] = rotated
+	return &token, nil
+}
+
+// SetReplacedBy records the hash of the token that replaced a rotated one.
+func (r *MemoryRefreshTokenRepository) SetReplacedBy(ctx context.Context, id, replacedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.ID == id {
+			token.ReplacedBy = replacedBy
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+// FindByHash returns the token with the hash, whatever its state.
+func (r *MemoryRefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, services.ErrInvalidRefreshToken
+	}
+	return &token, nil
+}
+
+// RevokeFamily revokes the tokens of the family that are not revoked yet.
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	r.revoke(func(token models.RefreshToken) bool { return token.FamilyID == familyID }, now)
+	return nil
+}
+
+// RevokeUser revokes the tokens of the user that are not revoked yet.
+func (r *MemoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID string, now time.Time) error {
+	r.revoke(func(token models.RefreshToken) bool { return token.UserID == userID }, now)
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) revoke(match func(models.RefreshToken) bool, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &now
+			r.tokens[hash] = token
+		}
+	}
+}
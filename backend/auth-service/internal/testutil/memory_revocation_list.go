@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"auth-service/internal/services"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationList keeps the access token denylist in memory with the
+// semantics of RevocationService, for tests. Nothing is published.
+type MemoryRevocationList struct {
+	mu            sync.Mutex
+	tokens        map[string]bool
+	revokedBefore map[string]time.Time
+}
+
+// NewMemoryRevocationList creates an empty MemoryRevocationList.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		tokens:        make(map[string]bool),
+		revokedBefore: make(map[string]time.Time),
+	}
+}
+
+// RevokeToken adds a single access token to the denylist.
+func (l *MemoryRevocationList) RevokeToken(ctx context.Context, claims *services.Claims, reason string) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return errors.New("token cannot be revoked individually")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens[claims.ID] = true
+	return nil
+}
+
+// RevokeAllForUser revokes every access token issued to the user up to now.
+func (l *MemoryRevocationList) RevokeAllForUser(ctx context.Context, userID, reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.revokedBefore[userID] = time.Now().UTC()
+	return nil
+}
+
+// IsRevoked reports whether the token is on the denylist. Tokens are ordered
+// against user-wide revocations like RevocationService does.
+func (l *MemoryRevocationList) IsRevoked(ctx context.Context, claims *services.Claims) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if claims.ID != "" && l.tokens[claims.ID] {
+		return true, nil
+	}
+	revokedBefore, ok := l.revokedBefore[claims.UserID]
+	if !ok || claims.UserID == "" {
+		return false, nil
+	}
+	return claims.IssuedNoLaterThan(revokedBefore), nil
+}
@@ -0,0 +1,168 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySessionStore keeps sessions in memory with the semantics of
+// SessionService, for tests. Terminating a session revokes its refresh token
+// family in refreshTokens.
+type MemorySessionStore struct {
+	refreshTokens services.RefreshTokenStore
+	ttl           time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore whose sessions
+// expire after ttl without a token refresh.
+func NewMemorySessionStore(refreshTokens services.RefreshTokenStore, ttl time.Duration) *MemorySessionStore {
+	return &MemorySessionStore{
+		refreshTokens: refreshTokens,
+		ttl:           ttl,
+		sessions:      make(map[string]models.Session),
+	}
+}
+
+// Create records a new session with a fresh refresh token family.
+func (s *MemorySessionStore) Create(ctx context.Context, userID string, amr []string, client models.ClientInfo) (*models.Session, error) {
+	now := time.Now().UTC()
+	session := models.Session{
+		ID:         primitive.NewObjectID().Hex(),
+		UserID:     userID,
+		FamilyID:   primitive.NewObjectID().Hex(),
+		UserAgent:  services.TruncateUserAgent(client.UserAgent),
+		IPAddress:  client.IPAddress,
+		AMR:        amr,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = session
+	return &session, nil
+}
+
+// Touch records activity on the session owning the refresh token family.
+func (s *MemorySessionStore) Touch(ctx context.Context, familyID string, client models.ClientInfo) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, session := range s.sessions {
+		if session.FamilyID != familyID || !session.ExpiresAt.After(now) {
+			continue
+		}
+		session.LastSeenAt = now
+		session.ExpiresAt = now.Add(s.ttl)
+		if client.IPAddress != "" {
+			session.IPAddress = client.IPAddress
+		}
+		if client.UserAgent != "" {
+			session.UserAgent = services.TruncateUserAgent(client.UserAgent)
+		}
+		s.sessions[id] = session
+		return &session, nil
+	}
+	return nil, services.ErrSessionNotFound
+}
+
+// IsActive reports whether the session exists and has not expired.
+func (s *MemorySessionStore) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	return ok && session.ExpiresAt.After(time.Now().UTC()), nil
+}
+
+// List returns the user's active sessions, most recently used first.
+func (s *MemorySessionStore) List(ctx context.Context, userID string) ([]models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	sessions := []models.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Terminate ends one of the user's sessions and revokes its refresh tokens.
+func (s *MemorySessionStore) Terminate(ctx context.Context, userID, sessionID string) error {
+	s.mu.Lock()
+	session, ok := s.sessions[sessionID]
+	if ok && session.UserID == userID {
+		delete(s.sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	if !ok || session.UserID != userID {
+		return services.ErrSessionNotFound
+	}
+	return s.refreshTokens.RevokeFamily(ctx, session.FamilyID)
+}
+
+// TerminateOthers ends every session of the user except keepSessionID and
+// returns how many were ended.
+func (s *MemorySessionStore) TerminateOthers(ctx context.Context, userID, keepSessionID string) (int, error) {
+	s.mu.Lock()
+	var ended []models.Session
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != keepSessionID {
+			ended = append(ended, session)
+			delete(s.sessions, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for i, session := range ended {
+		if err := s.refreshTokens.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return i, err
+		}
+	}
+	return len(ended), nil
+}
+
+// TerminateAll ends every session of the user and revokes all of their
+// refresh tokens.
+func (s *MemorySessionStore) TerminateAll(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.refreshTokens.RevokeAllForUser(ctx, userID)
+}
+
+// Expire lets every session run out, as if it had not been used for the
+// session lifetime. The refresh tokens of the sessions are left alone.
+func (s *MemorySessionStore) Expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		session.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		s.sessions[id] = session
+	}
+}
@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"auth-service/internal/logger"
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySigningKeyRepository keeps signing keys in memory with the semantics
+// of MongoSigningKeyRepository. Keys past their retention are left out of
+// listings, as if the TTL index had dropped them.
+type MemorySigningKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]models.SigningKey
+}
+
+// NewMemorySigningKeyRepository creates an empty MemorySigningKeyRepository.
+func NewMemorySigningKeyRepository() *MemorySigningKeyRepository {
+	return &MemorySigningKeyRepository{keys: make(map[string]models.SigningKey)}
+}
+
+// NewMemoryKeyStore creates a KeyStore over an empty
+// MemorySigningKeyRepository and loads it, which generates the first key.
+// Keys are retained for an hour; the store only rotates when asked to.
+func NewMemoryKeyStore(algorithm string) (*services.KeyStore, error) {
+	store, err := services.NewKeyStore(NewMemorySigningKeyRepository(), algorithm, 24*time.Hour, time.Hour, logger.NewNopLogger())
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Insert stores a copy of a new key.
+func (r *MemorySigningKeyRepository) Insert(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = *key
+	return nil
+}
+
+// RetireOthers retires every active key other than keepID.
+func (r *MemorySigningKeyRepository) RetireOthers(ctx context.Context, keepID string, retiredAt, verifyUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, key := range r.keys {
+		if id != keepID && key.RetiredAt == nil {
+			key.RetiredAt = &retiredAt
+			key.VerifyUntil = &verifyUntil
+			r.keys[id] = key
+		}
+	}
+	return nil
+}
+
+// ListVerifiable returns the keys that can still verify tokens at now, oldest
+// first.
+func (r *MemorySigningKeyRepository) ListVerifiable(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var keys []models.SigningKey
+	for _, key := range r.keys {
+		if key.VerifyUntil == nil || key.VerifyUntil.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
@@ -0,0 +1,44 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+)
+
+// MemoryTenantDirectory holds tenants in memory, for tests.
+type MemoryTenantDirectory struct {
+	mu      sync.Mutex
+	tenants map[string]models.Tenant
+}
+
+// NewMemoryTenantDirectory creates a MemoryTenantDirectory holding the given
+// tenants.
+func NewMemoryTenantDirectory(tenants ...models.Tenant) *MemoryTenantDirectory {
+	d := &MemoryTenantDirectory{tenants: make(map[string]models.Tenant)}
+	for _, tenant := range tenants {
+		d.Put(tenant)
+	}
+	return d
+}
+
+// Put adds a tenant or replaces the tenant with the same ID.
+func (d *MemoryTenantDirectory) Put(tenant models.Tenant) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tenants[tenant.ID] = tenant
+}
+
+// Get returns the tenant with the given ID.
+func (d *MemoryTenantDirectory) Get(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tenant, ok := d.tenants[tenantID]
+	if !ok {
+		return nil, services.ErrTenantNotFound
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,76 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryTenantRepository keeps tenants in memory with the semantics of
+// MongoTenantRepository.
+type MemoryTenantRepository struct {
+	mu      sync.Mutex
+	tenants map[string]models.Tenant
+}
+
+// NewMemoryTenantRepository creates an empty MemoryTenantRepository.
+func NewMemoryTenantRepository() *MemoryTenantRepository {
+	return &MemoryTenantRepository{tenants: make(map[string]models.Tenant)}
+}
+
+// Insert stores a copy of a new tenant.
+func (r *MemoryTenantRepository) Insert(ctx context.Context, tenant *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenant.ID]; ok {
+		return services.ErrTenantExists
+	}
+	r.tenants[tenant.ID] = *tenant
+	return nil
+}
+
+// FindByID returns the tenant with the ID.
+func (r *MemoryTenantRepository) FindByID(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, services.ErrTenantNotFound
+	}
+	return &tenant, nil
+}
+
+// List returns every tenant, ordered by ID.
+func (r *MemoryTenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenants := []models.Tenant{}
+	for _, tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+// Update replaces the name, status and settings of a tenant.
+func (r *MemoryTenantRepository) Update(ctx context.Context, tenantID string, req models.UpdateTenantRequest, now time.Time) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, services.ErrTenantNotFound
+	}
+	tenant.Name = req.Name
+	tenant.Status = req.Status
+	tenant.Settings = req.Settings
+	tenant.UpdatedAt = now
+	r.tenants[tenantID] = tenant
+	return &tenant, nil
+}
@@ -0,0 +1,169 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository keeps accounts in memory with the semantics of
+// MongoUserRepository, including one account per address and tenant. It lets
+// services and handlers run in tests without a database; nothing is
+// persisted.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+// NewMemoryUserRepository creates an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]models.User)}
+}
+
+// Create stores a copy of a new account.
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok {
+		return services.ErrUserExists
+	}
+	if r.emailTaken(user.TenantID, user.EmailNormalized, user.ID) {
+		return services.ErrUserExists
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+// FindByID returns the account of the tenant with the given ID.
+func (r *MemoryUserRepository) FindByID(ctx context.Context, tenantID, userID string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, services.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// FindByEmail returns the account of the tenant with the given address.
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, tenantID, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email = services.NormalizeEmail(email)
+	for _, user := range r.users {
+		if user.TenantID == tenantID && user.EmailNormalized == email {
+			return &user, nil
+		}
+	}
+	return nil, services.ErrUserNotFound
+}
+
+// SetRole changes the role of an account and returns the updated account.
+func (r *MemoryUserRepository) SetRole(ctx context.Context, tenantID, userID, role string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, services.ErrUserNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return &user, nil
+}
+
+// Activate moves a pending_verification account to active and returns the
+// updated account.
+func (r *MemoryUserRepository) Activate(ctx context.Context, userID, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.Email != email || user.Status != "pending_verification" {
+		return nil, services.ErrUserNotFound
+	}
+	user.Status = "active"
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return &user, nil
+}
+
+// UpdateProfile changes the name and address of an account.
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, tenantID, userID, name, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return services.ErrUserNotFound
+	}
+	if name != "" {
+		user.Name = name
+	}
+	if email = strings.TrimSpace(email); email != "" {
+		if r.emailTaken(tenantID, services.NormalizeEmail(email), userID) {
+			return services.ErrUserExists
+		}
+		user.Email = email
+		user.EmailNormalized = services.NormalizeEmail(email)
+	}
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return nil
+}
+
+// Delete removes an account.
+func (r *MemoryUserRepository) Delete(ctx context.Context, tenantID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return services.ErrUserNotFound
+	}
+	delete(r.users, userID)
+	return nil
+}
+
+// SetPasswordHash replaces the password hash of an account if it still
+// equals previous.
+func (r *MemoryUserRepository) SetPasswordHash(ctx context.Context, tenantID, userID, previous, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID || user.Password != previous {
+		return services.ErrUserNotFound
+	}
+	user.Password = hash
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return nil
+}
+
+// emailTaken reports whether an account of the tenant other than exceptID
+// has the normalized address, as the unique index would.
+func (r *MemoryUserRepository) emailTaken(tenantID, emailNormalized, exceptID string) bool {
+	for id, user := range r.users {
+		if id != exceptID && user.TenantID == tenantID && user.EmailNormalized == emailNormalized {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryTransactor runs functions directly, for services backed by in-memory
+// repositories. Writes made before fn fails are not rolled back.
+type MemoryTransactor struct{}
+
+// WithTransaction runs fn.
+func (MemoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,68 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sync"
+)
+
+// SentVerification is a verification email recorded by
+// MemoryVerificationSender. Purpose is PurposeEmailVerification or
+// PurposeEmailChange.
+type SentVerification struct {
+	UserID  string
+	Email   string
+	Purpose string
+}
+
+// MemoryVerificationSender records the verification emails it is asked to
+// send, for tests.
+type MemoryVerificationSender struct {
+	mu   sync.Mutex
+	sent []SentVerification
+	err  error
+}
+
+// NewMemoryVerificationSender creates an empty MemoryVerificationSender.
+func NewMemoryVerificationSender() *MemoryVerificationSender {
+	return &MemoryVerificationSender{}
+}
+
+// FailWith makes every later send fail with err without recording an email.
+// A nil err makes sending succeed again.
+func (s *MemoryVerificationSender) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Send records a verification email for the user's current address.
+func (s *MemoryVerificationSender) Send(ctx context.Context, user *models.User) error {
+	return s.record(SentVerification{UserID: user.ID, Email: user.Email, Purpose: services.PurposeEmailVerification})
+}
+
+// SendEmailChange records a confirmation email for a new address.
+func (s *MemoryVerificationSender) SendEmailChange(ctx context.Context, user *models.User, email string) error {
+	return s.record(SentVerification{UserID: user.ID, Email: email, Purpose: services.PurposeEmailChange})
+}
+
+func (s *MemoryVerificationSender) record(sent SentVerification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, sent)
+	return nil
+}
+
+// Sent returns the recorded emails in the order they were sent.
+func (s *MemoryVerificationSender) Sent() []SentVerification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SentVerification(nil), s.sent...)
+}
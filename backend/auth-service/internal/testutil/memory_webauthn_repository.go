@@ -0,0 +1,127 @@
+package testutil
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/services"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryWebAuthnRepository keeps passkeys and ceremony sessions in memory
+// with the semantics of MongoWebAuthnRepository, for tests.
+type MemoryWebAuthnRepository struct {
+	mu          sync.Mutex
+	credentials map[string]models.WebAuthnCredential
+	sessions    map[string]models.WebAuthnSession
+}
+
+// NewMemoryWebAuthnRepository creates an empty MemoryWebAuthnRepository.
+func NewMemoryWebAuthnRepository() *MemoryWebAuthnRepository {
+	return &MemoryWebAuthnRepository{
+		credentials: make(map[string]models.WebAuthnCredential),
+		sessions:    make(map[string]models.WebAuthnSession),
+	}
+}
+
+// InsertCredential stores a copy of a new credential.
+func (r *MemoryWebAuthnRepository) InsertCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.credentials[credential.ID]; ok {
+		return services.ErrWebAuthnCredentialExists
+	}
+	r.credentials[credential.ID] = *credential
+	return nil
+}
+
+// FindCredential returns the credential with the given ID.
+func (r *MemoryWebAuthnRepository) FindCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, services.ErrWebAuthnCredentialNotFound
+	}
+	return &credential, nil
+}
+
+// ListCredentials returns the user's credentials, oldest first.
+func (r *MemoryWebAuthnRepository) ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credentials := []models.WebAuthnCredential{}
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].CreatedAt.Before(credentials[j].CreatedAt) })
+	return credentials, nil
+}
+
+// UpdateSignCount records a use of the credential if its sign count is still
+// oldCount.
+func (r *MemoryWebAuthnRepository) UpdateSignCount(ctx context.Context, id string, oldCount, newCount uint32, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || credential.SignCount != oldCount {
+		return false, nil
+	}
+	credential.SignCount = newCount
+	credential.LastUsedAt = &usedAt
+	r.credentials[id] = credential
+	return true, nil
+}
+
+// DeleteCredential removes one of the user's credentials.
+func (r *MemoryWebAuthnRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || credential.UserID != userID {
+		return services.ErrWebAuthnCredentialNotFound
+	}
+	delete(r.credentials, id)
+	return nil
+}
+
+// InsertSession stores a copy of the session of a new ceremony.
+func (r *MemoryWebAuthnRepository) InsertSession(ctx context.Context, session *models.WebAuthnSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+// ConsumeSession removes and returns an unexpired session of the ceremony.
+func (r *MemoryWebAuthnRepository) ConsumeSession(ctx context.Context, id, ceremony string, now time.Time) (*models.WebAuthnSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.Ceremony != ceremony || !session.ExpiresAt.After(now) {
+		return nil, services.ErrWebAuthnSessionInvalid
+	}
+	delete(r.sessions, id)
+	return &session, nil
+}
+
+// ExpireSessions lets every pending ceremony session expire.
+func (r *MemoryWebAuthnRepository) ExpireSessions() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		session.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		r.sessions[id] = session
+	}
+}
@@ -0,0 +1,35 @@
+// Package testutil provides in-memory implementations of the dependencies of
+// the services, so services and handlers can be tested without MongoDB,
+// Kafka or a mail server. Nothing is persisted.
+package testutil
+
+import "auth-service/internal/services"
+
+// Keep the in-memory implementations in step with the interfaces they stand
+// in for.
+var (
+	_ services.AuditRecorder               = (*MemoryAuditRecorder)(nil)
+	_ services.AuditRepository             = (*MemoryAuditRepository)(nil)
+	_ services.AuthorizationCodeRepository = (*MemoryAuthorizationCodeRepository)(nil)
+	_ services.CredentialRepository        = (*MemoryUserRepository)(nil)
+	_ services.EventPublisher              = (*MemoryEventPublisher)(nil)
+	_ services.InvitationRedeemer          = (*MemoryInvitationRedeemer)(nil)
+	_ services.InvitationRepository        = (*MemoryInvitationRepository)(nil)
+	_ services.LoginAttemptRepository      = (*MemoryLoginAttemptRepository)(nil)
+	_ services.MFAVerifier                 = (*MemoryMFAVerifier)(nil)
+	_ services.Mailer                      = (*MemoryMailer)(nil)
+	_ services.OAuthClientDirectory        = (*MemoryOAuthClientDirectory)(nil)
+	_ services.OAuthClientRepository       = (*MemoryOAuthClientRepository)(nil)
+	_ services.OneTimeTokenRepository      = (*MemoryOneTimeTokenRepository)(nil)
+	_ services.OutboxRepository            = (*MemoryOutboxRepository)(nil)
+	_ services.RefreshTokenRepository      = (*MemoryRefreshTokenRepository)(nil)
+	_ services.RevocationList              = (*MemoryRevocationList)(nil)
+	_ services.SessionStore                = (*MemorySessionStore)(nil)
+	_ services.SigningKeyRepository        = (*MemorySigningKeyRepository)(nil)
+	_ services.TenantDirectory             = (*MemoryTenantDirectory)(nil)
+	_ services.TenantRepository            = (*MemoryTenantRepository)(nil)
+	_ services.Transactor                  = MemoryTransactor{}
+	_ services.UserRepository              = (*MemoryUserRepository)(nil)
+	_ services.VerificationSender          = (*MemoryVerificationSender)(nil)
+	_ services.WebAuthnRepository          = (*MemoryWebAuthnRepository)(nil)
+)
@@ -0,0 +1,157 @@
+// Package authclient is a client for the auth-service gRPC API, for services
+// that need to check access tokens issued by auth-service.
+package authclient
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxCacheEntries bounds the validation cache. Once it is full, expired
+// entries are dropped, and if none have expired the cache starts over.
+const maxCacheEntries = 10000
+
+// Client calls the auth-service gRPC API and caches positive token
+// validations. Invalid tokens are never cached, so a token is accepted from
+// the cache for at most the cache TTL after it has been revoked.
+type Client struct {
+	conn     *grpc.ClientConn
+	api      authv1.AuthServiceClient
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedValidation
+}
+
+type cachedValidation struct {
+	response  *authv1.ValidateTokenResponse
+	expiresAt time.Time
+}
+
+// New creates a Client for the auth-service gRPC API at addr (host:port).
+// Valid tokens are cached for cacheTTL, or until they expire if that is
+// sooner; zero disables the cache. Without dial options the connection is
+// unencrypted, which is only suitable inside a private network.
+func New(addr string, cacheTTL time.Duration, opts ...grpc.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:     conn,
+		api:      authv1.NewAuthServiceClient(conn),
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedValidation),
+	}, nil
+}
+
+// ValidateToken checks an access token, including revocation and session
+// termination. An invalid token is not an error; the response has valid=false.
+func (c *Client) ValidateToken(ctx context.Context, token string) (*authv1.ValidateTokenResponse, error) {
+	key := cacheKey(token)
+	if response, ok := c.lookup(key); ok {
+		return response, nil
+	}
+
+	response, err := c.api.ValidateToken(ctx, &authv1.ValidateTokenRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	if response.GetValid() {
+		c.store(key, response)
+	}
+	return response, nil
+}
+
+// Introspect describes an access token as defined by RFC 7662. The client ID
+// and secret must belong to a service account allowed the tokens:introspect
+// scope. Results are not cached.
+func (c *Client) Introspect(ctx context.Context, token, clientID, clientSecret string) (*authv1.IntrospectResponse, error) {
+	return c.api.Introspect(ctx, &authv1.IntrospectRequest{
+		Token:        token,
+		ClientId:     clientID,
+		ClientSecret: clientSecret,
+	})
+}
+
+// GetPublicKeys returns the public keys that verify access token signatures,
+// for callers that verify tokens themselves.
+func (c *Client) GetPublicKeys(ctx context.Context) ([]*authv1.PublicKey, error) {
+	response, err := c.api.GetPublicKeys(ctx, &authv1.GetPublicKeysRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetKeys(), nil
+}
+
+// Close closes the connection to auth-service.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) lookup(key string) (*authv1.ValidateTokenResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(cached.expiresAt) {
+		delete(c.cache, key)
+		return nil, false
+	}
+	// Callers may modify the response they get.
+	return proto.Clone(cached.response).(*authv1.ValidateTokenResponse), true
+}
+
+func (c *Client) store(key string, response *authv1.ValidateTokenResponse) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(c.cacheTTL)
+	if response.GetExpiresAt() > 0 {
+		if tokenExpiry := time.Unix(response.GetExpiresAt(), 0); tokenExpiry.Before(expiresAt) {
+			expiresAt = tokenExpiry
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= maxCacheEntries {
+		for k, cached := range c.cache {
+			if now.After(cached.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= maxCacheEntries {
+			c.cache = make(map[string]cachedValidation)
+		}
+	}
+	c.cache[key] = cachedValidation{
+		response:  proto.Clone(response).(*authv1.ValidateTokenResponse),
+		expiresAt: expiresAt,
+	}
+}
+
+// cacheKey hashes the token so the cache does not hold usable tokens.
+func cacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package authclient_test
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"auth-service/pkg/authclient"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuthService answers ValidateToken from a fixed set of valid tokens and
+// counts the calls per token.
+type fakeAuthService struct {
+	authv1.UnimplementedAuthServiceServer
+
+	mu    sync.Mutex
+	valid map[string]*authv1.ValidateTokenResponse
+	calls map[string]int
+}
+
+func (f *fakeAuthService) ValidateToken(ctx context.Context, req *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls[req.GetToken()]++
+	if response, ok := f.valid[req.GetToken()]; ok {
+		return response, nil
+	}
+	return &authv1.ValidateTokenResponse{Valid: false}, nil
+}
+
+// revoke makes the service report the token as invalid from now on.
+func (f *fakeAuthService) revoke(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.valid, token)
+}
+
+func (f *fakeAuthService) callsFor(token string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[token]
+}
+
+// newTestClient returns a Client with the cache TTL connected in memory to a
+// fakeAuthService that knows the valid tokens.
+func newTestClient(t *testing.T, cacheTTL time.Duration, valid map[string]*authv1.ValidateTokenResponse) (*authclient.Client, *fakeAuthService) {
+	t.Helper()
+
+	fake := &fakeAuthService{valid: valid, calls: make(map[string]int)}
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	authv1.RegisterAuthServiceServer(server, fake)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := authclient.New("passthrough:///bufnet", cacheTTL,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, fake
+}
+
+// validFor is the response for a valid token of the user expiring in ttl.
+func validFor(userID string, ttl time.Duration) *authv1.ValidateTokenResponse {
+	return &authv1.ValidateTokenResponse{Valid: true, UserId: userID, ExpiresAt: time.Now().Add(ttl).Unix()}
+}
+
+func validate(t *testing.T, client *authclient.Client, token string) *authv1.ValidateTokenResponse {
+	t.Helper()
+
+	response, err := client.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	return response
+}
+
+func TestValidateTokenCachesValidTokens(t *testing.T) {
+	client, fake := newTestClient(t, time.Minute, map[string]*authv1.ValidateTokenResponse{
+		"jane-token": validFor("jane", time.Hour),
+		"joe-token":  validFor("joe", time.Hour),
+	})
+
+	for i := 0; i < 3; i++ {
+		if got := validate(t, client, "jane-token"); !got.GetValid() || got.GetUserId() != "jane" {
+			t.Fatalf("validation = %v, want jane", got)
+		}
+	}
+	if got := fake.callsFor("jane-token"); got != 1 {
+		t.Errorf("auth-service called %d times, want 1", got)
+	}
+
+	// Each token has its own entry.
+	if got := validate(t, client, "joe-token"); got.GetUserId() != "joe" {
+		t.Errorf("validation = %v, want joe", got)
+	}
+	if got := fake.callsFor("joe-token"); got != 1 {
+		t.Errorf("auth-service called %d times for another token, want 1", got)
+	}
+}
+
+func TestValidateTokenDoesNotCacheInvalidTokens(t *testing.T) {
+	client, fake := newTestClient(t, time.Minute, map[string]*authv1.ValidateTokenResponse{})
+
+	for i := 0; i < 2; i++ {
+		if got := validate(t, client, "forged"); got.GetValid() {
+			t.Fatalf("validation = %v, want valid=false", got)
+		}
+	}
+	if got := fake.callsFor("forged"); got != 2 {
+		t.Errorf("auth-service called %d times, want 2", got)
+	}
+}
+
+func TestValidateTokenCacheExpiry(t *testing.T) {
+	t.Run("revocation is seen after the TTL", func(t *testing.T) {
+		client, fake := newTestClient(t, 20*time.Millisecond, map[string]*authv1.ValidateTokenResponse{
+			"jane-token": validFor("jane", time.Hour),
+		})
+		validate(t, client, "jane-token")
+		fake.revoke("jane-token")
+
+		if got := validate(t, client, "jane-token"); !got.GetValid() {
+			t.Errorf("validation within the TTL = %v, want the cached one", got)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if got := validate(t, client, "jane-token"); got.GetValid() {
+			t.Errorf("validation after the TTL = %v, want valid=false", got)
+		}
+	})
+
+	t.Run("not past the token expiry", func(t *testing.T) {
+		client, fake := newTestClient(t, time.Hour, map[string]*authv1.ValidateTokenResponse{
+			"jane-token": validFor("jane", -time.Second),
+		})
+		validate(t, client, "jane-token")
+		validate(t, client, "jane-token")
+
+		if got := fake.callsFor("jane-token"); got != 2 {
+			t.Errorf("auth-service called %d times, want 2", got)
+		}
+	})
+
+	t.Run("zero TTL disables the cache", func(t *testing.T) {
+		client, fake := newTestClient(t, 0, map[string]*authv1.ValidateTokenResponse{
+			"jane-token": validFor("jane", time.Hour),
+		})
+		validate(t, client, "jane-token")
+		validate(t, client, "jane-token")
+
+		if got := fake.callsFor("jane-token"); got != 2 {
+			t.Errorf("auth-service called %d times, want 2", got)
+		}
+	})
+}
+
+func TestValidateTokenCallersCannotChangeTheCache(t *testing.T) {
+	client, _ := newTestClient(t, time.Minute, map[string]*authv1.ValidateTokenResponse{
+		"jane-token": validFor("jane", time.Hour),
+	})
+
+	validate(t, client, "jane-token").UserId = "admin"
+	cached := validate(t, client, "jane-token")
+	cached.Permissions = append(cached.Permissions, "users:delete")
+
+	if got := validate(t, client, "jane-token"); got.GetUserId() != "jane" || len(got.GetPermissions()) != 0 {
+		t.Errorf("validation = %v, want the response of auth-service", got)
+	}
+}
@@ -7,13 +7,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"auth-service/pkg/authclient"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"user-service/internal/config"
 	"user-service/internal/handlers"
 	"user-service/internal/logger"
 	"user-service/internal/middleware"
+	"user-service/internal/migrations"
 	"user-service/internal/services"
 )
 
@@ -42,6 +45,30 @@ func main() {
 	}()
 	log.Info("MongoDB connection established")
 
+	// Profile changes and their events are written in one transaction,
+	// which standalone servers do not support
+	transactionsCtx, cancelTransactions := context.WithTimeout(context.Background(), 10*time.Second)
+	supportsTransactions, err := mongoConfig.SupportsTransactions(transactionsCtx)
+	cancelTransactions()
+	if err != nil {
+		log.Error("Failed to inspect MongoDB deployment", zap.Error(err))
+		os.Exit(1)
+	}
+	if !supportsTransactions {
+		log.Error("MongoDB must run as a replica set or sharded cluster to support transactions")
+		os.Exit(1)
+	}
+
+	// Apply pending migrations before anything reads the collections
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+	err = mongoConfig.Migrate(migrateCtx, migrations.All)
+	cancelMigrate()
+	if err != nil {
+		log.Error("Failed to apply database migrations", zap.Error(err))
+		os.Exit(1)
+	}
+	log.Info("Database migrations applied")
+
 	// Initialize Kafka publisher for user lifecycle events.
 	publisher, err := services.NewKafkaPublisher(
 		cfg.KafkaBrokers,
@@ -61,8 +88,40 @@ func main() {
 		}
 	}()
 
+	// Initialize the transactional outbox for user lifecycle events
+	outboxConfig := config.NewOutboxConfig(cfg.OutboxPollInterval, cfg.OutboxRetryBase, cfg.OutboxRetryMax, cfg.OutboxRetention)
+	outboxRepository := services.NewMongoOutboxRepository(mongoConfig)
+	outboxService := services.NewOutboxService(outboxRepository, publisher, cfg.KafkaTopicUserUpdated, cfg.KafkaTopicUserDeleted, outboxConfig, log)
+	indexCtx, cancelIndex := context.WithTimeout(context.Background(), 10*time.Second)
+	err = outboxRepository.EnsureIndexes(indexCtx)
+	cancelIndex()
+	if err != nil {
+		log.Error("Failed to create outbox indexes", zap.Error(err))
+		os.Exit(1)
+	}
+	outboxCtx, cancelOutbox := context.WithCancel(context.Background())
+	defer cancelOutbox()
+	go outboxService.Start(outboxCtx)
+
 	// Initialize services
-	userService := services.NewUserService(mongoConfig, publisher)
+	userRepository := services.NewMongoUserRepository(mongoConfig)
+	userService := services.NewUserService(userRepository, mongoConfig, outboxService)
+	var tokenVerifier middleware.TokenVerifier = services.NewTokenVerifier(cfg.AuthJWKSURL, cfg.JWTIssuer, cfg.JWTAudience)
+	if cfg.AuthGRPCAddr != "" {
+		// Asking auth-service also catches revoked tokens and ended sessions
+		authClient, err := authclient.New(cfg.AuthGRPCAddr, cfg.AuthGRPCCacheTTL)
+		if err != nil {
+			log.Error("Failed to initialize auth gRPC client", zap.Error(err))
+			os.Exit(1)
+		}
+		defer func() {
+			if closeErr := authClient.Close(); closeErr != nil {
+				log.Error("Failed to close auth gRPC client", zap.Error(closeErr))
+			}
+		}()
+		tokenVerifier = services.NewGRPCTokenVerifier(authClient)
+		log.Info("Verifying tokens through auth-service gRPC", zap.String("addr", cfg.AuthGRPCAddr))
+	}
 	userHandler := handlers.NewUserHandler(userService, log)
 	log.Info("User service and handlers initialized")
 
@@ -97,7 +156,7 @@ func main() {
 	}()
 
 	// Setup routes using the router
-	r := SetupRoutes(userHandler, log)
+	r := SetupRoutes(userHandler, outboxService, tokenVerifier, log)
 
 	// Start the server
 	serverAddr := fmt.Sprintf(":%s", cfg.Port)
@@ -115,6 +174,7 @@ func main() {
 
 	<-quit
 	cancelConsumer()
+	cancelOutbox()
 	log.Info("Shutting down user service...")
 }
 
@@ -122,7 +182,7 @@ func main() {
 func EnableCORS(c *gin.Context) {
 	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 	c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID")
 
 	if c.Request.Method == "OPTIONS" {
 		c.AbortWithStatus(http.StatusOK)
@@ -133,7 +193,7 @@ func EnableCORS(c *gin.Context) {
 }
 
 // SetupRoutes configures all routes for the user service
-func SetupRoutes(userHandler *handlers.UserHandler, log logger.Logger) *gin.Engine {
+func SetupRoutes(userHandler *handlers.UserHandler, outboxService *services.OutboxService, tokenVerifier middleware.TokenVerifier, log logger.Logger) *gin.Engine {
 	r := gin.Default()
 
 	// Enable CORS
@@ -143,17 +203,22 @@ func SetupRoutes(userHandler *handlers.UserHandler, log logger.Logger) *gin.Engi
 	r.Use(middleware.ZapMiddleware(log))
 
 	// API routes
-	api := r.Group("/api/users")
+	api := r.Group("/api/users", middleware.RequireAuth(tokenVerifier))
 	{
-		api.GET("/profile/:id", userHandler.GetUserByID)
-		api.GET("/list", userHandler.ListUsers)
-		api.PUT("/profile/:id", userHandler.UpdateUser)
-		api.DELETE("/profile/:id", userHandler.DeleteUser)
+		api.GET("/me", middleware.RequirePermission(middleware.PermissionUsersRead), userHandler.GetCurrentUser)
+		api.GET("/profile/:id", middleware.RequireOwnerOrPermission("id", middleware.PermissionUsersRead, middleware.PermissionUsersReadAny), userHandler.GetUserByID)
+		api.GET("/list", middleware.RequirePermission(middleware.PermissionUsersReadAny), userHandler.ListUsers)
+		api.PUT("/profile/:id", middleware.RequireOwnerOrPermission("id", middleware.PermissionUsersWrite, middleware.PermissionUsersWriteAny), userHandler.UpdateUser)
+		api.DELETE("/profile/:id", middleware.ForbidImpersonation(), middleware.RequirePermission(middleware.PermissionUsersDelete), userHandler.DeleteUser)
 	}
 
-	// Health check endpoint
+	// Health check endpoint, reporting the events not yet published
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy", "service": "user-service"})
+		health := gin.H{"status": "healthy", "service": "user-service"}
+		if backlog, err := outboxService.Backlog(c.Request.Context()); err == nil {
+			health["outbox_backlog"] = backlog
+		}
+		c.JSON(http.StatusOK, health)
 	})
 
 	return r
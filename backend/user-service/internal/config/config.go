@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 )
 
 // Config holds all configuration for the user service
@@ -15,6 +16,15 @@ type Config struct {
 	KafkaTopicUserCreated string
 	KafkaTopicUserUpdated string
 	KafkaTopicUserDeleted string
+	OutboxPollInterval    time.Duration
+	OutboxRetryBase       time.Duration
+	OutboxRetryMax        time.Duration
+	OutboxRetention       time.Duration
+	AuthJWKSURL           string
+	JWTIssuer             string
+	JWTAudience           string
+	AuthGRPCAddr          string
+	AuthGRPCCacheTTL      time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -29,6 +39,15 @@ func LoadConfig() *Config {
 		KafkaTopicUserCreated: getEnv("KAFKA_TOPIC_USER_CREATED", "user.created.v1"),
 		KafkaTopicUserUpdated: getEnv("KAFKA_TOPIC_USER_UPDATED", "user.updated.v1"),
 		KafkaTopicUserDeleted: getEnv("KAFKA_TOPIC_USER_DELETED", "user.deleted.v1"),
+		OutboxPollInterval:    getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetryBase:       getEnvDuration("OUTBOX_RETRY_BASE", time.Second),
+		OutboxRetryMax:        getEnvDuration("OUTBOX_RETRY_MAX", 5*time.Minute),
+		OutboxRetention:       getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		AuthJWKSURL:           getEnv("AUTH_JWKS_URL", "http://auth-service:8081/.well-known/jwks.json"),
+		JWTIssuer:             getEnv("JWT_ISSUER", "http://auth-service:8081"),
+		JWTAudience:           getEnv("JWT_AUDIENCE", "project-api"),
+		AuthGRPCAddr:          getEnv("AUTH_GRPC_ADDR", ""),
+		AuthGRPCCacheTTL:      getEnvDuration("AUTH_GRPC_CACHE_TTL", 30*time.Second),
 	}
 }
 
@@ -39,3 +58,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration gets a duration environment variable (e.g. "30s") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
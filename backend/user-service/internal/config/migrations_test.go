@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"user-service/internal/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryMigrationLog records applied migrations in memory.
+type memoryMigrationLog map[int]time.Time
+
+func (l memoryMigrationLog) Applied(ctx context.Context, version int) (bool, error) {
+	_, ok := l[version]
+	return ok, nil
+}
+
+func (l memoryMigrationLog) Record(ctx context.Context, migration config.Migration, appliedAt time.Time) error {
+	l[migration.Version] = appliedAt
+	return nil
+}
+
+// recordingMigrations returns migrations with the versions that append their
+// version to ran, and fail with err if it is their version's entry in fail.
+func recordingMigrations(ran *[]int, fail map[int]error, versions ...int) []config.Migration {
+	migrations := make([]config.Migration, len(versions))
+	for i, version := range versions {
+		migrations[i] = config.Migration{
+			Version:     version,
+			Description: "test migration",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				if err := fail[version]; err != nil {
+					return err
+				}
+				*ran = append(*ran, version)
+				return nil
+			},
+		}
+	}
+	return migrations
+}
+
+func TestRunMigrationsInVersionOrderOnce(t *testing.T) {
+	log := memoryMigrationLog{}
+	var ran []int
+	migrations := recordingMigrations(&ran, nil, 3, 1, 2)
+
+	if err := config.RunMigrations(context.Background(), nil, log, migrations); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if !slices.Equal(ran, []int{1, 2, 3}) {
+		t.Errorf("ran %v, want 1, 2, 3", ran)
+	}
+	if len(log) != 3 {
+		t.Errorf("recorded %v, want all three", log)
+	}
+
+	ran = nil
+	if err := config.RunMigrations(context.Background(), nil, log, migrations); err != nil {
+		t.Fatalf("second RunMigrations: %v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("second run ran %v, want nothing", ran)
+	}
+}
+
+func TestRunMigrationsSkipsApplied(t *testing.T) {
+	log := memoryMigrationLog{2: time.Now()}
+	var ran []int
+
+	if err := config.RunMigrations(context.Background(), nil, log, recordingMigrations(&ran, nil, 1, 2, 3)); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if !slices.Equal(ran, []int{1, 3}) {
+		t.Errorf("ran %v, want 1 and 3", ran)
+	}
+}
+
+func TestRunMigrationsStopsAtFailure(t *testing.T) {
+	log := memoryMigrationLog{}
+	var ran []int
+	duplicates := errors.New("duplicate emails")
+
+	err := config.RunMigrations(context.Background(), nil, log, recordingMigrations(&ran, map[int]error{2: duplicates}, 1, 2, 3))
+	if !errors.Is(err, duplicates) {
+		t.Fatalf("RunMigrations error = %v, want the error of migration 2", err)
+	}
+	if !slices.Equal(ran, []int{1}) {
+		t.Errorf("ran %v, want only 1", ran)
+	}
+	if _, ok := log[2]; ok || len(log) != 1 {
+		t.Errorf("recorded %v, want only 1", log)
+	}
+
+	// Once fixed, the next start picks up where the last one stopped.
+	ran = nil
+	if err := config.RunMigrations(context.Background(), nil, log, recordingMigrations(&ran, nil, 1, 2, 3)); err != nil {
+		t.Fatalf("RunMigrations after the fix: %v", err)
+	}
+	if !slices.Equal(ran, []int{2, 3}) {
+		t.Errorf("ran %v, want 2 and 3", ran)
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// OutboxConfig holds the settings of the relay that publishes outbox messages
+type OutboxConfig struct {
+	PollInterval time.Duration
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	Retention    time.Duration
+}
+
+// NewOutboxConfig creates a new outbox relay configuration
+func NewOutboxConfig(pollInterval, retryBase, retryMax, retention time.Duration) *OutboxConfig {
+	return &OutboxConfig{
+		PollInterval: pollInterval,
+		RetryBase:    retryBase,
+		RetryMax:     retryMax,
+		Retention:    retention,
+	}
+}
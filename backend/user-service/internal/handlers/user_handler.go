@@ -1,17 +1,15 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 
 	"user-service/internal/logger"
+	"user-service/internal/middleware"
 	"user-service/internal/models"
 	"user-service/internal/services"
 )
@@ -30,66 +28,24 @@ func NewUserHandler(userService *services.UserService, logger logger.Logger) *Us
 	}
 }
 
-// GetUserByID handles requests to get the current user's profile using JWT token
-func (h *UserHandler) GetUserByID(c *gin.Context) {
-	// Get token from Authorization header
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		h.logger.Error("Missing Authorization header",
-			zap.String("client_ip", c.ClientIP()),
-		)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
-		return
-	}
-
-	// Extract the token from "Bearer <token>"
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader { // No Bearer prefix found
-		h.logger.Error("Invalid Authorization header format")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token format"})
-		return
-	}
-
-	// Parse the token to get user ID
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the alg is what you expect:
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		// Replace with your actual secret key
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil {
-		h.logger.Error("Invalid token",
-			zap.Error(err),
-			zap.String("client_ip", c.ClientIP()),
-		)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		return
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		h.logger.Error("Invalid token claims")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-		return
-	}
+// GetCurrentUser handles requests to get the caller's own profile
+func (h *UserHandler) GetCurrentUser(c *gin.Context) {
+	h.getUser(c, middleware.GetClaims(c).UserID)
+}
 
-	// Get user ID from token claims
-	userID, ok := claims["user_id"].(string)
-	if !ok || userID == "" {
-		h.logger.Error("User ID not found in token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID in token"})
-		return
-	}
+// GetUserByID handles requests to get a profile by ID. Access is checked by
+// the route's authorization middleware.
+func (h *UserHandler) GetUserByID(c *gin.Context) {
+	h.getUser(c, c.Param("id"))
+}
 
-	h.logger.Info("Getting user by ID from token",
+func (h *UserHandler) getUser(c *gin.Context, userID string) {
+	h.logger.Info("Getting user by ID",
 		zap.String("user_id", userID),
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(middleware.TenantID(c), userID)
 	if err != nil {
 		h.logger.Warn("User not found",
 			zap.String("user_id", userID),
@@ -128,7 +84,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	response, err := h.userService.ListUsers(page, size)
+	response, err := h.userService.ListUsers(middleware.TenantID(c), page, size)
 	if err != nil {
 		h.logger.Error("Failed to list users",
 			zap.Error(err),
@@ -175,7 +131,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	user, err := h.userService.UpdateUser(id, req)
+	user, err := h.userService.UpdateUser(middleware.TenantID(c), id, req)
+	if errors.Is(err, services.ErrEmailTaken) {
+		h.logger.Warn("Failed to update user, email already in use",
+			zap.String("user_id", id),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Warn("Failed to update user",
 			zap.String("user_id", id),
@@ -209,7 +173,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		zap.String("client_ip", c.ClientIP()),
 	)
 
-	err := h.userService.DeleteUser(id)
+	err := h.userService.DeleteUser(middleware.TenantID(c), id)
 	if err != nil {
 		h.logger.Warn("Failed to delete user",
 			zap.String("user_id", id),
@@ -0,0 +1,276 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"user-service/internal/handlers"
+	"user-service/internal/middleware"
+	"user-service/internal/models"
+	"user-service/internal/services"
+	"user-service/internal/testutil"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(msg string, fields ...zap.Field)  {}
+func (nopLogger) Warn(msg string, fields ...zap.Field)  {}
+func (nopLogger) Error(msg string, fields ...zap.Field) {}
+func (nopLogger) Sync() error                           { return nil }
+
+// staticVerifier accepts the tokens it was given and nothing else.
+type staticVerifier map[string]*services.Claims
+
+func (v staticVerifier) Verify(tokenString string) (*services.Claims, error) {
+	claims, ok := v[tokenString]
+	if !ok {
+		return nil, errors.New("unknown token")
+	}
+	return claims, nil
+}
+
+var testTokens = staticVerifier{
+	"ada": {
+		UserID:      "u1",
+		TenantID:    "acme",
+		Role:        "customer",
+		Permissions: []string{middleware.PermissionUsersRead, middleware.PermissionUsersWrite, middleware.PermissionUsersDelete},
+	},
+	"admin": {
+		UserID:      "u9",
+		TenantID:    "acme",
+		Role:        "admin",
+		Permissions: []string{middleware.PermissionUsersReadAny, middleware.PermissionUsersWriteAny, middleware.PermissionUsersDelete},
+	},
+	"impersonated-ada": {
+		UserID:      "u1",
+		TenantID:    "acme",
+		Role:        "customer",
+		Permissions: []string{middleware.PermissionUsersRead, middleware.PermissionUsersWrite, middleware.PermissionUsersDelete},
+		Actor:       &services.Actor{Subject: "u9"},
+	},
+	"globex-admin": {
+		UserID:      "g9",
+		TenantID:    "globex",
+		Role:        "admin",
+		Permissions: []string{middleware.PermissionUsersReadAny, middleware.PermissionUsersWriteAny, middleware.PermissionUsersDelete},
+	},
+	"service": {
+		ClientID: "reporting",
+		Scope:    middleware.PermissionUsersReadAny,
+	},
+}
+
+type testServer struct {
+	router *gin.Engine
+	users  *testutil.MemoryUserRepository
+	events *testutil.MemoryEventPublisher
+}
+
+// newTestServer routes like the service's main package, with profiles u1
+// and u2 in the acme tenant and g1 in the globex tenant.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	users := testutil.NewMemoryUserRepository()
+	events := testutil.NewMemoryEventPublisher()
+	for _, user := range []models.User{
+		{ID: "u1", TenantID: "acme", Name: "Ada", Email: "ada@example.com", Status: "active", Role: "customer"},
+		{ID: "u2", TenantID: "acme", Name: "Grace", Email: "grace@example.com", Status: "active", Role: "customer"},
+		{ID: "g1", TenantID: "globex", Name: "Linus", Email: "linus@example.com", Status: "active", Role: "customer"},
+	} {
+		user := user
+		if err := users.Upsert(context.Background(), &user); err != nil {
+			t.Fatalf("Upsert(%s) error = %v", user.ID, err)
+		}
+	}
+	userHandler := handlers.NewUserHandler(services.NewUserService(users, testutil.MemoryTransactor{}, events), nopLogger{})
+
+	r := gin.New()
+	api := r.Group("/api/users", middleware.RequireAuth(testTokens))
+	{
+		api.GET("/me", middleware.RequirePermission(middleware.PermissionUsersRead), userHandler.GetCurrentUser)
+		api.GET("/profile/:id", middleware.RequireOwnerOrPermission("id", middleware.PermissionUsersRead, middleware.PermissionUsersReadAny), userHandler.GetUserByID)
+		api.GET("/list", middleware.RequirePermission(middleware.PermissionUsersReadAny), userHandler.ListUsers)
+		api.PUT("/profile/:id", middleware.RequireOwnerOrPermission("id", middleware.PermissionUsersWrite, middleware.PermissionUsersWriteAny), userHandler.UpdateUser)
+		api.DELETE("/profile/:id", middleware.ForbidImpersonation(), middleware.RequirePermission(middleware.PermissionUsersDelete), userHandler.DeleteUser)
+	}
+	return &testServer{router: r, users: users, events: events}
+}
+
+func (s *testServer) do(t *testing.T, method, path, bearer string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireAuth(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		bearer string
+		want   int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "unknown token", bearer: "forged", want: http.StatusUnauthorized},
+		{name: "user token", bearer: "ada", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(t, http.MethodGet, "/api/users/me", tt.bearer, nil, nil); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCurrentUserHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.do(t, http.MethodGet, "/api/users/me", "ada", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var user models.User
+	if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if user.ID != "u1" || user.Email != "ada@example.com" {
+		t.Errorf("user = %+v, want u1", user)
+	}
+}
+
+func TestGetUserByIDHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		bearer  string
+		id      string
+		headers map[string]string
+		want    int
+	}{
+		{name: "own profile", bearer: "ada", id: "u1", want: http.StatusOK},
+		{name: "other profile without users:read:any", bearer: "ada", id: "u2", want: http.StatusForbidden},
+		{name: "admin reads tenant profile", bearer: "admin", id: "u2", want: http.StatusOK},
+		{name: "admin of another tenant", bearer: "globex-admin", id: "u1", want: http.StatusNotFound},
+		{name: "tenant header cannot override user token", bearer: "globex-admin", id: "u1", headers: map[string]string{middleware.TenantHeader: "acme"}, want: http.StatusNotFound},
+		{name: "service account in named tenant", bearer: "service", id: "g1", headers: map[string]string{middleware.TenantHeader: "globex"}, want: http.StatusOK},
+		{name: "service account defaults to default tenant", bearer: "service", id: "g1", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(t, http.MethodGet, "/api/users/profile/"+tt.id, tt.bearer, nil, tt.headers); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestListUsersHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	if rec := s.do(t, http.MethodGet, "/api/users/list", "ada", nil, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("customer status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec := s.do(t, http.MethodGet, "/api/users/list?page=1&size=500", "admin", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var response models.UserListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Total != 2 || len(response.Users) != 2 {
+		t.Errorf("listed %d of %d users, want the 2 acme customers", len(response.Users), response.Total)
+	}
+	if response.Size != 10 {
+		t.Errorf("size = %d, want out-of-range sizes to fall back to 10", response.Size)
+	}
+}
+
+func TestUpdateUserHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		bearer string
+		id     string
+		email  string
+		want   int
+	}{
+		{name: "other profile without users:write:any", bearer: "ada", id: "u2", email: "x@example.com", want: http.StatusForbidden},
+		{name: "address of another profile", bearer: "ada", id: "u1", email: "GRACE@example.com", want: http.StatusConflict},
+		{name: "profile of another tenant", bearer: "globex-admin", id: "u1", email: "x@example.com", want: http.StatusNotFound},
+		{name: "own profile", bearer: "ada", id: "u1", email: "ada.l@example.com", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.events.Reset()
+			body := models.UpdateUserRequest{Name: "New Name", Email: tt.email}
+			rec := s.do(t, http.MethodPut, "/api/users/profile/"+tt.id, tt.bearer, body, nil)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+
+			wantEvents := 0
+			if tt.want == http.StatusOK {
+				wantEvents = 1
+			}
+			if got := len(s.events.Events()); got != wantEvents {
+				t.Errorf("published %d events, want %d", got, wantEvents)
+			}
+		})
+	}
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	s := newTestServer(t)
+
+	if rec := s.do(t, http.MethodDelete, "/api/users/profile/u1", "impersonated-ada", nil, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("impersonated status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := s.do(t, http.MethodDelete, "/api/users/profile/u1", "globex-admin", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("other tenant status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if len(s.events.Events()) != 0 {
+		t.Fatalf("published %d events before a successful delete, want none", len(s.events.Events()))
+	}
+
+	if rec := s.do(t, http.MethodDelete, "/api/users/profile/u1", "ada", nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := s.do(t, http.MethodGet, "/api/users/me", "ada", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("status after delete = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	events := s.events.Events()
+	if len(events) != 1 || events[0].EventType != "user.deleted.v1" {
+		t.Errorf("events = %+v, want one user.deleted.v1", events)
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"user-service/internal/services"
+)
+
+// Permissions checked by user-service routes. They are granted through roles
+// by auth-service and carried in the access token, or in the scope of a
+// service account token. A plain permission covers the caller's own profile;
+// the ":any" variant covers every profile.
+const (
+	PermissionUsersRead     = "users:read"
+	PermissionUsersReadAny  = "users:read:any"
+	PermissionUsersWrite    = "users:write"
+	PermissionUsersWriteAny = "users:write:any"
+	PermissionUsersDelete   = "users:delete"
+)
+
+// TokenVerifier verifies a bearer token and returns its claims. It is
+// implemented by services.TokenVerifier, which checks tokens locally against
+// the auth-service JWKS, and by services.GRPCTokenVerifier.
+type TokenVerifier interface {
+	Verify(tokenString string) (*services.Claims, error)
+}
+
+// ClaimsKey is the Gin context key holding the authenticated *services.Claims.
+const ClaimsKey = "claims"
+
+// TenantHeader names the tenant of a request. The API gateway sets it from
+// the request's subdomain when the client does not.
+const TenantHeader = "X-Tenant-ID"
+
+// RequireAuth rejects requests without a valid bearer token issued to a user
+// or a service account and stores the token claims in the context.
+func RequireAuth(verifier TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if authHeader == "" || tokenString == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
+			return
+		}
+
+		claims, err := verifier.Verify(tokenString)
+		if err != nil || (claims.UserID == "" && !claims.IsServiceAccount()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// RequirePermission rejects authenticated requests whose token grants none of
+// the given permissions. It must run after RequireAuth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
+			return
+		}
+
+		for _, permission := range permissions {
+			if claims.HasPermission(permission) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
+
+// RequireOwnerOrPermission allows the request if the user ID in the named
+// path parameter is the caller's own and the token grants ownPermission, or
+// if the token grants anyPermission. It must run after RequireAuth.
+func RequireOwnerOrPermission(param, ownPermission, anyPermission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization token is required"})
+			return
+		}
+
+		if claims.HasPermission(anyPermission) ||
+			(c.Param(param) == claims.UserID && claims.HasPermission(ownPermission)) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+	}
+}
+
+// ForbidImpersonation rejects requests made with an impersonation token. It
+// guards operations only the account owner or a real admin may perform, such
+// as deleting an account. It must run after RequireAuth.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := GetClaims(c); claims != nil && claims.IsImpersonated() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not allowed while impersonating a user"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantID returns the tenant an authenticated request acts in. User tokens
+// are bound to the tenant of their account; service accounts are
+// deployment-wide and name the tenant in the X-Tenant-ID header.
+func TenantID(c *gin.Context) string {
+	if claims := GetClaims(c); claims != nil && !claims.IsServiceAccount() {
+		return claims.Tenant()
+	}
+	if tenantID := strings.ToLower(strings.TrimSpace(c.GetHeader(TenantHeader))); tenantID != "" {
+		return tenantID
+	}
+	return services.DefaultTenantID
+}
+
+// GetClaims returns the claims stored by RequireAuth, or nil.
+func GetClaims(c *gin.Context) *services.Claims {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := value.(*services.Claims)
+	return claims
+}
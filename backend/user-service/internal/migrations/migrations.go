@@ -0,0 +1,97 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"user-service/internal/config"
+	"user-service/internal/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All holds every migration in version order. Applied migrations are recorded
+// in schema_migrations; never change or renumber one that has shipped.
+var All = []config.Migration{
+	{
+		Version:     1,
+		Description: "backfill normalized emails of profiles",
+		Up:          backfillNormalizedEmails,
+	},
+	{
+		Version:     2,
+		Description: "unique index on normalized profile emails",
+		Up:          uniqueNormalizedEmailIndex,
+	},
+	{
+		Version:     3,
+		Description: "assign existing profiles to the default tenant",
+		Up:          backfillTenants,
+	},
+	{
+		Version:     4,
+		Description: "scope unique profile emails to their tenant",
+		Up:          tenantScopedEmailIndex,
+	},
+}
+
+// indexNotFound is the server error code for dropping a missing index.
+const indexNotFound = 27
+
+// backfillNormalizedEmails sets emailNormalized, the lowercased and trimmed
+// address, on every profile.
+func backfillNormalizedEmails(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user_profiles").UpdateMany(ctx, bson.M{}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"emailNormalized": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}},
+		}}},
+	})
+	return err
+}
+
+// uniqueNormalizedEmailIndex makes the database reject two profiles with the
+// same address. Profiles without an address are left out of the index. It
+// fails if existing profiles already share an address; those have to be fixed
+// by hand before the service can start.
+func uniqueNormalizedEmailIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user_profiles").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "emailNormalized", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"emailNormalized": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
+// backfillTenants assigns profiles created before tenants existed to the
+// default tenant.
+func backfillTenants(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user_profiles").UpdateMany(ctx,
+		bson.M{"tenantId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"tenantId": services.DefaultTenantID}},
+	)
+	return err
+}
+
+// tenantScopedEmailIndex replaces the deployment-wide unique email index with
+// one per tenant, so the same address can hold a profile in every tenant.
+func tenantScopedEmailIndex(ctx context.Context, db *mongo.Database) error {
+	indexes := db.Collection("user_profiles").Indexes()
+	_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "emailNormalized", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"emailNormalized": bson.M{"$gt": ""}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = indexes.DropOne(ctx, "emailNormalized_1")
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// OutboxMessage is an event stored in the same transaction as the change it
+// describes, waiting to be published to Kafka by the outbox relay. Delivered
+// messages are removed by a TTL index once ExpiresAt has passed.
+type OutboxMessage struct {
+	ID            string     `json:"id" bson:"_id"`
+	Topic         string     `json:"topic" bson:"topic"`
+	Key           string     `json:"key" bson:"key"`
+	Payload       []byte     `json:"payload" bson:"payload"`
+	Attempts      int        `json:"attempts" bson:"attempts"`
+	LastError     string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	CreatedAt     time.Time  `json:"createdAt" bson:"createdAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
@@ -4,14 +4,16 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id" bson:"_id,omitempty"`
-	Name      string    `json:"name" bson:"name"`
-	Email     string    `json:"email" bson:"email"`
-	Password  string    `json:"password" bson:"password"`
-	Status    string    `json:"status" bson:"status"`
-	Role      string    `json:"role" bson:"role"`
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	ID              string    `json:"id" bson:"_id,omitempty"`
+	TenantID        string    `json:"tenant_id" bson:"tenantId"`
+	Name            string    `json:"name" bson:"name"`
+	Email           string    `json:"email" bson:"email"`
+	EmailNormalized string    `json:"-" bson:"emailNormalized"`
+	Password        string    `json:"password" bson:"password"`
+	Status          string    `json:"status" bson:"status"`
+	Role            string    `json:"role" bson:"role"`
+	CreatedAt       time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
 // UserListResponse represents a paginated list of users
@@ -22,9 +24,9 @@ type UserListResponse struct {
 	Size  int    `json:"size"`
 }
 
-// UpdateUserRequest represents a user update request
+// UpdateUserRequest represents a user update request. Roles are managed by
+// auth-service and arrive through user.updated.v1 events.
 type UpdateUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
-	Role  string `json:"role"`
 }
@@ -2,14 +2,20 @@ package models
 
 import "time"
 
-// UserEvent represents a user lifecycle event consumed from Kafka.
+// UserEvent represents a user lifecycle event exchanged with auth-service
+// over Kafka. Source names the service that published the event; each service
+// ignores its own events so changes are not echoed back and forth.
+// PreviousEmail is set on user.updated events that changed the address.
 type UserEvent struct {
-	EventID   string    `json:"event_id"`
-	EventType string    `json:"event_type"`
-	Timestamp time.Time `json:"timestamp"`
-	UserID    string    `json:"user_id"`
-	Email     string    `json:"email,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Status    string    `json:"status,omitempty"`
-	Role      string    `json:"role,omitempty"`
+	EventID       string    `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Timestamp     time.Time `json:"timestamp"`
+	UserID        string    `json:"user_id"`
+	TenantID      string    `json:"tenant_id,omitempty"`
+	Email         string    `json:"email,omitempty"`
+	PreviousEmail string    `json:"previous_email,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	Source        string    `json:"source,omitempty"`
 }
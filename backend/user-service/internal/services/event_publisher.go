@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"user-service/internal/models"
+)
+
+// EventPublisher publishes user lifecycle events. OutboxService publishes
+// them in the transaction of the change they describe, KafkaPublisher right
+// away, and testutil.MemoryEventPublisher records them for tests.
+type EventPublisher interface {
+	PublishUserUpdated(ctx context.Context, event models.UserEvent) error
+	PublishUserDeleted(ctx context.Context, event models.UserEvent) error
+}
+
+var (
+	_ EventPublisher = (*OutboxService)(nil)
+	_ EventPublisher = (*KafkaPublisher)(nil)
+)
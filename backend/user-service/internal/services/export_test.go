@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/logger"
+)
+
+// Internals used by the tests in package services_test.
+
+// MessageReader is the part of *kafka.Reader UserEventConsumer uses.
+type MessageReader = messageReader
+
+// NewTestUserEventConsumer returns a consumer reading the user.created.v1,
+// user.updated.v1 and user.deleted.v1 topics from readers, which retries
+// failed events after a millisecond.
+func NewTestUserEventConsumer(service *UserService, log logger.Logger, readers ...MessageReader) *UserEventConsumer {
+	return &UserEventConsumer{
+		logger:           log,
+		service:          service,
+		readers:          readers,
+		topicUserCreated: "user.created.v1",
+		topicUserUpdated: "user.updated.v1",
+		topicUserDeleted: "user.deleted.v1",
+		retryBackoff:     time.Millisecond,
+	}
+}
+
+// NewTestOutboxService returns an OutboxService writing user.updated.v1 and
+// user.deleted.v1 and relaying through writer.
+func NewTestOutboxService(repository OutboxRepository, writer MessageWriter, outboxConfig *config.OutboxConfig, log logger.Logger) *OutboxService {
+	s := NewOutboxService(repository, nil, "user.updated.v1", "user.deleted.v1", outboxConfig, log)
+	s.writer = writer
+	return s
+}
+
+func (s *OutboxService) Relay(ctx context.Context) error {
+	return s.relay(ctx)
+}
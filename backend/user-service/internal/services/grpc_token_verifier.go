@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"auth-service/pkg/authclient"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned when auth-service reports a token as invalid.
+var ErrInvalidToken = errors.New("invalid token")
+
+// GRPCTokenVerifier verifies access tokens by asking auth-service over gRPC.
+// Unlike TokenVerifier it also rejects revoked tokens and tokens of terminated
+// sessions, once they have left the client's validation cache.
+type GRPCTokenVerifier struct {
+	client *authclient.Client
+}
+
+// NewGRPCTokenVerifier creates a GRPCTokenVerifier using the given client.
+func NewGRPCTokenVerifier(client *authclient.Client) *GRPCTokenVerifier {
+	return &GRPCTokenVerifier{client: client}
+}
+
+// Verify asks auth-service whether the token is valid and returns its claims.
+func (v *GRPCTokenVerifier) Verify(tokenString string) (*Claims, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := v.client.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !response.GetValid() {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &Claims{
+		UserID:      response.GetUserId(),
+		TenantID:    response.GetTenantId(),
+		Role:        response.GetRole(),
+		Permissions: response.GetPermissions(),
+		Scope:       response.GetScope(),
+		ClientID:    response.GetClientId(),
+	}
+	if actorID := response.GetActorId(); actorID != "" {
+		claims.Actor = &Actor{Subject: actorID}
+	}
+	if expiresAt := response.GetExpiresAt(); expiresAt > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(expiresAt, 0))
+	}
+	return claims, nil
+}
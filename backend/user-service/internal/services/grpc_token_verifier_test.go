@@ -0,0 +1,100 @@
+package services_test
+
+import (
+	authv1 "auth-service/api/auth/v1"
+	"auth-service/pkg/authclient"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+	"user-service/internal/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuthService answers ValidateToken from a fixed set of valid tokens.
+type fakeAuthService struct {
+	authv1.UnimplementedAuthServiceServer
+	valid map[string]*authv1.ValidateTokenResponse
+}
+
+func (f *fakeAuthService) ValidateToken(ctx context.Context, req *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+	if response, ok := f.valid[req.GetToken()]; ok {
+		return response, nil
+	}
+	return &authv1.ValidateTokenResponse{Valid: false}, nil
+}
+
+// newGRPCTokenVerifier returns a GRPCTokenVerifier asking a fakeAuthService
+// that knows the valid tokens, in memory and without a cache.
+func newGRPCTokenVerifier(t *testing.T, valid map[string]*authv1.ValidateTokenResponse) (*services.GRPCTokenVerifier, *grpc.Server) {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	authv1.RegisterAuthServiceServer(server, &fakeAuthService{valid: valid})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := authclient.New("passthrough:///bufnet", 0,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("authclient.New: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return services.NewGRPCTokenVerifier(client), server
+}
+
+func TestGRPCTokenVerifier(t *testing.T) {
+	expiresAt := time.Now().Add(15 * time.Minute).Unix()
+	verifier, _ := newGRPCTokenVerifier(t, map[string]*authv1.ValidateTokenResponse{
+		"impersonation": {
+			Valid:       true,
+			UserId:      "u1",
+			TenantId:    "acme",
+			Role:        "customer",
+			Permissions: []string{"users:read"},
+			ActorId:     "admin-1",
+			ExpiresAt:   expiresAt,
+		},
+		"service": {Valid: true, ClientId: "billing", Scope: "users:read:any", ExpiresAt: expiresAt},
+	})
+
+	claims, err := verifier.Verify("impersonation")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != "u1" || claims.TenantID != "acme" || claims.Role != "customer" || !claims.HasPermission("users:read") ||
+		claims.Actor == nil || claims.Actor.Subject != "admin-1" || claims.ExpiresAt == nil || claims.ExpiresAt.Unix() != expiresAt {
+		t.Errorf("claims = %+v, want u1 of acme held by admin-1", claims)
+	}
+
+	claims, err = verifier.Verify("service")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.ClientID != "billing" || claims.Scope != "users:read:any" || claims.UserID != "" || claims.Actor != nil {
+		t.Errorf("claims = %+v, want the billing service account", claims)
+	}
+
+	if _, err := verifier.Verify("revoked"); !errors.Is(err, services.ErrInvalidToken) {
+		t.Errorf("Verify of a token auth-service rejects error = %v, want services.ErrInvalidToken", err)
+	}
+}
+
+func TestGRPCTokenVerifierAuthServiceDown(t *testing.T) {
+	verifier, server := newGRPCTokenVerifier(t, map[string]*authv1.ValidateTokenResponse{})
+	server.Stop()
+
+	claims, err := verifier.Verify("token")
+	if err == nil || errors.Is(err, services.ErrInvalidToken) || claims != nil {
+		t.Errorf("Verify = %+v, %v, want an error other than services.ErrInvalidToken", claims, err)
+	}
+}
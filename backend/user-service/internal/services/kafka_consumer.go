@@ -6,6 +6,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"time"
 	"user-service/internal/logger"
 	"user-service/internal/models"
 
@@ -14,13 +15,30 @@ import (
 )
 
 // UserEventConsumer consumes user lifecycle events and updates user profiles.
+// Events user-service published itself are skipped; the profile already
+// reflects them.
 type UserEventConsumer struct {
 	logger           logger.Logger
 	service          *UserService
-	readers          []*kafka.Reader
+	readers          []messageReader
 	topicUserCreated string
 	topicUserUpdated string
 	topicUserDeleted string
+	retryBackoff     time.Duration
+}
+
+// Events that fail to apply are retried after eventRetryBackoff, doubling up
+// to eventRetryMaxBackoff.
+const (
+	eventRetryBackoff    = 500 * time.Millisecond
+	eventRetryMaxBackoff = 30 * time.Second
+)
+
+// messageReader is the part of *kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
 }
 
 // NewUserEventConsumer creates a Kafka consumer for user lifecycle topics.
@@ -44,7 +62,7 @@ func NewUserEventConsumer(
 	}
 
 	topics := []string{topicUserCreated, topicUserUpdated, topicUserDeleted}
-	readers := make([]*kafka.Reader, 0, len(topics))
+	readers := make([]messageReader, 0, len(topics))
 
 	for _, topic := range topics {
 		topic = strings.TrimSpace(topic)
@@ -75,6 +93,7 @@ func NewUserEventConsumer(
 		topicUserCreated: strings.TrimSpace(topicUserCreated),
 		topicUserUpdated: strings.TrimSpace(topicUserUpdated),
 		topicUserDeleted: strings.TrimSpace(topicUserDeleted),
+		retryBackoff:     eventRetryBackoff,
 	}, nil
 }
 
@@ -88,7 +107,7 @@ func (c *UserEventConsumer) Start(ctx context.Context) {
 	wg.Add(len(c.readers))
 
 	for _, reader := range c.readers {
-		go func(r *kafka.Reader) {
+		go func(r messageReader) {
 			defer wg.Done()
 			c.consumeLoop(ctx, r)
 		}(reader)
@@ -97,7 +116,10 @@ func (c *UserEventConsumer) Start(ctx context.Context) {
 	wg.Wait()
 }
 
-func (c *UserEventConsumer) consumeLoop(ctx context.Context, reader *kafka.Reader) {
+// consumeLoop applies the messages of one topic in order. A message is only
+// committed once applied: committing a later offset would also commit it, so
+// a failing message is retried until it succeeds or ctx is cancelled.
+func (c *UserEventConsumer) consumeLoop(ctx context.Context, reader messageReader) {
 	for {
 		msg, err := reader.FetchMessage(ctx)
 		if err != nil {
@@ -120,14 +142,24 @@ func (c *UserEventConsumer) consumeLoop(ctx context.Context, reader *kafka.Reade
 			continue
 		}
 
-		if err := c.handleEvent(msg.Topic, event); err != nil {
-			c.logger.Error("Failed to process user event",
-				zap.Error(err),
-				zap.String("topic", msg.Topic),
+		if event.Source == EventSource {
+			if err := reader.CommitMessages(ctx, msg); err != nil {
+				c.logger.Error("Failed to commit Kafka message", zap.Error(err))
+			}
+			continue
+		}
+
+		err = c.processEvent(ctx, msg.Topic, event)
+		if errors.Is(err, ErrEmailTaken) {
+			// Retrying cannot help; auth-service enforces the same uniqueness,
+			// so this only happens for profiles that were already out of sync.
+			c.logger.Warn("Skipping user event, email address belongs to another profile",
 				zap.String("event_type", event.EventType),
 				zap.String("user_id", event.UserID),
 			)
-			continue
+		} else if err != nil {
+			// Cancelled; the uncommitted message is delivered again.
+			return
 		}
 
 		if err := reader.CommitMessages(ctx, msg); err != nil {
@@ -136,6 +168,32 @@ func (c *UserEventConsumer) consumeLoop(ctx context.Context, reader *kafka.Reade
 	}
 }
 
+// processEvent applies the event, retrying with exponential backoff until it
+// succeeds or ctx is cancelled. ErrEmailTaken is returned without retrying.
+func (c *UserEventConsumer) processEvent(ctx context.Context, topic string, event models.UserEvent) error {
+	backoff := c.retryBackoff
+	for {
+		err := c.handleEvent(topic, event)
+		if err == nil || errors.Is(err, ErrEmailTaken) {
+			return err
+		}
+		c.logger.Error("Failed to process user event, retrying",
+			zap.Error(err),
+			zap.String("topic", topic),
+			zap.String("event_type", event.EventType),
+			zap.String("user_id", event.UserID),
+			zap.Duration("retry_in", backoff),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, eventRetryMaxBackoff)
+	}
+}
+
 func (c *UserEventConsumer) handleEvent(topic string, event models.UserEvent) error {
 	switch topic {
 	case c.topicUserCreated, c.topicUserUpdated:
@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-service/internal/models"
+	"user-service/internal/services"
+	"user-service/internal/testutil"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// countingLogger counts the errors and warnings logged.
+type countingLogger struct {
+	mu     sync.Mutex
+	errors int
+	warns  int
+}
+
+func (l *countingLogger) Info(msg string, fields ...zap.Field) {}
+func (l *countingLogger) Sync() error                          { return nil }
+
+func (l *countingLogger) Warn(msg string, fields ...zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warns++
+}
+
+func (l *countingLogger) Error(msg string, fields ...zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors++
+}
+
+func (l *countingLogger) Errors() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.errors
+}
+
+func (l *countingLogger) Warnings() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.warns
+}
+
+// flakyUsers fails writes while down is set.
+type flakyUsers struct {
+	*testutil.MemoryUserRepository
+	mu   sync.Mutex
+	down bool
+}
+
+func (r *flakyUsers) SetDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+func (r *flakyUsers) Upsert(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	down := r.down
+	r.mu.Unlock()
+	if down {
+		return errors.New("database unavailable")
+	}
+	return r.MemoryUserRepository.Upsert(ctx, user)
+}
+
+// fakeReader hands out its messages in order, then blocks until cancelled,
+// and records the offsets committed.
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []int64
+}
+
+func newFakeReader(t *testing.T, topic string, events ...models.UserEvent) *fakeReader {
+	t.Helper()
+	r := &fakeReader{}
+	for i, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		r.messages = append(r.messages, kafka.Message{Topic: topic, Offset: int64(i), Value: value})
+	}
+	return r
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+func (r *fakeReader) Committed() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.committed...)
+}
+
+// eventually fails the test unless cond holds within a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// startConsumer runs the consumer until the returned function is called.
+func startConsumer(consumer *services.UserEventConsumer) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		consumer.Start(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func authServiceEvent(eventType, userID, email string) models.UserEvent {
+	return models.UserEvent{EventType: eventType, UserID: userID, TenantID: "acme", Email: email, Name: "Ada", Source: "auth-service"}
+}
+
+func TestUserEventConsumerRetriesUntilApplied(t *testing.T) {
+	users := &flakyUsers{MemoryUserRepository: testutil.NewMemoryUserRepository()}
+	userService := services.NewUserService(users, testutil.MemoryTransactor{}, testutil.NewMemoryEventPublisher())
+	log := &countingLogger{}
+	users.SetDown(true)
+
+	created := newFakeReader(t, "user.created.v1",
+		authServiceEvent("user.created.v1", "u1", "ada@example.com"),
+		authServiceEvent("user.created.v1", "u2", "grace@example.com"),
+	)
+	stop := startConsumer(services.NewTestUserEventConsumer(userService, log, created))
+	defer stop()
+
+	eventually(t, "a few retries", func() bool { return log.Errors() >= 3 })
+	if got := created.Committed(); len(got) != 0 {
+		t.Fatalf("committed %v before the event was applied, want nothing", got)
+	}
+
+	users.SetDown(false)
+	eventually(t, "both events to be committed", func() bool { return len(created.Committed()) == 2 })
+	for _, id := range []string{"u1", "u2"} {
+		if _, err := userService.GetUserByID("acme", id); err != nil {
+			t.Errorf("GetUserByID(%s) error = %v", id, err)
+		}
+	}
+}
+
+func TestUserEventConsumerLeavesFailedEventUncommittedWhenStopped(t *testing.T) {
+	users := &flakyUsers{MemoryUserRepository: testutil.NewMemoryUserRepository()}
+	userService := services.NewUserService(users, testutil.MemoryTransactor{}, testutil.NewMemoryEventPublisher())
+	log := &countingLogger{}
+	users.SetDown(true)
+
+	created := newFakeReader(t, "user.created.v1", authServiceEvent("user.created.v1", "u1", "ada@example.com"))
+	stop := startConsumer(services.NewTestUserEventConsumer(userService, log, created))
+	eventually(t, "a retry", func() bool { return log.Errors() >= 1 })
+	stop()
+
+	if got := created.Committed(); len(got) != 0 {
+		t.Errorf("committed %v, want the failed event left for redelivery", got)
+	}
+}
+
+func TestUserEventConsumerSkipsTakenAddressAndOwnEvents(t *testing.T) {
+	userService, users, _ := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com"})
+	log := &countingLogger{}
+
+	own := authServiceEvent("user.deleted.v1", "u1", "ada@example.com")
+	own.Source = services.EventSource
+	updated := newFakeReader(t, "user.updated.v1", authServiceEvent("user.updated.v1", "u2", "ADA@example.com"))
+	deleted := newFakeReader(t, "user.deleted.v1", own)
+	stop := startConsumer(services.NewTestUserEventConsumer(userService, log, updated, deleted))
+	defer stop()
+
+	eventually(t, "both events to be committed", func() bool {
+		return len(updated.Committed()) == 1 && len(deleted.Committed()) == 1
+	})
+	if log.Warnings() != 1 {
+		t.Errorf("logged %d warnings, want 1 for the taken address", log.Warnings())
+	}
+	if _, err := userService.GetUserByID("acme", "u1"); err != nil {
+		t.Errorf("GetUserByID after its own deletion event: %v", err)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
+// EventSource is the source of the user lifecycle events user-service publishes.
+const EventSource = "user-service"
+
 // KafkaPublisher publishes user lifecycle events.
 type KafkaPublisher struct {
 	writer           *kafka.Writer
@@ -24,9 +27,11 @@ func NewKafkaPublisher(brokers, clientID, createdTopic, updatedTopic, deletedTop
 		return nil, nil
 	}
 
+	// Messages are partitioned by key, so the events of a user stay in the
+	// order the outbox relay publishes them.
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(parsedBrokers...),
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     &kafka.Hash{},
 		RequiredAcks: kafka.RequireAll,
 		Async:        false,
 		Transport: &kafka.Transport{
@@ -47,14 +52,20 @@ func (p *KafkaPublisher) publish(ctx context.Context, topic string, event models
 		return nil
 	}
 
+	event.Source = EventSource
 	payload, err := json.Marshal(event)
 	if err != nil {
 		return err
 	}
 
+	return p.WriteMessage(ctx, topic, event.UserID, payload)
+}
+
+// WriteMessage publishes an already encoded event.
+func (p *KafkaPublisher) WriteMessage(ctx context.Context, topic, key string, payload []byte) error {
 	return p.writer.WriteMessages(ctx, kafka.Message{
 		Topic: topic,
-		Key:   []byte(event.UserID),
+		Key:   []byte(key),
 		Value: payload,
 	})
 }
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	outboxCollection      = "outbox"
+	outboxLeaseCollection = "outbox_leases"
+	outboxLeaseID         = "relay"
+)
+
+// OutboxRepository stores the outbox messages and the relay lease.
+// MongoOutboxRepository is the production implementation and
+// testutil.MemoryOutboxRepository the one for tests.
+type OutboxRepository interface {
+	// Insert stores a new message. ctx carries the transaction of the change
+	// the message describes.
+	Insert(ctx context.Context, message *models.OutboxMessage) error
+	// Due returns up to limit undelivered messages whose next attempt is due
+	// at now, oldest first.
+	Due(ctx context.Context, now time.Time, limit int64) ([]models.OutboxMessage, error)
+	// FirstPending returns the oldest undelivered message of a key.
+	FirstPending(ctx context.Context, key string) (*models.OutboxMessage, error)
+	// MarkDelivered records the delivery of a message, which is removed once
+	// expiresAt has passed.
+	MarkDelivered(ctx context.Context, id string, deliveredAt, expiresAt time.Time) error
+	// MarkFailed records a failed attempt and when to make the next one.
+	MarkFailed(ctx context.Context, id string, attempts int, lastError string, nextAttemptAt time.Time) error
+	// Backlog returns the number of undelivered messages.
+	Backlog(ctx context.Context) (int64, error)
+	// AcquireLease takes or renews the relay lease for owner until now + ttl,
+	// reporting whether owner holds it.
+	AcquireLease(ctx context.Context, owner string, now time.Time, ttl time.Duration) (bool, error)
+}
+
+var _ OutboxRepository = (*MongoOutboxRepository)(nil)
+
+// MongoOutboxRepository stores messages in the outbox collection and the
+// relay lease in outbox_leases.
+type MongoOutboxRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoOutboxRepository creates a new MongoOutboxRepository
+func NewMongoOutboxRepository(mongoConfig *config.MongoDBConfig) *MongoOutboxRepository {
+	return &MongoOutboxRepository{mongoConfig: mongoConfig}
+}
+
+// EnsureIndexes creates the relay lookup indexes and the TTL index removing
+// delivered messages.
+func (r *MongoOutboxRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "deliveredAt", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "key", Value: 1}, {Key: "deliveredAt", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Insert stores a new message.
+func (r *MongoOutboxRepository) Insert(ctx context.Context, message *models.OutboxMessage) error {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	_, err := collection.InsertOne(ctx, message)
+	return err
+}
+
+// Due returns up to limit undelivered messages due at now, oldest first.
+func (r *MongoOutboxRepository) Due(ctx context.Context, now time.Time, limit int64) ([]models.OutboxMessage, error) {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	cursor, err := collection.Find(ctx,
+		bson.M{"deliveredAt": nil, "nextAttemptAt": bson.M{"$lte": now}},
+		options.Find().
+			SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var messages []models.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// FirstPending returns the oldest undelivered message of a key.
+func (r *MongoOutboxRepository) FirstPending(ctx context.Context, key string) (*models.OutboxMessage, error) {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	var message models.OutboxMessage
+	err := collection.FindOne(ctx,
+		bson.M{"key": key, "deliveredAt": nil},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}),
+	).Decode(&message)
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// MarkDelivered records the delivery of a message.
+func (r *MongoOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt, expiresAt time.Time) error {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set": bson.M{"deliveredAt": deliveredAt, "expiresAt": expiresAt},
+			"$inc": bson.M{"attempts": 1},
+		},
+	)
+	return err
+}
+
+// MarkFailed records a failed attempt.
+func (r *MongoOutboxRepository) MarkFailed(ctx context.Context, id string, attempts int, lastError string, nextAttemptAt time.Time) error {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"attempts":      attempts,
+			"lastError":     lastError,
+			"nextAttemptAt": nextAttemptAt,
+		}},
+	)
+	return err
+}
+
+// Backlog returns the number of undelivered messages.
+func (r *MongoOutboxRepository) Backlog(ctx context.Context) (int64, error) {
+	collection := r.mongoConfig.GetCollection(outboxCollection)
+	return collection.CountDocuments(ctx, bson.M{"deliveredAt": nil})
+}
+
+// AcquireLease takes the lease if it is free or expired, or renews it if
+// owner already holds it.
+func (r *MongoOutboxRepository) AcquireLease(ctx context.Context, owner string, now time.Time, ttl time.Duration) (bool, error) {
+	collection := r.mongoConfig.GetCollection(outboxLeaseCollection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": outboxLeaseID, "$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expiresAt": bson.M{"$lt": now}},
+		}},
+		bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	// The upsert collides with the lease of another replica.
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/logger"
+	"user-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxLeaseTTL is how long a replica stays the relay without renewing
+	// its lease. A batch is cut short well before the lease runs out, so two
+	// replicas never publish at the same time.
+	outboxLeaseTTL = 30 * time.Second
+	// outboxBatchTimeout bounds the time spent publishing one batch.
+	outboxBatchTimeout = 20 * time.Second
+	// outboxBatchSize is the maximum number of messages read per poll.
+	outboxBatchSize = 100
+)
+
+// MessageWriter writes a message to a Kafka topic. *KafkaPublisher is the
+// production implementation.
+type MessageWriter interface {
+	WriteMessage(ctx context.Context, topic, key string, payload []byte) error
+}
+
+// OutboxService makes user lifecycle events as durable as the changes they
+// describe. Events are written to the outbox collection in the transaction of
+// the change and published to Kafka by a relay running in the background.
+//
+// One replica at a time relays, the one holding the relay lease. Messages
+// with the same key, the user ID, are published in the order they were
+// written: a message waiting for a retry holds back the later messages of its
+// key. Delivery is at least once; a message published just before the relay
+// lost its lease or failed to mark it delivered is published again.
+type OutboxService struct {
+	repository       OutboxRepository
+	writer           MessageWriter
+	topicUserUpdated string
+	topicUserDeleted string
+	config           *config.OutboxConfig
+	logger           logger.Logger
+	owner            string
+}
+
+// NewOutboxService creates a new OutboxService writing events to the given
+// topics. Messages are written to the outbox even without a publisher, and
+// wait there until a replica with one relays them.
+func NewOutboxService(
+	repository OutboxRepository,
+	publisher *KafkaPublisher,
+	topicUserUpdated string,
+	topicUserDeleted string,
+	outboxConfig *config.OutboxConfig,
+	log logger.Logger,
+) *OutboxService {
+	s := &OutboxService{
+		repository:       repository,
+		topicUserUpdated: topicUserUpdated,
+		topicUserDeleted: topicUserDeleted,
+		config:           outboxConfig,
+		logger:           log,
+		owner:            primitive.NewObjectID().Hex(),
+	}
+	if publisher != nil {
+		s.writer = publisher
+	}
+	return s
+}
+
+// PublishUserUpdated writes user.updated.v1 to the outbox. ctx must carry the
+// transaction that updates the profile.
+func (s *OutboxService) PublishUserUpdated(ctx context.Context, event models.UserEvent) error {
+	return s.enqueue(ctx, s.topicUserUpdated, event)
+}
+
+// PublishUserDeleted writes user.deleted.v1 to the outbox. ctx must carry the
+// transaction that deletes the profile.
+func (s *OutboxService) PublishUserDeleted(ctx context.Context, event models.UserEvent) error {
+	return s.enqueue(ctx, s.topicUserDeleted, event)
+}
+
+func (s *OutboxService) enqueue(ctx context.Context, topic string, event models.UserEvent) error {
+	if topic == "" {
+		return nil
+	}
+
+	event.Source = EventSource
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return s.repository.Insert(ctx, &models.OutboxMessage{
+		ID:            primitive.NewObjectID().Hex(),
+		Topic:         topic,
+		Key:           event.UserID,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+// Backlog returns the number of messages not yet published.
+func (s *OutboxService) Backlog(ctx context.Context) (int64, error) {
+	return s.repository.Backlog(ctx)
+}
+
+// Start relays pending messages every poll interval until ctx is cancelled.
+// Without a publisher it returns immediately, leaving the messages to the
+// replicas that have one.
+func (s *OutboxService) Start(ctx context.Context) {
+	if s.writer == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.relay(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to relay outbox messages", zap.Error(err))
+			}
+		}
+	}
+}
+
+// relay publishes one batch of due messages if this replica holds the lease.
+func (s *OutboxService) relay(ctx context.Context) error {
+	leader, err := s.repository.AcquireLease(ctx, s.owner, time.Now().UTC(), outboxLeaseTTL)
+	if err != nil || !leader {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outboxBatchTimeout)
+	defer cancel()
+
+	messages, err := s.repository.Due(ctx, time.Now().UTC(), outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	// Within the batch, the messages of a key are in order, but an earlier
+	// one waiting for a retry is not part of it.
+	checked := make(map[string]bool)
+	blocked := make(map[string]bool)
+	for _, message := range messages {
+		if blocked[message.Key] {
+			continue
+		}
+		if !checked[message.Key] {
+			checked[message.Key] = true
+			first, err := s.repository.FirstPending(ctx, message.Key)
+			if err != nil {
+				return err
+			}
+			if first.ID != message.ID {
+				blocked[message.Key] = true
+				continue
+			}
+		}
+
+		if err := s.deliver(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			blocked[message.Key] = true
+			s.logger.Error("Failed to publish outbox message",
+				zap.Error(err),
+				zap.String("topic", message.Topic),
+				zap.String("key", message.Key),
+				zap.Int("attempts", message.Attempts+1),
+			)
+		}
+	}
+	return nil
+}
+
+// deliver publishes a message and marks it delivered, or schedules the next
+// attempt if publishing fails.
+func (s *OutboxService) deliver(ctx context.Context, message models.OutboxMessage) error {
+	now := time.Now().UTC()
+
+	if err := s.writer.WriteMessage(ctx, message.Topic, message.Key, message.Payload); err != nil {
+		attempts := message.Attempts + 1
+		updateErr := s.repository.MarkFailed(ctx, message.ID, attempts, err.Error(), now.Add(s.retryDelay(attempts)))
+		return errors.Join(err, updateErr)
+	}
+
+	return s.repository.MarkDelivered(ctx, message.ID, now, now.Add(s.config.Retention))
+}
+
+// retryDelay returns the delay before the next attempt after the given number
+// of failed attempts: the base delay doubling with each failure up to the
+// configured maximum.
+func (s *OutboxService) retryDelay(attempts int) time.Duration {
+	exponent := attempts - 1
+	if exponent > 30 {
+		return s.config.RetryMax
+	}
+	delay := s.config.RetryBase << exponent
+	if delay <= 0 || delay > s.config.RetryMax {
+		return s.config.RetryMax
+	}
+	return delay
+}
@@ -0,0 +1,143 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/models"
+	"user-service/internal/services"
+	"user-service/internal/testutil"
+)
+
+// recordingWriter records the events it writes and fails those of the keys
+// marked down.
+type recordingWriter struct {
+	mu      sync.Mutex
+	written []models.UserEvent
+	down    map[string]bool
+}
+
+func newRecordingWriter() *recordingWriter {
+	return &recordingWriter{down: make(map[string]bool)}
+}
+
+func (w *recordingWriter) WriteMessage(ctx context.Context, topic, key string, payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.down[key] {
+		return errors.New("partition unavailable")
+	}
+	var event models.UserEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+	w.written = append(w.written, event)
+	return nil
+}
+
+func (w *recordingWriter) SetDown(key string, down bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.down[key] = down
+}
+
+func (w *recordingWriter) Written() []models.UserEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]models.UserEvent(nil), w.written...)
+}
+
+// Retries are due right away, so a second relay retries them
+var testOutboxConfig = config.NewOutboxConfig(time.Second, time.Nanosecond, time.Nanosecond, time.Hour)
+
+func TestOutboxWritesEventsWithoutPublisher(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	outbox := services.NewOutboxService(repository, nil, "user.updated.v1", "user.deleted.v1", testOutboxConfig, &countingLogger{})
+
+	if err := outbox.PublishUserDeleted(context.Background(), models.UserEvent{UserID: "u1", EventType: "user.deleted.v1"}); err != nil {
+		t.Fatalf("PublishUserDeleted error = %v", err)
+	}
+	backlog, err := outbox.Backlog(context.Background())
+	if err != nil {
+		t.Fatalf("Backlog error = %v", err)
+	}
+	if backlog != 1 {
+		t.Errorf("backlog = %d, want the event waiting for a relay", backlog)
+	}
+	messages := repository.Messages()
+	if len(messages) != 1 || messages[0].Topic != "user.deleted.v1" || messages[0].Key != "u1" {
+		t.Errorf("messages = %+v, want user.deleted.v1 for u1", messages)
+	}
+}
+
+func TestOutboxRelayHoldsBackKeyOfFailedMessage(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	writer := newRecordingWriter()
+	outbox := services.NewTestOutboxService(repository, writer, testOutboxConfig, &countingLogger{})
+
+	ctx := context.Background()
+	for _, event := range []models.UserEvent{
+		{UserID: "u1", Name: "first"},
+		{UserID: "u2", Name: "first"},
+		{UserID: "u1", Name: "second"},
+	} {
+		if err := outbox.PublishUserUpdated(ctx, event); err != nil {
+			t.Fatalf("PublishUserUpdated error = %v", err)
+		}
+	}
+
+	writer.SetDown("u1", true)
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("Relay error = %v", err)
+	}
+	if got := writer.Written(); len(got) != 1 || got[0].UserID != "u2" {
+		t.Fatalf("written = %+v, want only u2 while u1 fails", got)
+	}
+
+	writer.SetDown("u1", false)
+	if err := outbox.Relay(ctx); err != nil {
+		t.Fatalf("second Relay error = %v", err)
+	}
+	got := writer.Written()
+	if len(got) != 3 || got[1].Name != "first" || got[2].Name != "second" {
+		t.Errorf("written = %+v, want the events of u1 in order after u2", got)
+	}
+	for _, event := range got {
+		if event.Source != services.EventSource {
+			t.Errorf("source = %q, want %q", event.Source, services.EventSource)
+		}
+	}
+}
+
+func TestOutboxRelayRequiresLease(t *testing.T) {
+	repository := testutil.NewMemoryOutboxRepository()
+	writer := newRecordingWriter()
+	first := services.NewTestOutboxService(repository, writer, testOutboxConfig, &countingLogger{})
+	second := services.NewTestOutboxService(repository, writer, testOutboxConfig, &countingLogger{})
+
+	ctx := context.Background()
+	if err := first.Relay(ctx); err != nil {
+		t.Fatalf("Relay error = %v", err)
+	}
+	if err := second.PublishUserUpdated(ctx, models.UserEvent{UserID: "u1"}); err != nil {
+		t.Fatalf("PublishUserUpdated error = %v", err)
+	}
+	if err := second.Relay(ctx); err != nil {
+		t.Fatalf("Relay without the lease error = %v", err)
+	}
+	if got := len(writer.Written()); got != 0 {
+		t.Fatalf("written %d events without the lease, want 0", got)
+	}
+
+	repository.ExpireLease()
+	if err := second.Relay(ctx); err != nil {
+		t.Fatalf("Relay after the lease expired error = %v", err)
+	}
+	if got := len(writer.Written()); got != 1 {
+		t.Errorf("written %d events, want 1", got)
+	}
+}
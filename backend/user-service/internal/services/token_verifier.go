@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownSigningKey is returned when a token references a kid that is not
+// published in the auth-service JWKS.
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// minJWKSRefreshInterval limits refetching the JWKS when unknown kids are seen.
+const minJWKSRefreshInterval = 30 * time.Second
+
+// Claims defines the claims issued by auth-service access tokens. Tokens
+// issued to service accounts carry a client ID and scope but no user ID.
+// Tokens held by an admin impersonating a user name the admin in Actor.
+type Claims struct {
+	UserID      string   `json:"user_id,omitempty"`
+	TenantID    string   `json:"tenant_id,omitempty"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Actor       *Actor   `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Actor identifies who acts on behalf of the token subject (RFC 8693).
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// IsImpersonated reports whether someone other than the user holds the token.
+func (c *Claims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+// ActorID returns the user ID of the impersonating admin, or "".
+func (c *Claims) ActorID() string {
+	if c.Actor == nil {
+		return ""
+	}
+	return c.Actor.Subject
+}
+
+// Tenant returns the tenant of the user the token was issued to. Tokens
+// issued before tenants existed belong to the default tenant.
+func (c *Claims) Tenant() string {
+	if c.TenantID == "" {
+		return DefaultTenantID
+	}
+	return c.TenantID
+}
+
+// IsServiceAccount reports whether the token was issued to a service account
+// rather than a user.
+func (c *Claims) IsServiceAccount() bool {
+	return c.UserID == "" && c.ClientID != ""
+}
+
+// HasPermission reports whether the token grants the permission. Service
+// account tokens grant the permissions listed in their scope.
+func (c *Claims) HasPermission(permission string) bool {
+	permissions := c.Permissions
+	if c.IsServiceAccount() {
+		permissions = strings.Fields(c.Scope)
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// jwk is a public JSON Web Key as published by auth-service.
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+}
+
+type verificationKey struct {
+	algorithm string
+	public    crypto.PublicKey
+}
+
+// TokenVerifier verifies auth-service access tokens against the public keys
+// published at the auth-service JWKS endpoint.
+type TokenVerifier struct {
+	jwksURL    string
+	issuer     string
+	audience   string
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]verificationKey
+	lastFetched time.Time
+}
+
+// NewTokenVerifier creates a TokenVerifier for the given JWKS URL, issuer and audience.
+func NewTokenVerifier(jwksURL, issuer, audience string) *TokenVerifier {
+	return &TokenVerifier{
+		jwksURL:    jwksURL,
+		issuer:     issuer,
+		audience:   audience,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       make(map[string]verificationKey),
+	}
+}
+
+// Verify validates the token signature, issuer, audience and expiry.
+func (v *TokenVerifier) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, v.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "EdDSA"}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return claims, nil
+}
+
+func (v *TokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownSigningKey
+	}
+
+	key, ok := v.lookup(kid)
+	if !ok {
+		// The key may have been rotated in since the last fetch.
+		if err := v.refresh(); err != nil {
+			return nil, err
+		}
+		if key, ok = v.lookup(kid); !ok {
+			return nil, ErrUnknownSigningKey
+		}
+	}
+
+	if token.Method.Alg() != key.algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+func (v *TokenVerifier) lookup(kid string) (verificationKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func (v *TokenVerifier) refresh() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Since(v.lastFetched) < minJWKSRefreshInterval {
+		return nil
+	}
+	v.lastFetched = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, key := range set.Keys {
+		public, err := key.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[key.KeyID] = verificationKey{algorithm: key.Algorithm, public: public}
+	}
+	v.keys = keys
+	return nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
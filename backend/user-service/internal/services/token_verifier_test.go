@@ -0,0 +1,200 @@
+package services_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-service/internal/services"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testIssuer signs tokens like auth-service and publishes its key in a JWKS.
+type testIssuer struct {
+	key    ed25519.PrivateKey
+	server *httptest.Server
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"kid": "key-1",
+			"alg": "EdDSA",
+			"use": "sig",
+			"x":   base64.RawURLEncoding.EncodeToString(public),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jwks)
+	}))
+	t.Cleanup(server.Close)
+	return &testIssuer{key: private, server: server}
+}
+
+func (i *testIssuer) verifier() *services.TokenVerifier {
+	return services.NewTokenVerifier(i.server.URL, "auth-service", "api")
+}
+
+// sign returns a token for the claims signed with key under kid.
+func sign(t *testing.T, key ed25519.PrivateKey, kid string, claims *services.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+// userClaims returns valid claims of a customer of the acme tenant.
+func userClaims() *services.Claims {
+	now := time.Now()
+	return &services.Claims{
+		UserID:      "u1",
+		TenantID:    "acme",
+		Role:        "customer",
+		Permissions: []string{"users:read"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-1",
+			Issuer:    "auth-service",
+			Subject:   "u1",
+			Audience:  jwt.ClaimStrings{"api"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(15 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+func TestTokenVerifierReadsActor(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := issuer.verifier()
+
+	claims, err := verifier.Verify(sign(t, issuer.key, "key-1", userClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != "u1" || claims.Tenant() != "acme" || claims.IsImpersonated() || claims.ActorID() != "" {
+		t.Errorf("claims = %+v, want u1 of acme held by the user", claims)
+	}
+
+	impersonation := userClaims()
+	impersonation.Actor = &services.Actor{Subject: "u9"}
+	claims, err = verifier.Verify(sign(t, issuer.key, "key-1", impersonation))
+	if err != nil {
+		t.Fatalf("Verify of an impersonation token: %v", err)
+	}
+	if claims.UserID != "u1" || !claims.IsImpersonated() || claims.ActorID() != "u9" {
+		t.Errorf("claims = %+v, want u1 held by u9", claims)
+	}
+}
+
+func TestTokenVerifierRejects(t *testing.T) {
+	issuer := newTestIssuer(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{
+			name: "expired",
+			token: func() string {
+				claims := userClaims()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return sign(t, issuer.key, "key-1", claims)
+			},
+		},
+		{
+			name: "other issuer",
+			token: func() string {
+				claims := userClaims()
+				claims.Issuer = "evil"
+				return sign(t, issuer.key, "key-1", claims)
+			},
+		},
+		{
+			name: "other audience",
+			token: func() string {
+				claims := userClaims()
+				claims.Audience = jwt.ClaimStrings{"admin-api"}
+				return sign(t, issuer.key, "key-1", claims)
+			},
+		},
+		{
+			name: "unpublished key",
+			token: func() string {
+				return sign(t, otherKey, "key-2", userClaims())
+			},
+		},
+		{
+			name: "other key under a published kid",
+			token: func() string {
+				return sign(t, otherKey, "key-1", userClaims())
+			},
+		},
+		{
+			name: "unsigned",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, userClaims())
+				token.Header["kid"] = "key-1"
+				signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatalf("SignedString: %v", err)
+				}
+				return signed
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if claims, err := issuer.verifier().Verify(tt.token()); err == nil {
+				t.Errorf("Verify = %+v, want an error", claims)
+			}
+		})
+	}
+}
+
+func TestClaimsHasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     services.Claims
+		permission string
+		want       bool
+	}{
+		{name: "user with the permission", claims: services.Claims{UserID: "u1", Permissions: []string{"users:read"}}, permission: "users:read", want: true},
+		{name: "user without the permission", claims: services.Claims{UserID: "u1", Permissions: []string{"users:read"}}, permission: "users:read:any"},
+		{name: "user with a scope", claims: services.Claims{UserID: "u1", ClientID: "app", Scope: "users:read:any"}, permission: "users:read:any"},
+		{name: "service account with the scope", claims: services.Claims{ClientID: "reporting", Scope: "users:read:any users:delete"}, permission: "users:delete", want: true},
+		{name: "service account with permissions but no scope", claims: services.Claims{ClientID: "reporting", Permissions: []string{"users:delete"}}, permission: "users:delete"},
+		{name: "scope prefix", claims: services.Claims{ClientID: "reporting", Scope: "users:read:any"}, permission: "users:read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.HasPermission(tt.permission); got != tt.want {
+				t.Errorf("HasPermission(%q) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"user-service/internal/config"
+	"user-service/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const userCollection = "user_profiles"
+
+// UserRepository stores the profiles. Every operation is scoped to a tenant,
+// so an ID from one tenant never resolves to a profile of another. Missing
+// profiles yield ErrUserNotFound, and an address already used by another
+// profile of the tenant, whatever its case, yields ErrEmailTaken.
+type UserRepository interface {
+	// FindByID returns the profile of the tenant with the given ID.
+	FindByID(ctx context.Context, tenantID, id string) (*models.User, error)
+	// List returns a page of the tenant's profiles with the given role and
+	// the total number of such profiles.
+	List(ctx context.Context, tenantID, role string, skip, limit int64) ([]models.User, int64, error)
+	// UpdateProfile sets the name and address of a profile and returns the
+	// updated profile.
+	UpdateProfile(ctx context.Context, tenantID, id, name, email string) (*models.User, error)
+	// Delete removes a profile.
+	Delete(ctx context.Context, tenantID, id string) error
+	// Upsert creates or replaces the name, address, status and role of a
+	// profile.
+	Upsert(ctx context.Context, user *models.User) error
+}
+
+// Transactor runs a function in a transaction. *config.MongoDBConfig is the
+// production implementation.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoUserRepository stores profiles in the user_profiles collection. The
+// unique index on tenantId and emailNormalized enforces one profile per
+// address and tenant.
+type MongoUserRepository struct {
+	mongoConfig *config.MongoDBConfig
+}
+
+// NewMongoUserRepository creates a new MongoUserRepository
+func NewMongoUserRepository(mongoConfig *config.MongoDBConfig) *MongoUserRepository {
+	return &MongoUserRepository{mongoConfig: mongoConfig}
+}
+
+// FindByID returns the profile of the tenant with the given ID.
+func (r *MongoUserRepository) FindByID(ctx context.Context, tenantID, id string) (*models.User, error) {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": id, "tenantId": tenantID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List returns a page of the tenant's profiles with the given role.
+func (r *MongoUserRepository) List(ctx context.Context, tenantID, role string, skip, limit int64) ([]models.User, int64, error) {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	filter := bson.M{"tenantId": tenantID, "role": role}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSkip(skip).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateProfile sets the name and address of a profile.
+func (r *MongoUserRepository) UpdateProfile(ctx context.Context, tenantID, id, name, email string) (*models.User, error) {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	var user models.User
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "tenantId": tenantID},
+		bson.M{"$set": bson.M{
+			"name":            name,
+			"email":           strings.TrimSpace(email),
+			"emailNormalized": NormalizeEmail(email),
+			"updatedAt":       time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrEmailTaken
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Delete removes a profile.
+func (r *MongoUserRepository) Delete(ctx context.Context, tenantID, id string) error {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id, "tenantId": tenantID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Upsert creates or replaces the name, address, status and role of a
+// profile. A profile with the same ID in another tenant also yields
+// ErrEmailTaken, as the index violations cannot be told apart.
+func (r *MongoUserRepository) Upsert(ctx context.Context, user *models.User) error {
+	collection := r.mongoConfig.GetCollection(userCollection)
+	now := time.Now()
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "tenantId": user.TenantID},
+		bson.M{
+			"$set": bson.M{
+				"name":            user.Name,
+				"email":           user.Email,
+				"emailNormalized": NormalizeEmail(user.Email),
+				"status":          user.Status,
+				"role":            user.Role,
+				"updatedAt":       now,
+			},
+			"$setOnInsert": bson.M{
+				"_id":       user.ID,
+				"tenantId":  user.TenantID,
+				"createdAt": now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrEmailTaken
+	}
+	return err
+}
@@ -3,71 +3,63 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
-	"user-service/internal/config"
 	"user-service/internal/models"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrEmailTaken is returned when a profile update uses an address that
+// belongs to another profile of the tenant.
+var ErrEmailTaken = errors.New("email address already in use")
+
+// ErrUserNotFound is returned when the tenant has no profile with the ID.
+var ErrUserNotFound = errors.New("user not found")
+
+// DefaultTenantID is the tenant of profiles and tokens created before tenants
+// existed, and of service account requests that name no tenant.
+const DefaultTenantID = "default"
+
 // UserService handles user-related business logic
 type UserService struct {
-	mongoConfig *config.MongoDBConfig
-	publisher   *KafkaPublisher
+	users        UserRepository
+	transactions Transactor
+	events       EventPublisher
 }
 
-// NewUserService creates a new UserService with the provided MongoDB configuration
-func NewUserService(mongoConfig *config.MongoDBConfig, publisher *KafkaPublisher) *UserService {
+// NewUserService creates a new UserService. Profile changes are stored and
+// published in one transaction.
+func NewUserService(users UserRepository, transactions Transactor, events EventPublisher) *UserService {
 	return &UserService{
-		mongoConfig: mongoConfig,
-		publisher:   publisher,
+		users:        users,
+		transactions: transactions,
+		events:       events,
 	}
 }
 
-// GetUserByID retrieves a user by their unique identifier
-func (s *UserService) GetUserByID(id string) (*models.User, error) {
-	collection := s.mongoConfig.GetCollection("user_profiles")
+// GetUserByID retrieves a user of the tenant by their unique identifier
+func (s *UserService) GetUserByID(tenantID, id string) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var user models.User
-	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	user, err := s.users.FindByID(ctx, tenantID, id)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-// ListUsers returns a paginated list of users and the total count
-func (s *UserService) ListUsers(page, pageSize int) (*models.UserListResponse, error) {
-	collection := s.mongoConfig.GetCollection("user_profiles")
+// ListUsers returns a paginated list of the tenant's users and the total count
+func (s *UserService) ListUsers(tenantID string, page, pageSize int) (*models.UserListResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	skip := int64((page - 1) * pageSize)
 	limit := int64(pageSize)
 
-	filter := bson.M{"role": "customer"}
-	findOptions := options.Find().SetSkip(skip).SetLimit(limit)
-	cursor, err := collection.Find(ctx, filter, findOptions)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	for cursor.Next(ctx) {
-		var user models.User
-		if err := cursor.Decode(&user); err != nil {
-			return nil, err
-		}
-		users = append(users, user)
-	}
-
-	total, err := collection.CountDocuments(ctx, filter)
+	users, total, err := s.users.List(ctx, tenantID, "customer", skip, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -80,110 +72,110 @@ func (s *UserService) ListUsers(page, pageSize int) (*models.UserListResponse, e
 	}, nil
 }
 
-// UpdateUser updates a user's information
-func (s *UserService) UpdateUser(id string, req models.UpdateUserRequest) (*models.User, error) {
-	collection := s.mongoConfig.GetCollection("user_profiles")
+// UpdateUser updates the information of a user of the tenant. Returns
+// ErrEmailTaken if another profile of the tenant has the same address,
+// whatever its case.
+func (s *UserService) UpdateUser(tenantID, id string, req models.UpdateUserRequest) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":      req.Name,
-			"email":     req.Email,
-			"role":      req.Role,
-			"updatedAt": time.Now(),
-		},
-	}
-
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
-	if err != nil {
-		return nil, err
-	}
+	var updatedUser *models.User
+	err := s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		previous, err := s.users.FindByID(ctx, tenantID, id)
+		if err != nil {
+			return err
+		}
+		user, err := s.users.UpdateProfile(ctx, tenantID, id, req.Name, req.Email)
+		if err != nil {
+			return err
+		}
+		updatedUser = user
 
-	if result.MatchedCount == 0 {
-		return nil, errors.New("user not found")
-	}
+		// auth-service asks the owner to confirm a new address, so the event
+		// tells address changes apart from other updates.
+		var previousEmail string
+		if NormalizeEmail(previous.Email) != NormalizeEmail(user.Email) {
+			previousEmail = previous.Email
+		}
 
-	updatedUser, err := s.GetUserByID(id)
+		return s.events.PublishUserUpdated(ctx, models.UserEvent{
+			EventID:       primitive.NewObjectID().Hex(),
+			EventType:     "user.updated.v1",
+			Timestamp:     time.Now().UTC(),
+			UserID:        user.ID,
+			TenantID:      user.TenantID,
+			Email:         user.Email,
+			PreviousEmail: previousEmail,
+			Name:          user.Name,
+			Status:        user.Status,
+			Role:          user.Role,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	event := models.UserEvent{
-		EventID:   primitive.NewObjectID().Hex(),
-		EventType: "user.updated.v1",
-		Timestamp: time.Now().UTC(),
-		UserID:    updatedUser.ID,
-		Email:     updatedUser.Email,
-		Name:      updatedUser.Name,
-		Status:    updatedUser.Status,
-		Role:      updatedUser.Role,
-	}
-	if err := s.publisher.PublishUserUpdated(ctx, event); err != nil {
-		// Keep API behavior successful even if async event publishing fails.
-	}
-
 	return updatedUser, nil
 }
 
-// DeleteUser deletes a user by ID
-func (s *UserService) DeleteUser(id string) error {
-	collection := s.mongoConfig.GetCollection("user_profiles")
+// DeleteUser deletes a user of the tenant by ID
+func (s *UserService) DeleteUser(tenantID, id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
-	if err != nil {
-		return err
-	}
-
-	if result.DeletedCount == 0 {
-		return errors.New("user not found")
-	}
-
-	event := models.UserEvent{
-		EventID:   primitive.NewObjectID().Hex(),
-		EventType: "user.deleted.v1",
-		Timestamp: time.Now().UTC(),
-		UserID:    id,
-	}
-	if err := s.publisher.PublishUserDeleted(ctx, event); err != nil {
-		// Keep API behavior successful even if async event publishing fails.
-	}
+	return s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.users.Delete(ctx, tenantID, id); err != nil {
+			return err
+		}
 
-	return nil
+		return s.events.PublishUserDeleted(ctx, models.UserEvent{
+			EventID:   primitive.NewObjectID().Hex(),
+			EventType: "user.deleted.v1",
+			Timestamp: time.Now().UTC(),
+			UserID:    id,
+			TenantID:  tenantID,
+		})
+	})
 }
 
-// UpsertUserProfileFromEvent creates or updates a profile from a user lifecycle event.
+// UpsertUserProfileFromEvent creates or updates a profile from a user lifecycle
+// event. Events published before tenants existed belong to the default tenant.
 func (s *UserService) UpsertUserProfileFromEvent(event models.UserEvent) error {
-	collection := s.mongoConfig.GetCollection("user_profiles")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"name":      event.Name,
-			"email":     event.Email,
-			"status":    event.Status,
-			"role":      event.Role,
-			"updatedAt": time.Now(),
-		},
-		"$setOnInsert": bson.M{
-			"_id":       event.UserID,
-			"createdAt": time.Now(),
-		},
-	}
-
-	_, err := collection.UpdateOne(ctx, bson.M{"_id": event.UserID}, update, options.Update().SetUpsert(true))
-	return err
+	return s.users.Upsert(ctx, &models.User{
+		ID:       event.UserID,
+		TenantID: eventTenant(event),
+		Name:     event.Name,
+		Email:    event.Email,
+		Status:   event.Status,
+		Role:     event.Role,
+	})
 }
 
 // DeleteUserProfileFromEvent removes a profile by user ID using event payload.
+// Profiles that are already gone are ignored.
 func (s *UserService) DeleteUserProfileFromEvent(event models.UserEvent) error {
-	collection := s.mongoConfig.GetCollection("user_profiles")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": event.UserID})
+	err := s.users.Delete(ctx, eventTenant(event), event.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
 	return err
 }
+
+// NormalizeEmail returns the form of an address profiles are deduplicated by.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// eventTenant returns the tenant a user lifecycle event belongs to.
+func eventTenant(event models.UserEvent) string {
+	if event.TenantID == "" {
+		return DefaultTenantID
+	}
+	return event.TenantID
+}
@@ -0,0 +1,236 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"user-service/internal/models"
+	"user-service/internal/services"
+	"user-service/internal/testutil"
+)
+
+func newTestUserService(t *testing.T) (*services.UserService, *testutil.MemoryUserRepository, *testutil.MemoryEventPublisher) {
+	t.Helper()
+	users := testutil.NewMemoryUserRepository()
+	events := testutil.NewMemoryEventPublisher()
+	return services.NewUserService(users, testutil.MemoryTransactor{}, events), users, events
+}
+
+func seedUser(t *testing.T, users *testutil.MemoryUserRepository, user models.User) {
+	t.Helper()
+	if err := users.Upsert(context.Background(), &user); err != nil {
+		t.Fatalf("Upsert(%s) error = %v", user.ID, err)
+	}
+}
+
+func TestGetUserByID(t *testing.T) {
+	userService, users, _ := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com", Role: "customer"})
+
+	tests := []struct {
+		name     string
+		tenantID string
+		id       string
+		wantErr  error
+	}{
+		{name: "own tenant", tenantID: "acme", id: "u1"},
+		{name: "unknown user", tenantID: "acme", id: "u2", wantErr: services.ErrUserNotFound},
+		{name: "other tenant", tenantID: "globex", id: "u1", wantErr: services.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := userService.GetUserByID(tt.tenantID, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetUserByID error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.ID != tt.id {
+				t.Errorf("user ID = %q, want %q", user.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestListUsersPagesTheTenantsCustomers(t *testing.T) {
+	userService, users, _ := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "a@example.com", Role: "customer"})
+	seedUser(t, users, models.User{ID: "u2", TenantID: "acme", Email: "b@example.com", Role: "customer"})
+	seedUser(t, users, models.User{ID: "u3", TenantID: "acme", Email: "c@example.com", Role: "customer"})
+	seedUser(t, users, models.User{ID: "u4", TenantID: "acme", Email: "d@example.com", Role: "admin"})
+	seedUser(t, users, models.User{ID: "u5", TenantID: "globex", Email: "e@example.com", Role: "customer"})
+
+	response, err := userService.ListUsers("acme", 2, 2)
+	if err != nil {
+		t.Fatalf("ListUsers error = %v", err)
+	}
+	if response.Total != 3 {
+		t.Errorf("total = %d, want 3", response.Total)
+	}
+	if len(response.Users) != 1 || response.Users[0].ID != "u3" {
+		t.Errorf("users = %+v, want only u3", response.Users)
+	}
+	if response.Page != 2 || response.Size != 2 {
+		t.Errorf("page, size = %d, %d, want 2, 2", response.Page, response.Size)
+	}
+}
+
+func TestUpdateUserPublishesUserUpdated(t *testing.T) {
+	userService, users, events := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com", Status: "active", Role: "customer"})
+
+	user, err := userService.UpdateUser("acme", "u1", models.UpdateUserRequest{Name: "Ada", Email: "Ada.L@example.com"})
+	if err != nil {
+		t.Fatalf("UpdateUser error = %v", err)
+	}
+	if user.Name != "Ada" || user.Email != "Ada.L@example.com" {
+		t.Errorf("user = %+v, want the new name and address", user)
+	}
+
+	published := events.Events()
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	event := published[0]
+	if event.EventType != "user.updated.v1" || event.UserID != "u1" || event.TenantID != "acme" {
+		t.Errorf("event = %+v, want user.updated.v1 for u1 in acme", event)
+	}
+	if event.Email != "Ada.L@example.com" || event.Role != "customer" || event.Source != services.EventSource {
+		t.Errorf("event = %+v, want the updated profile from %s", event, services.EventSource)
+	}
+	if event.PreviousEmail != "ada@example.com" {
+		t.Errorf("previous email = %q, want ada@example.com", event.PreviousEmail)
+	}
+
+	// Updates keeping the address, whatever its case, do not change it
+	events.Reset()
+	if _, err := userService.UpdateUser("acme", "u1", models.UpdateUserRequest{Name: "Ada L", Email: "ada.l@example.com"}); err != nil {
+		t.Fatalf("second UpdateUser error = %v", err)
+	}
+	if published := events.Events(); len(published) != 1 || published[0].PreviousEmail != "" {
+		t.Errorf("events = %+v, want one without a previous email", published)
+	}
+}
+
+func TestUpdateUserRejectsTakenAddress(t *testing.T) {
+	userService, users, events := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com"})
+	seedUser(t, users, models.User{ID: "u2", TenantID: "acme", Email: "grace@example.com"})
+	seedUser(t, users, models.User{ID: "u3", TenantID: "globex", Email: "linus@example.com"})
+
+	_, err := userService.UpdateUser("acme", "u1", models.UpdateUserRequest{Name: "Ada", Email: " GRACE@example.com"})
+	if !errors.Is(err, services.ErrEmailTaken) {
+		t.Fatalf("UpdateUser error = %v, want ErrEmailTaken", err)
+	}
+	if len(events.Events()) != 0 {
+		t.Errorf("published %d events, want none", len(events.Events()))
+	}
+
+	// Addresses are unique per tenant only
+	if _, err := userService.UpdateUser("acme", "u1", models.UpdateUserRequest{Name: "Ada", Email: "linus@example.com"}); err != nil {
+		t.Errorf("UpdateUser with another tenant's address error = %v, want nil", err)
+	}
+}
+
+func TestUpdateUserOfAnotherTenant(t *testing.T) {
+	userService, users, events := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com"})
+
+	_, err := userService.UpdateUser("globex", "u1", models.UpdateUserRequest{Name: "Mallory"})
+	if !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("UpdateUser error = %v, want ErrUserNotFound", err)
+	}
+	if len(events.Events()) != 0 {
+		t.Errorf("published %d events, want none", len(events.Events()))
+	}
+}
+
+func TestDeleteUserPublishesUserDeleted(t *testing.T) {
+	userService, users, events := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com"})
+
+	if err := userService.DeleteUser("globex", "u1"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("DeleteUser from another tenant error = %v, want ErrUserNotFound", err)
+	}
+	if err := userService.DeleteUser("acme", "u1"); err != nil {
+		t.Fatalf("DeleteUser error = %v", err)
+	}
+	if _, err := userService.GetUserByID("acme", "u1"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("GetUserByID after delete error = %v, want ErrUserNotFound", err)
+	}
+
+	published := events.Events()
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	if published[0].EventType != "user.deleted.v1" || published[0].UserID != "u1" || published[0].TenantID != "acme" {
+		t.Errorf("event = %+v, want user.deleted.v1 for u1 in acme", published[0])
+	}
+}
+
+func TestUpsertUserProfileFromEvent(t *testing.T) {
+	userService, _, events := newTestUserService(t)
+
+	err := userService.UpsertUserProfileFromEvent(models.UserEvent{
+		UserID: "u1",
+		Email:  "ada@example.com",
+		Name:   "Ada",
+		Status: "active",
+		Role:   "customer",
+	})
+	if err != nil {
+		t.Fatalf("UpsertUserProfileFromEvent error = %v", err)
+	}
+
+	// Events without a tenant predate tenants
+	user, err := userService.GetUserByID(services.DefaultTenantID, "u1")
+	if err != nil {
+		t.Fatalf("GetUserByID error = %v", err)
+	}
+	if user.Name != "Ada" || user.Role != "customer" {
+		t.Errorf("user = %+v, want the event's profile", user)
+	}
+
+	err = userService.UpsertUserProfileFromEvent(models.UserEvent{UserID: "u1", Email: "ada@example.com", Name: "Ada L", Status: "active", Role: "admin"})
+	if err != nil {
+		t.Fatalf("second UpsertUserProfileFromEvent error = %v", err)
+	}
+	user, _ = userService.GetUserByID(services.DefaultTenantID, "u1")
+	if user.Name != "Ada L" || user.Role != "admin" {
+		t.Errorf("user = %+v, want the second event's profile", user)
+	}
+	if len(events.Events()) != 0 {
+		t.Errorf("published %d events, want none for consumed events", len(events.Events()))
+	}
+}
+
+func TestDeleteUserProfileFromEvent(t *testing.T) {
+	userService, users, _ := newTestUserService(t)
+	seedUser(t, users, models.User{ID: "u1", TenantID: "acme", Email: "ada@example.com"})
+
+	if err := userService.DeleteUserProfileFromEvent(models.UserEvent{UserID: "u1", TenantID: "acme"}); err != nil {
+		t.Fatalf("DeleteUserProfileFromEvent error = %v", err)
+	}
+	if _, err := userService.GetUserByID("acme", "u1"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("GetUserByID after delete error = %v, want ErrUserNotFound", err)
+	}
+	// Redelivered events find the profile gone
+	if err := userService.DeleteUserProfileFromEvent(models.UserEvent{UserID: "u1", TenantID: "acme"}); err != nil {
+		t.Errorf("DeleteUserProfileFromEvent of a missing profile error = %v, want nil", err)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "jane@example.com", want: "jane@example.com"},
+		{email: "Jane@Example.COM", want: "jane@example.com"},
+		{email: "  jane@example.com\t", want: "jane@example.com"},
+		{email: " JANE.ROE+tag@Example.com ", want: "jane.roe+tag@example.com"},
+	}
+	for _, tt := range tests {
+		if got := services.NormalizeEmail(tt.email); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
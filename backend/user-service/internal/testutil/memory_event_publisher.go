@@ -0,0 +1,55 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"user-service/internal/models"
+	"user-service/internal/services"
+)
+
+// MemoryEventPublisher records the user lifecycle events published through
+// it, so tests can check them without a broker.
+type MemoryEventPublisher struct {
+	mu     sync.Mutex
+	events []models.UserEvent
+}
+
+// NewMemoryEventPublisher creates an empty MemoryEventPublisher.
+func NewMemoryEventPublisher() *MemoryEventPublisher {
+	return &MemoryEventPublisher{}
+}
+
+// PublishUserUpdated records user.updated.v1.
+func (p *MemoryEventPublisher) PublishUserUpdated(ctx context.Context, event models.UserEvent) error {
+	return p.record(event)
+}
+
+// PublishUserDeleted records user.deleted.v1.
+func (p *MemoryEventPublisher) PublishUserDeleted(ctx context.Context, event models.UserEvent) error {
+	return p.record(event)
+}
+
+func (p *MemoryEventPublisher) record(event models.UserEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	event.Source = services.EventSource
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the recorded events in the order they were published.
+func (p *MemoryEventPublisher) Events() []models.UserEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]models.UserEvent(nil), p.events...)
+}
+
+// Reset forgets the recorded events.
+func (p *MemoryEventPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = nil
+}
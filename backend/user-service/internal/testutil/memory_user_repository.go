@@ -0,0 +1,144 @@
+package testutil
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"user-service/internal/models"
+	"user-service/internal/services"
+)
+
+// MemoryUserRepository keeps profiles in memory with the semantics of
+// services.MongoUserRepository, including one profile per address and
+// tenant, where profiles without an address never conflict.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+// NewMemoryUserRepository creates an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]models.User)}
+}
+
+// FindByID returns the profile of the tenant with the given ID.
+func (r *MemoryUserRepository) FindByID(ctx context.Context, tenantID, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TenantID != tenantID {
+		return nil, services.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// List returns a page of the tenant's profiles with the given role, ordered
+// by ID.
+func (r *MemoryUserRepository) List(ctx context.Context, tenantID, role string, skip, limit int64) ([]models.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matching []models.User
+	for _, user := range r.users {
+		if user.TenantID == tenantID && user.Role == role {
+			matching = append(matching, user)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].ID < matching[j].ID })
+
+	total := int64(len(matching))
+	if skip >= total {
+		return nil, total, nil
+	}
+	end := total
+	if limit > 0 && skip+limit < total {
+		end = skip + limit
+	}
+	return matching[skip:end], total, nil
+}
+
+// UpdateProfile sets the name and address of a profile.
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, tenantID, id, name, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TenantID != tenantID {
+		return nil, services.ErrUserNotFound
+	}
+	if r.emailTaken(tenantID, services.NormalizeEmail(email), id) {
+		return nil, services.ErrEmailTaken
+	}
+	user.Name = name
+	user.Email = strings.TrimSpace(email)
+	user.EmailNormalized = services.NormalizeEmail(email)
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return &user, nil
+}
+
+// Delete removes a profile.
+func (r *MemoryUserRepository) Delete(ctx context.Context, tenantID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TenantID != tenantID {
+		return services.ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// Upsert creates or replaces the name, address, status and role of a
+// profile.
+func (r *MemoryUserRepository) Upsert(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	existing, ok := r.users[user.ID]
+	if ok && existing.TenantID != user.TenantID {
+		return services.ErrEmailTaken
+	}
+	if r.emailTaken(user.TenantID, services.NormalizeEmail(user.Email), user.ID) {
+		return services.ErrEmailTaken
+	}
+	if !ok {
+		existing = models.User{ID: user.ID, TenantID: user.TenantID, CreatedAt: now}
+	}
+	existing.Name = user.Name
+	existing.Email = user.Email
+	existing.EmailNormalized = services.NormalizeEmail(user.Email)
+	existing.Status = user.Status
+	existing.Role = user.Role
+	existing.UpdatedAt = now
+	r.users[user.ID] = existing
+	return nil
+}
+
+// emailTaken reports whether a profile of the tenant other than exceptID has
+// the normalized address, as the partial unique index would.
+func (r *MemoryUserRepository) emailTaken(tenantID, emailNormalized, exceptID string) bool {
+	if emailNormalized == "" {
+		return false
+	}
+	for id, user := range r.users {
+		if id != exceptID && user.TenantID == tenantID && user.EmailNormalized == emailNormalized {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryTransactor runs functions directly, for services backed by in-memory
+// repositories. Writes made before fn fails are not rolled back.
+type MemoryTransactor struct{}
+
+// WithTransaction runs fn.
+func (MemoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,15 @@
+// Package testutil provides in-memory implementations of the dependencies of
+// the services, so services and handlers can be tested without MongoDB or
+// Kafka. Nothing is persisted.
+package testutil
+
+import "user-service/internal/services"
+
+// Keep the in-memory implementations in step with the interfaces they stand
+// in for.
+var (
+	_ services.EventPublisher   = (*MemoryEventPublisher)(nil)
+	_ services.OutboxRepository = (*MemoryOutboxRepository)(nil)
+	_ services.Transactor       = MemoryTransactor{}
+	_ services.UserRepository   = (*MemoryUserRepository)(nil)
+)
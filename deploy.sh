@@ -63,7 +63,6 @@ setup_environment() {
 MONGO_INITDB_ROOT_USERNAME=admin
 MONGO_INITDB_ROOT_PASSWORD=$(openssl rand -base64 32)
 MONGO_INITDB_DATABASE=auth_db
-JWT_SECRET=$(openssl rand -base64 64)
 LOG_LEVEL=0
 API_GATEWAY_PORT=8080
 AUTH_SERVICE_PORT=8081
@@ -0,0 +1,143 @@
+import { Component, OnInit } from '@angular/core';
+import { ActivatedRoute, Router, RouterModule } from '@angular/router';
+import { FormBuilder, FormGroup, Validators, ReactiveFormsModule } from '@angular/forms';
+import { CommonModule } from '@angular/common';
+import { AuthService } from '../services/auth.service';
+
+@Component({
+  selector: 'app-magic-link',
+  standalone: true,
+  imports: [ReactiveFormsModule, CommonModule, RouterModule],
+  templateUrl: './magic-link.component.html',
+  styles: [`
+    .magic-link-container {
+      max-width: 400px;
+      margin: 2rem auto;
+      padding: 2rem;
+      border-radius: 8px;
+      box-shadow: 0 2px 4px rgba(0, 0, 0, 0.1);
+      background-color: white;
+    }
+
+    .magic-link-card {
+      display: flex;
+      flex-direction: column;
+      gap: 1.5rem;
+    }
+
+    h2 {
+      text-align: center;
+      color: #1a1a1a;
+    }
+
+    form {
+      display: flex;
+      flex-direction: column;
+      gap: 1rem;
+    }
+
+    .form-group {
+      display: flex;
+      flex-direction: column;
+      gap: 0.5rem;
+    }
+
+    label {
+      font-weight: 500;
+      color: #4a5568;
+    }
+
+    input {
+      padding: 0.75rem;
+      border: 1px solid #e2e8f0;
+      border-radius: 4px;
+      font-size: 1rem;
+    }
+
+    button {
+      padding: 0.75rem;
+      background-color: #3b82f6;
+      color: white;
+      border: none;
+      border-radius: 4px;
+      font-size: 1rem;
+      font-weight: 500;
+      cursor: pointer;
+    }
+
+    button:disabled {
+      background-color: #93c5fd;
+      cursor: not-allowed;
+    }
+
+    .error-message {
+      color: #ef4444;
+      font-size: 0.875rem;
+    }
+
+    .success-message {
+      color: #16a34a;
+      font-size: 0.875rem;
+    }
+
+    a {
+      color: #3b82f6;
+      text-decoration: none;
+      font-weight: 500;
+    }
+  `]
+})
+export class MagicLinkComponent implements OnInit {
+  token: string | null = null;
+  requestForm: FormGroup;
+  errorMessage: string = '';
+  successMessage: string = '';
+
+  constructor(
+    private fb: FormBuilder,
+    private route: ActivatedRoute,
+    private router: Router,
+    private authService: AuthService
+  ) {
+    this.requestForm = this.fb.group({
+      email: ['', [Validators.required, Validators.email]]
+    });
+  }
+
+  ngOnInit(): void {
+    this.token = this.route.snapshot.queryParamMap.get('token');
+    if (this.token) {
+      this.signIn(this.token);
+    }
+  }
+
+  requestLink() {
+    if (this.requestForm.invalid) {
+      return;
+    }
+    this.authService.requestMagicLink(this.requestForm.value.email).subscribe({
+      next: (response) => {
+        this.errorMessage = '';
+        this.successMessage = response.message;
+      },
+      error: () => {
+        this.errorMessage = 'Could not send a sign-in link. Please try again later.';
+      }
+    });
+  }
+
+  private signIn(token: string) {
+    this.authService.consumeMagicLink(token).subscribe({
+      next: (response) => {
+        if (response.status === 'success') {
+          this.router.navigate(['/dashboard']);
+        } else {
+          this.errorMessage = 'This account needs a second factor. Please sign in with your password.';
+        }
+      },
+      error: (error) => {
+        this.errorMessage = error.error?.error || 'The sign-in link is invalid or has expired.';
+      }
+    });
+  }
+}
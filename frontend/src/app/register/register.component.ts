@@ -2,7 +2,7 @@ import { Component, OnInit } from '@angular/core';
 import { Router, RouterModule } from '@angular/router';
 import { FormBuilder, FormGroup, Validators, ReactiveFormsModule } from '@angular/forms';
 import { CommonModule } from '@angular/common';
-import { AuthService, RegisterRequest } from '../services/auth.service';
+import { AuthService, PasswordViolation, RegisterRequest } from '../services/auth.service';
 
 @Component({
     selector: 'app-register',
@@ -72,6 +72,12 @@ import { AuthService, RegisterRequest } from '../services/auth.service';
             margin-top: 0.25rem;
         }
 
+        .success-message {
+            color: #16a34a;
+            font-size: 0.875rem;
+            margin-top: 0.25rem;
+        }
+
         button {
             padding: 0.75rem;
             background-color: #3b82f6;
@@ -113,6 +119,8 @@ import { AuthService, RegisterRequest } from '../services/auth.service';
 export class RegisterComponent implements OnInit {
     registerForm: FormGroup;
     errorMessage: string = '';
+    successMessage: string = '';
+    passwordViolations: PasswordViolation[] = [];
 
     constructor(
         private fb: FormBuilder,
@@ -122,7 +130,7 @@ export class RegisterComponent implements OnInit {
         this.registerForm = this.fb.group({
             name: ['', Validators.required],
             email: ['', [Validators.required, Validators.email]],
-            password: ['', [Validators.required, Validators.minLength(6)]],
+            password: ['', [Validators.required, Validators.minLength(8)]],
             confirmPassword: ['', Validators.required]
         }, {
             validator: this.passwordMatchValidator
@@ -147,17 +155,23 @@ export class RegisterComponent implements OnInit {
     onSubmit() {
         if (this.registerForm.valid) {
             const userData: RegisterRequest = this.registerForm.value;
+            this.passwordViolations = [];
             this.authService.register(userData).subscribe({
                 next: (response) => {
                     if (response.status === 'success') {
                         localStorage.setItem('token', response.token);
                         this.router.navigate(['/dashboard']);
+                    } else if (response.status === 'pending_verification') {
+                        this.errorMessage = '';
+                        this.successMessage = 'Registration successful. Check your email for a link to verify your address.';
+                        this.registerForm.reset();
                     } else {
                         this.errorMessage = 'Registration failed. Please try again.';
                     }
                 },
                 error: (error) => {
-                    this.errorMessage = error.error?.message || 'Registration failed. Please try again.';
+                    this.errorMessage = error.error?.error || error.error?.message || 'Registration failed. Please try again.';
+                    this.passwordViolations = error.error?.violations || [];
                 }
             });
         }
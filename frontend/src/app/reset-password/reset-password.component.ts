@@ -0,0 +1,152 @@
+import { Component, OnInit } from '@angular/core';
+import { ActivatedRoute, RouterModule } from '@angular/router';
+import { FormBuilder, FormGroup, Validators, ReactiveFormsModule } from '@angular/forms';
+import { CommonModule } from '@angular/common';
+import { AuthService, PasswordViolation } from '../services/auth.service';
+
+@Component({
+  selector: 'app-reset-password',
+  standalone: true,
+  imports: [ReactiveFormsModule, CommonModule, RouterModule],
+  templateUrl: './reset-password.component.html',
+  styles: [`
+    .reset-container {
+      max-width: 400px;
+      margin: 2rem auto;
+      padding: 2rem;
+      border-radius: 8px;
+      box-shadow: 0 2px 4px rgba(0, 0, 0, 0.1);
+      background-color: white;
+    }
+
+    .reset-card {
+      display: flex;
+      flex-direction: column;
+      gap: 1.5rem;
+    }
+
+    h2 {
+      text-align: center;
+      color: #1a1a1a;
+    }
+
+    form {
+      display: flex;
+      flex-direction: column;
+      gap: 1rem;
+    }
+
+    .form-group {
+      display: flex;
+      flex-direction: column;
+      gap: 0.5rem;
+    }
+
+    label {
+      font-weight: 500;
+      color: #4a5568;
+    }
+
+    input {
+      padding: 0.75rem;
+      border: 1px solid #e2e8f0;
+      border-radius: 4px;
+      font-size: 1rem;
+    }
+
+    button {
+      padding: 0.75rem;
+      background-color: #3b82f6;
+      color: white;
+      border: none;
+      border-radius: 4px;
+      font-size: 1rem;
+      font-weight: 500;
+      cursor: pointer;
+    }
+
+    button:disabled {
+      background-color: #93c5fd;
+      cursor: not-allowed;
+    }
+
+    .error-message {
+      color: #ef4444;
+      font-size: 0.875rem;
+    }
+
+    .success-message {
+      color: #16a34a;
+      font-size: 0.875rem;
+    }
+
+    a {
+      color: #3b82f6;
+      text-decoration: none;
+      font-weight: 500;
+    }
+  `]
+})
+export class ResetPasswordComponent implements OnInit {
+  token: string | null = null;
+  forgotForm: FormGroup;
+  resetForm: FormGroup;
+  errorMessage: string = '';
+  successMessage: string = '';
+  passwordViolations: PasswordViolation[] = [];
+
+  constructor(
+    private fb: FormBuilder,
+    private route: ActivatedRoute,
+    private authService: AuthService
+  ) {
+    this.forgotForm = this.fb.group({
+      email: ['', [Validators.required, Validators.email]]
+    });
+    this.resetForm = this.fb.group({
+      password: ['', [Validators.required, Validators.minLength(8)]],
+      confirmPassword: ['', Validators.required]
+    });
+  }
+
+  ngOnInit(): void {
+    this.token = this.route.snapshot.queryParamMap.get('token');
+  }
+
+  requestReset() {
+    if (this.forgotForm.invalid) {
+      return;
+    }
+    this.authService.forgotPassword(this.forgotForm.value.email).subscribe({
+      next: (response) => {
+        this.errorMessage = '';
+        this.successMessage = response.message;
+      },
+      error: () => {
+        this.errorMessage = 'Could not send a reset link. Please try again later.';
+      }
+    });
+  }
+
+  resetPassword() {
+    if (this.resetForm.invalid || !this.token) {
+      return;
+    }
+    const { password, confirmPassword } = this.resetForm.value;
+    if (password !== confirmPassword) {
+      this.errorMessage = 'Passwords do not match';
+      return;
+    }
+    this.passwordViolations = [];
+    this.authService.resetPassword(this.token, password, confirmPassword).subscribe({
+      next: () => {
+        this.errorMessage = '';
+        this.successMessage = 'Your password has been reset.';
+      },
+      error: (error) => {
+        this.errorMessage = error.error?.error || 'The reset link is invalid or has expired.';
+        this.passwordViolations = error.error?.violations || [];
+      }
+    });
+  }
+}
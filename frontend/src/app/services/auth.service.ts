@@ -16,8 +16,14 @@ export interface RegisterRequest {
     confirmPassword: string;
 }
 
+export interface PasswordViolation {
+    rule: string;
+    message: string;
+}
+
 export interface AuthResponse {
     status: string;
+    message?: string;
     token: string;
     user: {
         id: string;
@@ -59,11 +65,60 @@ export class AuthService {
                     }
                     return response;
                 }
+                if (response.status === 'pending_verification') {
+                    return response;
+                }
                 throw new Error('Registration failed');
             })
         );
     }
 
+    verifyEmail(token: string): Observable<any> {
+        return this.http.post<any>(`${this.apiUrl}/auth/verify-email`, { token });
+    }
+
+    resendVerification(email: string): Observable<any> {
+        return this.http.post<any>(`${this.apiUrl}/auth/verify-email/resend`, { email });
+    }
+
+    forgotPassword(email: string): Observable<any> {
+        return this.http.post<any>(`${this.apiUrl}/auth/password/forgot`, { email });
+    }
+
+    resetPassword(token: string, password: string, confirmPassword: string): Observable<any> {
+        return this.http.post<any>(`${this.apiUrl}/auth/password/reset`, { token, password, confirmPassword });
+    }
+
+    requestMagicLink(email: string): Observable<any> {
+        return this.http.post<any>(`${this.apiUrl}/auth/magic-link`, { email, nonce: this.magicLinkNonce() });
+    }
+
+    consumeMagicLink(token: string): Observable<AuthResponse> {
+        const nonce = this.isBrowser() ? localStorage.getItem('magicLinkNonce') || '' : '';
+        return this.http.post<AuthResponse>(`${this.apiUrl}/auth/magic-link/consume`, { token, nonce }).pipe(
+            map(response => {
+                if (response.token && this.isBrowser()) {
+                    localStorage.setItem('token', response.token);
+                    localStorage.removeItem('magicLinkNonce');
+                }
+                return response;
+            })
+        );
+    }
+
+    // The nonce binds magic links to this browser. It is kept until a link is
+    // used, so every link requested from here works.
+    private magicLinkNonce(): string {
+        let nonce = localStorage.getItem('magicLinkNonce');
+        if (!nonce) {
+            const bytes = new Uint8Array(32);
+            crypto.getRandomValues(bytes);
+            nonce = Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+            localStorage.setItem('magicLinkNonce', nonce);
+        }
+        return nonce;
+    }
+
     logout(): void {
         if (this.isBrowser()) {
             localStorage.removeItem('token');
@@ -0,0 +1,116 @@
+import { Component, OnInit } from '@angular/core';
+import { ActivatedRoute, RouterModule } from '@angular/router';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { AuthService } from '../services/auth.service';
+
+@Component({
+  selector: 'app-verify-email',
+  standalone: true,
+  imports: [CommonModule, FormsModule, RouterModule],
+  templateUrl: './verify-email.component.html',
+  styles: [`
+    .verify-container {
+      max-width: 400px;
+      margin: 2rem auto;
+      padding: 2rem;
+      border-radius: 8px;
+      box-shadow: 0 2px 4px rgba(0, 0, 0, 0.1);
+      background-color: white;
+    }
+
+    .verify-card {
+      display: flex;
+      flex-direction: column;
+      gap: 1rem;
+    }
+
+    h2 {
+      text-align: center;
+      color: #1a1a1a;
+    }
+
+    input {
+      padding: 0.75rem;
+      border: 1px solid #e2e8f0;
+      border-radius: 4px;
+      font-size: 1rem;
+    }
+
+    button {
+      padding: 0.75rem;
+      background-color: #3b82f6;
+      color: white;
+      border: none;
+      border-radius: 4px;
+      font-size: 1rem;
+      font-weight: 500;
+      cursor: pointer;
+    }
+
+    button:disabled {
+      background-color: #93c5fd;
+      cursor: not-allowed;
+    }
+
+    .error-message {
+      color: #ef4444;
+      font-size: 0.875rem;
+    }
+
+    .success-message {
+      color: #16a34a;
+      font-size: 0.875rem;
+    }
+
+    a {
+      color: #3b82f6;
+      text-decoration: none;
+      font-weight: 500;
+    }
+  `]
+})
+export class VerifyEmailComponent implements OnInit {
+  state: 'verifying' | 'verified' | 'failed' = 'verifying';
+  errorMessage: string = '';
+  resendEmail: string = '';
+  resendMessage: string = '';
+
+  constructor(
+    private route: ActivatedRoute,
+    private authService: AuthService
+  ) { }
+
+  ngOnInit(): void {
+    const token = this.route.snapshot.queryParamMap.get('token');
+    if (!token) {
+      this.state = 'failed';
+      this.errorMessage = 'The verification link is incomplete.';
+      return;
+    }
+
+    this.authService.verifyEmail(token).subscribe({
+      next: () => {
+        this.state = 'verified';
+      },
+      error: (error) => {
+        this.state = 'failed';
+        this.errorMessage = error.error?.error || 'The verification link is invalid or has expired.';
+      }
+    });
+  }
+
+  resend() {
+    if (!this.resendEmail) {
+      return;
+    }
+    this.authService.resendVerification(this.resendEmail).subscribe({
+      next: (response) => {
+        this.resendMessage = response.message;
+      },
+      error: () => {
+        this.resendMessage = 'Could not send a new link. Please try again later.';
+      }
+    });
+  }
+}